@@ -39,8 +39,9 @@ var helpText = func(colors logger.Colors) string {
                         is browser and cjs when platform is node)
   --loader:X=L          Use loader L to load file extension X, where L is
                         one of: base64 | binary | copy | css | dataurl |
-                        empty | file | global-css | js | json | jsx |
-                        local-css | text | ts | tsx
+                        empty | file | global-css | js | json | json5 |
+                        jsonc | jsx | local-css | text | toml | ts | tsx |
+                        wasm | yaml
   --minify              Minify the output (sets all --minify-* flags)
   --outdir=...          The output directory (for multiple entry points)
   --outfile=...         The output file (for one entry point)
@@ -49,7 +50,7 @@ var helpText = func(colors logger.Colors) string {
                         default browser)
   --serve=...           Start a local HTTP server on this host:port for outputs
   --sourcemap           Emit a source map
-  --splitting           Enable code splitting (currently only for esm)
+  --splitting           Enable code splitting
   --target=...          Environment target (e.g. es2017, chrome58, firefox57,
                         safari11, edge16, node10, ie9, opera45, default esnext)
   --watch               Watch mode: rebuild on file system changes (stops when
@@ -68,6 +69,8 @@ var helpText = func(colors logger.Colors) string {
   --chunk-names=...         Path template to use for code splitting chunks
                             (default "[name]-[hash]")
   --color=...               Force use of color terminal escapes (true | false)
+  --compressed-sizes=false  Don't compute gzip and brotli sizes for the build
+                            summary and metafile
   --drop:...                Remove certain constructs (console | debugger)
   --drop-labels=...         Remove labeled statements with these label names
   --entry-names=...         Path template to use for entry point output paths
@@ -79,6 +82,10 @@ var helpText = func(colors logger.Colors) string {
                             incorrect tree-shaking annotations
   --inject:F                Import the file F into all input files and
                             automatically replace matching globals with imports
+  --integrity=...           Compute subresource integrity hashes for output
+                            files, which are included in the metafile, the
+                            manifest, and "serve" response headers
+                            (sha256 | sha384 | sha512)
   --jsx-dev                 Use React's automatic runtime in development mode
   --jsx-factory=...         What to use for JSX instead of React.createElement
   --jsx-fragment=...        What to use for JSX instead of React.Fragment
@@ -100,17 +107,29 @@ var helpText = func(colors logger.Colors) string {
   --main-fields=...         Override the main file order in package.json
                             (default "browser,module,main" when platform is
                             browser and "main,module" when platform is node)
+  --manifest                Write a "manifest.json" file to the output directory
+                            for server-side integrations
+  --manual-chunk:N=...      Put these packages or paths into a code splitting
+                            chunk named N (e.g. "--manual-chunk:vendor=react")
   --mangle-cache=...        Save "mangle props" decisions to a JSON file
   --mangle-props=...        Rename all properties matching a regular expression
   --mangle-quoted=...       Enable renaming of quoted properties (true | false)
+  --max-chunks=...          Merge code splitting chunks together when it's safe
+                            until there are at most this many chunks
   --metafile=...            Write metadata about the build to a JSON file
                             (see also: ` + colors.Underline + `https://esbuild.github.io/analyze/` + colors.Reset + `)
+  --min-chunk-size=...      Merge code splitting chunks smaller than this many
+                            bytes into other chunks when it's safe
   --minify-whitespace       Remove whitespace in output files
   --minify-identifiers      Shorten identifiers in output files
   --minify-syntax           Use equivalent but shorter syntax in output files
+  --module-preload          Preload the chunks that code-split import()
+                            expressions depend on in parallel
   --out-extension:.js=.mjs  Use a custom output extension instead of ".js"
   --outbase=...             The base path used to determine entry point output
                             paths (for multiple entry points)
+  --preserve-eval-order     Lazily evaluate code in shared chunks so that code
+                            splitting doesn't change evaluation order
   --preserve-symlinks       Disable symlink resolution for module lookup
   --public-path=...         Set the base URL for the "file" loader
   --pure:N                  Mark the name N as a pure function for tree shaking
@@ -128,6 +147,8 @@ var helpText = func(colors logger.Colors) string {
   --tree-shaking=...        Force tree shaking on or off (false | true)
   --tsconfig=...            Use this tsconfig.json file instead of other ones
   --tsconfig-raw=...        Override all tsconfig.json files with this string
+  --wasm-inline             Embed WebAssembly modules instead of loading them
+                            as files (synchronous unless --format=esm)
   --version                 Print the current version (` + esbuildVersion + `) and exit
 
 ` + colors.Bold + `Examples:` + colors.Reset + `
@@ -785,6 +785,8 @@ func resolveKindToString(kind api.ResolveKind) string {
 		return "dynamic-import"
 	case api.ResolveJSRequireResolve:
 		return "require-resolve"
+	case api.ResolveJSWorker:
+		return "worker"
 
 	// CSS
 	case api.ResolveCSSImportRule:
@@ -813,6 +815,8 @@ func stringToResolveKind(kind string) (api.ResolveKind, bool) {
 		return api.ResolveJSDynamicImport, true
 	case "require-resolve":
 		return api.ResolveJSRequireResolve, true
+	case "worker":
+		return api.ResolveJSWorker, true
 
 	// CSS
 	case "import-rule":
@@ -1279,11 +1283,15 @@ func decodeStringArray(values []interface{}) []string {
 func encodeOutputFiles(outputFiles []api.OutputFile) []interface{} {
 	values := make([]interface{}, len(outputFiles))
 	for i, outputFile := range outputFiles {
-		values[i] = map[string]interface{}{
+		value := map[string]interface{}{
 			"path":     outputFile.Path,
 			"contents": outputFile.Contents,
 			"hash":     outputFile.Hash,
 		}
+		if outputFile.Integrity != "" {
+			value["integrity"] = outputFile.Integrity
+		}
+		values[i] = value
 	}
 	return values
 }
@@ -31,7 +31,7 @@ const cssFeatures: Record<string, CSSFeature> = {
   'css-matches-pseudo': 'IsPseudoClass',
 }
 
-const cssPrefixFeatures: Record<string, CSSProperty> = {
+const cssPrefixFeatures: Record<string, CSSProperty | CSSProperty[]> = {
   'css-appearance': 'DAppearance',
   'css-backdrop-filter': 'DBackdropFilter',
   'background-clip-text': 'DBackgroundClip',
@@ -39,6 +39,7 @@ const cssPrefixFeatures: Record<string, CSSProperty> = {
   'css-clip-path': 'DClipPath',
   'font-kerning': 'DFontKerning',
   'css-hyphens': 'DHyphens',
+  'css-image-set': ['DBackground', 'DBackgroundImage', 'DBorderImage', 'DBorderImageSource', 'DContent', 'DCursor', 'DListStyle', 'DListStyleImage'],
   'css-initial-letter': 'DInitialLetter',
   'css-sticky': 'DPosition',
   'css-color-adjust': 'DPrintColorAdjust',
@@ -144,5 +145,8 @@ for (const feature in cssPrefixFeatures) {
     }
   }
 
-  cssPrefix[cssPrefixFeatures[feature]] = prefixData
+  const properties = cssPrefixFeatures[feature]
+  for (const property of Array.isArray(properties) ? properties : [properties]) {
+    cssPrefix[property] = prefixData
+  }
 }
@@ -59,7 +59,9 @@ export const jsFeatures = {
   Hashbang: true,
   ImportAssertions: true,
   ImportAttributes: true,
+  ImportDefer: true,
   ImportMeta: true,
+  ImportSource: true,
   InlineScript: true,
   LogicalAssignment: true,
   NestedRestBinding: true,
@@ -89,15 +91,19 @@ export const jsFeatures = {
 
 export type CSSFeature = keyof typeof cssFeatures
 export const cssFeatures = {
+  AtScope: true,
   ColorFunctions: true,
   GradientDoublePosition: true,
   GradientInterpolation: true,
   GradientMidpoints: true,
   HexRGBA: true,
   HWB: true,
+  ImageSetOptions: true,
   InlineStyle: true,
   InsetProperty: true,
   IsPseudoClass: true,
+  LightDark: true,
+  MediaRange: true,
   Modern_RGB_HSL: true,
   Nesting: true,
   RebeccaPurple: true,
@@ -107,12 +113,20 @@ export type CSSProperty = keyof typeof cssProperties
 export const cssProperties = {
   DAppearance: true,
   DBackdropFilter: true,
+  DBackground: true,
   DBackgroundClip: true,
+  DBackgroundImage: true,
+  DBorderImage: true,
+  DBorderImageSource: true,
   DBoxDecorationBreak: true,
   DClipPath: true,
+  DContent: true,
+  DCursor: true,
   DFontKerning: true,
   DHyphens: true,
   DInitialLetter: true,
+  DListStyle: true,
+  DListStyleImage: true,
   DMaskComposite: true,
   DMaskImage: true,
   DMaskOrigin: true,
@@ -26,6 +26,7 @@ const jsFeatures: Partial<Record<JSFeature, string>> = {
 }
 
 const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
+  AtScope: 'css.at-rules.scope',
   ColorFunctions: [
     'css.types.color.color',
     'css.types.color.lab',
@@ -62,7 +63,10 @@ const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
   ],
   HexRGBA: 'css.types.color.rgb_hexadecimal_notation.alpha_hexadecimal_notation',
   HWB: 'css.types.color.hwb',
+  ImageSetOptions: 'css.types.image.image-set.type',
   InsetProperty: 'css.properties.inset',
+  LightDark: 'css.types.color.light-dark',
+  MediaRange: 'css.at-rules.media.range_syntax',
   Modern_RGB_HSL: [
     'css.types.color.hsl.alpha_parameter',
     'css.types.color.hsl.space_separated_parameters',
@@ -29,13 +29,18 @@ const (
 	// A call to "require.resolve()"
 	ImportRequireResolve
 
+	// A "new URL()" expression passed to "new Worker()", "new SharedWorker()",
+	// or "navigator.serviceWorker.register()"
+	ImportWorker
+
 	// A CSS "@import" rule
 	ImportAt
 
 	// A CSS "composes" declaration
 	ImportComposesFrom
 
-	// A CSS "url(...)" token
+	// A CSS "url(...)" token, a JavaScript "new URL()" expression with
+	// "import.meta.url" as the base, or a call to "import.meta.resolve()"
 	ImportURL
 )
 
@@ -49,6 +54,8 @@ func (kind ImportKind) StringForMetafile() string {
 		return "dynamic-import"
 	case ImportRequireResolve:
 		return "require-resolve"
+	case ImportWorker:
+		return "worker"
 	case ImportAt:
 		return "import-rule"
 	case ImportComposesFrom:
@@ -78,7 +85,7 @@ func (kind ImportKind) MustResolveToCSS() bool {
 	return false
 }
 
-type ImportRecordFlags uint16
+type ImportRecordFlags uint32
 
 const (
 	// Sometimes the parser creates an import record and decides it isn't needed.
@@ -142,6 +149,13 @@ const (
 	// Unique keys are randomly-generated strings that are used to replace paths
 	// in the source code after it's printed. These must not ever be split apart.
 	ContainsUniqueKey
+
+	// Tell the printer to wrap this cross-chunk "import()" in "__preload(...)"
+	// so that the chunks it depends on are fetched in parallel
+	PreloadDependencies
+
+	// If true, this worker was created with "{ type: 'module' }"
+	IsModuleWorker
 )
 
 func (flags ImportRecordFlags) Has(flag ImportRecordFlags) bool {
@@ -168,8 +182,25 @@ type ImportRecord struct {
 
 	Flags ImportRecordFlags
 	Kind  ImportKind
+	Phase ImportPhase
 }
 
+// This is the phase in which an import statement loads its module. Most imports
+// use the evaluation phase. The others come from the "import defer" and "source
+// phase imports" proposals.
+type ImportPhase uint8
+
+const (
+	// "import * as ns from 'path'"
+	EvaluationPhase ImportPhase = iota
+
+	// "import defer * as ns from 'path'"
+	DeferPhase
+
+	// "import source x from 'path'"
+	SourcePhase
+)
+
 type AssertOrWithKeyword uint8
 
 const (
@@ -215,6 +246,18 @@ type GlobPattern struct {
 	Parts       []helpers.GlobPart
 	ExportAlias string
 	Kind        ImportKind
+
+	// This is only present for "import.meta.glob()" calls. The kind is
+	// "ImportStmt" for eager globs and "ImportDynamic" for lazy globs.
+	ImportMeta *ImportMetaGlob
+}
+
+type ImportMetaGlob struct {
+	// If present, only this export is used instead of the module namespace
+	Import string
+
+	// If present, this URL query is appended to each import path (e.g. "?raw")
+	Query string
 }
 
 // This stores a 32-bit index where the zero value is an invalid index. This is
@@ -0,0 +1,348 @@
+// This is a small brotli encoder (https://www.rfc-editor.org/rfc/rfc7932). It's
+// only used to report compressed output sizes, so it trades compression ratio
+// for simplicity and speed. It doesn't use the static dictionary, context
+// modeling, or block splitting, so a production brotli compressor at its
+// maximum quality setting will usually produce somewhat smaller output.
+//
+// The output is a valid brotli stream. It's just not as small as it could be.
+package brotli
+
+const (
+	windowBits        = 22
+	maxBackwardLength = (1 << windowBits) - 16
+	maxMetaBlockLen   = 1 << 20
+
+	minMatchLen  = 4
+	maxChainLen  = 32
+	hashBits     = 16
+	hashMultiply = 0x1e35a7bd
+
+	numLiteralSymbols  = 256
+	numCommandSymbols  = 704
+	numDistanceSymbols = 64 // With NPOSTFIX = 0 and NDIRECT = 0
+)
+
+// Each insert-and-copy command inserts some literals, then copies some bytes
+// from earlier in the output. A copy length of 0 means there's no copy, which
+// can only happen for the last command in a meta-block.
+type command struct {
+	insertLen uint32
+	copyLen   uint32
+	distance  uint32
+}
+
+func Encode(data []byte) []byte {
+	w := bitWriter{}
+
+	// Stream header: WBITS is encoded as a 1 bit followed by "WBITS - 17"
+	w.writeBits(1, 1)
+	w.writeBits(3, windowBits-17)
+
+	m := matcher{
+		data:  data,
+		head:  make([]int32, 1<<hashBits),
+		chain: make([]int32, len(data)),
+	}
+	for i := range m.head {
+		m.head[i] = -1
+	}
+
+	// The last distance starts off as 4 (the distance ring buffer is 16, 15, 11, 4)
+	lastDistance := uint32(4)
+
+	for start := 0; start < len(data); start += maxMetaBlockLen {
+		end := start + maxMetaBlockLen
+		if end > len(data) {
+			end = len(data)
+		}
+		commands := m.findCommands(start, end)
+		lastDistance = writeMetaBlock(&w, data[start:end], commands, lastDistance, end == len(data))
+	}
+
+	// An empty stream still needs a last meta-block
+	if len(data) == 0 {
+		w.writeBits(1, 1) // ISLAST
+		w.writeBits(1, 1) // ISLASTEMPTY
+	}
+
+	return w.finish()
+}
+
+type matcher struct {
+	data  []byte
+	head  []int32
+	chain []int32
+}
+
+func (m *matcher) hash(i int) uint32 {
+	d := m.data
+	v := uint32(d[i]) | uint32(d[i+1])<<8 | uint32(d[i+2])<<16 | uint32(d[i+3])<<24
+	return (v * hashMultiply) >> (32 - hashBits)
+}
+
+func (m *matcher) insert(i int) {
+	if i+minMatchLen <= len(m.data) {
+		h := m.hash(i)
+		m.chain[i] = m.head[h]
+		m.head[h] = int32(i)
+	}
+}
+
+// Returns the longest match for position "i" that doesn't extend past "end"
+func (m *matcher) longestMatch(i int, end int) (bestLen int, bestDistance int) {
+	if i+minMatchLen > end {
+		return
+	}
+	d := m.data
+	limit := end - i
+	candidate := m.head[m.hash(i)]
+	for n := 0; candidate >= 0 && n < maxChainLen; n++ {
+		distance := i - int(candidate)
+		if distance > maxBackwardLength {
+			break
+		}
+		if c := int(candidate); d[c+bestLen] == d[i+bestLen] {
+			length := 0
+			for length < limit && d[c+length] == d[i+length] {
+				length++
+			}
+			if length > bestLen {
+				bestLen = length
+				bestDistance = distance
+				if length == limit {
+					break
+				}
+			}
+		}
+		candidate = m.chain[candidate]
+	}
+	if bestLen < minMatchLen {
+		bestLen = 0
+	}
+	return
+}
+
+// This uses greedy matching with one step of lazy evaluation, which is what
+// most fast LZ77 compressors do
+func (m *matcher) findCommands(start int, end int) (commands []command) {
+	literalStart := start
+	i := start
+	for i < end {
+		length, distance := m.longestMatch(i, end)
+		if length == 0 {
+			m.insert(i)
+			i++
+			continue
+		}
+		m.insert(i)
+		if nextLength, nextDistance := m.longestMatch(i+1, end); nextLength > length {
+			i++
+			length, distance = nextLength, nextDistance
+			m.insert(i)
+		}
+		commands = append(commands, command{
+			insertLen: uint32(i - literalStart),
+			copyLen:   uint32(length),
+			distance:  uint32(distance),
+		})
+		for j := i + 1; j < i+length; j++ {
+			m.insert(j)
+		}
+		i += length
+		literalStart = i
+	}
+	if literalStart < end {
+		commands = append(commands, command{insertLen: uint32(end - literalStart)})
+	}
+	return
+}
+
+type lengthCode struct {
+	base      uint32
+	extraBits uint8
+}
+
+var insertLengthCodes = [24]lengthCode{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 1}, {8, 1},
+	{10, 2}, {14, 2}, {18, 3}, {26, 3}, {34, 4}, {50, 4}, {66, 5}, {98, 5},
+	{130, 6}, {194, 7}, {322, 8}, {578, 9}, {1090, 10}, {2114, 12}, {6210, 14}, {22594, 24},
+}
+
+var copyLengthCodes = [24]lengthCode{
+	{2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0},
+	{10, 1}, {12, 1}, {14, 2}, {18, 2}, {22, 3}, {30, 3}, {38, 4}, {54, 4},
+	{70, 5}, {102, 5}, {134, 6}, {198, 7}, {326, 8}, {582, 9}, {1094, 10}, {2118, 24},
+}
+
+func findLengthCode(codes *[24]lengthCode, length uint32) uint32 {
+	code := uint32(len(codes) - 1)
+	for code > 0 && codes[code].base > length {
+		code--
+	}
+	return code
+}
+
+// See the table in section 5 of the specification. Only commands with short
+// insert and copy lengths can use the last distance implicitly.
+func combineLengthCodes(insertCode uint32, copyCode uint32, useLastDistance bool) uint32 {
+	bits64 := (copyCode & 7) | ((insertCode & 7) << 3)
+	if useLastDistance && insertCode < 8 && copyCode < 16 {
+		if copyCode < 8 {
+			return bits64
+		}
+		return bits64 | 64
+	}
+	cell := [9]uint32{2, 3, 6, 4, 5, 8, 7, 9, 10}[(copyCode>>3)+3*(insertCode>>3)]
+	return cell<<6 | bits64
+}
+
+// Distances are encoded relative to "NPOSTFIX = 0" and "NDIRECT = 0". Distance
+// code 0 means "use the last distance" and codes 1-15 are never used.
+func encodeDistance(distance uint32) (code uint32, extraBits uint32, extra uint32) {
+	x := distance + 3
+	n := uint32(0)
+	for (x >> (n + 1)) != 0 {
+		n++
+	}
+	extraBits = n - 1
+	prefix := (x >> extraBits) & 1
+	code = 16 + 2*(extraBits-1) + prefix
+	extra = x - ((2 + prefix) << extraBits)
+	return
+}
+
+type encodedCommand struct {
+	command     command
+	symbol      uint32
+	insertCode  uint32
+	copyCode    uint32
+	hasDistance bool
+	distCode    uint32
+	distBits    uint32
+	distExtra   uint32
+}
+
+func writeMetaBlock(w *bitWriter, data []byte, commands []command, lastDistance uint32, isLast bool) uint32 {
+	// Compute the symbols and their histograms first
+	var literalCounts [numLiteralSymbols]uint32
+	var commandCounts [numCommandSymbols]uint32
+	var distanceCounts [numDistanceSymbols]uint32
+	encoded := make([]encodedCommand, len(commands))
+	pos := 0
+	for i, cmd := range commands {
+		for _, c := range data[pos : pos+int(cmd.insertLen)] {
+			literalCounts[c]++
+		}
+		pos += int(cmd.insertLen + cmd.copyLen)
+
+		e := encodedCommand{command: cmd}
+		e.insertCode = findLengthCode(&insertLengthCodes, cmd.insertLen)
+		if cmd.copyLen == 0 {
+			// The decoder stops at the end of the meta-block before reading the
+			// copy, so the copy length and distance here don't matter
+			e.symbol = combineLengthCodes(e.insertCode, 0, true)
+		} else {
+			e.copyCode = findLengthCode(&copyLengthCodes, cmd.copyLen)
+			useLastDistance := cmd.distance == lastDistance
+			e.symbol = combineLengthCodes(e.insertCode, e.copyCode, useLastDistance)
+			if e.symbol >= 128 {
+				e.hasDistance = true
+				if !useLastDistance {
+					e.distCode, e.distBits, e.distExtra = encodeDistance(cmd.distance)
+					lastDistance = cmd.distance
+				}
+				distanceCounts[e.distCode]++
+			}
+		}
+		commandCounts[e.symbol]++
+		encoded[i] = e
+	}
+
+	literalCode := buildPrefixCode(literalCounts[:], 15)
+	commandCode := buildPrefixCode(commandCounts[:], 15)
+	distanceCode := buildPrefixCode(distanceCounts[:], 15)
+
+	// Meta-block header
+	w.writeBits(1, boolToBit(isLast))
+	if isLast {
+		w.writeBits(1, 0) // ISLASTEMPTY
+	}
+	mlen := uint32(len(data) - 1)
+	nibbles := uint32(4)
+	for nibbles < 6 && (mlen>>(nibbles*4)) != 0 {
+		nibbles++
+	}
+	w.writeBits(2, nibbles-4)
+	w.writeBits(uint(nibbles*4), mlen)
+	if !isLast {
+		w.writeBits(1, 0) // ISUNCOMPRESSED
+	}
+	w.writeBits(1, 0) // NBLTYPESL = 1
+	w.writeBits(1, 0) // NBLTYPESI = 1
+	w.writeBits(1, 0) // NBLTYPESD = 1
+	w.writeBits(2, 0) // NPOSTFIX = 0
+	w.writeBits(4, 0) // NDIRECT = 0
+	w.writeBits(2, 0) // CMODE = LSB6
+	w.writeBits(1, 0) // NTREESL = 1
+	w.writeBits(1, 0) // NTREESD = 1
+	literalCode.writeTo(w, 8)
+	commandCode.writeTo(w, 10)
+	distanceCode.writeTo(w, 6)
+
+	// Meta-block data
+	pos = 0
+	for _, e := range encoded {
+		commandCode.writeSymbol(w, e.symbol)
+		insert := &insertLengthCodes[e.insertCode]
+		w.writeBits(uint(insert.extraBits), e.command.insertLen-insert.base)
+		if e.command.copyLen != 0 {
+			copy := &copyLengthCodes[e.copyCode]
+			w.writeBits(uint(copy.extraBits), e.command.copyLen-copy.base)
+		} else {
+			w.writeBits(uint(copyLengthCodes[0].extraBits), 0)
+		}
+		for _, c := range data[pos : pos+int(e.command.insertLen)] {
+			literalCode.writeSymbol(w, uint32(c))
+		}
+		pos += int(e.command.insertLen + e.command.copyLen)
+		if e.hasDistance {
+			distanceCode.writeSymbol(w, e.distCode)
+			w.writeBits(uint(e.distBits), e.distExtra)
+		}
+	}
+
+	return lastDistance
+}
+
+func boolToBit(value bool) uint32 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+type bitWriter struct {
+	bytes []byte
+	bits  uint64
+	count uint
+}
+
+// Bits are packed starting with the least significant bit
+func (w *bitWriter) writeBits(count uint, value uint32) {
+	w.bits |= uint64(value) << w.count
+	w.count += count
+	for w.count >= 8 {
+		w.bytes = append(w.bytes, byte(w.bits))
+		w.bits >>= 8
+		w.count -= 8
+	}
+}
+
+func (w *bitWriter) finish() []byte {
+	if w.count > 0 {
+		w.bytes = append(w.bytes, byte(w.bits))
+		w.bits = 0
+		w.count = 0
+	}
+	return w.bytes
+}
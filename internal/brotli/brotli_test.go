@@ -0,0 +1,32 @@
+package brotli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+)
+
+// These outputs were verified by decompressing them with another brotli
+// implementation (node's "zlib.brotliDecompressSync")
+func TestEncode(t *testing.T) {
+	expect := func(input string, output string) {
+		t.Helper()
+		t.Run(input, func(t *testing.T) {
+			t.Helper()
+			test.AssertEqualWithDiff(t, hex.EncodeToString(Encode([]byte(input))), output)
+		})
+	}
+
+	expect("", "3b")
+	expect("a", "1b00000020c202810000")
+	expect("hello hello hello hello\n", "1b1700000036c67e67f54fd010e400b855d742ea0f6c02")
+}
+
+func TestEncodeRepetitive(t *testing.T) {
+	input := bytes.Repeat([]byte("export default function() { return 123 }\n"), 10000)
+	if n := len(Encode(input)); n > 200 {
+		t.Fatalf("Expected repetitive input to compress well, got %d bytes", n)
+	}
+}
@@ -0,0 +1,266 @@
+package brotli
+
+import "sort"
+
+// This is a canonical prefix code (i.e. a Huffman code) for an alphabet. The
+// lengths determine the codes, and the codes are stored bit-reversed since
+// they are written starting with the most significant bit.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+
+	// If fewer than two symbols are used, all lengths are zero and this is the
+	// symbol that is used (or zero if no symbols are used)
+	onlySymbol uint32
+	usedCount  int
+}
+
+func buildPrefixCode(counts []uint32, maxLength uint8) prefixCode {
+	code := prefixCode{
+		lengths: huffmanLengths(counts, maxLength),
+		codes:   make([]uint16, len(counts)),
+	}
+	for symbol, count := range counts {
+		if count != 0 {
+			code.onlySymbol = uint32(symbol)
+			code.usedCount++
+		}
+	}
+
+	// Assign codes in order of length and then symbol, like DEFLATE does
+	var lengthCounts [16]uint16
+	for _, length := range code.lengths {
+		lengthCounts[length]++
+	}
+	lengthCounts[0] = 0
+	var nextCode [16]uint16
+	for length := 1; length < len(nextCode); length++ {
+		nextCode[length] = (nextCode[length-1] + lengthCounts[length-1]) << 1
+	}
+	for symbol, length := range code.lengths {
+		if length != 0 {
+			code.codes[symbol] = reverseBits(nextCode[length], length)
+			nextCode[length]++
+		}
+	}
+	return code
+}
+
+func reverseBits(value uint16, count uint8) (result uint16) {
+	for i := uint8(0); i < count; i++ {
+		result = result<<1 | (value & 1)
+		value >>= 1
+	}
+	return
+}
+
+func (code *prefixCode) writeSymbol(w *bitWriter, symbol uint32) {
+	w.writeBits(uint(code.lengths[symbol]), uint32(code.codes[symbol]))
+}
+
+// Returns code lengths that are no longer than "maxLength". Symbols with a
+// count of zero get a length of zero. If fewer than two symbols are used, the
+// only used symbol (if any) gets a length of zero since it doesn't need any
+// bits to be identified.
+func huffmanLengths(counts []uint32, maxLength uint8) []uint8 {
+	lengths := make([]uint8, len(counts))
+
+	type node struct {
+		count uint32
+		left  int32 // Negative values are symbols (one's complement)
+		right int32
+	}
+
+	var symbols []int32
+	for symbol, count := range counts {
+		if count != 0 {
+			symbols = append(symbols, int32(symbol))
+		}
+	}
+	if len(symbols) < 2 {
+		return lengths
+	}
+
+	// If the tree is too deep, flatten the distribution and try again
+	for minCount := uint32(1); ; minCount *= 2 {
+		leaves := make([]node, len(symbols))
+		for i, symbol := range symbols {
+			count := counts[symbol]
+			if count < minCount {
+				count = minCount
+			}
+			leaves[i] = node{count: count, left: ^symbol, right: ^symbol}
+		}
+		sort.SliceStable(leaves, func(i, j int) bool {
+			return leaves[i].count < leaves[j].count
+		})
+
+		// Use the two-queue algorithm since the leaves are already sorted
+		internal := make([]node, 0, len(leaves)-1)
+		i, j := 0, 0
+		pick := func() (int32, uint32) {
+			if i < len(leaves) && (j >= len(internal) || leaves[i].count <= internal[j].count) {
+				i++
+				return leaves[i-1].left, leaves[i-1].count
+			}
+			j++
+			return int32(j - 1), internal[j-1].count
+		}
+		for len(internal) < len(leaves)-1 {
+			left, leftCount := pick()
+			right, rightCount := pick()
+			internal = append(internal, node{count: leftCount + rightCount, left: left, right: right})
+		}
+
+		// Walk the tree to compute the depth of each leaf
+		tooDeep := false
+		var visit func(index int32, depth uint8)
+		visit = func(index int32, depth uint8) {
+			if index < 0 {
+				if depth > maxLength {
+					tooDeep = true
+				}
+				lengths[^index] = depth
+				return
+			}
+			visit(internal[index].left, depth+1)
+			visit(internal[index].right, depth+1)
+		}
+		visit(int32(len(internal)-1), 0)
+		if !tooDeep {
+			return lengths
+		}
+	}
+}
+
+// The order in which code length code lengths are stored
+var codeLengthOrder = [18]uint8{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// The static prefix code used for code length code lengths (section 3.5)
+var codeLengthCodeLengthSymbols = [6]uint32{0, 7, 3, 2, 1, 15}
+var codeLengthCodeLengthBits = [6]uint{2, 4, 3, 2, 2, 4}
+
+const (
+	repeatPreviousLength = 16
+	repeatZeroLength     = 17
+)
+
+type lengthSymbol struct {
+	symbol uint8
+	extra  uint8
+}
+
+// Consecutive repeat codes of the same kind combine: each one after the first
+// turns the repeat count "n" into "(n - 2) << extraBits" plus its own count.
+// This means a run can be written as digits in base "1 << extraBits" with the
+// most significant digit first. This is what the reference encoder does.
+func appendRepeatedLength(symbols []lengthSymbol, length uint8, count int, repeatSymbol uint8, extraBits uint) []lengthSymbol {
+	// Like the reference encoder, write one length directly for this count. It
+	// takes the same number of symbols as two chained repeat codes would.
+	if count == 3+(1<<extraBits) {
+		symbols = append(symbols, lengthSymbol{symbol: length})
+		count--
+	}
+
+	if count < 3 {
+		for ; count > 0; count-- {
+			symbols = append(symbols, lengthSymbol{symbol: length})
+		}
+		return symbols
+	}
+
+	start := len(symbols)
+	count -= 3
+	for {
+		symbols = append(symbols, lengthSymbol{symbol: repeatSymbol, extra: uint8(count & ((1 << extraBits) - 1))})
+		count >>= extraBits
+		if count == 0 {
+			break
+		}
+		count--
+	}
+
+	// The digits were generated least significant first
+	for i, j := start, len(symbols)-1; i < j; i, j = i+1, j-1 {
+		symbols[i], symbols[j] = symbols[j], symbols[i]
+	}
+	return symbols
+}
+
+func (code *prefixCode) writeTo(w *bitWriter, alphabetBits uint) {
+	// Use a simple prefix code with one symbol if there are fewer than two
+	// symbols, since a complex prefix code must have at least two symbols
+	if code.usedCount < 2 {
+		w.writeBits(2, 1) // HSKIP = 1 means a simple prefix code
+		w.writeBits(2, 0) // NSYM - 1
+		w.writeBits(alphabetBits, code.onlySymbol)
+		return
+	}
+
+	// Trailing zero lengths are implied once the code space is filled up
+	end := len(code.lengths)
+	for code.lengths[end-1] == 0 {
+		end--
+	}
+
+	// Run-length encode the code lengths
+	var symbols []lengthSymbol
+	for i := 0; i < end; {
+		length := code.lengths[i]
+		run := 1
+		for i+run < end && code.lengths[i+run] == length {
+			run++
+		}
+		i += run
+		if length == 0 {
+			symbols = appendRepeatedLength(symbols, 0, run, repeatZeroLength, 3)
+		} else {
+			// Repeat codes repeat the previous non-zero length, so start with that
+			symbols = append(symbols, lengthSymbol{symbol: length})
+			symbols = appendRepeatedLength(symbols, length, run-1, repeatPreviousLength, 2)
+		}
+	}
+
+	// Build the prefix code for the code lengths themselves
+	var counts [18]uint32
+	for _, s := range symbols {
+		counts[s.symbol]++
+	}
+	lengthCode := buildPrefixCode(counts[:], 5)
+
+	// A code length code with only one symbol uses zero bits for that symbol,
+	// but it still needs a non-zero length to be present in the header
+	onlyOneLength := lengthCode.usedCount == 1
+	if onlyOneLength {
+		lengthCode.lengths[lengthCode.onlySymbol] = 1
+	}
+
+	// Write the code length code lengths, stopping once the code space is full
+	w.writeBits(2, 0) // HSKIP = 0
+	count := len(codeLengthOrder)
+	if !onlyOneLength {
+		for lengthCode.lengths[codeLengthOrder[count-1]] == 0 {
+			count--
+		}
+	}
+	for _, symbol := range codeLengthOrder[:count] {
+		length := lengthCode.lengths[symbol]
+		w.writeBits(codeLengthCodeLengthBits[length], codeLengthCodeLengthSymbols[length])
+	}
+	if onlyOneLength {
+		for symbol := range lengthCode.lengths {
+			lengthCode.lengths[symbol] = 0
+		}
+	}
+
+	// Write the code lengths
+	for _, s := range symbols {
+		lengthCode.writeSymbol(w, uint32(s.symbol))
+		switch s.symbol {
+		case repeatPreviousLength:
+			w.writeBits(2, uint32(s.extra))
+		case repeatZeroLength:
+			w.writeBits(3, uint32(s.extra))
+		}
+	}
+}
@@ -8,6 +8,8 @@ package bundler
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base32"
 	"encoding/base64"
 	"fmt"
@@ -22,10 +24,12 @@ import (
 	"unicode/utf8"
 
 	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/brotli"
 	"github.com/evanw/esbuild/internal/cache"
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
 	"github.com/evanw/esbuild/internal/css_parser"
+	"github.com/evanw/esbuild/internal/css_printer"
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
@@ -36,7 +40,10 @@ import (
 	"github.com/evanw/esbuild/internal/resolver"
 	"github.com/evanw/esbuild/internal/runtime"
 	"github.com/evanw/esbuild/internal/sourcemap"
+	"github.com/evanw/esbuild/internal/toml_parser"
+	"github.com/evanw/esbuild/internal/wasm_parser"
 	"github.com/evanw/esbuild/internal/xxhash"
+	"github.com/evanw/esbuild/internal/yaml_parser"
 )
 
 type scannerFile struct {
@@ -178,21 +185,45 @@ func parseFile(args parseArgs) {
 	// "copy" is kind of like "external"). But only do this if this file was not
 	// loaded by a plugin. Plugins are allowed to assign whatever semantics they
 	// want to import attributes.
+	hasTypeAttribute := false
 	if loader != config.LoaderCopy && pluginName == "" {
+		var typeLoader, attrLoader config.Loader
 		for _, attr := range source.KeyPath.ImportAttributes.DecodeIntoArray() {
 			var errorText string
 			var errorRange js_lexer.KeyOrValue
 
-			// We only currently handle "type: json"
-			if attr.Key != "type" {
+			switch attr.Key {
+			case "type":
+				switch attr.Value {
+				case "json":
+					typeLoader = config.LoaderWithTypeJSON
+				case "text":
+					typeLoader = config.LoaderText
+				case "bytes":
+					typeLoader = config.LoaderBinary
+				case "css":
+					typeLoader = config.LoaderWithTypeCSS
+				default:
+					errorText = fmt.Sprintf("Importing with a type attribute of %q is not supported", attr.Value)
+					errorRange = js_lexer.ValueRange
+				}
+
+			case "loader":
+				// This is an esbuild-specific way to pick the loader for one import
+				if value, ok := loaderFromImportAttribute(attr.Value); ok {
+					attrLoader = value
+				} else {
+					errorText = fmt.Sprintf("Importing with a loader attribute of %q is not supported", attr.Value)
+					errorRange = js_lexer.ValueRange
+				}
+
+			default:
 				errorText = fmt.Sprintf("Importing with the %q attribute is not supported", attr.Key)
 				errorRange = js_lexer.KeyRange
-			} else if attr.Value == "json" {
-				loader = config.LoaderWithTypeJSON
+			}
+
+			if errorText == "" {
 				continue
-			} else {
-				errorText = fmt.Sprintf("Importing with a type attribute of %q is not supported", attr.Value)
-				errorRange = js_lexer.ValueRange
 			}
 
 			// Everything else is an error
@@ -210,6 +241,28 @@ func parseFile(args parseArgs) {
 			args.results <- parseResult{}
 			return
 		}
+
+		// An explicit "loader" attribute takes precedence over the "type" attribute
+		if attrLoader != config.LoaderNone {
+			loader = attrLoader
+		} else if typeLoader != config.LoaderNone {
+			loader = typeLoader
+			hasTypeAttribute = true
+		}
+	}
+
+	// Only WebAssembly modules currently have a source representation
+	if source.KeyPath.IsSourcePhase() && loader != config.LoaderWasm {
+		tracker := logger.MakeLineColumnTracker(args.importSource)
+		args.log.AddError(&tracker, args.importPathRange,
+			fmt.Sprintf("Source phase imports are only supported for WebAssembly modules: %s", source.PrettyPath))
+		if args.inject != nil {
+			args.inject <- config.InjectedFile{
+				Source: source,
+			}
+		}
+		args.results <- parseResult{}
+		return
 	}
 
 	if loader == config.LoaderEmpty {
@@ -280,21 +333,40 @@ func parseFile(args parseArgs) {
 		result.file.inputFile.Repr = &graph.CSSRepr{AST: ast}
 		result.ok = true
 
-	case config.LoaderJSON, config.LoaderWithTypeJSON:
+	case config.LoaderJSON, config.LoaderWithTypeJSON, config.LoaderJSONC, config.LoaderJSON5:
+		flavor := js_lexer.JSON
+		switch loader {
+		case config.LoaderJSONC:
+			flavor = js_lexer.JSONC
+		case config.LoaderJSON5:
+			flavor = js_lexer.JSON5
+		}
 		expr, ok := args.caches.JSONCache.Parse(args.log, source, js_parser.JSONOptions{
 			UnsupportedJSFeatures: args.options.UnsupportedJSFeatures,
+			Flavor:                flavor,
 		})
 		ast := js_parser.LazyExportAST(args.log, source, js_parser.OptionsFromConfig(&args.options), expr, "")
-		if loader == config.LoaderWithTypeJSON {
-			// The exports kind defaults to "none", in which case the linker picks
-			// either ESM or CommonJS depending on the situation. Dynamic imports
-			// causes the linker to pick CommonJS which uses "require()" and then
-			// converts the return value to ESM, which adds extra properties that
-			// aren't supposed to be there when "{ with: { type: 'json' } }" is
-			// present. So if there's an import attribute, we force the type to
-			// be ESM to avoid this.
-			ast.ExportsKind = js_ast.ExportsESM
+		if pluginName != "" {
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_PureData_FromPlugin
+		} else {
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_PureData
 		}
+		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
+		result.ok = ok
+
+	case config.LoaderYAML, config.LoaderTOML:
+		var expr js_ast.Expr
+		var ok bool
+		if loader == config.LoaderYAML {
+			expr, ok = yaml_parser.Parse(args.log, source, yaml_parser.Options{
+				UnsupportedJSFeatures: args.options.UnsupportedJSFeatures,
+			})
+		} else {
+			expr, ok = toml_parser.Parse(args.log, source, toml_parser.Options{
+				UnsupportedJSFeatures: args.options.UnsupportedJSFeatures,
+			})
+		}
+		ast := js_parser.LazyExportAST(args.log, source, js_parser.OptionsFromConfig(&args.options), expr, "")
 		if pluginName != "" {
 			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_PureData_FromPlugin
 		} else {
@@ -303,6 +375,28 @@ func parseFile(args parseArgs) {
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
 
+	case config.LoaderWithTypeCSS:
+		// CSS module scripts evaluate to a "CSSStyleSheet" object. Note that the
+		// contents are not bundled since "@import" isn't allowed in this case.
+		tree := args.caches.CSSCache.Parse(args.log, source, css_parser.OptionsFromConfig(config.LoaderCSS, &args.options))
+		symbols := ast.NewSymbolMap(int(source.Index) + 1)
+		symbols.SymbolsForSource[source.Index] = tree.Symbols
+		css := css_printer.Print(tree, symbols, css_printer.Options{
+			LineLimit:           args.options.LineLimit,
+			UnsupportedFeatures: args.options.UnsupportedCSSFeatures,
+			MinifyWhitespace:    args.options.MinifyWhitespace,
+			ASCIIOnly:           args.options.ASCIIOnly,
+		}).CSS
+		expr := js_ast.Expr{Data: &js_ast.EString{Value: helpers.StringToUTF16(string(css))}}
+		ast := js_parser.LazyExportAST(args.log, source, js_parser.OptionsFromConfig(&args.options), expr, "__toCSSStyleSheet")
+		if pluginName != "" {
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_PureData_FromPlugin
+		} else {
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_PureData
+		}
+		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
+		result.ok = true
+
 	case config.LoaderText:
 		encoded := base64.StdEncoding.EncodeToString([]byte(source.Contents))
 		expr := js_ast.Expr{Data: &js_ast.EString{Value: helpers.StringToUTF16(source.Contents)}}
@@ -381,6 +475,45 @@ func parseFile(args parseArgs) {
 		// Mark that this file is from the "file" loader
 		result.file.inputFile.UniqueKeyForAdditionalFile = uniqueKey
 
+	case config.LoaderWasm:
+		module, errorText := wasm_parser.Parse(source.Contents)
+		if errorText != "" {
+			tracker := logger.MakeLineColumnTracker(args.importSource)
+			args.log.AddErrorWithNotes(&tracker, args.importPathRange,
+				fmt.Sprintf("Invalid WebAssembly module: %s", source.PrettyPath),
+				[]logger.MsgData{{Text: errorText}})
+			break
+		}
+
+		// Modules can only be loaded from a separate file when bundling, since
+		// otherwise there is nothing to copy the file into the output directory
+		inline := args.options.WasmInline || source.KeyPath.Namespace != "file" || args.options.Mode != config.ModeBundle
+		if !inline && !args.options.OutputFormat.KeepESMImportExportSyntax() {
+			tracker := logger.MakeLineColumnTracker(args.importSource)
+			args.log.AddErrorWithNotes(&tracker, args.importPathRange,
+				fmt.Sprintf("Loading the WebAssembly module %q requires top-level await, which is not available with the %q output format",
+					source.PrettyPath, args.options.OutputFormat.String()),
+				[]logger.MsgData{{Text: "You can either use the \"esm\" output format or enable the \"wasm-inline\" setting " +
+					"to embed the module in the output and instantiate it synchronously."}})
+			break
+		}
+
+		wasm := wasmOptions{
+			fileName: base + ext,
+			inline:   inline,
+			async:    args.options.OutputFormat.KeepESMImportExportSyntax(),
+			platform: args.options.Platform,
+		}
+		if source.KeyPath.IsSourcePhase() {
+			source.Contents = wasmSourceToJS(source.Contents, wasm)
+		} else {
+			source.Contents = wasmModuleToJS(module, source.Contents, wasm)
+		}
+		result.file.inputFile.Source.Contents = source.Contents
+		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
+		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
+		result.ok = ok
+
 	case config.LoaderCopy:
 		uniqueKey := fmt.Sprintf("%sA%08d", args.uniqueKeyPrefix, args.sourceIndex)
 		uniqueKeyPath := uniqueKey + source.KeyPath.IgnoredSuffix
@@ -403,6 +536,19 @@ func parseFile(args parseArgs) {
 		args.log.AddError(&tracker, args.importPathRange, message)
 	}
 
+	// The exports kind defaults to "none", in which case the linker picks
+	// either ESM or CommonJS depending on the situation. Dynamic imports
+	// causes the linker to pick CommonJS which uses "require()" and then
+	// converts the return value to ESM, which adds extra properties that
+	// aren't supposed to be there when "{ with: { type: 'json' } }" is
+	// present. So if there's a "type" import attribute, we force the type
+	// to be ESM to avoid this.
+	if hasTypeAttribute {
+		if repr, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok {
+			repr.AST.ExportsKind = js_ast.ExportsESM
+		}
+	}
+
 	// Only continue now if parsing was successful
 	if result.ok {
 		// Run the resolver on the parse thread so it's not run on the main thread.
@@ -422,6 +568,7 @@ func parseFile(args parseArgs) {
 
 				type cacheKey struct {
 					kind  ast.ImportKind
+					phase ast.ImportPhase
 					path  string
 					attrs logger.ImportAttributes
 				}
@@ -448,11 +595,15 @@ func parseFile(args parseArgs) {
 					// Special-case glob pattern imports
 					if record.GlobPattern != nil {
 						prettyPath := helpers.GlobPatternToString(record.GlobPattern.Parts)
-						switch record.GlobPattern.Kind {
-						case ast.ImportRequire:
-							prettyPath = fmt.Sprintf("require(%q)", prettyPath)
-						case ast.ImportDynamic:
-							prettyPath = fmt.Sprintf("import(%q)", prettyPath)
+						if record.GlobPattern.ImportMeta != nil {
+							prettyPath = fmt.Sprintf("import.meta.glob(%q)", prettyPath)
+						} else {
+							switch record.GlobPattern.Kind {
+							case ast.ImportRequire:
+								prettyPath = fmt.Sprintf("require(%q)", prettyPath)
+							case ast.ImportDynamic:
+								prettyPath = fmt.Sprintf("import(%q)", prettyPath)
+							}
 						}
 						if results, msg := args.res.ResolveGlob(absResolveDir, record.GlobPattern.Parts, record.GlobPattern.Kind, prettyPath); results != nil {
 							if msg != nil {
@@ -461,11 +612,25 @@ func parseFile(args parseArgs) {
 							if result.globResolveResults == nil {
 								result.globResolveResults = make(map[uint32]globResolveResult)
 							}
+							var query string
+							if record.GlobPattern.ImportMeta != nil {
+								query = record.GlobPattern.ImportMeta.Query
+							}
 							for key, result := range results {
 								result.PathPair.Primary.ImportAttributes = attrs
 								if result.PathPair.HasSecondary() {
 									result.PathPair.Secondary.ImportAttributes = attrs
 								}
+								if query != "" {
+									if result.PathPair.IsExternal {
+										result.PathPair.Primary.Text += query
+									} else {
+										result.PathPair.Primary.IgnoredSuffix = query
+										if result.PathPair.HasSecondary() {
+											result.PathPair.Secondary.IgnoredSuffix = query
+										}
+									}
+								}
 								results[key] = result
 							}
 							result.globResolveResults[uint32(importRecordIndex)] = globResolveResult{
@@ -489,6 +654,7 @@ func parseFile(args parseArgs) {
 					// Cache the path in case it's imported multiple times in this file
 					cacheKey := cacheKey{
 						kind:  record.Kind,
+						phase: record.Phase,
 						path:  record.Path.Text,
 						attrs: attrs,
 					}
@@ -517,6 +683,14 @@ func parseFile(args parseArgs) {
 							if resolveResult.PathPair.HasSecondary() {
 								resolveResult.PathPair.Secondary.ImportAttributes = attrs
 							}
+
+							// Source phase imports load a different representation of the file
+							if record.Phase == ast.SourcePhase && !resolveResult.PathPair.IsExternal {
+								resolveResult.PathPair.Primary.Flags |= logger.PathSourcePhase
+								if resolveResult.PathPair.HasSecondary() {
+									resolveResult.PathPair.Secondary.Flags |= logger.PathSourcePhase
+								}
+							}
 						}
 						entry = cacheEntry{
 							resolveResult: resolveResult,
@@ -544,6 +718,18 @@ func parseFile(args parseArgs) {
 					// have been logged for nil entries if the previous instances had
 					// the "HandlesImportErrors" flag.
 					if entry.resolveResult == nil {
+						// "new URL()" and "import.meta.resolve()" in JavaScript may refer to
+						// paths that only exist at run-time, so they are left unchanged
+						if _, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok && record.Kind == ast.ImportURL {
+							if !entry.didLogError {
+								args.log.AddID(logger.MsgID_None, logger.Debug, &tracker, record.Range,
+									fmt.Sprintf("The URL %q was left unchanged because it could not be resolved", record.Path.Text))
+								entry.didLogError = true
+								resolverCache[cacheKey] = entry
+							}
+							continue
+						}
+
 						// Failed imports inside a try/catch are silently turned into
 						// external imports instead of causing errors. This matches a common
 						// code pattern for conditionally importing a module with a graceful
@@ -2046,7 +2232,7 @@ func (s *scanner) scanAllDependencies() {
 						sourceIndex := s.allocateGlobSourceIndex(result.file.inputFile.Source.Index, uint32(importRecordIndex))
 						record.SourceIndex = ast.MakeIndex32(sourceIndex)
 						s.results[sourceIndex] = s.generateResultForGlobResolve(sourceIndex, globResults.absPath,
-							&result.file.inputFile.Source, record.Range, with, record.GlobPattern.Kind, globResults, record.AssertOrWith)
+							&result.file.inputFile.Source, record.Range, with, record.GlobPattern, globResults, record.AssertOrWith)
 					}
 					continue
 				}
@@ -2093,10 +2279,11 @@ func (s *scanner) generateResultForGlobResolve(
 	importSource *logger.Source,
 	importRange logger.Range,
 	importWith *ast.ImportAssertOrWith,
-	kind ast.ImportKind,
+	glob *ast.GlobPattern,
 	result globResolveResult,
 	assertions *ast.ImportAssertOrWith,
 ) parseResult {
+	kind := glob.Kind
 	keys := make([]string, 0, len(result.resolveResults))
 	for key := range result.resolveResults {
 		keys = append(keys, key)
@@ -2149,6 +2336,11 @@ func (s *scanner) generateResultForGlobResolve(
 			Kind:         kind,
 		})
 
+		// The object for "import.meta.glob()" is generated separately below
+		if glob.ImportMeta != nil {
+			continue
+		}
+
 		switch kind {
 		case ast.ImportDynamic:
 			value.Data = &js_ast.EImportString{ImportRecordIndex: importRecordIndex}
@@ -2172,7 +2364,12 @@ func (s *scanner) generateResultForGlobResolve(
 		PrettyPath: result.prettyPath,
 		Index:      sourceIndex,
 	}
-	ast := js_parser.GlobResolveAST(s.log, source, importRecords, &object, result.exportAlias)
+	var ast js_ast.AST
+	if glob.ImportMeta != nil {
+		ast = s.importMetaGlobAST(&source, importSource, importRange, keys, importRecords, kind, glob.ImportMeta, result.exportAlias)
+	} else {
+		ast = js_parser.GlobResolveAST(s.log, source, importRecords, &object, result.exportAlias)
+	}
 
 	// Fill out "nil" for any additional imports (i.e. from the runtime)
 	for len(resolveResults) < len(ast.ImportRecords) {
@@ -2194,6 +2391,211 @@ func (s *scanner) generateResultForGlobResolve(
 	}
 }
 
+type wasmOptions struct {
+	fileName string
+	platform config.Platform
+	inline   bool
+
+	// If true, inline modules are compiled asynchronously using top-level
+	// await. Otherwise they are compiled synchronously, which works with every
+	// output format but only for small modules in the browser (Chrome refuses
+	// to compile modules larger than 8 MB synchronously on the main thread).
+	async bool
+}
+
+// This generates a JavaScript module that follows the "ESM integration"
+// proposal for WebAssembly. Each import of the WebAssembly module becomes an
+// import statement and each export becomes an export of the JavaScript module.
+func wasmModuleToJS(module wasm_parser.Module, contents string, wasm wasmOptions) string {
+	var sb strings.Builder
+
+	quoteName := func(name string) string {
+		if js_ast.IsIdentifier(name) {
+			return name
+		}
+		return string(helpers.QuoteForJSON(name, false))
+	}
+
+	// Group the imports by module so they can be passed to the instance
+	var modules []string
+	names := make(map[string][]string)
+	for i, imp := range module.Imports {
+		sb.WriteString(fmt.Sprintf("import { %s as i%d } from %s;\n", quoteName(imp.Name), i, helpers.QuoteForJSON(imp.Module, false)))
+		if _, ok := names[imp.Module]; !ok {
+			modules = append(modules, imp.Module)
+		}
+		names[imp.Module] = append(names[imp.Module], fmt.Sprintf("%s: i%d", helpers.QuoteForJSON(imp.Name, false), i))
+	}
+	var imports strings.Builder
+	imports.WriteByte('{')
+	for i, name := range modules {
+		if i > 0 {
+			imports.WriteByte(',')
+		}
+		imports.WriteString(fmt.Sprintf(" %s: { %s }", helpers.QuoteForJSON(name, false), strings.Join(names[name], ", ")))
+	}
+	if len(modules) > 0 {
+		imports.WriteByte(' ')
+	}
+	imports.WriteByte('}')
+
+	if wasm.inline && wasm.async {
+		sb.WriteString(fmt.Sprintf("var { instance } = await WebAssembly.instantiate(%s, %s);\n",
+			wasmInlineBytes(contents, wasm.platform), imports.String()))
+	} else if wasm.inline {
+		sb.WriteString(fmt.Sprintf("var instance = new WebAssembly.Instance(new WebAssembly.Module(%s), %s);\n",
+			wasmInlineBytes(contents, wasm.platform), imports.String()))
+	} else {
+		sb.WriteString(wasmFileImport(wasm.fileName, wasm.platform))
+		if wasm.platform == config.PlatformNode {
+			sb.WriteString(fmt.Sprintf("var { instance } = await WebAssembly.instantiate(await readFile(new URL(url, import.meta.url)), %s);\n", imports.String()))
+		} else {
+			sb.WriteString(fmt.Sprintf("var { instance } = await WebAssembly.instantiateStreaming(fetch(new URL(url, import.meta.url)), %s);\n", imports.String()))
+		}
+	}
+
+	for i, exp := range module.Exports {
+		sb.WriteString(fmt.Sprintf("var e%d = instance.exports[%s];\n", i, helpers.QuoteForJSON(exp.Name, false)))
+		sb.WriteString(fmt.Sprintf("export { e%d as %s };\n", i, quoteName(exp.Name)))
+	}
+
+	return sb.String()
+}
+
+// This generates a JavaScript module for "import source x from 'file.wasm'".
+// The default export is a "WebAssembly.Module" object that hasn't been
+// instantiated yet, so the imports of the WebAssembly module aren't needed.
+func wasmSourceToJS(contents string, wasm wasmOptions) string {
+	if wasm.inline && wasm.async {
+		return fmt.Sprintf("export default await WebAssembly.compile(%s);\n", wasmInlineBytes(contents, wasm.platform))
+	}
+	if wasm.inline {
+		return fmt.Sprintf("export default new WebAssembly.Module(%s);\n", wasmInlineBytes(contents, wasm.platform))
+	}
+	if wasm.platform == config.PlatformNode {
+		return wasmFileImport(wasm.fileName, wasm.platform) +
+			"export default await WebAssembly.compile(await readFile(new URL(url, import.meta.url)));\n"
+	}
+	return wasmFileImport(wasm.fileName, wasm.platform) +
+		"export default await WebAssembly.compileStreaming(fetch(new URL(url, import.meta.url)));\n"
+}
+
+func wasmInlineBytes(contents string, platform config.Platform) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(contents))
+	if platform == config.PlatformNode {
+		return fmt.Sprintf("Buffer.from(\"%s\", \"base64\")", encoded)
+	}
+	return fmt.Sprintf("Uint8Array.from(atob(\"%s\"), (c) => c.charCodeAt(0))", encoded)
+}
+
+// This references the WebAssembly module itself using the "file" loader
+func wasmFileImport(fileName string, platform config.Platform) string {
+	url := string(helpers.QuoteForJSON("./"+fileName, false))
+	text := fmt.Sprintf("import url from %s with { loader: \"file\" };\n", url)
+	if platform == config.PlatformNode {
+		text += "import { readFile } from \"node:fs/promises\";\n"
+	}
+	return text
+}
+
+// The object for "import.meta.glob()" is generated as JavaScript source code
+// and then parsed. This way eager globs can use regular import statements,
+// which lets the linker bind to each module's exports as usual. Each import
+// record in the generated code lines up with the matching resolved path.
+func (s *scanner) importMetaGlobAST(
+	source *logger.Source,
+	importSource *logger.Source,
+	importRange logger.Range,
+	keys []string,
+	importRecords []ast.ImportRecord,
+	kind ast.ImportKind,
+	importMeta *ast.ImportMetaGlob,
+	exportAlias string,
+) js_ast.AST {
+	var imports strings.Builder
+	var object strings.Builder
+
+	// Generate either "m.name" or "m['name']" for a specific export
+	var member string
+	if importMeta.Import != "" {
+		if js_ast.IsIdentifier(importMeta.Import) {
+			member = "." + importMeta.Import
+		} else {
+			member = "[" + string(helpers.QuoteForJSON(importMeta.Import, false)) + "]"
+		}
+	}
+
+	object.WriteString(fmt.Sprintf("export var %s = {", exportAlias))
+	for i, key := range keys {
+		quoted := string(helpers.QuoteForJSON(key, false))
+		if i > 0 {
+			object.WriteByte(',')
+		}
+		object.WriteString(fmt.Sprintf("\n  %s: ", quoted))
+
+		if kind == ast.ImportStmt {
+			// Eager: "import * as m0 from './a.js'" or "import { name as m0 } from './a.js'"
+			if importMeta.Import == "" {
+				imports.WriteString(fmt.Sprintf("import * as m%d from %s;\n", i, quoted))
+			} else if js_ast.IsIdentifier(importMeta.Import) {
+				imports.WriteString(fmt.Sprintf("import { %s as m%d } from %s;\n", importMeta.Import, i, quoted))
+			} else {
+				imports.WriteString(fmt.Sprintf("import { %s as m%d } from %s;\n", helpers.QuoteForJSON(importMeta.Import, false), i, quoted))
+			}
+			object.WriteString(fmt.Sprintf("m%d", i))
+		} else {
+			// Lazy: "() => import('./a.js')" or "() => import('./a.js').then((m) => m.name)"
+			object.WriteString(fmt.Sprintf("() => import(%s)", quoted))
+			if member != "" {
+				object.WriteString(fmt.Sprintf(".then((m) => m%s)", member))
+			}
+		}
+	}
+	object.WriteString("\n};\n")
+
+	// The generated code may not be valid for the configured target (e.g. if it
+	// doesn't support arbitrary module namespace names). Report any problems at
+	// the location of the "import.meta.glob()" call instead of in the generated
+	// code, which the user has never seen.
+	source.Contents = imports.String() + object.String()
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, s.log.Overrides)
+	tree, ok := js_parser.Parse(log, *source, js_parser.OptionsFromConfig(&s.options))
+	msgs := log.Done()
+	if !ok || log.HasErrors() {
+		var notes []logger.MsgData
+		for _, msg := range msgs {
+			if msg.Kind == logger.Error {
+				notes = append(notes, logger.MsgData{Text: msg.Data.Text})
+			}
+		}
+		tracker := logger.MakeLineColumnTracker(importSource)
+		s.log.AddErrorWithNotes(&tracker, importRange, "Could not generate the object for this \"import.meta.glob()\" call", notes)
+		return js_parser.GlobResolveAST(s.log, *source, importRecords, &js_ast.EObject{}, exportAlias)
+	}
+	for _, msg := range msgs {
+		s.log.AddMsg(msg)
+	}
+
+	// Substitute the resolved paths into the generated import records
+	for i := range importRecords {
+		record := &tree.ImportRecords[i]
+		record.Path = importRecords[i].Path
+		record.SourceIndex = importRecords[i].SourceIndex
+		record.AssertOrWith = importRecords[i].AssertOrWith
+	}
+	return tree
+}
+
+// This is used for the "loader" import attribute
+func loaderFromImportAttribute(text string) (config.Loader, bool) {
+	for i, name := range config.LoaderToString {
+		if loader := config.Loader(i); name == text && loader != config.LoaderNone && loader != config.LoaderDefault {
+			return loader, true
+		}
+	}
+	return config.LoaderNone, false
+}
+
 func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scannerFile {
 	s.timer.Begin("Process scanned files")
 	defer s.timer.End("Process scanned files")
@@ -2213,10 +2615,11 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 		}
 	}
 
-	// Import attributes can result in the same file being imported multiple
-	// times in different ways. If that happens, append the import attributes
-	// to the pretty-printed file names to disambiguate them. This renaming
-	// must happen before we construct the metafile JSON chunks below.
+	// Import attributes and source phase imports can result in the same file
+	// being imported multiple times in different ways. If that happens, append
+	// the import attributes to the pretty-printed file names to disambiguate
+	// them. This renaming must happen before we construct the metafile JSON
+	// chunks below.
 	for _, sourceIndices := range importAttributeNameCollisions {
 		if len(sourceIndices) == 1 {
 			continue
@@ -2224,6 +2627,9 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 
 		for _, sourceIndex := range sourceIndices {
 			source := &s.results[sourceIndex].file.inputFile.Source
+			if source.KeyPath.IsSourcePhase() {
+				source.PrettyPath = "source " + source.PrettyPath
+			}
 			attrs := source.KeyPath.ImportAttributes.DecodeIntoArray()
 			if len(attrs) == 0 {
 				continue
@@ -2384,6 +2790,27 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 					}
 
 				case ast.ImportURL:
+					// "new URL()" and "import.meta.resolve()" in JavaScript are only
+					// rewritten if they refer to an asset. Anything else (e.g. a JSON
+					// file that's read at run-time) is left unchanged.
+					if _, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok {
+						hasURL := true
+						switch otherRepr := otherFile.inputFile.Repr.(type) {
+						case *graph.CSSRepr:
+							hasURL = false
+						case *graph.JSRepr:
+							hasURL = otherRepr.AST.URLForCSS != "" || otherFile.inputFile.Loader == config.LoaderEmpty
+						}
+						if !hasURL {
+							s.log.AddID(logger.MsgID_None, logger.Debug, &tracker, record.Range,
+								fmt.Sprintf("The URL %q was left unchanged because %q was loaded with the %q loader, which doesn't provide a URL",
+									record.Path.Text, otherFile.inputFile.Source.PrettyPath, config.LoaderToString[otherFile.inputFile.Loader]))
+							record.SourceIndex = ast.Index32{}
+							continue
+						}
+						break
+					}
+
 					// Using a JavaScript or CSS file with CSS "url()" is not allowed
 					switch otherRepr := otherFile.inputFile.Repr.(type) {
 					case *graph.CSSRepr:
@@ -2589,26 +3016,27 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 				Ext:  &templateExt,
 			})) + ext
 
+			// Generate the additional file to copy into the output directory
+			outputFile := graph.OutputFile{
+				AbsPath:  s.fs.Join(s.options.AbsOutputDir, relPath),
+				Contents: bytes,
+			}
+			ComputeOutputFileHashes(&outputFile, &s.options)
+
 			// Optionally add metadata about the file
-			var jsonMetadataChunk string
 			if s.options.NeedsMetafile {
 				inputs := fmt.Sprintf("{\n        %s: {\n          \"bytesInOutput\": %d\n        }\n      }",
 					helpers.QuoteForJSON(result.file.inputFile.Source.PrettyPath, s.options.ASCIIOnly),
 					len(bytes),
 				)
-				jsonMetadataChunk = fmt.Sprintf(
-					"{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": %s,\n      \"bytes\": %d\n    }",
+				outputFile.JSONMetadataChunk = fmt.Sprintf(
+					"{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": %s,\n      %s",
 					inputs,
-					len(bytes),
+					JSONMetadataForContents(&outputFile, s.options.ASCIIOnly),
 				)
 			}
 
-			// Generate the additional file to copy into the output directory
-			result.file.inputFile.AdditionalFiles = []graph.OutputFile{{
-				AbsPath:           s.fs.Join(s.options.AbsOutputDir, relPath),
-				Contents:          bytes,
-				JSONMetadataChunk: jsonMetadataChunk,
-			}}
+			result.file.inputFile.AdditionalFiles = []graph.OutputFile{outputFile}
 		}
 
 		s.results[sourceIndex] = result
@@ -2729,7 +3157,13 @@ func DefaultExtensionToLoaderMap() map[string]config.Loader {
 		".css":        config.LoaderCSS,
 		".module.css": config.LoaderLocalCSS,
 		".json":       config.LoaderJSON,
+		".json5":      config.LoaderJSON5,
+		".jsonc":      config.LoaderJSONC,
+		".toml":       config.LoaderTOML,
 		".txt":        config.LoaderText,
+		".wasm":       config.LoaderWasm,
+		".yaml":       config.LoaderYAML,
+		".yml":        config.LoaderYAML,
 	}
 }
 
@@ -2835,8 +3269,15 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 		files[i] = file.inputFile
 	}
 
+	// When code splitting is disabled, each worker is linked separately as its
+	// own entry point. Otherwise the linker turns workers into separate chunks.
+	entryPoints := b.entryPoints
+	if !options.CodeSplitting {
+		entryPoints = appendWorkerEntryPoints(files, entryPoints)
+	}
+
 	// Get the base path from the options or choose the lowest common ancestor of all entry points
-	allReachableFiles := findReachableFiles(files, b.entryPoints)
+	allReachableFiles := findReachableFiles(files, entryPoints, true)
 
 	// Compute source map data in parallel with linking
 	timer.Begin("Spawn source map tasks")
@@ -2844,40 +3285,85 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 	timer.End("Spawn source map tasks")
 
 	var resultGroups [][]graph.OutputFile
-	if options.CodeSplitting || len(b.entryPoints) == 1 {
+	if options.CodeSplitting || len(entryPoints) == 1 {
 		// If code splitting is enabled or if there's only one entry point, link all entry points together
 		resultGroups = [][]graph.OutputFile{link(&options, timer, log, b.fs, b.res,
-			files, b.entryPoints, b.uniqueKeyPrefix, allReachableFiles, dataForSourceMaps)}
+			files, entryPoints, b.uniqueKeyPrefix, allReachableFiles, dataForSourceMaps)}
 	} else {
 		// Otherwise, link each entry point with the runtime file separately
-		waitGroup := sync.WaitGroup{}
-		resultGroups = make([][]graph.OutputFile, len(b.entryPoints))
-		serializer := helpers.MakeSerializer(len(b.entryPoints))
-		for i, entryPoint := range b.entryPoints {
-			waitGroup.Add(1)
-			go func(i int, entryPoint graph.EntryPoint) {
-				entryPoints := []graph.EntryPoint{entryPoint}
-				forked := timer.Fork()
-
-				// Each goroutine needs a separate options object
-				optionsClone := options
-				optionsClone.ExclusiveMangleCacheUpdate = func(cb func(
-					mangleCache map[string]interface{},
-					cssUsedLocalNames map[string]bool,
-				)) {
-					// Serialize all accesses to the mangle cache in entry point order for determinism
-					serializer.Enter(i)
-					defer serializer.Leave(i)
-					cb(mangleCache, cssUsedLocalNames)
+		resultGroups = make([][]graph.OutputFile, len(entryPoints))
+		reachableFiles := make([][]uint32, len(entryPoints))
+		workerDeps := make([][]uint32, len(entryPoints))
+		for i, entryPoint := range entryPoints {
+			reachableFiles[i] = findReachableFiles(files, []graph.EntryPoint{entryPoint}, false)
+			workerDeps[i] = findWorkerReferences(files, reachableFiles[i], entryPoint.SourceIndex)
+		}
+
+		// Workers must be linked before the entry points that reference them
+		// because the final paths of the workers are substituted into the code
+		// that references them. So link the entry points in waves, where each
+		// wave contains the entry points whose workers have all been linked.
+		workerOutputPaths := make(map[uint32]string)
+		isLinked := make([]bool, len(entryPoints))
+		serializer := helpers.MakeSerializer(len(entryPoints))
+		linkOrder := 0
+		for linkOrder < len(entryPoints) {
+			var wave []int
+		nextEntryPoint:
+			for i := range entryPoints {
+				if !isLinked[i] {
+					for _, sourceIndex := range workerDeps[i] {
+						if _, ok := workerOutputPaths[sourceIndex]; !ok {
+							continue nextEntryPoint
+						}
+					}
+					wave = append(wave, i)
 				}
+			}
+			if len(wave) == 0 {
+				log.AddError(nil, logger.Range{}, "Workers that reference each other in a cycle are only supported when code splitting is enabled")
+				break
+			}
 
-				resultGroups[i] = link(&optionsClone, forked, log, b.fs, b.res, files, entryPoints,
-					b.uniqueKeyPrefix, findReachableFiles(files, entryPoints), dataForSourceMaps)
-				timer.Join(forked)
-				waitGroup.Done()
-			}(i, entryPoint)
+			waitGroup := sync.WaitGroup{}
+			for _, i := range wave {
+				waitGroup.Add(1)
+				go func(i int, serializerIndex int, entryPoint graph.EntryPoint) {
+					entryPoints := []graph.EntryPoint{entryPoint}
+					forked := timer.Fork()
+
+					// Each goroutine needs a separate options object
+					optionsClone := options
+					optionsClone.WorkerOutputPaths = workerOutputPaths
+					optionsClone.ExclusiveMangleCacheUpdate = func(cb func(
+						mangleCache map[string]interface{},
+						cssUsedLocalNames map[string]bool,
+					)) {
+						// Serialize all accesses to the mangle cache in link order for determinism
+						serializer.Enter(serializerIndex)
+						defer serializer.Leave(serializerIndex)
+						cb(mangleCache, cssUsedLocalNames)
+					}
+
+					resultGroups[i] = link(&optionsClone, forked, log, b.fs, b.res, files, entryPoints,
+						b.uniqueKeyPrefix, reachableFiles[i], dataForSourceMaps)
+					timer.Join(forked)
+					waitGroup.Done()
+				}(i, linkOrder, entryPoints[i])
+				isLinked[i] = true
+				linkOrder++
+			}
+			waitGroup.Wait()
+
+			// Remember the output paths of everything in this wave
+			for _, i := range wave {
+				for _, outputFile := range resultGroups[i] {
+					if outputFile.EntryPointSourceIndex.IsValid() && outputFile.EntryPointSourceIndex.GetIndex() == entryPoints[i].SourceIndex {
+						workerOutputPaths[entryPoints[i].SourceIndex] = outputFile.AbsPath
+					}
+				}
+			}
 		}
-		waitGroup.Wait()
 	}
 
 	// Join the results in entry point order for determinism
@@ -2886,6 +3372,33 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 		outputFiles = append(outputFiles, group...)
 	}
 
+	// The manifest is an output file itself, so it must be generated before the
+	// metafile. It also includes the integrity hashes of the other output files.
+	if options.NeedsManifest {
+		timer.Begin("Generate manifest JSON")
+		manifest := b.generateManifestFile(outputFiles, options)
+
+		// The manifest always has the same name, so make sure it doesn't replace
+		// an output file such as an asset that happens to be named "manifest.json"
+		manifestKey := canonicalFileSystemPathForWindows(manifest.AbsPath)
+		hasCollision := false
+		for _, outputFile := range outputFiles {
+			if canonicalFileSystemPathForWindows(outputFile.AbsPath) == manifestKey {
+				manifestPath := manifest.AbsPath
+				if relPath, ok := b.fs.Rel(b.fs.Cwd(), manifestPath); ok {
+					manifestPath = relPath
+				}
+				log.AddError(nil, logger.Range{}, "Cannot write the manifest because another output file has the same path: "+manifestPath)
+				hasCollision = true
+				break
+			}
+		}
+		if !hasCollision {
+			outputFiles = append(outputFiles, manifest)
+		}
+		timer.End("Generate manifest JSON")
+	}
+
 	// Also generate the metadata file if necessary
 	var metafileJSON string
 	if options.NeedsMetafile {
@@ -2930,22 +3443,30 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 		// Make an exception for files that have identical contents. In that case
 		// the duplicate is just silently filtered out. This can happen with the
 		// "file" loader, for example.
-		outputFileMap := make(map[string][]byte)
+		outputFileMap := make(map[string]int)
 		end := 0
 		for _, outputFile := range outputFiles {
 			absPathKey := canonicalFileSystemPathForWindows(outputFile.AbsPath)
-			contents, ok := outputFileMap[absPathKey]
+			index, ok := outputFileMap[absPathKey]
 
 			// If this isn't a duplicate, keep the output file
 			if !ok {
-				outputFileMap[absPathKey] = outputFile.Contents
+				outputFileMap[absPathKey] = end
 				outputFiles[end] = outputFile
 				end++
 				continue
 			}
 
-			// If the names and contents are both the same, only keep the first one
-			if bytes.Equal(contents, outputFile.Contents) {
+			// If the names and contents are both the same, only keep the first one.
+			// Streamed output files no longer have their contents, so compare their
+			// hashes instead.
+			if other := &outputFiles[index]; other.IsStreamed || outputFile.IsStreamed {
+				otherSize, otherHash := other.SizeAndHash()
+				size, hash := outputFile.SizeAndHash()
+				if size == otherSize && hash == otherHash {
+					continue
+				}
+			} else if bytes.Equal(other.Contents, outputFile.Contents) {
 				continue
 			}
 
@@ -2966,7 +3487,9 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 // deterministic given that the entry point order is deterministic, since the
 // returned order is the postorder of the graph traversal and import record
 // order within a given file is deterministic.
-func findReachableFiles(files []graph.InputFile, entryPoints []graph.EntryPoint) []uint32 {
+// Workers are only followed when code splitting is enabled, since they are
+// otherwise linked separately as their own entry points
+func findReachableFiles(files []graph.InputFile, entryPoints []graph.EntryPoint, followWorkers bool) []uint32 {
 	visited := make(map[uint32]bool)
 	var order []uint32
 	var visit func(uint32)
@@ -2981,6 +3504,9 @@ func findReachableFiles(files []graph.InputFile, entryPoints []graph.EntryPoint)
 			}
 			if recordsPtr := file.Repr.ImportRecords(); recordsPtr != nil {
 				for _, record := range *recordsPtr {
+					if record.Kind == ast.ImportWorker && !followWorkers {
+						continue
+					}
 					if record.SourceIndex.IsValid() {
 						visit(record.SourceIndex.GetIndex())
 					} else if record.CopySourceIndex.IsValid() {
@@ -3005,6 +3531,39 @@ func findReachableFiles(files []graph.InputFile, entryPoints []graph.EntryPoint)
 	return order
 }
 
+// Add all workers referenced by any entry point (or by other workers) as new
+// entry points. This is used when code splitting is disabled.
+func appendWorkerEntryPoints(files []graph.InputFile, entryPoints []graph.EntryPoint) []graph.EntryPoint {
+	isEntryPoint := make(map[uint32]bool)
+	for _, entryPoint := range entryPoints {
+		isEntryPoint[entryPoint.SourceIndex] = true
+	}
+	for _, sourceIndex := range findReachableFiles(files, entryPoints, true) {
+		for _, otherSourceIndex := range findWorkerReferences(files, []uint32{sourceIndex}, ^uint32(0)) {
+			if !isEntryPoint[otherSourceIndex] {
+				isEntryPoint[otherSourceIndex] = true
+				entryPoints = append(entryPoints, graph.EntryPoint{SourceIndex: otherSourceIndex, IsWorker: true})
+			}
+		}
+	}
+	return entryPoints
+}
+
+// Returns the source indices of the workers referenced by any of these files.
+// A worker that references itself is ignored since it can't know its own path.
+func findWorkerReferences(files []graph.InputFile, sourceIndices []uint32, selfSourceIndex uint32) (workers []uint32) {
+	for _, sourceIndex := range sourceIndices {
+		if repr, ok := files[sourceIndex].Repr.(*graph.JSRepr); ok {
+			for _, record := range repr.AST.ImportRecords {
+				if record.Kind == ast.ImportWorker && record.SourceIndex.IsValid() && record.SourceIndex.GetIndex() != selfSourceIndex {
+					workers = append(workers, record.SourceIndex.GetIndex())
+				}
+			}
+		}
+	}
+	return
+}
+
 // This is done in parallel with linking because linking is a mostly serial
 // phase and there are extra resources for parallelism. This could also be done
 // during parsing but that would slow down parsing and delay the start of the
@@ -3078,6 +3637,159 @@ func (b *Bundle) computeDataForSourceMapsInParallel(options *config.Options, rea
 	}
 }
 
+// Some information about an output file is derived from its final contents.
+// The metafile entry for the output file includes this information, so this
+// must be called before that entry is generated.
+func ComputeOutputFileHashes(outputFile *graph.OutputFile, options *config.Options) {
+	if options.Integrity != 0 {
+		outputFile.Integrity = computeIntegrity(outputFile.Contents, options.Integrity)
+	}
+
+	// Source maps are skipped since they aren't usually served to users, and
+	// they tend to be large enough that compressing them would be slow
+	if options.CompressedSizes && !strings.HasSuffix(outputFile.AbsPath, ".map") {
+		outputFile.GzipBytes = helpers.GzipSize(outputFile.Contents)
+		outputFile.BrotliBytes = len(brotli.Encode(outputFile.Contents))
+	}
+}
+
+// This generates the properties at the end of an output file's metafile entry
+// that describe its final contents. It includes the "}" that ends the entry.
+func JSONMetadataForContents(outputFile *graph.OutputFile, asciiOnly bool) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("\"bytes\": %d", len(outputFile.Contents)))
+	if outputFile.GzipBytes != 0 {
+		sb.WriteString(fmt.Sprintf(",\n      \"gzipBytes\": %d", outputFile.GzipBytes))
+		sb.WriteString(fmt.Sprintf(",\n      \"brotliBytes\": %d", outputFile.BrotliBytes))
+	}
+	if outputFile.Integrity != "" {
+		sb.WriteString(fmt.Sprintf(",\n      \"integrity\": %s", helpers.QuoteForJSON(outputFile.Integrity, asciiOnly)))
+	}
+	sb.WriteString("\n    }")
+	return sb.String()
+}
+
+// This generates a value for the "integrity" attribute on HTML elements:
+// https://www.w3.org/TR/SRI/#the-integrity-attribute
+func computeIntegrity(contents []byte, algorithms config.IntegrityAlgorithms) string {
+	var sb strings.Builder
+	add := func(name string, hash []byte) {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(name)
+		sb.WriteByte('-')
+		sb.WriteString(base64.StdEncoding.EncodeToString(hash))
+	}
+	if (algorithms & config.IntegritySHA256) != 0 {
+		hash := sha256.Sum256(contents)
+		add("sha256", hash[:])
+	}
+	if (algorithms & config.IntegritySHA384) != 0 {
+		hash := sha512.Sum384(contents)
+		add("sha384", hash[:])
+	}
+	if (algorithms & config.IntegritySHA512) != 0 {
+		hash := sha512.Sum512(contents)
+		add("sha512", hash[:])
+	}
+	return sb.String()
+}
+
+// The manifest lets server-side integrations figure out which files to put in
+// the HTML for a given entry point without having to parse the metafile. It's
+// a JSON object mapping the pretty path of each entry point to its output
+// file, its CSS bundle (if any), all chunks it statically imports (directly or
+// indirectly), and the entry points it dynamically imports. All output paths
+// are relative to the output directory.
+func (b *Bundle) generateManifestFile(outputFiles []graph.OutputFile, options config.Options) graph.OutputFile {
+	integrityByPath := make(map[string]string)
+	relPath := func(absPath string) string {
+		if rel, ok := b.fs.Rel(options.AbsOutputDir, absPath); ok {
+			return strings.ReplaceAll(rel, "\\", "/")
+		}
+		return absPath
+	}
+	for _, outputFile := range outputFiles {
+		if outputFile.Integrity != "" {
+			integrityByPath[outputFile.AbsPath] = outputFile.Integrity
+		}
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("{")
+	isFirst := true
+	keys := make(map[string]bool)
+	for _, outputFile := range outputFiles {
+		entry := outputFile.ManifestEntry
+		if entry == nil || keys[entry.EntryPoint] {
+			continue
+		}
+		keys[entry.EntryPoint] = true
+		if isFirst {
+			isFirst = false
+			sb.WriteString("\n  ")
+		} else {
+			sb.WriteString(",\n  ")
+		}
+		sb.WriteString(fmt.Sprintf("%s: {\n    \"file\": %s",
+			helpers.QuoteForJSON(entry.EntryPoint, options.ASCIIOnly),
+			helpers.QuoteForJSON(relPath(outputFile.AbsPath), options.ASCIIOnly)))
+		files := []string{outputFile.AbsPath}
+		if entry.CSSBundle != "" {
+			sb.WriteString(fmt.Sprintf(",\n    \"cssBundle\": %s", helpers.QuoteForJSON(relPath(entry.CSSBundle), options.ASCIIOnly)))
+			files = append(files, entry.CSSBundle)
+		}
+		writeArray := func(name string, values []string) {
+			sb.WriteString(fmt.Sprintf(",\n    %q: [", name))
+			for i, value := range values {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString("\n      ")
+				sb.Write(helpers.QuoteForJSON(value, options.ASCIIOnly))
+			}
+			if len(values) > 0 {
+				sb.WriteString("\n    ")
+			}
+			sb.WriteString("]")
+		}
+		imports := make([]string, len(entry.Imports))
+		for i, absPath := range entry.Imports {
+			imports[i] = relPath(absPath)
+		}
+		writeArray("imports", imports)
+		writeArray("dynamicImports", entry.DynamicImports)
+		files = append(files, entry.Imports...)
+		if options.Integrity != 0 {
+			sb.WriteString(",\n    \"integrity\": {")
+			for i, absPath := range files {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString(fmt.Sprintf("\n      %s: %s",
+					helpers.QuoteForJSON(relPath(absPath), options.ASCIIOnly),
+					helpers.QuoteForJSON(integrityByPath[absPath], options.ASCIIOnly)))
+			}
+			sb.WriteString("\n    }")
+		}
+		sb.WriteString("\n  }")
+	}
+	if !isFirst {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+
+	manifest := graph.OutputFile{
+		AbsPath:  b.fs.Join(options.AbsOutputDir, "manifest.json"),
+		Contents: []byte(sb.String()),
+	}
+	ComputeOutputFileHashes(&manifest, &options)
+	manifest.JSONMetadataChunk = "{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      " +
+		JSONMetadataForContents(&manifest, options.ASCIIOnly)
+	return manifest
+}
+
 func (b *Bundle) generateMetadataJSON(results []graph.OutputFile, allReachableFiles []uint32, asciiOnly bool) string {
 	sb := strings.Builder{}
 	sb.WriteString("{\n  \"inputs\": {")
@@ -628,6 +628,73 @@ func TestImportCSSFromJSLocalAtContainer(t *testing.T) {
 	})
 }
 
+func TestImportCSSFromJSLocalAtProperty(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import "./global.css"
+				import styles from "./local.module.css"
+				console.log(styles)
+			`,
+			"/global.css": `
+				:root { --angle: 10deg; --b: 1px }
+			`,
+			"/local.module.css": `
+				.spin {
+					--angle: 0deg;
+					transform: rotate(var(--angle));
+					transition: --angle 1s, --unregistered 1s;
+					color: var(--unregistered, var(--color));
+				}
+				@property --angle { syntax: "<angle>"; inherits: false; initial-value: 0deg }
+				@property --color { syntax: "<color>"; inherits: true; initial-value: red }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".css":        config.LoaderCSS,
+				".module.css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
+func TestImportCSSFromJSLocalAtPropertyMinifyIdentifiers(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import "./global.css"
+				import styles from "./local.module.css"
+				console.log(styles)
+			`,
+			"/global.css": `
+				:root { --a: 1px; --b: 2px }
+			`,
+			"/local.module.css": `
+				.a { --x: 0deg; rotate: var(--x) }
+				.b { --y: 0px; translate: var(--y) }
+				@property --x { syntax: "<angle>"; inherits: false; initial-value: 0deg }
+				@property --y { syntax: "<length>"; inherits: false; initial-value: 0px }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".css":        config.LoaderCSS,
+				".module.css": config.LoaderLocalCSS,
+			},
+			MinifyIdentifiers: true,
+		},
+	})
+}
+
 func TestImportCSSFromJSNthIndexLocal(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -2618,6 +2685,182 @@ func TestCSSCaseInsensitivity(t *testing.T) {
 	})
 }
 
+func TestCSSAtCustomMediaAndSelectorBundle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./media.css";
+				@import "./selectors.css";
+				@media (--narrow) {
+					:--heading { color: red }
+				}
+				@media (--narrow) and (hover) {
+					:--button:hover, .nav :--heading { color: blue }
+				}
+				@media (--print-or-narrow) {
+					.a > :--deep, :not(:--heading) { color: green }
+				}
+				@media (--undefined) {
+					:--undefined { color: pink }
+				}
+			`,
+			"/media.css": `
+				@custom-media --narrow (max-width: 100px);
+				@custom-media --print-or-narrow print, (--narrow);
+			`,
+			"/selectors.css": `
+				@import "./base.css";
+				@custom-selector :--heading h1, h2;
+				@custom-selector :--deep .b .c;
+			`,
+			"/base.css": `
+				@custom-selector :--heading h1;
+				@custom-selector :--button button, [role=button];
+				:--heading { color: black }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedCompileLog: `entry.css: WARNING: The custom media query "--undefined" is not defined
+entry.css: WARNING: The custom selector ":--undefined" is not defined
+`,
+	})
+}
+
+func TestCSSAtCustomMediaAndSelectorExternalImport(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "https://example.com/defs.css";
+				@media (--narrow) {
+					:--heading { color: red }
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtCustomMediaAndSelectorNoBundle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./defs.css";
+				@media (--narrow) {
+					:--heading { color: red }
+				}
+			`,
+			"/defs.css": `
+				@custom-media --narrow (max-width: 100px);
+				@custom-selector :--heading h1, h2;
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeConvertFormat,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtCustomMediaAndSelectorCycles(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./defs.css";
+				@media (--a) {
+					:--x { color: red }
+				}
+				@media (--b) {
+					:--y { color: blue }
+				}
+			`,
+			"/defs.css": `
+				@custom-media --a (--b) and (hover);
+				@custom-media --b (--a) and (--c);
+				@custom-selector :--x :--y.x;
+				@custom-selector :--y :--x.y, :--z;
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedCompileLog: `defs.css: WARNING: The custom media query "--a" is defined in terms of itself
+defs.css: WARNING: The custom media query "--c" is not defined
+defs.css: WARNING: The custom selector ":--x" is defined in terms of itself
+defs.css: WARNING: The custom selector ":--z" is not defined
+entry.css: WARNING: The custom media query "--a" is defined in terms of itself
+entry.css: WARNING: The custom selector ":--x" is defined in terms of itself
+`,
+	})
+}
+
+func TestCSSAtCustomMediaAndSelectorNoIsPseudoClass(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./defs.css";
+				:--heading + p { color: red }
+				.a:--input:focus { color: blue }
+				:--heading :--input { color: green }
+			`,
+			"/defs.css": `
+				@custom-selector :--heading h1, h2;
+				@custom-selector :--input input, textarea;
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:                   config.ModeBundle,
+			AbsOutputFile:          "/out.css",
+			UnsupportedCSSFeatures: compat.IsPseudoClass,
+		},
+	})
+}
+
+func TestCSSImageSetBundle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				a {
+					background-image: image-set("images/a.png" 1x, "images/a@2x.png" 2x);
+				}
+				b {
+					background-image: -webkit-image-set(url(images/a.png) 1x, url(images/a@2x.png) 2x);
+				}
+				c {
+					background: image-set("images/a.avif" type("image/avif") 1dppx, "images/a.png" type("image/png") 192dpi) no-repeat;
+				}
+			`,
+			"/images/a.png":    `...`,
+			"/images/a@2x.png": `...`,
+			"/images/a.avif":   `...`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+			ExtensionToLoader: map[string]config.Loader{
+				".css":  config.LoaderCSS,
+				".png":  config.LoaderFile,
+				".avif": config.LoaderFile,
+			},
+			CSSPrefixData: compat.CSSPrefixData(map[compat.Engine]compat.Semver{
+				compat.Safari: {Parts: []int{12}},
+			}),
+		},
+	})
+}
+
 func TestCSSAssetPathsWithSpacesBundle(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
 		files: map[string]string{
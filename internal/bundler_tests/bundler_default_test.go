@@ -8218,6 +8218,122 @@ func TestMetafileImportWithTypeJSON(t *testing.T) {
 	})
 }
 
+func TestMetafileIntegrity(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/project/entry.js": `
+				import './entry.css'
+				import('./dynamic')
+			`,
+			"/project/entry.css":  `a { color: red }`,
+			"/project/dynamic.js": `export default 1`,
+		},
+		entryPaths: []string{"/project/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			NeedsMetafile: true,
+			Integrity:     config.IntegritySHA256 | config.IntegritySHA384,
+		},
+	})
+}
+
+func TestMetafileCompressedSizes(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/project/entry.js": `
+				import './entry.css'
+				console.log('hello hello hello hello hello hello')
+			`,
+			"/project/entry.css": `a { color: red }`,
+		},
+		entryPaths: []string{"/project/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			AbsOutputDir:    "/out",
+			SourceMap:       config.SourceMapLinkedWithComment,
+			NeedsMetafile:   true,
+			CompressedSizes: true,
+			Integrity:       config.IntegritySHA256,
+		},
+	})
+}
+
+func TestManifest(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/project/a.js": `
+				import './a.css'
+				import { shared } from './shared'
+				import('./b')
+				console.log(shared)
+			`,
+			"/project/b.js": `
+				import { shared } from './shared'
+				console.log(shared)
+			`,
+			"/project/c.css":     `@import './a.css'; b { color: blue }`,
+			"/project/a.css":     `a { color: red }`,
+			"/project/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/project/a.js", "/project/b.js", "/project/c.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			NeedsManifest: true,
+		},
+	})
+}
+
+func TestManifestCollision(t *testing.T) {
+	default_suite.expectBundledUnix(t, bundled{
+		files: map[string]string{
+			"/project/entry.js":      `import './manifest.json'`,
+			"/project/manifest.json": `{}`,
+		},
+		entryPaths: []string{"/project/entry.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			AbsOutputDir:      "/out",
+			AssetPathTemplate: []config.PathTemplate{{Data: "./", Placeholder: config.NamePlaceholder}},
+			NeedsManifest:     true,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".json": config.LoaderCopy,
+			},
+		},
+		expectedCompileLog: `ERROR: Cannot write the manifest because another output file has the same path: out/manifest.json
+`,
+	})
+}
+
+func TestManifestIntegrity(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/project/entry.js": `
+				import './entry.css'
+				import('./dynamic')
+			`,
+			"/project/entry.css":  `a { color: red }`,
+			"/project/dynamic.js": `export default 1`,
+		},
+		entryPaths: []string{"/project/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			NeedsManifest: true,
+			NeedsMetafile: true,
+			Integrity:     config.IntegritySHA256,
+		},
+	})
+}
+
 func TestCommentPreservation(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -9095,3 +9211,260 @@ func TestStringExportNamesIIFE(t *testing.T) {
 		},
 	})
 }
+
+func TestWorkerNewURL(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./worker.js", import.meta.url), { type: "module" })
+				new SharedWorker(new URL("./shared-worker.js", import.meta.url))
+				navigator.serviceWorker.register(new URL("../sw.js", import.meta.url))
+				new Worker(new URL("https://example.com/remote.js", import.meta.url))
+				new Worker(new URL("./not-import-meta.js", location.href))
+				console.log(shared)
+			`,
+			"/src/worker.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./nested-worker.js", import.meta.url))
+				console.log("worker", shared)
+			`,
+			"/src/nested-worker.js": `
+				new Worker(new URL("./nested-worker.js", import.meta.url))
+				console.log("nested worker")
+			`,
+			"/src/shared-worker.js": `console.log("shared worker")`,
+			"/src/shared.js":        `export let shared = 123`,
+			"/sw.js":                `console.log("service worker")`,
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+			EntryPathTemplate: []config.PathTemplate{
+				{Data: "./", Placeholder: config.NamePlaceholder},
+				{Data: "-", Placeholder: config.HashPlaceholder},
+			},
+			ChunkPathTemplate: []config.PathTemplate{
+				{Data: "./workers/", Placeholder: config.NamePlaceholder},
+				{Data: "-", Placeholder: config.HashPlaceholder},
+			},
+			NeedsMetafile: true,
+		},
+	})
+}
+
+func TestWorkerNewURLOutfile(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js":  `new Worker(new URL("./worker.js", import.meta.url))`,
+			"/worker.js": `console.log("worker")`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out/app.js",
+		},
+	})
+}
+
+func TestWorkerNewURLIIFE(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				new Worker(new URL("./worker.js", import.meta.url))
+				navigator.serviceWorker.register(new URL("./sw.js", import.meta.url))
+			`,
+			"/worker.js": `console.log("worker")`,
+			"/sw.js":     `console.log("service worker")`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatIIFE,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestWorkerNewURLCommonJS(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js":  `new Worker(new URL("./worker.js", import.meta.url))`,
+			"/worker.js": `console.log("worker")`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatCommonJS,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestWorkerNewURLDefineImportMetaURL(t *testing.T) {
+	defines := config.ProcessDefines(map[string]config.DefineData{
+		"import.meta.url": {
+			DefineExpr: &config.DefineExpr{
+				Parts: []string{"document", "baseURI"},
+			},
+		},
+	})
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js":  `new Worker(new URL("./worker.js", import.meta.url))`,
+			"/worker.js": `console.log("worker")`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatIIFE,
+			AbsOutputDir: "/out",
+			Defines:      &defines,
+		},
+	})
+}
+
+func TestWorkerNewURLCycle(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `new Worker(new URL("./a.js", import.meta.url))`,
+			"/a.js":     `new Worker(new URL("./b.js", import.meta.url))`,
+			"/b.js":     `new Worker(new URL("./a.js", import.meta.url))`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+		},
+		expectedCompileLog: `ERROR: Workers that reference each other in a cycle are only supported when code splitting is enabled
+`,
+	})
+}
+
+func TestImportDefer(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import defer * as esm from './esm.js'
+				import defer * as cjs from './cjs.js'
+				import defer * as unused from './unused.js'
+				export function run() {
+					console.log(esm.value, esm[Symbol.toStringTag], cjs.value)
+				}
+			`,
+			"/esm.js": `
+				console.log('evaluating esm')
+				export let value = 1
+			`,
+			"/cjs.js": `
+				console.log('evaluating cjs')
+				exports.value = 2
+			`,
+			"/unused.js": `
+				console.log('evaluating unused')
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestImportDeferSharedWithEagerImport(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import defer * as lazy from './foo.js'
+				import { value } from './foo.js'
+				console.log(lazy.value, value)
+			`,
+			"/foo.js": `
+				console.log('evaluating foo')
+				export let value = 1
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestImportDeferTopLevelAwait(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import defer * as lazy from './foo.js'
+				export function run() {
+					return lazy.value
+				}
+			`,
+			"/foo.js": `
+				import './bar.js'
+				export let value = 1
+			`,
+			"/bar.js": `
+				await 0
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestImportDeferExternalESM(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import defer * as ns from 'pkg'
+				console.log(ns.value)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"pkg": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestImportDeferExternalCommonJS(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import defer * as ns from 'pkg'
+				console.log(ns.value)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"pkg": true,
+				}},
+			},
+		},
+	})
+}
@@ -3,6 +3,7 @@ package bundler_tests
 import (
 	"testing"
 
+	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
 )
 
@@ -289,3 +290,180 @@ func TestGlobWildcardNoSlash(t *testing.T) {
 		},
 	})
 }
+
+func TestGlobImportMetaLazy(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const pages = import.meta.glob('./pages/*.js')
+				const defaults = import.meta.glob('./pages/*.js', { import: 'default' })
+				const again = import.meta.glob('./pages/*.js')
+				console.log(pages, defaults, again)
+			`,
+			"/pages/a.js":        `export default 'a'`,
+			"/pages/b.js":        `export default 'b'`,
+			"/pages/nested/c.js": `DO NOT BUNDLE`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestGlobImportMetaEager(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const modules = import.meta.glob('./pages/**/*.js', { eager: true })
+				const defaults = import.meta.glob('./pages/**/*.js', { eager: true, import: 'default' })
+				const titles = import.meta.glob('./pages/**/*.js', { eager: true, import: 'page-title' })
+				console.log(modules, defaults, titles)
+			`,
+			"/pages/a.js":        `export default 'a'; export let foo = 1; export { foo as 'page-title' }`,
+			"/pages/nested/b.js": `export default 'b'; let title = 'B'; export { title as 'page-title' }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestGlobImportMetaCommonJS(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const lazy = import.meta.glob('./pages/*.js')
+				const eager = import.meta.glob('./pages/*.js', { eager: true, import: 'default' })
+				console.log(lazy, eager, import.meta.url)
+			`,
+			"/pages/a.js": `export default 'a'`,
+			"/pages/b.js": `export default 'b'`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `entry.js: WARNING: "import.meta" is not available with the "cjs" output format and will be empty
+NOTE: You need to set the output format to "esm" for "import.meta" to work correctly.
+`,
+	})
+}
+
+func TestGlobImportMetaIIFE(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const lazy = import.meta.glob('./pages/*.js')
+				const eager = import.meta.glob('./pages/*.js', { eager: true })
+				console.log(lazy, eager)
+			`,
+			"/pages/a.js": `export default 'a'`,
+			"/pages/b.js": `export default 'b'`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatIIFE,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestGlobImportMetaES2019(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const eager = import.meta.glob('./pages/*.js', { eager: true, import: 'default' })
+				console.log(eager)
+			`,
+			"/pages/a.js": `export default 'a'`,
+			"/pages/b.js": `export default 'b'`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: compat.ImportMeta,
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestGlobImportMetaQuery(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const raw = import.meta.glob('./data/*.txt', { eager: true, import: 'default', query: 'raw' })
+				console.log(raw)
+			`,
+			"/data/a.txt": `a`,
+			"/data/b.txt": `b`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			NeedsMetafile: true,
+		},
+	})
+}
+
+func TestGlobImportMetaErrors(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import.meta.glob(pattern)
+				import.meta.glob('pages/*.js')
+				import.meta.glob('./pages/*.js', options)
+				import.meta.glob('./pages/*.js', { eager: 1 })
+				import.meta.glob('./pages/*.js', { import: false })
+				import.meta.glob('./pages/*.js', { as: 'raw' })
+				import.meta.glob('./missing/*.js')
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: The first argument to "import.meta.glob()" must be a string literal
+entry.js: ERROR: The glob pattern "pages/*.js" must start with "./" or "../"
+entry.js: ERROR: The second argument to "import.meta.glob()" must be an object literal
+entry.js: ERROR: The "eager" option must be a boolean literal
+entry.js: ERROR: The "import" option must be a string literal
+entry.js: ERROR: Unsupported option "as" for "import.meta.glob()"
+entry.js: ERROR: Could not resolve import.meta.glob("./missing/*.js")
+`,
+	})
+}
+
+func TestGlobImportMetaInvalidImportName(t *testing.T) {
+	glob_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const values = import.meta.glob('./pages/*.js', { eager: true, import: '\uD800' })
+				console.log(values)
+			`,
+			"/pages/a.js": `export default 'a'`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: Could not generate the object for this "import.meta.glob()" call
+NOTE: This import alias is invalid because it contains the unpaired Unicode surrogate U+D800
+`,
+	})
+}
@@ -310,6 +310,168 @@ func TestLoaderJSONMissingES6(t *testing.T) {
 	})
 }
 
+func TestLoaderJSONC(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { compilerOptions } from './tsconfig.jsonc'
+				console.log(compilerOptions)
+			`,
+			"/tsconfig.jsonc": `
+				// This is a comment
+				{
+					"compilerOptions": {
+						"strict": true, /* trailing comma */
+					},
+					"unused": [1, 2, 3,],
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderJSON5(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { unquoted, single, hex, numbers, multiline } from './data.json5'
+				console.log(unquoted, single, hex, numbers, multiline)
+			`,
+			"/data.json5": `
+				// Comments are allowed
+				{
+					unquoted: 'and you can quote me on that',
+					single: 'I can use "double quotes" here',
+					hex: 0xDECAF,
+					numbers: [.5, 5., +1, -Infinity, NaN],
+					multiline: 'Look, Mom! \
+No \\n\'s!',
+					unused: true,
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderJSON5Errors(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import './a.json5'
+				import './b.json5'
+				import './c.jsonc'
+			`,
+			"/a.json5": `{ x: 0b101 }`,
+			"/b.json5": `{ 'x': undefined }`,
+			"/c.jsonc": `{ x: 1 }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `a.json5: ERROR: Unexpected "0b101" in JSON
+b.json5: ERROR: Unexpected "undefined" in JSON
+c.jsonc: ERROR: Expected string in JSON but found "x"
+`,
+	})
+}
+
+func TestLoaderYAML(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { name, jobs } from './config.yaml'
+				import other from './other.yml'
+				console.log(name, jobs, other)
+			`,
+			"/config.yaml": `
+name: build
+on: [push, pull_request]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: npm test
+unused: true
+`,
+			"/other.yml": "defaults: &defaults\n  x: 1\nvalue:\n  <<: *defaults\n  y: 2\n",
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderTOML(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { title, owner } from './config.toml'
+				console.log(title, owner)
+			`,
+			"/config.toml": `
+title = "Example"
+
+[owner]
+name = "Tom"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+ports = [8000, 8001, 8002]
+enabled = true
+`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderYAMLAndTOMLErrors(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import './a.yaml'
+				import './b.yaml'
+				import './c.toml'
+				import './d.toml'
+			`,
+			"/a.yaml": "a: 1\na: 2\n",
+			"/b.yaml": "a: *missing\n",
+			"/c.toml": "a = 1\na = 2\n",
+			"/d.toml": "a = { b = 1, }\n",
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `a.yaml: ERROR: Duplicate key "a" in YAML mapping
+a.yaml: NOTE: The original key "a" is here:
+b.yaml: ERROR: The YAML anchor "missing" has not been defined
+c.toml: ERROR: Duplicate key "a"
+c.toml: NOTE: The original definition of "a" is here:
+d.toml: ERROR: TOML does not support trailing commas in inline tables
+`,
+	})
+}
+
 func TestLoaderTextCommonJSAndES6(t *testing.T) {
 	loader_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1338,6 +1500,114 @@ entry.js: ERROR: Importing with a type attribute of "garbage" is not supported
 	})
 }
 
+func TestWithTypeTextBytesCSS(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import text from './data.bin' with { type: 'text' }
+				import bytes from './data.bin' with { type: 'bytes' }
+				import sheet from './style.css' with { type: 'css' }
+				document.adoptedStyleSheets = [sheet]
+				console.log(text, bytes)
+				import('./data.bin', { with: { type: 'text' } }).then(console.log)
+			`,
+			"/data.bin": `hello`,
+			"/style.css": `
+				a { color: red }
+				b { color: blue }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestWithLoaderAttribute(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './data.txt' with { loader: 'garbage' }
+				console.log(a)
+			`,
+			"/data.txt": `hello`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: Importing with a loader attribute of "garbage" is not supported
+`,
+	})
+}
+
+func TestWithLoaderAttributeValid(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './data.txt' with { loader: 'base64' }
+				import b from './data.txt' with { loader: 'dataurl' }
+				import c from './data.txt' with { loader: 'text', type: 'bytes' }
+				import d from './data.txt'
+				console.log(a, b, c, d)
+			`,
+			"/data.txt": `hello`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestWithTypeCSSURL(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import sheet from './style.css' with { type: 'css' }
+				document.adoptedStyleSheets = [sheet]
+			`,
+			"/style.css": `
+				a { background: url(./image.png) }
+			`,
+			"/image.png": `x`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".css": config.LoaderCSS,
+				".png": config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestWithTypeExternalPassThrough(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import sheet from './style.css' with { type: 'css' }
+				import text from './data.txt' with { type: 'text' }
+				console.log(sheet, text)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 func TestWithBadAttribute(t *testing.T) {
 	loader_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1739,3 +2009,381 @@ func TestLoaderJSONPrototypeES5(t *testing.T) {
 		},
 	})
 }
+
+func TestLoaderNewURLImportMetaURL(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entries/entry.js": `
+				import x from '../images/image.png'
+				console.log(x, new URL('../images/image.png', import.meta.url))
+				console.log(new URL('../images/copy.txt', import.meta.url).href)
+				console.log(new URL('../images/icon.svg', import.meta.url))
+				console.log(import.meta.resolve('../images/other.png'))
+				console.log(new URL('https://example.com/image.png', import.meta.url))
+				console.log(new URL('../images/unresolved.png', document.baseURI))
+				console.log(import.meta.resolve('some-package'))
+			`,
+			"/src/images/image.png": "x",
+			"/src/images/other.png": "y",
+			"/src/images/copy.txt":  "z",
+			"/src/images/icon.svg":  "<svg/>",
+		},
+		entryPaths: []string{"/src/entries/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputBase: "/src",
+			AbsOutputDir:  "/out",
+			AssetPathTemplate: []config.PathTemplate{
+				{Data: "", Placeholder: config.DirPlaceholder},
+				{Data: "/", Placeholder: config.NamePlaceholder},
+				{Data: "-", Placeholder: config.HashPlaceholder},
+			},
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".png": config.LoaderFile,
+				".txt": config.LoaderCopy,
+				".svg": config.LoaderDataURL,
+			},
+			NeedsMetafile: true,
+		},
+	})
+}
+
+func TestLoaderNewURLImportMetaURLIIFE(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				console.log(new URL('./image.png', import.meta.url))
+				console.log(new URL('./unresolved.png', import.meta.url))
+			`,
+			"/src/image.png": "x",
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatIIFE,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".png": config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestLoaderNewURLImportMetaURLCommonJS(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js":  `console.log(new URL('./image.png', import.meta.url))`,
+			"/src/image.png": "x",
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatCommonJS,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".png": config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestLoaderNewURLImportMetaURLPublicPath(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entries/entry.js": `
+				console.log(new URL('../images/image.png', import.meta.url))
+				console.log(import.meta.resolve('../images/image.png'))
+			`,
+			"/src/images/image.png": "x",
+		},
+		entryPaths: []string{"/src/entries/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputBase: "/src",
+			AbsOutputDir:  "/out",
+			PublicPath:    "https://example.com",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".png": config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestLoaderNewURLImportMetaURLNoURL(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				console.log(new URL('./other.js', import.meta.url))
+				console.log(import.meta.resolve('./style.css'))
+				console.log(new URL('../package.json', import.meta.url))
+				console.log(new URL('./image.png', import.meta.url))
+			`,
+			"/src/other.js":  `console.log('other')`,
+			"/src/style.css": `a { color: red }`,
+			"/src/image.png": "x",
+			"/package.json":  `{ "version": "1.0.0" }`,
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".css":  config.LoaderCSS,
+				".json": config.LoaderJSON,
+				".png":  config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestLoaderNewURLImportMetaURLUnresolved(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log(new URL('./', import.meta.url))
+				console.log(new URL('./generated/at-runtime.json', import.meta.url))
+				console.log(import.meta.resolve('./missing.js'))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+// This module imports "log" from "./env.js" and exports "add" and "memory"
+const wasmTestModule = "\x00asm\x01\x00\x00\x00\x02\x10\x01\x08./env.js\x03log\x00\x00\x07\x10\x02\x03add\x00\x01\x06memory\x02\x00"
+
+func TestLoaderWasm(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { add, memory } from './math.wasm'
+				console.log(add(1, 2), memory)
+			`,
+			"/env.js":    `export function log(x) { console.log(x) }`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderWasmNode(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { add } from './math.wasm'
+				console.log(add(1, 2))
+			`,
+			"/env.js":    `export function log(x) { console.log(x) }`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			Platform:      config.PlatformNode,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderWasmInline(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { add } from './math.wasm'
+				console.log(add(1, 2))
+			`,
+			"/env.js":    `export function log(x) { console.log(x) }`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatIIFE,
+			WasmInline:    true,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderWasmInlineESM(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import source mod from './math.wasm'
+				import { add } from './math.wasm'
+				console.log(mod instanceof WebAssembly.Module, add(1, 2))
+			`,
+			"/env.js":    `export function log(x) { console.log(x) }`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			WasmInline:    true,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderWasmErrors(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import './invalid.wasm'
+				import './valid.wasm'
+			`,
+			"/env.js":       `export function log(x) { console.log(x) }`,
+			"/invalid.wasm": "\x00asm\x02\x00\x00\x00",
+			"/valid.wasm":   wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+		expectedScanLog: `entry.js: ERROR: Invalid WebAssembly module: invalid.wasm
+NOTE: Unsupported WebAssembly version 2
+entry.js: ERROR: Loading the WebAssembly module "valid.wasm" requires top-level await, which is not available with the "cjs" output format
+NOTE: You can either use the "esm" output format or enable the "wasm-inline" setting to embed the module in the output and instantiate it synchronously.
+`,
+	})
+}
+
+func TestLoaderWasmSourcePhase(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import source mod from './math.wasm'
+				import { add } from './math.wasm'
+				console.log(mod instanceof WebAssembly.Module, add(1, 2))
+			`,
+			"/env.js":    `export function log(x) { console.log(x) }`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderWasmSourcePhaseInline(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import source mod from './math.wasm'
+				console.log(new WebAssembly.Instance(mod, { './env.js': { log: console.log } }))
+			`,
+			"/math.wasm": wasmTestModule,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatIIFE,
+			Platform:      config.PlatformNode,
+			WasmInline:    true,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".wasm": config.LoaderWasm,
+			},
+		},
+	})
+}
+
+func TestLoaderSourcePhaseErrors(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import source a from './foo.js'
+				import source b from 'pkg'
+				console.log(a, b)
+			`,
+			"/foo.js": `export default 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"pkg": true,
+				}},
+			},
+		},
+		expectedScanLog: `entry.js: ERROR: Source phase imports are only supported for WebAssembly modules: foo.js
+`,
+	})
+}
+
+func TestLoaderSourcePhaseExternalCommonJS(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import source mod from 'pkg'
+				console.log(mod)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"pkg": true,
+				}},
+			},
+		},
+		expectedCompileLog: `entry.js: ERROR: Source phase imports of external modules are not supported with the "cjs" output format
+`,
+	})
+}
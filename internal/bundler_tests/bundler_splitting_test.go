@@ -3,6 +3,7 @@ package bundler_tests
 import (
 	"testing"
 
+	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
 )
 
@@ -597,3 +598,408 @@ func TestEdgeCaseIssue2793WithoutSplitting(t *testing.T) {
 		},
 	})
 }
+
+func TestSplittingModulePreload(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { shared } from './shared'
+				console.log(shared, import('./route'), import('./leaf'))
+			`,
+			"/route.js": `
+				import './route.css'
+				import { shared } from './shared'
+				import { nested } from './nested'
+				export default [shared, nested]
+			`,
+			"/nested.js": `
+				import { deep } from './deep'
+				export let nested = deep
+			`,
+			"/b.js": `
+				import { nested } from './nested'
+				console.log(nested)
+			`,
+			"/deep.js":   `export let deep = 1`,
+			"/shared.js": `export let shared = 2`,
+			"/leaf.js":   `export default 3`,
+			"/route.css": `a { color: red }`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			ModulePreload: true,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingModulePreloadNoArrow(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { shared } from './shared'
+				console.log(shared, import('./route'))
+			`,
+			"/route.js": `
+				import { shared } from './shared'
+				import './route.css'
+				export default shared
+			`,
+			"/route.css": `a { color: red }`,
+			"/shared.js": `export let shared = 1`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			CodeSplitting:         true,
+			ModulePreload:         true,
+			UnsupportedJSFeatures: compat.Arrow,
+			AbsOutputDir:          "/out",
+		},
+	})
+}
+
+func TestSplittingModulePreloadNothingToPreload(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log(import('./route'))
+			`,
+			"/route.js": `
+				import { helper } from './helper'
+				export default helper
+			`,
+			"/helper.js": `export let helper = 1`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			ModulePreload: true,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingManualChunks(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/project/a.js": `
+				import React from 'react'
+				import { render } from 'react-dom'
+				import { format } from './utils/format'
+				console.log(React, render, format)
+			`,
+			"/project/b.js": `
+				import React from 'react'
+				import { shared } from './shared'
+				console.log(React, shared, import('./lazy'))
+			`,
+			"/project/lazy.js": `
+				import { format } from './utils/format'
+				import { render } from 'react-dom'
+				export default [format, render]
+			`,
+			"/project/shared.js":                       `export let shared = 1`,
+			"/project/utils/format.js":                 `export let format = x => x + ''`,
+			"/project/node_modules/react/index.js":     `export default { version: 18 }`,
+			"/project/node_modules/react-dom/index.js": `import React from 'react'; export let render = () => React`,
+			"/project/node_modules/unrelated/index.js": `export let unused = 1`,
+		},
+		entryPaths: []string{"/project/a.js", "/project/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			AbsOutputDir:  "/out",
+			ManualChunks: []config.ManualChunk{
+				{
+					Name:         "utils",
+					PathPatterns: []config.WildcardPattern{{Prefix: "/project/utils/"}},
+				},
+				{
+					Name:            "vendor-react",
+					PackagePatterns: []config.WildcardPattern{{Prefix: "react"}},
+				},
+			},
+		},
+	})
+}
+
+func TestSplittingMinChunkSize(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { ab } from './ab'
+				import { ac } from './ac'
+				import { abc } from './abc'
+				console.log(ab, ac, abc)
+			`,
+			"/b.js": `
+				import { ab } from './ab'
+				import { bc } from './bc'
+				import { abc } from './abc'
+				console.log(ab, bc, abc)
+			`,
+			"/c.js": `
+				import { ac } from './ac'
+				import { bc } from './bc'
+				import { abc } from './abc'
+				console.log(ac, bc, abc)
+			`,
+			"/ab.js":  `export let ab = 'ab'`,
+			"/bc.js":  `export let bc = 'bc'`,
+			"/abc.js": `export let abc = 'abc'`,
+
+			// This has side effects, so it can't be merged with chunks that the
+			// entry point "b" loads
+			"/ac.js": `
+				export let ac = 'ac'
+				console.log('side effect')
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js", "/c.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			MinChunkSize:  1000,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingMaxChunks(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { ab } from './ab'
+				import { ac } from './ac'
+				console.log(ab, ac)
+			`,
+			"/b.js": `
+				import { ab } from './ab'
+				import { bc } from './bc'
+				console.log(ab, bc)
+			`,
+			"/c.js": `
+				import { ac } from './ac'
+				import { bc } from './bc'
+				console.log(ac, bc)
+			`,
+			"/ab.js": `export let ab = 'ab'`,
+			"/bc.js": `export let bc = 'bc'`,
+			"/ac.js": `export let ac = 'this string is longer than the others'`,
+		},
+		entryPaths: []string{"/a.js", "/b.js", "/c.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			MaxChunks:     4,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSharedES6IntoCommonJS(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {foo, setFoo} from "./shared.js"
+				setFoo(1)
+				console.log(foo)
+				import("./lazy.js").then(ns => console.log(ns.default))
+			`,
+			"/b.js": `
+				import {foo} from "./shared.js"
+				console.log({foo})
+				export let bar = foo
+			`,
+			"/shared.js": `
+				export let foo = 123
+				export function setFoo(value) { foo = value }
+			`,
+			"/lazy.js": `export default 'lazy'`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSharedES6IntoIIFE(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {foo, setFoo} from "./shared.js"
+				setFoo(1)
+				console.log(foo)
+				import("./lazy.js").then(ns => console.log(ns.default))
+			`,
+			"/b.js": `
+				import {foo} from "./shared.js"
+				console.log({foo})
+				export let bar = foo
+			`,
+			"/shared.js": `
+				export let foo = 123
+				export function setFoo(value) { foo = value }
+			`,
+			"/lazy.js": `export default 'lazy'`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatIIFE,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSharedES6IntoIIFEMinify(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {foo} from "./shared.js"
+				console.log(foo)
+			`,
+			"/b.js": `
+				import {foo} from "./shared.js"
+				console.log(foo)
+			`,
+			"/shared.js": `export let foo = 123`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			CodeSplitting:         true,
+			OutputFormat:          config.FormatIIFE,
+			MinifyIdentifiers:     true,
+			MinifyWhitespace:      true,
+			MinifySyntax:          true,
+			UnsupportedJSFeatures: compat.Arrow,
+			AbsOutputDir:          "/out",
+		},
+	})
+}
+
+func TestSplittingPreserveEvalOrder(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import "./polyfill.js"
+				import {foo} from "./shared.js"
+				console.log('a', foo)
+			`,
+			"/b.js": `
+				import {foo} from "./shared.js"
+				import "./polyfill.js"
+				console.log('b', foo)
+			`,
+			"/polyfill.js": `globalThis.polyfilled = true`,
+			"/shared.js": `
+				console.log('shared', globalThis.polyfilled)
+				export let foo = 123
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			CodeSplitting:     true,
+			PreserveEvalOrder: true,
+			OutputFormat:      config.FormatESModule,
+			AbsOutputDir:      "/out",
+		},
+	})
+}
+
+func TestSplittingWorkerNewURL(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./worker.js", import.meta.url), { type: "module" })
+				console.log(shared)
+			`,
+			"/worker.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./worker.js", import.meta.url), { type: "module" })
+				console.log("worker", shared)
+			`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingWorkerNewURLNotModule(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./worker.js", import.meta.url))
+				new Worker(new URL("./worker.js", import.meta.url), { type: "classic" })
+				new Worker(new URL("./worker.js", import.meta.url), { type: "module" })
+				console.log(shared)
+			`,
+			"/worker.js": `
+				import { shared } from "./shared"
+				console.log("worker", shared)
+			`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+		},
+		expectedCompileLog: `entry.js: WARNING: This worker should be created with "{ type: 'module' }" because code splitting generates workers that are ES modules
+NOTE: Workers that aren't module workers can't use "import" statements, so the worker will fail to load if it shares code with another chunk.
+entry.js: WARNING: This worker should be created with "{ type: 'module' }" because code splitting generates workers that are ES modules
+NOTE: Workers that aren't module workers can't use "import" statements, so the worker will fail to load if it shares code with another chunk.
+`,
+	})
+}
+
+func TestSplittingWorkerNewURLIIFE(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { shared } from "./shared"
+				new Worker(new URL("./worker.js", import.meta.url))
+				console.log(shared)
+			`,
+			"/worker.js": `
+				import { shared } from "./shared"
+				console.log("worker", shared)
+			`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatIIFE,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
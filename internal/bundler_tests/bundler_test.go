@@ -7,6 +7,7 @@ package bundler_tests
 // inspect the diff to ensure the expected values are valid.
 
 import (
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -150,6 +151,24 @@ func (s *suite) __expectBundledImpl(t *testing.T, args bundled, fsKind fs.MockKi
 			}
 			args.options.ExternalSettings.PostResolve.Exact = replace
 
+			// Manual chunk paths always use "/" as the separator
+			manualChunks := make([]config.ManualChunk, len(args.options.ManualChunks))
+			for i, chunk := range args.options.ManualChunks {
+				pathPatterns := make([]config.WildcardPattern, len(chunk.PathPatterns))
+				for j, pattern := range chunk.PathPatterns {
+					pattern.Prefix = "C:" + pattern.Prefix
+					pathPatterns[j] = pattern
+				}
+				exactPaths := make(map[string]bool)
+				for k, v := range chunk.ExactPaths {
+					exactPaths["C:"+k] = v
+				}
+				chunk.PathPatterns = pathPatterns
+				chunk.ExactPaths = exactPaths
+				manualChunks[i] = chunk
+			}
+			args.options.ManualChunks = manualChunks
+
 			args.options.AbsOutputFile = unix2win(args.options.AbsOutputFile)
 			args.options.AbsOutputBase = unix2win(args.options.AbsOutputBase)
 			args.options.AbsOutputDir = unix2win(args.options.AbsOutputDir)
@@ -191,7 +210,12 @@ func (s *suite) __expectBundledImpl(t *testing.T, args bundled, fsKind fs.MockKi
 			if fsKind == fs.MockWindows {
 				result.AbsPath = win2unix(result.AbsPath)
 			}
-			generated += fmt.Sprintf("---------- %s ----------\n%s", result.AbsPath, string(result.Contents))
+			contents := string(result.Contents)
+			if strings.IndexByte(contents, 0) != -1 {
+				// Don't turn the snapshot file into a binary file
+				contents = fmt.Sprintf("(binary) %s\n", base64.StdEncoding.EncodeToString(result.Contents))
+			}
+			generated += fmt.Sprintf("---------- %s ----------\n%s", result.AbsPath, contents)
 		}
 		if metafileJSON != "" {
 			generated += fmt.Sprintf("---------- metafile.json ----------\n%s", metafileJSON)
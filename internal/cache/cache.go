@@ -49,13 +49,13 @@ func MakeCacheSet() *CacheSet {
 			entries: make(map[string]*fsEntry),
 		},
 		CSSCache: CSSCache{
-			entries: make(map[logger.Path]*cssCacheEntry),
+			entries: make(map[logger.Path][]*cssCacheEntry),
 		},
 		JSONCache: JSONCache{
-			entries: make(map[logger.Path]*jsonCacheEntry),
+			entries: make(map[logger.Path][]*jsonCacheEntry),
 		},
 		JSCache: JSCache{
-			entries: make(map[logger.Path]*jsCacheEntry),
+			entries: make(map[logger.Path][]*jsCacheEntry),
 		},
 	}
 }
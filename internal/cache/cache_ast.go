@@ -22,12 +22,16 @@ import (
 // be the same pointer, which makes the comparison trivial. Also we want to
 // cache the AST for plugins in the common case that the plugin output stays
 // the same.
+//
+// Several builds may share the same cache (see "api.ContextMany"), in which
+// case the same file may be parsed with different options by each build. So
+// each path can have several cache entries, one for each set of options.
 
 ////////////////////////////////////////////////////////////////////////////////
 // CSS
 
 type CSSCache struct {
-	entries map[logger.Path]*cssCacheEntry
+	entries map[logger.Path][]*cssCacheEntry
 	mutex   sync.Mutex
 }
 
@@ -43,11 +47,16 @@ func (c *CSSCache) Parse(log logger.Log, source logger.Source, options css_parse
 	entry := func() *cssCacheEntry {
 		c.mutex.Lock()
 		defer c.mutex.Unlock()
-		return c.entries[source.KeyPath]
+		for _, entry := range c.entries[source.KeyPath] {
+			if entry.options.Equal(&options) {
+				return entry
+			}
+		}
+		return nil
 	}()
 
 	// Cache hit
-	if entry != nil && entry.source == source && entry.options.Equal(&options) {
+	if entry != nil && entry.source == source {
 		for _, msg := range entry.msgs {
 			log.AddMsg(msg)
 		}
@@ -73,7 +82,14 @@ func (c *CSSCache) Parse(log logger.Log, source logger.Source, options css_parse
 	// Save for next time
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.entries[source.KeyPath] = entry
+	entries := c.entries[source.KeyPath]
+	for i, other := range entries {
+		if other.options.Equal(&options) {
+			entries[i] = entry
+			return ast
+		}
+	}
+	c.entries[source.KeyPath] = append(entries, entry)
 	return ast
 }
 
@@ -81,7 +97,7 @@ func (c *CSSCache) Parse(log logger.Log, source logger.Source, options css_parse
 // JSON
 
 type JSONCache struct {
-	entries map[logger.Path]*jsonCacheEntry
+	entries map[logger.Path][]*jsonCacheEntry
 	mutex   sync.Mutex
 }
 
@@ -98,11 +114,16 @@ func (c *JSONCache) Parse(log logger.Log, source logger.Source, options js_parse
 	entry := func() *jsonCacheEntry {
 		c.mutex.Lock()
 		defer c.mutex.Unlock()
-		return c.entries[source.KeyPath]
+		for _, entry := range c.entries[source.KeyPath] {
+			if entry.options == options {
+				return entry
+			}
+		}
+		return nil
 	}()
 
 	// Cache hit
-	if entry != nil && entry.source == source && entry.options == options {
+	if entry != nil && entry.source == source {
 		for _, msg := range entry.msgs {
 			log.AddMsg(msg)
 		}
@@ -129,7 +150,14 @@ func (c *JSONCache) Parse(log logger.Log, source logger.Source, options js_parse
 	// Save for next time
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.entries[source.KeyPath] = entry
+	entries := c.entries[source.KeyPath]
+	for i, other := range entries {
+		if other.options == options {
+			entries[i] = entry
+			return expr, ok
+		}
+	}
+	c.entries[source.KeyPath] = append(entries, entry)
 	return expr, ok
 }
 
@@ -137,7 +165,7 @@ func (c *JSONCache) Parse(log logger.Log, source logger.Source, options js_parse
 // JS
 
 type JSCache struct {
-	entries map[logger.Path]*jsCacheEntry
+	entries map[logger.Path][]*jsCacheEntry
 	mutex   sync.Mutex
 }
 
@@ -154,11 +182,16 @@ func (c *JSCache) Parse(log logger.Log, source logger.Source, options js_parser.
 	entry := func() *jsCacheEntry {
 		c.mutex.Lock()
 		defer c.mutex.Unlock()
-		return c.entries[source.KeyPath]
+		for _, entry := range c.entries[source.KeyPath] {
+			if entry.options.Equal(&options) {
+				return entry
+			}
+		}
+		return nil
 	}()
 
 	// Cache hit
-	if entry != nil && entry.source == source && entry.options.Equal(&options) {
+	if entry != nil && entry.source == source {
 		for _, msg := range entry.msgs {
 			log.AddMsg(msg)
 		}
@@ -185,6 +218,13 @@ func (c *JSCache) Parse(log logger.Log, source logger.Source, options js_parser.
 	// Save for next time
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.entries[source.KeyPath] = entry
+	entries := c.entries[source.KeyPath]
+	for i, other := range entries {
+		if other.options.Equal(&options) {
+			entries[i] = entry
+			return ast, ok
+		}
+	}
+	c.entries[source.KeyPath] = append(entries, entry)
 	return ast, ok
 }
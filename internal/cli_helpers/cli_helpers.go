@@ -45,20 +45,30 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 		return api.LoaderJS, nil
 	case "json":
 		return api.LoaderJSON, nil
+	case "json5":
+		return api.LoaderJSON5, nil
+	case "jsonc":
+		return api.LoaderJSONC, nil
 	case "jsx":
 		return api.LoaderJSX, nil
 	case "local-css":
 		return api.LoaderLocalCSS, nil
 	case "text":
 		return api.LoaderText, nil
+	case "toml":
+		return api.LoaderTOML, nil
 	case "ts":
 		return api.LoaderTS, nil
 	case "tsx":
 		return api.LoaderTSX, nil
+	case "wasm":
+		return api.LoaderWasm, nil
+	case "yaml":
+		return api.LoaderYAML, nil
 	default:
 		return api.LoaderNone, MakeErrorWithNote(
 			fmt.Sprintf("Invalid loader value: %q", text),
-			"Valid values are \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"global-css\", \"js\", \"json\", \"jsx\", \"local-css\", \"text\", \"ts\", or \"tsx\".",
+			"Valid values are \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"global-css\", \"js\", \"json\", \"json5\", \"jsonc\", \"jsx\", \"local-css\", \"text\", \"toml\", \"ts\", \"tsx\", \"wasm\", or \"yaml\".",
 		)
 	}
 }
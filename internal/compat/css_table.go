@@ -9,30 +9,38 @@ import (
 type CSSFeature uint16
 
 const (
-	ColorFunctions CSSFeature = 1 << iota
+	AtScope CSSFeature = 1 << iota
+	ColorFunctions
 	GradientDoublePosition
 	GradientInterpolation
 	GradientMidpoints
 	HWB
 	HexRGBA
+	ImageSetOptions
 	InlineStyle
 	InsetProperty
 	IsPseudoClass
+	LightDark
+	MediaRange
 	Modern_RGB_HSL
 	Nesting
 	RebeccaPurple
 )
 
 var StringToCSSFeature = map[string]CSSFeature{
+	"at-scope":                 AtScope,
 	"color-functions":          ColorFunctions,
 	"gradient-double-position": GradientDoublePosition,
 	"gradient-interpolation":   GradientInterpolation,
 	"gradient-midpoints":       GradientMidpoints,
 	"hwb":                      HWB,
 	"hex-rgba":                 HexRGBA,
+	"image-set-options":        ImageSetOptions,
 	"inline-style":             InlineStyle,
 	"inset-property":           InsetProperty,
 	"is-pseudo-class":          IsPseudoClass,
+	"light-dark":               LightDark,
+	"media-range":              MediaRange,
 	"modern-rgb-hsl":           Modern_RGB_HSL,
 	"nesting":                  Nesting,
 	"rebecca-purple":           RebeccaPurple,
@@ -47,6 +55,14 @@ func (features CSSFeature) ApplyOverrides(overrides CSSFeature, mask CSSFeature)
 }
 
 var cssTable = map[CSSFeature]map[Engine][]versionRange{
+	AtScope: {
+		Chrome:  {{start: v{118, 0, 0}}},
+		Edge:    {{start: v{118, 0, 0}}},
+		Firefox: {{start: v{146, 0, 0}}},
+		IOS:     {{start: v{17, 4, 0}}},
+		Opera:   {{start: v{104, 0, 0}}},
+		Safari:  {{start: v{17, 4, 0}}},
+	},
 	ColorFunctions: {
 		Chrome:  {{start: v{111, 0, 0}}},
 		Edge:    {{start: v{111, 0, 0}}},
@@ -94,6 +110,14 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		Opera:   {{start: v{49, 0, 0}}},
 		Safari:  {{start: v{10, 0, 0}}},
 	},
+	ImageSetOptions: {
+		Chrome:  {{start: v{113, 0, 0}}},
+		Edge:    {{start: v{113, 0, 0}}},
+		Firefox: {{start: v{89, 0, 0}}},
+		IOS:     {{start: v{17, 0, 0}}},
+		Opera:   {{start: v{99, 0, 0}}},
+		Safari:  {{start: v{17, 0, 0}}},
+	},
 	InlineStyle: {},
 	InsetProperty: {
 		Chrome:  {{start: v{87, 0, 0}}},
@@ -111,6 +135,22 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		Opera:   {{start: v{75, 0, 0}}},
 		Safari:  {{start: v{14, 0, 0}}},
 	},
+	LightDark: {
+		Chrome:  {{start: v{123, 0, 0}}},
+		Edge:    {{start: v{123, 0, 0}}},
+		Firefox: {{start: v{120, 0, 0}}},
+		IOS:     {{start: v{17, 5, 0}}},
+		Opera:   {{start: v{109, 0, 0}}},
+		Safari:  {{start: v{17, 5, 0}}},
+	},
+	MediaRange: {
+		Chrome:  {{start: v{104, 0, 0}}},
+		Edge:    {{start: v{104, 0, 0}}},
+		Firefox: {{start: v{63, 0, 0}}},
+		IOS:     {{start: v{16, 4, 0}}},
+		Opera:   {{start: v{91, 0, 0}}},
+		Safari:  {{start: v{16, 4, 0}}},
+	},
 	Modern_RGB_HSL: {
 		Chrome:  {{start: v{66, 0, 0}}},
 		Edge:    {{start: v{79, 0, 0}}},
@@ -194,6 +234,13 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
 	},
+	css_ast.DBackground: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
 	css_ast.DBackgroundClip: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
 		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{15, 0, 0}},
@@ -202,6 +249,27 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{106, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
 	},
+	css_ast.DBackgroundImage: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
+	css_ast.DBorderImage: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
+	css_ast.DBorderImageSource: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
 	css_ast.DBoxDecorationBreak: {
 		{engine: Chrome, prefix: WebkitPrefix},
 		{engine: Edge, prefix: WebkitPrefix},
@@ -215,6 +283,20 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{42, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{13, 1, 0}},
 	},
+	css_ast.DContent: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
+	css_ast.DCursor: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
 	css_ast.DFontKerning: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{33, 0, 0}},
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{12, 0, 0}},
@@ -232,6 +314,20 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: IOS, prefix: WebkitPrefix},
 		{engine: Safari, prefix: WebkitPrefix},
 	},
+	css_ast.DListStyle: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
+	css_ast.DListStyleImage: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
 	css_ast.DMaskComposite: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
 		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
@@ -181,6 +181,16 @@ func (lc LegalComments) HasExternalFile() bool {
 	return lc == LegalCommentsLinkedWithComment || lc == LegalCommentsExternalWithoutComment
 }
 
+// These are the hash functions allowed by the Subresource Integrity spec:
+// https://www.w3.org/TR/SRI/#cryptographic-hash-functions
+type IntegrityAlgorithms uint8
+
+const (
+	IntegritySHA256 IntegrityAlgorithms = 1 << iota
+	IntegritySHA384
+	IntegritySHA512
+)
+
 type Loader uint8
 
 const (
@@ -189,6 +199,7 @@ const (
 	LoaderBinary
 	LoaderCopy
 	LoaderCSS
+	LoaderWithTypeCSS // Has a "with { type: 'css' }" attribute
 	LoaderDataURL
 	LoaderDefault
 	LoaderEmpty
@@ -197,12 +208,17 @@ const (
 	LoaderJS
 	LoaderJSON
 	LoaderWithTypeJSON // Has a "with { type: 'json' }" attribute
+	LoaderJSON5
+	LoaderJSONC
 	LoaderJSX
 	LoaderLocalCSS
 	LoaderText
+	LoaderTOML
 	LoaderTS
 	LoaderTSNoAmbiguousLessThan // Used with ".mts" and ".cts"
 	LoaderTSX
+	LoaderWasm
+	LoaderYAML
 )
 
 var LoaderToString = []string{
@@ -211,6 +227,7 @@ var LoaderToString = []string{
 	"binary",
 	"copy",
 	"css",
+	"css",
 	"dataurl",
 	"default",
 	"empty",
@@ -219,12 +236,17 @@ var LoaderToString = []string{
 	"js",
 	"json",
 	"json",
+	"json5",
+	"jsonc",
 	"jsx",
 	"local-css",
 	"text",
+	"toml",
 	"ts",
 	"ts",
 	"tsx",
+	"wasm",
+	"yaml",
 }
 
 func (loader Loader) IsTypeScript() bool {
@@ -250,7 +272,8 @@ func (loader Loader) CanHaveSourceMap() bool {
 		LoaderJS, LoaderJSX,
 		LoaderTS, LoaderTSNoAmbiguousLessThan, LoaderTSX,
 		LoaderCSS, LoaderGlobalCSS, LoaderLocalCSS,
-		LoaderJSON, LoaderWithTypeJSON, LoaderText:
+		LoaderJSON, LoaderWithTypeJSON, LoaderJSON5, LoaderJSONC, LoaderText,
+		LoaderTOML, LoaderYAML:
 		return true
 	}
 	return false
@@ -323,6 +346,18 @@ type WildcardPattern struct {
 	Suffix string
 }
 
+// Files that match one of these patterns are put into a chunk with this name
+// when code splitting instead of a chunk derived from entry point reachability.
+// Package patterns are matched against the path after the last "node_modules"
+// directory, and path patterns are matched against the absolute path with "/"
+// as the path separator.
+type ManualChunk struct {
+	Name            string
+	PackagePatterns []WildcardPattern
+	PathPatterns    []WildcardPattern
+	ExactPaths      map[string]bool
+}
+
 type ExternalMatchers struct {
 	Exact    map[string]bool
 	Patterns []WildcardPattern
@@ -399,6 +434,12 @@ type Options struct {
 		cssUsedLocalNames map[string]bool,
 	))
 
+	// When code splitting is disabled, each worker is linked separately before
+	// the entry points that reference it. This maps the source index of each
+	// worker that has already been linked to the absolute path of its output
+	// file so that the path can be substituted into the code that references it.
+	WorkerOutputPaths map[uint32]string
+
 	// This is the original information that was used to generate the
 	// unsupported feature sets above. It's used for error messages.
 	OriginalTargetEnv string
@@ -423,6 +464,7 @@ type Options struct {
 	ExtensionToLoader  map[string]Loader
 
 	PublicPath      string
+	WasmInline      bool
 	InjectPaths     []string
 	InjectedDefines []InjectedDefine
 	InjectedFiles   []InjectedFile
@@ -459,6 +501,11 @@ type Options struct {
 	MinifySyntax      bool
 	ProfilerNames     bool
 	CodeSplitting     bool
+	ModulePreload     bool
+	PreserveEvalOrder bool
+	ManualChunks      []ManualChunk
+	MinChunkSize      int
+	MaxChunks         int
 	WatchMode         bool
 	AllowOverwrite    bool
 	LegalComments     LegalComments
@@ -466,6 +513,12 @@ type Options struct {
 	// If true, make sure to generate a single file that can be written to stdout
 	WriteToStdout bool
 
+	// If present, the linker passes each output file to this as soon as its
+	// contents are final and then releases the contents. This lets output files
+	// be written out without holding all of them in memory at the same time.
+	// It may be called from multiple goroutines at once.
+	StreamOutputFile func(absPath string, contents []byte, isExecutable bool)
+
 	OmitRuntimeForTests    bool
 	OmitJSXRuntimeForTests bool
 	ASCIIOnly              bool
@@ -477,6 +530,9 @@ type Options struct {
 	Platform               Platform
 	OutputFormat           Format
 	NeedsMetafile          bool
+	NeedsManifest          bool
+	Integrity              IntegrityAlgorithms
+	CompressedSizes        bool
 	SourceMap              SourceMap
 	ExcludeSourcesContent  bool
 }
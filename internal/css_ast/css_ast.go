@@ -160,6 +160,18 @@ func (check *CrossFileEqualityCheck) RefsAreEquivalent(a ast.Ref, b ast.Ref) boo
 	return symbolA.Kind == ast.SymbolGlobalCSS && symbolB.Kind == ast.SymbolGlobalCSS && symbolA.OriginalName == symbolB.OriginalName
 }
 
+func (check *CrossFileEqualityCheck) symbolsAreEquivalent(a uint32, b uint32) bool {
+	if check == nil {
+		// If both symbols are in the same file, just compare the index
+		return a == b
+	}
+
+	// If the symbols come from separate files, compare the symbols themselves
+	refA := ast.Ref{SourceIndex: check.SourceIndexA, InnerIndex: a}
+	refB := ast.Ref{SourceIndex: check.SourceIndexB, InnerIndex: b}
+	return check.RefsAreEquivalent(refA, refB)
+}
+
 func (a Token) Equal(b Token, check *CrossFileEqualityCheck) bool {
 	if a.Kind == b.Kind && a.Text == b.Text && a.Whitespace == b.Whitespace {
 		// URLs should be compared based on the text of the associated import record
@@ -187,20 +199,8 @@ func (a Token) Equal(b Token, check *CrossFileEqualityCheck) bool {
 
 		// Symbols should be compared based on the symbol reference instead of the
 		// original text
-		if a.Kind == css_lexer.TSymbol {
-			if check == nil {
-				// If both tokens are in the same file, just compare the index
-				if a.PayloadIndex != b.PayloadIndex {
-					return false
-				}
-			} else {
-				// If the tokens come from separate files, compare the symbols themselves
-				refA := ast.Ref{SourceIndex: check.SourceIndexA, InnerIndex: a.PayloadIndex}
-				refB := ast.Ref{SourceIndex: check.SourceIndexB, InnerIndex: b.PayloadIndex}
-				if !check.RefsAreEquivalent(refA, refB) {
-					return false
-				}
-			}
+		if a.Kind == css_lexer.TSymbol && !check.symbolsAreEquivalent(a.PayloadIndex, b.PayloadIndex) {
+			return false
 		}
 
 		if a.Children == nil && b.Children == nil {
@@ -656,11 +656,18 @@ type RDeclaration struct {
 	KeyRange  logger.Range
 	Key       D // Compare using this instead of "Key" for speed
 	Important bool
+
+	// This is valid when the key is a custom property that has been registered
+	// using "@property" in a file with local names. Then the key is printed
+	// using the symbol instead of "KeyText" so that it can be renamed.
+	KeySymbol ast.Index32
 }
 
 func (a *RDeclaration) Equal(rule R, check *CrossFileEqualityCheck) bool {
 	b, ok := rule.(*RDeclaration)
-	return ok && a.KeyText == b.KeyText && TokensEqual(a.Value, b.Value, check) && a.Important == b.Important
+	return ok && a.KeyText == b.KeyText && TokensEqual(a.Value, b.Value, check) && a.Important == b.Important &&
+		a.KeySymbol.IsValid() == b.KeySymbol.IsValid() && (!a.KeySymbol.IsValid() ||
+		check.symbolsAreEquivalent(a.KeySymbol.GetIndex(), b.KeySymbol.GetIndex()))
 }
 
 func (r *RDeclaration) Hash() (uint32, bool) {
@@ -753,6 +760,67 @@ func (r *RAtLayer) Hash() (uint32, bool) {
 	return hash, true
 }
 
+type RAtScope struct {
+	Start         []ComplexSelector // This is nil for "@scope {}"
+	End           []ComplexSelector // This is nil if there's no "to (...)"
+	Rules         []Rule
+	CloseBraceLoc logger.Loc
+}
+
+func (a *RAtScope) Equal(rule R, check *CrossFileEqualityCheck) bool {
+	b, ok := rule.(*RAtScope)
+	return ok && (a.Start == nil) == (b.Start == nil) && ComplexSelectorsEqual(a.Start, b.Start, check) &&
+		(a.End == nil) == (b.End == nil) && ComplexSelectorsEqual(a.End, b.End, check) && RulesEqual(a.Rules, b.Rules, check)
+}
+
+func (r *RAtScope) Hash() (uint32, bool) {
+	hash := uint32(14)
+	hash = helpers.HashCombine(hash, uint32(len(r.Start)))
+	hash = HashComplexSelectors(hash, r.Start)
+	hash = helpers.HashCombine(hash, uint32(len(r.End)))
+	hash = HashComplexSelectors(hash, r.End)
+	hash = HashRules(hash, r.Rules)
+	return hash, true
+}
+
+// This is the "@custom-media --name <media-query-list>;" rule
+type RAtCustomMedia struct {
+	Name    string
+	NameLoc logger.Loc
+	Query   []Token
+}
+
+func (a *RAtCustomMedia) Equal(rule R, check *CrossFileEqualityCheck) bool {
+	b, ok := rule.(*RAtCustomMedia)
+	return ok && a.Name == b.Name && TokensEqual(a.Query, b.Query, check)
+}
+
+func (r *RAtCustomMedia) Hash() (uint32, bool) {
+	hash := uint32(15)
+	hash = helpers.HashCombineString(hash, r.Name)
+	hash = HashTokens(hash, r.Query)
+	return hash, true
+}
+
+// This is the "@custom-selector :--name <selector-list>;" rule
+type RAtCustomSelector struct {
+	Name      string
+	NameRange logger.Range
+	Selectors []ComplexSelector
+}
+
+func (a *RAtCustomSelector) Equal(rule R, check *CrossFileEqualityCheck) bool {
+	b, ok := rule.(*RAtCustomSelector)
+	return ok && a.Name == b.Name && ComplexSelectorsEqual(a.Selectors, b.Selectors, check)
+}
+
+func (r *RAtCustomSelector) Hash() (uint32, bool) {
+	hash := uint32(16)
+	hash = helpers.HashCombineString(hash, r.Name)
+	hash = HashComplexSelectors(hash, r.Selectors)
+	return hash, true
+}
+
 type ComplexSelector struct {
 	Selectors []CompoundSelector
 }
@@ -106,6 +106,7 @@ const (
 	DColor
 	DColorInterpolation
 	DColorInterpolationFilters
+	DColorScheme
 	DColumnCount
 	DColumnFill
 	DColumnGap
@@ -437,6 +438,7 @@ var KnownDeclarations = map[string]D{
 	"color":                       DColor,
 	"color-interpolation":         DColorInterpolation,
 	"color-interpolation-filters": DColorInterpolationFilters,
+	"color-scheme":                DColorScheme,
 	"column-count":                DColumnCount,
 	"column-fill":                 DColumnFill,
 	"column-gap":                  DColumnGap,
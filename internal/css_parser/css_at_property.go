@@ -0,0 +1,465 @@
+package css_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Reference: https://drafts.css-houdini.org/css-properties-values-api/#the-syntax-descriptor
+type propertySyntaxComponent struct {
+	name       string // Either a data type name (without the "<>") or a keyword
+	isDataType bool
+	multiplier byte // Either 0, '+', or '#'
+}
+
+var propertySyntaxDataTypes = map[string]bool{
+	"angle":              true,
+	"color":              true,
+	"custom-ident":       true,
+	"image":              true,
+	"integer":            true,
+	"length":             true,
+	"length-percentage":  true,
+	"number":             true,
+	"percentage":         true,
+	"resolution":         true,
+	"string":             true,
+	"time":               true,
+	"transform-function": true,
+	"transform-list":     true,
+	"url":                true,
+}
+
+// Parses the contents of the "syntax" descriptor. The universal syntax "*"
+// is returned as a nil slice.
+func parsePropertySyntax(text string) ([]propertySyntaxComponent, bool) {
+	text = strings.TrimSpace(text)
+	if text == "*" {
+		return nil, true
+	}
+
+	parts := strings.Split(text, "|")
+	components := make([]propertySyntaxComponent, 0, len(parts))
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		var component propertySyntaxComponent
+
+		// Parse the optional multiplier
+		if n := len(part); n > 0 && (part[n-1] == '+' || part[n-1] == '#') {
+			component.multiplier = part[n-1]
+			part = part[:n-1]
+		}
+
+		if strings.HasPrefix(part, "<") && strings.HasSuffix(part, ">") {
+			// "<length>"
+			component.name = part[1 : len(part)-1]
+			component.isDataType = true
+			if !propertySyntaxDataTypes[component.name] {
+				return nil, false
+			}
+
+			// "<transform-list>" is already a list, so it can't have a multiplier
+			if component.name == "transform-list" && component.multiplier != 0 {
+				return nil, false
+			}
+		} else {
+			// "auto"
+			if !css_lexer.WouldStartIdentifierWithoutEscapes(part) || !isPropertySyntaxKeyword(part) {
+				return nil, false
+			}
+			component.name = part
+		}
+
+		components = append(components, component)
+	}
+
+	return components, true
+}
+
+func isPropertySyntaxKeyword(text string) bool {
+	for _, c := range text {
+		if !css_lexer.IsNameContinue(c) {
+			return false
+		}
+	}
+	lower := strings.ToLower(text)
+	return !cssWideAndReservedKeywords[lower] && lower != "default"
+}
+
+func printPropertySyntax(components []propertySyntaxComponent) string {
+	if components == nil {
+		return "*"
+	}
+	sb := strings.Builder{}
+	for i, component := range components {
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		if component.isDataType {
+			sb.WriteByte('<')
+			sb.WriteString(component.name)
+			sb.WriteByte('>')
+		} else {
+			sb.WriteString(component.name)
+		}
+		if component.multiplier != 0 {
+			sb.WriteByte(component.multiplier)
+		}
+	}
+	return sb.String()
+}
+
+// Validates and minifies the descriptors in an "@property" rule. Registering
+// a custom property with "@property" in a file with local names also makes the
+// name of the custom property into a local name.
+//
+// Reference: https://drafts.css-houdini.org/css-properties-values-api/#at-property-rule
+func (p *parser) processAtProperty(atRange logger.Range, prelude []css_ast.Token, rules []css_ast.Rule) {
+	if len(prelude) != 1 || prelude[0].Kind != css_lexer.TIdent || !strings.HasPrefix(prelude[0].Text, "--") || len(prelude[0].Text) == 2 {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, atRange,
+			"Expected a custom property name after \"@property\"")
+	} else if p.makeLocalSymbols {
+		t := &prelude[0]
+		if p.localCustomProperties == nil {
+			p.localCustomProperties = make(map[string]bool)
+		}
+		p.localCustomProperties[t.Text] = true
+		t.Kind = css_lexer.TSymbol
+		t.PayloadIndex = p.symbolForName(t.Loc, t.Text).Ref.InnerIndex
+	}
+
+	var syntax *css_ast.RDeclaration
+	var inherits *css_ast.RDeclaration
+	var initialValue *css_ast.RDeclaration
+
+	// Later descriptors override earlier ones
+	for _, rule := range rules {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok {
+			switch strings.ToLower(decl.KeyText) {
+			case "syntax":
+				syntax = decl
+			case "inherits":
+				inherits = decl
+			case "initial-value":
+				initialValue = decl
+			}
+		}
+	}
+
+	// Validate the "syntax" descriptor
+	var components []propertySyntaxComponent
+	isValidSyntax := false
+	if syntax == nil {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, atRange,
+			"\"@property\" rules must have a \"syntax\" descriptor")
+	} else if len(syntax.Value) != 1 || syntax.Value[0].Kind != css_lexer.TString {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, syntax.KeyRange,
+			"The \"syntax\" descriptor must be a string")
+	} else if components, isValidSyntax = parsePropertySyntax(syntax.Value[0].Text); !isValidSyntax {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, syntax.KeyRange,
+			fmt.Sprintf("%q is not a valid syntax for a custom property", syntax.Value[0].Text))
+	} else if p.options.minifySyntax {
+		syntax.Value[0].Text = printPropertySyntax(components)
+	}
+
+	// Validate the "inherits" descriptor
+	if inherits == nil {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, atRange,
+			"\"@property\" rules must have an \"inherits\" descriptor")
+	} else if len(inherits.Value) != 1 || inherits.Value[0].Kind != css_lexer.TIdent ||
+		(!strings.EqualFold(inherits.Value[0].Text, "true") && !strings.EqualFold(inherits.Value[0].Text, "false")) {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, inherits.KeyRange,
+			"The \"inherits\" descriptor must be either \"true\" or \"false\"")
+	} else if p.options.minifySyntax {
+		inherits.Value[0].Text = strings.ToLower(inherits.Value[0].Text)
+	}
+
+	// Validate the "initial-value" descriptor
+	if initialValue == nil {
+		if isValidSyntax && components != nil {
+			p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, atRange,
+				"\"@property\" rules must have an \"initial-value\" descriptor unless the syntax is \"*\"")
+		}
+	} else if !isComputationallyIndependent(initialValue.Value) {
+		p.log.AddIDWithNotes(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, initialValue.KeyRange,
+			"The \"initial-value\" descriptor must be computationally independent",
+			[]logger.MsgData{{Text: "This means it can't use relative units such as \"em\" or refer to other properties using \"var()\"."}})
+	} else if isValidSyntax && components != nil {
+		if !initialValueMatchesSyntax(initialValue.Value, components) {
+			p.log.AddID(logger.MsgID_CSS_InvalidAtProperty, logger.Warning, &p.tracker, initialValue.KeyRange,
+				fmt.Sprintf("The \"initial-value\" descriptor doesn't match the syntax %q", syntax.Value[0].Text))
+		} else if p.options.minifySyntax && len(components) == 1 && components[0].isDataType &&
+			components[0].multiplier == 0 && len(initialValue.Value) == 1 {
+			switch components[0].name {
+			case "color":
+				initialValue.Value[0] = p.lowerAndMinifyColor(initialValue.Value[0], nil)
+			case "length", "length-percentage":
+				initialValue.Value[0].TurnLengthIntoNumberIfZero()
+			}
+		}
+	}
+}
+
+// Reference: https://drafts.css-houdini.org/css-properties-values-api/#computationally-independent
+func isComputationallyIndependent(tokens []css_ast.Token) bool {
+	for _, t := range tokens {
+		switch t.Kind {
+		case css_lexer.TDimension:
+			switch strings.ToLower(t.DimensionUnit()) {
+			case "em", "ex", "cap", "ch", "ic", "lh", "rem", "rex", "rcap", "rch", "ric", "rlh":
+				return false
+			}
+
+		case css_lexer.TFunction:
+			switch strings.ToLower(t.Text) {
+			case "var", "attr":
+				return false
+			}
+		}
+
+		if t.Children != nil && !isComputationallyIndependent(*t.Children) {
+			return false
+		}
+	}
+	return true
+}
+
+func initialValueMatchesSyntax(tokens []css_ast.Token, components []propertySyntaxComponent) bool {
+	for _, component := range components {
+		if initialValueMatchesSyntaxComponent(tokens, component) {
+			return true
+		}
+	}
+	return false
+}
+
+func initialValueMatchesSyntaxComponent(tokens []css_ast.Token, component propertySyntaxComponent) bool {
+	switch component.multiplier {
+	case '+':
+		// A space-separated list
+		if len(tokens) == 0 {
+			return false
+		}
+		for _, t := range tokens {
+			if !tokenMatchesSyntaxComponent(t, component) {
+				return false
+			}
+		}
+		return true
+
+	case '#':
+		// A comma-separated list
+		if len(tokens)%2 == 0 {
+			return false
+		}
+		for i, t := range tokens {
+			if i%2 == 1 {
+				if t.Kind != css_lexer.TComma {
+					return false
+				}
+			} else if !tokenMatchesSyntaxComponent(t, component) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		// Data types that can span multiple tokens aren't checked
+		if component.isDataType {
+			switch component.name {
+			case "image", "transform-function", "transform-list":
+				return true
+			}
+		}
+		return len(tokens) == 1 && tokenMatchesSyntaxComponent(tokens[0], component)
+	}
+}
+
+// This deliberately errs on the side of accepting the value when the type of
+// the token can't be easily determined (e.g. for "calc()")
+func tokenMatchesSyntaxComponent(t css_ast.Token, component propertySyntaxComponent) bool {
+	if !component.isDataType {
+		return t.Kind == css_lexer.TIdent && t.Text == component.name
+	}
+
+	switch component.name {
+	case "image", "transform-function", "transform-list":
+		return true
+
+	case "color":
+		return looksLikeColor(t) || t.Kind == css_lexer.TFunction ||
+			(t.Kind == css_lexer.TIdent && strings.EqualFold(t.Text, "currentcolor"))
+
+	case "custom-ident":
+		return t.Kind == css_lexer.TIdent && isPropertySyntaxKeyword(t.Text)
+
+	case "string":
+		return t.Kind == css_lexer.TString
+
+	case "url":
+		return t.Kind == css_lexer.TURL
+	}
+
+	// The remaining data types are all numeric
+	if t.Kind == css_lexer.TFunction {
+		switch strings.ToLower(t.Text) {
+		case "calc", "min", "max", "clamp":
+			return true
+		}
+		return false
+	}
+
+	switch component.name {
+	case "number":
+		return t.Kind == css_lexer.TNumber
+
+	case "integer":
+		return t.Kind == css_lexer.TNumber && !strings.ContainsAny(t.Text, ".eE")
+
+	case "percentage":
+		return t.Kind == css_lexer.TPercentage
+
+	case "length":
+		return t.IsZero() || (t.Kind == css_lexer.TDimension && lengthUnits[strings.ToLower(t.DimensionUnit())])
+
+	case "length-percentage":
+		return t.IsZero() || t.Kind == css_lexer.TPercentage || (t.Kind == css_lexer.TDimension && lengthUnits[strings.ToLower(t.DimensionUnit())])
+
+	case "angle":
+		return t.IsAngle()
+
+	case "time":
+		if t.Kind == css_lexer.TDimension {
+			unit := strings.ToLower(t.DimensionUnit())
+			return unit == "s" || unit == "ms"
+		}
+
+	case "resolution":
+		if t.Kind == css_lexer.TDimension {
+			unit := strings.ToLower(t.DimensionUnit())
+			return unit == "dpi" || unit == "dpcm" || unit == "dppx" || unit == "x"
+		}
+	}
+
+	return false
+}
+
+// Reference: https://drafts.csswg.org/css-values-4/#lengths
+var lengthUnits = map[string]bool{
+	// Absolute lengths
+	"cm": true, "mm": true, "q": true, "in": true, "pt": true, "pc": true, "px": true,
+
+	// Font-relative lengths
+	"em": true, "rem": true, "ex": true, "rex": true, "cap": true, "rcap": true,
+	"ch": true, "rch": true, "ic": true, "ric": true, "lh": true, "rlh": true,
+
+	// Viewport-relative lengths
+	"vw": true, "svw": true, "lvw": true, "dvw": true,
+	"vh": true, "svh": true, "lvh": true, "dvh": true,
+	"vi": true, "svi": true, "lvi": true, "dvi": true,
+	"vb": true, "svb": true, "lvb": true, "dvb": true,
+	"vmin": true, "svmin": true, "lvmin": true, "dvmin": true,
+	"vmax": true, "svmax": true, "lvmax": true, "dvmax": true,
+
+	// Container query lengths
+	"cqw": true, "cqh": true, "cqi": true, "cqb": true, "cqmin": true, "cqmax": true,
+}
+
+// Custom properties that were registered using "@property" in a file with
+// local names are renamed along with the rest of the local names. This has to
+// happen after the whole file has been parsed since "@property" can come after
+// the places where the custom property is used. Unregistered custom properties
+// are given global symbols so that local names don't collide with them.
+func (p *parser) bindCustomPropertySymbols(rules []css_ast.Rule) {
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RDeclaration:
+			if strings.HasPrefix(r.KeyText, "--") && !r.KeySymbol.IsValid() {
+				r.KeySymbol = p.customPropertySymbol(r.KeyRange.Loc, r.KeyText)
+			}
+			switch r.Key {
+			case css_ast.DTransition, css_ast.DTransitionProperty, css_ast.DWillChange:
+				// These properties can refer to custom properties by name
+				p.bindCustomPropertySymbolsInTokens(r.Value, true)
+			default:
+				p.bindCustomPropertySymbolsInTokens(r.Value, false)
+			}
+
+		case *css_ast.RSelector:
+			p.bindCustomPropertySymbols(r.Rules)
+
+		case *css_ast.RQualified:
+			p.bindCustomPropertySymbols(r.Rules)
+
+		case *css_ast.RKnownAt:
+			p.bindCustomPropertySymbols(r.Rules)
+
+		case *css_ast.RAtLayer:
+			p.bindCustomPropertySymbols(r.Rules)
+
+		case *css_ast.RAtScope:
+			p.bindCustomPropertySymbols(r.Rules)
+
+		case *css_ast.RAtKeyframes:
+			for _, block := range r.Blocks {
+				p.bindCustomPropertySymbols(block.Rules)
+			}
+		}
+	}
+}
+
+func (p *parser) bindCustomPropertySymbolsInTokens(tokens []css_ast.Token, isPropertyName bool) {
+	for i := range tokens {
+		t := &tokens[i]
+
+		// "transition-property: --foo"
+		if isPropertyName && t.Kind == css_lexer.TIdent && strings.HasPrefix(t.Text, "--") {
+			p.bindCustomPropertySymbolToToken(t)
+		}
+
+		if t.Children != nil {
+			children := *t.Children
+
+			// "var(--foo)"
+			if t.Kind == css_lexer.TFunction && strings.EqualFold(t.Text, "var") {
+				for j := range children {
+					if child := &children[j]; child.Kind != css_lexer.TWhitespace {
+						if child.Kind == css_lexer.TIdent && strings.HasPrefix(child.Text, "--") {
+							p.bindCustomPropertySymbolToToken(child)
+						}
+						break
+					}
+				}
+			}
+
+			p.bindCustomPropertySymbolsInTokens(children, false)
+		}
+	}
+}
+
+func (p *parser) bindCustomPropertySymbolToToken(t *css_ast.Token) {
+	if symbol := p.customPropertySymbol(t.Loc, t.Text); symbol.IsValid() {
+		t.Kind = css_lexer.TSymbol
+		t.PayloadIndex = symbol.GetIndex()
+	}
+}
+
+// Returns an invalid index for custom properties with global names, since
+// those don't need to be printed using a symbol
+func (p *parser) customPropertySymbol(loc logger.Loc, name string) ast.Index32 {
+	local := p.makeLocalSymbols
+	p.makeLocalSymbols = p.localCustomProperties[name]
+	ref := p.symbolForName(loc, name).Ref
+	isLocal := p.makeLocalSymbols
+	p.makeLocalSymbols = local
+	if isLocal {
+		return ast.MakeIndex32(ref.InnerIndex)
+	}
+	return ast.Index32{}
+}
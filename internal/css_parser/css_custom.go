@@ -0,0 +1,626 @@
+package css_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Reference: https://drafts.csswg.org/mediaqueries-5/#custom-mq
+func (p *parser) parseAtCustomMedia(atRange logger.Range, context atRuleContext) (css_ast.Rule, bool) {
+	if !context.isTopLevel {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtCustomMedia, logger.Warning, &p.tracker, atRange, "\"@custom-media\" is only valid at the top level")
+		return css_ast.Rule{}, false
+	}
+
+	// Parse the name
+	p.eat(css_lexer.TWhitespace)
+	nameLoc := p.current().Range.Loc
+	name := p.decoded()
+	if !p.peek(css_lexer.TIdent) || !strings.HasPrefix(name, "--") {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtCustomMedia, logger.Warning, &p.tracker, p.current().Range,
+			"Expected a custom media query name starting with \"--\"")
+		return css_ast.Rule{}, false
+	}
+	p.advance()
+
+	// Parse the media query list
+	p.eat(css_lexer.TWhitespace)
+	queryStart := p.index
+loop:
+	for {
+		switch p.current().Kind {
+		case css_lexer.TSemicolon, css_lexer.TEndOfFile:
+			break loop
+
+		case css_lexer.TOpenBrace, css_lexer.TCloseBrace:
+			p.expect(css_lexer.TSemicolon)
+			return css_ast.Rule{}, false
+		}
+		p.parseComponentValue()
+	}
+	query := p.convertTokens(p.tokens[queryStart:p.index])
+	if len(query) == 0 {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtCustomMedia, logger.Warning, &p.tracker, p.current().Range,
+			fmt.Sprintf("Expected a media query after %q", name))
+		return css_ast.Rule{}, false
+	}
+	query[len(query)-1].Whitespace &= ^css_ast.WhitespaceAfter
+	p.expect(css_lexer.TSemicolon)
+
+	return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtCustomMedia{
+		Name:    name,
+		NameLoc: nameLoc,
+		Query:   p.lowerMediaQueryRanges(query),
+	}}, true
+}
+
+// Reference: https://drafts.csswg.org/css-extensions/#custom-selectors
+func (p *parser) parseAtCustomSelector(atRange logger.Range, context atRuleContext) (css_ast.Rule, bool) {
+	if !context.isTopLevel {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtCustomSelector, logger.Warning, &p.tracker, atRange, "\"@custom-selector\" is only valid at the top level")
+		return css_ast.Rule{}, false
+	}
+
+	// Parse the name
+	p.eat(css_lexer.TWhitespace)
+	colonRange := p.current().Range
+	if !p.eat(css_lexer.TColon) || !p.peek(css_lexer.TIdent) || !strings.HasPrefix(p.decoded(), "--") {
+		p.log.AddID(logger.MsgID_CSS_InvalidAtCustomSelector, logger.Warning, &p.tracker, colonRange,
+			"Expected a custom selector name starting with \":--\"")
+		return css_ast.Rule{}, false
+	}
+	name := p.decoded()
+	nameRange := logger.Range{Loc: colonRange.Loc, Len: p.current().Range.End() - colonRange.Loc.Start}
+	p.advance()
+
+	// Parse the selector list. Save and restore the local symbol state in case
+	// there are any bare ":global" or ":local" annotations.
+	p.eat(css_lexer.TWhitespace)
+	local := p.makeLocalSymbols
+	list, ok := p.parseSelectorList(parseSelectorOpts{
+		stopOnSemicolon:     true,
+		noLeadingCombinator: true,
+	})
+	p.makeLocalSymbols = local
+	if !ok || !p.expect(css_lexer.TSemicolon) {
+		return css_ast.Rule{}, false
+	}
+
+	return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtCustomSelector{
+		Name:      name,
+		NameRange: nameRange,
+		Selectors: list,
+	}}, true
+}
+
+// This holds the "@custom-media" and "@custom-selector" definitions for a
+// chunk. These are resolved at build time by the linker instead of by the
+// parser because definitions can come from other files. Definitions should
+// be added in bundle order since later definitions replace earlier ones with
+// the same name, just like they would if all files were concatenated.
+type CustomDefinitions struct {
+	media     map[string]customMediaDefinition
+	selectors map[string]customSelectorDefinition
+
+	// If true, references to undefined names are left alone without a warning
+	// since they may be defined in a file that isn't part of this chunk (e.g.
+	// an external "@import" or any "@import" when not bundling)
+	IgnoreUndefined bool
+
+	// Definitions are resolved at most once per chunk so that any warnings
+	// about the references inside them are only reported once
+	resolvedMedia     map[string][]css_ast.Token
+	resolvedSelectors map[string][]css_ast.ComplexSelector
+	inProgress        map[string]bool
+	cycles            map[string]bool
+}
+
+type customMediaDefinition struct {
+	rule   *css_ast.RAtCustomMedia
+	source *logger.Source
+}
+
+type customSelectorDefinition struct {
+	rule   *css_ast.RAtCustomSelector
+	source *logger.Source
+}
+
+func (defs *CustomDefinitions) AddDefinitionsFromRules(source *logger.Source, rules []css_ast.Rule) {
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RAtCustomMedia:
+			if defs.media == nil {
+				defs.media = make(map[string]customMediaDefinition)
+			}
+			defs.media[r.Name] = customMediaDefinition{rule: r, source: source}
+
+		case *css_ast.RAtCustomSelector:
+			if defs.selectors == nil {
+				defs.selectors = make(map[string]customSelectorDefinition)
+			}
+			defs.selectors[r.Name] = customSelectorDefinition{rule: r, source: source}
+		}
+	}
+}
+
+// Replaces "(--name)" in "@media" rules and ":--name" in selectors with the
+// corresponding definitions, and removes the definitions themselves. Note that
+// the linker calls this, so the rules themselves must not be modified. Only
+// the top-level slice is modified in place. References that are left alone
+// because they are undefined or part of a cycle generate a warning.
+func SubstituteCustomDefinitions(
+	log logger.Log,
+	source *logger.Source,
+	rules []css_ast.Rule,
+	defs *CustomDefinitions,
+	options Options,
+) []css_ast.Rule {
+	if defs.resolvedMedia == nil {
+		defs.resolvedMedia = make(map[string][]css_ast.Token)
+		defs.resolvedSelectors = make(map[string][]css_ast.ComplexSelector)
+		defs.inProgress = make(map[string]bool)
+		defs.cycles = make(map[string]bool)
+	}
+
+	s := customSubstituter{
+		log:      log,
+		source:   source,
+		defs:     defs,
+		canUseIs: !options.unsupportedCSSFeatures.Has(compat.IsPseudoClass),
+	}
+
+	end := 0
+	for _, rule := range rules {
+		switch rule.Data.(type) {
+		case *css_ast.RAtCustomMedia, *css_ast.RAtCustomSelector:
+			continue
+		}
+		rules[end] = s.substituteRule(rule)
+		end++
+	}
+	return rules[:end]
+}
+
+type customSubstituter struct {
+	log      logger.Log
+	source   *logger.Source
+	defs     *CustomDefinitions
+	canUseIs bool
+}
+
+func (s *customSubstituter) warn(msgID logger.MsgID, r logger.Range, text string) {
+	tracker := logger.MakeLineColumnTracker(s.source)
+	s.log.AddID(msgID, logger.Warning, &tracker, r, text)
+}
+
+func (s *customSubstituter) substituteRule(rule css_ast.Rule) css_ast.Rule {
+	switch r := rule.Data.(type) {
+	case *css_ast.RSelector:
+		selectors, selectorsChanged := s.substituteSelectorList(r.Selectors)
+		rules, rulesChanged := s.substituteRules(r.Rules)
+		if selectorsChanged || rulesChanged {
+			clone := *r
+			clone.Selectors = selectors
+			clone.Rules = rules
+			rule.Data = &clone
+		}
+
+	case *css_ast.RKnownAt:
+		prelude, preludeChanged := r.Prelude, false
+		if strings.EqualFold(r.AtToken, "media") {
+			prelude, preludeChanged = s.substituteMediaQuery(r.Prelude, true)
+		}
+		rules, rulesChanged := s.substituteRules(r.Rules)
+		if preludeChanged || rulesChanged {
+			clone := *r
+			clone.Prelude = prelude
+			clone.Rules = rules
+			rule.Data = &clone
+		}
+
+	case *css_ast.RAtLayer:
+		if rules, ok := s.substituteRules(r.Rules); ok {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+		}
+
+	case *css_ast.RAtScope:
+		start, startChanged := s.substituteSelectorList(r.Start)
+		end, endChanged := s.substituteSelectorList(r.End)
+		rules, rulesChanged := s.substituteRules(r.Rules)
+		if startChanged || endChanged || rulesChanged {
+			clone := *r
+			clone.Start = start
+			clone.End = end
+			clone.Rules = rules
+			rule.Data = &clone
+		}
+	}
+
+	return rule
+}
+
+func (s *customSubstituter) substituteRules(rules []css_ast.Rule) ([]css_ast.Rule, bool) {
+	var result []css_ast.Rule
+	for i, rule := range rules {
+		if substituted := s.substituteRule(rule); substituted.Data != rule.Data {
+			if result == nil {
+				result = append(make([]css_ast.Rule, 0, len(rules)), rules[:i]...)
+			}
+			rule = substituted
+		}
+		if result != nil {
+			result = append(result, rule)
+		}
+	}
+	if result == nil {
+		return rules, false
+	}
+	return result, true
+}
+
+// Returns the definition of a custom media query with any custom media
+// queries in the definition substituted too, or nil if it's part of a cycle
+func (s *customSubstituter) resolveMedia(name string) []css_ast.Token {
+	defs := s.defs
+	if query, ok := defs.resolvedMedia[name]; ok {
+		return query
+	}
+	key := "(" + name
+	if defs.inProgress[key] {
+		defs.cycles[key] = true
+		return nil
+	}
+	def := defs.media[name]
+	source := s.source
+	s.source = def.source
+	defs.inProgress[key] = true
+	query, _ := s.substituteMediaQuery(def.rule.Query, true)
+	delete(defs.inProgress, key)
+	s.source = source
+
+	// Definitions that refer to themselves are left alone
+	if defs.cycles[key] {
+		query = nil
+	}
+	defs.resolvedMedia[name] = query
+	return query
+}
+
+// Returns the definition of a custom selector with any custom selectors in
+// the definition substituted too, or nil if it's part of a cycle
+func (s *customSubstituter) resolveSelector(name string) []css_ast.ComplexSelector {
+	defs := s.defs
+	if list, ok := defs.resolvedSelectors[name]; ok {
+		return list
+	}
+	key := ":" + name
+	if defs.inProgress[key] {
+		defs.cycles[key] = true
+		return nil
+	}
+	def := defs.selectors[name]
+	source := s.source
+	s.source = def.source
+	defs.inProgress[key] = true
+	list, _ := s.substituteSelectorList(def.rule.Selectors)
+	delete(defs.inProgress, key)
+	s.source = source
+
+	// Definitions that refer to themselves are left alone
+	if defs.cycles[key] {
+		list = nil
+	}
+	defs.resolvedSelectors[name] = list
+	return list
+}
+
+// "@media (--narrow) and (hover)" => "@media (max-width: 30em) and (hover)"
+func (s *customSubstituter) substituteMediaQuery(tokens []css_ast.Token, isTopLevel bool) ([]css_ast.Token, bool) {
+	var result []css_ast.Token
+
+	for i, t := range tokens {
+		var replacement []css_ast.Token
+
+		if name, ok := customMediaReference(t); ok {
+			if s.defs.media[name].rule == nil {
+				if !s.defs.IgnoreUndefined {
+					s.warn(logger.MsgID_CSS_InvalidAtCustomMedia, rangeOfParens(s.source.Contents, t.Loc),
+						fmt.Sprintf("The custom media query %q is not defined", name))
+				}
+			} else if query := s.resolveMedia(name); query == nil {
+				s.warn(logger.MsgID_CSS_InvalidAtCustomMedia, rangeOfParens(s.source.Contents, t.Loc),
+					fmt.Sprintf("The custom media query %q is defined in terms of itself", name))
+			} else {
+				if isTopLevel && (i == 0 || tokens[i-1].Kind == css_lexer.TComma) && (i+1 == len(tokens) || tokens[i+1].Kind == css_lexer.TComma) {
+					// Anything can be substituted for an entire media query:
+					// "(--screens), (hover)" => "screen, print, (hover)"
+					replacement = css_ast.CloneTokensWithoutImportRecords(query)
+				} else if isMediaCondition(query) {
+					// Otherwise it must be wrapped in parentheses, which only works for
+					// media conditions: "(--a) and (hover)" => "((a) or (b)) and (hover)"
+					if len(query) == 1 && query[0].Kind == css_lexer.TOpenParen {
+						replacement = css_ast.CloneTokensWithoutImportRecords(query)
+					} else {
+						children := css_ast.CloneTokensWithoutImportRecords(query)
+						replacement = []css_ast.Token{{Loc: t.Loc, Kind: css_lexer.TOpenParen, Text: "(", Children: &children}}
+					}
+				}
+			}
+		} else if t.Children != nil {
+			if children, ok := s.substituteMediaQuery(*t.Children, false); ok {
+				t.Children = &children
+				replacement = []css_ast.Token{t}
+			}
+		}
+
+		if replacement == nil {
+			if result != nil {
+				result = append(result, t)
+			}
+			continue
+		}
+
+		if result == nil {
+			result = append(make([]css_ast.Token, 0, len(tokens)), tokens[:i]...)
+		}
+		first := &replacement[0]
+		last := &replacement[len(replacement)-1]
+		first.Whitespace = (first.Whitespace & ^css_ast.WhitespaceBefore) | (t.Whitespace & css_ast.WhitespaceBefore)
+		last.Whitespace = (last.Whitespace & ^css_ast.WhitespaceAfter) | (t.Whitespace & css_ast.WhitespaceAfter)
+		result = append(result, replacement...)
+	}
+
+	if result == nil {
+		return tokens, false
+	}
+	return result, true
+}
+
+// Matches "(--name)"
+func customMediaReference(t css_ast.Token) (string, bool) {
+	if t.Kind == css_lexer.TOpenParen && t.Children != nil && len(*t.Children) == 1 {
+		if child := (*t.Children)[0]; child.Kind == css_lexer.TIdent && strings.HasPrefix(child.Text, "--") {
+			return child.Text, true
+		}
+	}
+	return "", false
+}
+
+// Returns true if this is a "<media-condition>" (i.e. a single media query
+// without a media type), which can be placed inside parentheses
+func isMediaCondition(tokens []css_ast.Token) bool {
+	for _, t := range tokens {
+		switch t.Kind {
+		case css_lexer.TOpenParen, css_lexer.TFunction:
+		case css_lexer.TIdent:
+			if lower := strings.ToLower(t.Text); lower != "and" && lower != "or" && lower != "not" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ":--heading" => "h1, h2, h3"
+// ":--heading + p" => ":is(h1, h2, h3) + p"
+func (s *customSubstituter) substituteSelectorList(list []css_ast.ComplexSelector) ([]css_ast.ComplexSelector, bool) {
+	var result []css_ast.ComplexSelector
+	for i, sel := range list {
+		if expanded, ok := s.substituteComplexSelector(sel); ok {
+			if result == nil {
+				result = append(make([]css_ast.ComplexSelector, 0, len(list)), list[:i]...)
+			}
+			result = append(result, expanded...)
+		} else if result != nil {
+			result = append(result, sel)
+		}
+	}
+	if result == nil {
+		return list, false
+	}
+	return result, true
+}
+
+func (s *customSubstituter) substituteComplexSelector(sel css_ast.ComplexSelector) ([]css_ast.ComplexSelector, bool) {
+	// A lone reference is replaced by the whole list
+	if len(sel.Selectors) == 1 {
+		if compound := sel.Selectors[0]; compound.Combinator.Byte == 0 && compound.TypeSelector == nil &&
+			!compound.HasNestingSelector() && len(compound.SubclassSelectors) == 1 {
+			if index, list := s.findCustomSelectorReference(compound); index != -1 {
+				result := make([]css_ast.ComplexSelector, len(list))
+				for i, complex := range list {
+					result[i] = complex.CloneWithoutLeadingCombinator()
+				}
+				return result, true
+			}
+		}
+	}
+
+	results := [][]css_ast.CompoundSelector{nil}
+	changed := false
+
+	for _, compound := range sel.Selectors {
+		s.checkCustomSelectorReferences(compound)
+		compound, compoundChanged := s.substituteInsideCompoundSelector(compound)
+		if compoundChanged {
+			changed = true
+		}
+		for i := range results {
+			results[i] = append(results[i], compound)
+		}
+
+		// Substitute each reference in this compound selector one at a time. Each
+		// substitution multiplies the number of results if ":is()" isn't supported
+		// since each alternative must then be written out separately.
+		for {
+			var next [][]css_ast.CompoundSelector
+			found := false
+			for _, result := range results {
+				prefix := result[:len(result)-1]
+				last := result[len(result)-1]
+				index, list := s.findCustomSelectorReference(last)
+				if index == -1 {
+					next = append(next, result)
+					continue
+				}
+				found = true
+				alternatives := [][]css_ast.ComplexSelector{list}
+				if !s.canUseIs && len(list) > 1 {
+					alternatives = make([][]css_ast.ComplexSelector, len(list))
+					for i := range list {
+						alternatives[i] = list[i : i+1]
+					}
+				}
+				for _, alternative := range alternatives {
+					substituted := substituteCustomSelectorInCompound(last, index, alternative, len(prefix) == 0)
+					clone := make([]css_ast.CompoundSelector, 0, len(prefix)+len(substituted))
+					clone = append(clone, prefix...)
+					next = append(next, append(clone, substituted...))
+				}
+			}
+			results = next
+			if !found {
+				break
+			}
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil, false
+	}
+	list := make([]css_ast.ComplexSelector, len(results))
+	for i, compounds := range results {
+		list[i] = css_ast.ComplexSelector{Selectors: compounds}
+	}
+	return list, true
+}
+
+// Warns about references in this compound selector that will be left alone.
+// This is done separately from substitution because substitution may look at
+// the same compound selector more than once.
+func (s *customSubstituter) checkCustomSelectorReferences(compound css_ast.CompoundSelector) {
+	for _, ss := range compound.SubclassSelectors {
+		if name, ok := customSelectorReference(ss); ok {
+			if s.defs.selectors[name].rule == nil {
+				if !s.defs.IgnoreUndefined {
+					s.warn(logger.MsgID_CSS_InvalidAtCustomSelector, ss.Range,
+						fmt.Sprintf("The custom selector \":%s\" is not defined", name))
+				}
+			} else if s.resolveSelector(name) == nil {
+				s.warn(logger.MsgID_CSS_InvalidAtCustomSelector, ss.Range,
+					fmt.Sprintf("The custom selector \":%s\" is defined in terms of itself", name))
+			}
+		}
+	}
+}
+
+// Returns the index of the first subclass selector that refers to a custom
+// selector along with its definition, or -1 if there isn't one
+func (s *customSubstituter) findCustomSelectorReference(compound css_ast.CompoundSelector) (int, []css_ast.ComplexSelector) {
+	for i, ss := range compound.SubclassSelectors {
+		if name, ok := customSelectorReference(ss); ok && s.defs.selectors[name].rule != nil {
+			if list := s.resolveSelector(name); list != nil {
+				return i, list
+			}
+		}
+	}
+	return -1, nil
+}
+
+// Matches ":--name"
+func customSelectorReference(ss css_ast.SubclassSelector) (string, bool) {
+	if pseudo, ok := ss.Data.(*css_ast.SSPseudoClass); ok && !pseudo.IsElement && pseudo.Args == nil && strings.HasPrefix(pseudo.Name, "--") {
+		return pseudo.Name, true
+	}
+	return "", false
+}
+
+// ":not(:--heading)" => ":not(h1, h2, h3)"
+func (s *customSubstituter) substituteInsideCompoundSelector(compound css_ast.CompoundSelector) (css_ast.CompoundSelector, bool) {
+	var subclassSelectors []css_ast.SubclassSelector
+	for i, ss := range compound.SubclassSelectors {
+		if pseudo, ok := ss.Data.(*css_ast.SSPseudoClassWithSelectorList); ok {
+			if list, ok := s.substituteSelectorList(pseudo.Selectors); ok {
+				if subclassSelectors == nil {
+					subclassSelectors = append(make([]css_ast.SubclassSelector, 0, len(compound.SubclassSelectors)), compound.SubclassSelectors[:i]...)
+				}
+				clone := *pseudo
+				clone.Selectors = list
+				ss.Data = &clone
+			}
+		}
+		if subclassSelectors != nil {
+			subclassSelectors = append(subclassSelectors, ss)
+		}
+	}
+	if subclassSelectors == nil {
+		return compound, false
+	}
+	compound.SubclassSelectors = subclassSelectors
+	return compound, true
+}
+
+// Replaces the subclass selector at "index" with the given selector list,
+// returning the compound selectors to use instead of this compound selector.
+// The last returned compound selector may still contain other references.
+func substituteCustomSelectorInCompound(
+	compound css_ast.CompoundSelector,
+	index int,
+	list []css_ast.ComplexSelector,
+	isFirst bool,
+) (result []css_ast.CompoundSelector) {
+	before := compound.SubclassSelectors[:index]
+	after := compound.SubclassSelectors[index+1:]
+
+	if len(list) == 1 {
+		replacement := list[0].CloneWithoutLeadingCombinator()
+		last := replacement.Selectors[len(replacement.Selectors)-1]
+
+		// The leading compound selectors can only be moved in front of this one if
+		// nothing comes before it: ":--a.b" => ".x .y.b" but "p > :--a" can't be
+		// "p > .x .y" since that's not the same thing
+		if (len(replacement.Selectors) == 1 || (isFirst && compound.Combinator.Byte == 0)) &&
+			(compound.TypeSelector == nil || last.TypeSelector == nil) {
+			if len(replacement.Selectors) > 1 {
+				replacement.Selectors[0].Combinator = compound.Combinator
+				compound.Combinator = last.Combinator
+				result = append(result, replacement.Selectors[:len(replacement.Selectors)-1]...)
+			}
+			if last.TypeSelector != nil {
+				compound.TypeSelector = last.TypeSelector
+			}
+			if last.HasNestingSelector() && !compound.HasNestingSelector() {
+				compound.NestingSelectorLoc = last.NestingSelectorLoc
+			}
+			compound.SubclassSelectors = make([]css_ast.SubclassSelector, 0, len(before)+len(last.SubclassSelectors)+len(after))
+			compound.SubclassSelectors = append(compound.SubclassSelectors, before...)
+			compound.SubclassSelectors = append(compound.SubclassSelectors, last.SubclassSelectors...)
+			compound.SubclassSelectors = append(compound.SubclassSelectors, after...)
+			return append(result, compound)
+		}
+	}
+
+	// Otherwise, wrap the list in ":is()"
+	clones := make([]css_ast.ComplexSelector, len(list))
+	for i, complex := range list {
+		clones[i] = complex.CloneWithoutLeadingCombinator()
+	}
+	compound.SubclassSelectors = make([]css_ast.SubclassSelector, 0, len(before)+1+len(after))
+	compound.SubclassSelectors = append(compound.SubclassSelectors, before...)
+	compound.SubclassSelectors = append(compound.SubclassSelectors, css_ast.SubclassSelector{
+		Range: clones[0].Selectors[0].Range(),
+		Data:  &css_ast.SSPseudoClassWithSelectorList{Kind: css_ast.PseudoClassIs, Selectors: clones},
+	})
+	compound.SubclassSelectors = append(compound.SubclassSelectors, after...)
+	return append(result, compound)
+}
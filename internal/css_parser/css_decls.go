@@ -90,6 +90,7 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 	didWarnAboutComposes := false
 	wouldClipColorFlag := false
 	var declarationKeys map[string]struct{}
+	var colorSchemeMedia css_ast.Rule
 
 	// Don't automatically generate the "inset" property if it's not supported
 	if p.options.unsupportedCSSFeatures.Has(compat.InsetProperty) {
@@ -142,6 +143,11 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 			wouldClipColor = &wouldClipColorFlag
 		}
 
+		// Lower "light-dark()" anywhere in the value, including in custom properties
+		if p.options.unsupportedCSSFeatures.Has(compat.LightDark) {
+			decl.Value = p.lowerLightDark(decl.Value)
+		}
+
 		switch decl.Key {
 		case css_ast.DComposes:
 			// Only process "composes" directives if we're in "local-css" or
@@ -209,6 +215,14 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 				decl.Value[0] = p.lowerAndMinifyColor(decl.Value[0], wouldClipColor)
 			}
 
+		case css_ast.DColorScheme:
+			if p.options.unsupportedCSSFeatures.Has(compat.LightDark) {
+				if decls, media := p.lowerColorScheme(rule.Loc, decl); decls != nil {
+					rewrittenRules = append(rewrittenRules, decls...)
+					colorSchemeMedia = media
+				}
+			}
+
 		case css_ast.DTransform:
 			if p.options.minifySyntax {
 				decl.Value = p.mangleTransforms(decl.Value)
@@ -354,7 +368,11 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 			}
 		}
 
-		if prefixes, ok := p.options.cssPrefixData[decl.Key]; ok {
+		prefixes := p.options.cssPrefixData[decl.Key]
+		if (prefixes&compat.WebkitPrefix) == 0 && p.imageSetNeedsWebkitFallback(decl) {
+			prefixes |= compat.WebkitPrefix
+		}
+		if prefixes != compat.NoPrefix {
 			if declarationKeys == nil {
 				// Only generate this map if it's needed
 				declarationKeys = make(map[string]struct{})
@@ -406,6 +424,12 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 		}
 	}
 
+	// The "@media" rule for the last "color-scheme" declaration goes at the end
+	// so that it's not interleaved with the declarations before it
+	if colorSchemeMedia.Data != nil {
+		rewrittenRules = append(rewrittenRules, colorSchemeMedia)
+	}
+
 	// Compact removed rules
 	if p.options.minifySyntax {
 		end := 0
@@ -444,6 +468,20 @@ func (p *parser) insertPrefixedDeclaration(rules []css_ast.Rule, prefix string,
 		if len(decl.Value) != 1 || decl.Value[0].Kind != css_lexer.TIdent || !strings.EqualFold(decl.Value[0].Text, "sticky") {
 			return rules
 		}
+
+	case css_ast.DBackground, css_ast.DBackgroundImage, css_ast.DBorderImage, css_ast.DBorderImageSource,
+		css_ast.DContent, css_ast.DCursor, css_ast.DListStyle, css_ast.DListStyleImage:
+		// The prefix is only needed for "image-set()"
+		if !hasImageSetFunction(decl.Value, "image-set") {
+			return rules
+		}
+
+		// Don't insert a fallback if the previous declaration already is one
+		if n := len(rules); n >= 2 {
+			if prev, ok := rules[n-2].Data.(*css_ast.RDeclaration); ok && prev.Key == decl.Key && hasImageSetFunction(prev.Value, "-webkit-image-set") {
+				return rules
+			}
+		}
 	}
 
 	value := css_ast.CloneTokensWithoutImportRecords(decl.Value)
@@ -455,6 +493,14 @@ func (p *parser) insertPrefixedDeclaration(rules []css_ast.Rule, prefix string,
 		keyText = decl.KeyText
 		value[0].Text = "-webkit-sticky"
 
+	case css_ast.DBackground, css_ast.DBackgroundImage, css_ast.DBorderImage, css_ast.DBorderImageSource,
+		css_ast.DContent, css_ast.DCursor, css_ast.DListStyle, css_ast.DListStyleImage:
+		// The prefix applies to the "image-set()" function, not the property
+		keyText = decl.KeyText
+		if !p.lowerImageSetForWebkit(value) {
+			return rules
+		}
+
 	case css_ast.DUserSelect:
 		// The prefix applies to the value as well as the property
 		if prefix == "-moz-" && len(value) == 1 && value[0].Kind == css_lexer.TIdent && strings.EqualFold(value[0].Text, "none") {
@@ -0,0 +1,204 @@
+package css_parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+)
+
+// These are the only image types that are assumed to be understood by
+// browsers that only support the prefixed "-webkit-image-set()" function
+var webkitImageSetTypes = map[string]bool{
+	"image/gif":     true,
+	"image/jpeg":    true,
+	"image/png":     true,
+	"image/svg+xml": true,
+}
+
+func hasImageSetFunction(tokens []css_ast.Token, name string) bool {
+	for _, t := range tokens {
+		if t.Kind == css_lexer.TFunction && strings.EqualFold(t.Text, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Some browsers (e.g. Safari 14 to 16) support the unprefixed "image-set()"
+// function but not "type()" or resolution units other than "x". Those still
+// need the prefixed fallback even though the property itself doesn't.
+func (p *parser) imageSetNeedsWebkitFallback(decl *css_ast.RDeclaration) bool {
+	if !p.options.unsupportedCSSFeatures.Has(compat.ImageSetOptions) {
+		return false
+	}
+
+	switch decl.Key {
+	case css_ast.DBackground, css_ast.DBackgroundImage, css_ast.DBorderImage, css_ast.DBorderImageSource,
+		css_ast.DContent, css_ast.DCursor, css_ast.DListStyle, css_ast.DListStyleImage:
+	default:
+		return false
+	}
+
+	for _, t := range decl.Value {
+		if t.Kind != css_lexer.TFunction || !strings.EqualFold(t.Text, "image-set") || t.Children == nil {
+			continue
+		}
+		for _, child := range *t.Children {
+			switch child.Kind {
+			case css_lexer.TFunction:
+				if strings.EqualFold(child.Text, "type") {
+					return true
+				}
+			case css_lexer.TDimension:
+				if !strings.EqualFold(child.DimensionUnit(), "x") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// The prefixed "-webkit-image-set()" function doesn't support "type()" or any
+// resolution units other than "x". This rewrites each "image-set()" function
+// in the (already cloned) tokens into that form in place. Options with image
+// types that older browsers may not understand are dropped. This returns false
+// if there's nothing to rewrite or if the result wouldn't be usable.
+func (p *parser) lowerImageSetForWebkit(tokens []css_ast.Token) bool {
+	found := false
+
+	for i, t := range tokens {
+		if t.Kind != css_lexer.TFunction || !strings.EqualFold(t.Text, "image-set") || t.Children == nil {
+			continue
+		}
+
+		var options [][]css_ast.Token
+		children := *t.Children
+		for len(children) > 0 {
+			end := 0
+			for end < len(children) && children[end].Kind != css_lexer.TComma {
+				end++
+			}
+			option, keep, ok := lowerImageSetOption(children[:end])
+			if !ok {
+				return false
+			}
+			if keep {
+				options = append(options, option)
+			}
+			if end < len(children) {
+				end++
+			}
+			children = children[end:]
+		}
+
+		// Don't generate a fallback without any images in it
+		if len(options) == 0 {
+			return false
+		}
+
+		var nested []css_ast.Token
+		for j, option := range options {
+			if j > 0 {
+				comma := css_ast.Token{Loc: option[0].Loc, Kind: css_lexer.TComma, Text: ","}
+				if !p.options.minifyWhitespace {
+					comma.Whitespace = css_ast.WhitespaceAfter
+					option[0].Whitespace = css_ast.WhitespaceBefore
+				}
+				nested = append(nested, comma)
+			}
+			nested = append(nested, option...)
+		}
+
+		tokens[i].Text = "-webkit-image-set"
+		tokens[i].Children = &nested
+		found = true
+	}
+
+	return found
+}
+
+// This returns "keep" as false if the option should be dropped because of its
+// type, and "ok" as false if the option contains something unexpected.
+func lowerImageSetOption(tokens []css_ast.Token) (option []css_ast.Token, keep bool, ok bool) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	image := tokens[0]
+
+	// A variable could expand to anything, including a resolution
+	if image.Kind == css_lexer.TFunction && strings.EqualFold(image.Text, "var") {
+		return
+	}
+	image.Whitespace = 0
+	var resolution *css_ast.Token
+	keep = true
+
+	for _, t := range tokens[1:] {
+		switch t.Kind {
+		case css_lexer.TDimension:
+			if resolution != nil {
+				return
+			}
+			r, valid := lowerImageSetResolution(t)
+			if !valid {
+				return
+			}
+			resolution = &r
+
+		case css_lexer.TFunction:
+			if !strings.EqualFold(t.Text, "type") || t.Children == nil || len(*t.Children) != 1 || (*t.Children)[0].Kind != css_lexer.TString {
+				return
+			}
+			if !webkitImageSetTypes[strings.ToLower((*t.Children)[0].Text)] {
+				keep = false
+			}
+
+		default:
+			return
+		}
+	}
+
+	// The resolution is optional in "image-set()" but not in older browsers
+	if resolution == nil {
+		resolution = &css_ast.Token{Loc: image.Loc, Kind: css_lexer.TDimension, Text: "1x", UnitOffset: 1}
+	}
+	resolution.Whitespace = css_ast.WhitespaceBefore
+
+	option = []css_ast.Token{image, *resolution}
+	ok = true
+	return
+}
+
+func lowerImageSetResolution(t css_ast.Token) (css_ast.Token, bool) {
+	var scale float64
+	switch strings.ToLower(t.DimensionUnit()) {
+	case "x":
+		return t, true
+	case "dppx":
+		scale = 1
+	case "dpi":
+		scale = 1.0 / 96
+	case "dpcm":
+		scale = 2.54 / 96
+	default:
+		return css_ast.Token{}, false
+	}
+
+	n, err := strconv.ParseFloat(t.DimensionValue(), 64)
+	if err != nil {
+		return css_ast.Token{}, false
+	}
+
+	// Round to avoid floating-point error (e.g. "0.9999999999999999x")
+	n = math.Round(n*scale*1e6) / 1e6
+	text := strconv.FormatFloat(n, 'f', -1, 64)
+	t.Text = text + "x"
+	t.UnitOffset = uint16(len(text))
+	return t, true
+}
@@ -0,0 +1,227 @@
+package css_parser
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// The "light-dark()" color function is lowered using the "space toggle"
+// technique. Each "light-dark(a, b)" becomes this:
+//
+//	var(--esbuild-light, a) var(--esbuild-dark, b)
+//
+// A custom property with the value "initial" makes "var()" use the fallback,
+// while a custom property with an empty value makes "var()" substitute
+// nothing. So the right color is picked if exactly one of these two custom
+// properties is set to "initial". That is done for each "color-scheme"
+// declaration, which is what decides between light and dark for the real
+// "light-dark()" function:
+//
+//	color-scheme: light dark;
+//	--esbuild-light: initial;
+//	--esbuild-dark: ;
+//	@media (prefers-color-scheme: dark) {
+//	  --esbuild-light: ;
+//	  --esbuild-dark: initial;
+//	}
+//
+// Custom properties are inherited, so this works as long as "color-scheme" is
+// set on the element or one of its ancestors (usually ":root").
+const (
+	lightDarkLightVar = "--esbuild-light"
+	lightDarkDarkVar  = "--esbuild-dark"
+)
+
+// Replace all "light-dark()" functions in the token list, including ones
+// nested inside other functions. The tokens are modified in place.
+func (p *parser) lowerLightDark(tokens []css_ast.Token) []css_ast.Token {
+	var result []css_ast.Token
+
+	for i, t := range tokens {
+		if t.Kind == css_lexer.TFunction && strings.EqualFold(t.Text, "light-dark") {
+			if light, dark, ok := splitLightDarkArgs(*t.Children); ok {
+				if result == nil {
+					result = make([]css_ast.Token, 0, len(tokens)+1)
+					result = append(result, tokens[:i]...)
+				}
+				lightToken := p.lightDarkVar(t.Loc, lightDarkLightVar, p.lowerLightDark(light))
+				darkToken := p.lightDarkVar(t.Loc, lightDarkDarkVar, p.lowerLightDark(dark))
+				lightToken.Whitespace = (t.Whitespace & css_ast.WhitespaceBefore) | css_ast.WhitespaceAfter
+				darkToken.Whitespace = (t.Whitespace & css_ast.WhitespaceAfter) | css_ast.WhitespaceBefore
+				result = append(result, lightToken, darkToken)
+				continue
+			}
+		}
+
+		if t.Children != nil {
+			children := p.lowerLightDark(*t.Children)
+			t.Children = &children
+		}
+		if result != nil {
+			result = append(result, t)
+		} else {
+			tokens[i] = t
+		}
+	}
+
+	if result == nil {
+		return tokens
+	}
+	return result
+}
+
+func splitLightDarkArgs(tokens []css_ast.Token) (light []css_ast.Token, dark []css_ast.Token, ok bool) {
+	for i, t := range tokens {
+		if t.Kind == css_lexer.TComma {
+			if light != nil {
+				return nil, nil, false
+			}
+			light = tokens[:i]
+			dark = tokens[i+1:]
+		}
+	}
+	if len(light) == 0 || len(dark) == 0 {
+		return nil, nil, false
+	}
+	return light, dark, true
+}
+
+// Generates "var(--esbuild-light, value)" or "var(--esbuild-dark, value)"
+func (p *parser) lightDarkVar(loc logger.Loc, name string, value []css_ast.Token) css_ast.Token {
+	// The fallback value won't be processed as a color anymore once it's inside
+	// of "var()", so lower it now. Colors that can't be represented in sRGB are
+	// clipped since this is only used for older browsers.
+	if len(value) == 1 {
+		value[0] = p.lowerAndMinifyColor(value[0], nil)
+	}
+
+	value[0].Whitespace &= ^css_ast.WhitespaceBefore
+	value[len(value)-1].Whitespace &= ^css_ast.WhitespaceAfter
+
+	children := make([]css_ast.Token, 0, len(value)+2)
+	children = append(children, css_ast.Token{Loc: loc, Kind: css_lexer.TIdent, Text: name}, p.commaToken(loc))
+	children = append(children, value...)
+	if !p.options.minifyWhitespace {
+		children[2].Whitespace |= css_ast.WhitespaceBefore
+	}
+
+	return css_ast.Token{
+		Loc:      loc,
+		Kind:     css_lexer.TFunction,
+		Text:     "var",
+		Children: &children,
+	}
+}
+
+type colorSchemeKind uint8
+
+const (
+	colorSchemeUnknown colorSchemeKind = iota
+	colorSchemeLight
+	colorSchemeDark
+	colorSchemeLightAndDark
+)
+
+// Reference: https://drafts.csswg.org/css-color-adjust-1/#color-scheme-prop
+func parseColorScheme(tokens []css_ast.Token) colorSchemeKind {
+	hasLight := false
+	hasDark := false
+
+	for _, t := range tokens {
+		if t.Kind != css_lexer.TIdent {
+			return colorSchemeUnknown
+		}
+		lower := strings.ToLower(t.Text)
+		if cssWideAndReservedKeywords[lower] {
+			return colorSchemeUnknown
+		}
+		switch lower {
+		case "light":
+			hasLight = true
+		case "dark":
+			hasDark = true
+		}
+	}
+
+	switch {
+	case hasLight && hasDark:
+		return colorSchemeLightAndDark
+	case hasDark:
+		return colorSchemeDark
+	case len(tokens) > 0:
+		// "normal" and unknown color schemes both use the light color
+		return colorSchemeLight
+	default:
+		return colorSchemeUnknown
+	}
+}
+
+// Generates the custom properties that "light-dark()" depends on for a single
+// "color-scheme" declaration. Returns the declarations that go right after the
+// "color-scheme" declaration as well as an optional nested "@media" rule that
+// should be appended to the end of the declaration list.
+func (p *parser) lowerColorScheme(loc logger.Loc, decl *css_ast.RDeclaration) (decls []css_ast.Rule, media css_ast.Rule) {
+	kind := parseColorScheme(decl.Value)
+	if kind == colorSchemeUnknown {
+		return
+	}
+
+	decls = []css_ast.Rule{
+		p.lightDarkToggle(loc, decl, lightDarkLightVar, kind != colorSchemeDark),
+		p.lightDarkToggle(loc, decl, lightDarkDarkVar, kind == colorSchemeDark),
+	}
+
+	// Only generate a nested "@media" rule inside of a style rule, since
+	// nesting isn't valid in other declaration lists (e.g. "@page")
+	if kind == colorSchemeLightAndDark && p.inSelectorSubtree > 0 {
+		darkWhitespace := css_ast.WhitespaceBefore
+		if p.options.minifyWhitespace {
+			darkWhitespace = 0
+		}
+		media.Loc = loc
+		media.Data = &css_ast.RKnownAt{
+			AtToken: "media",
+			Prelude: []css_ast.Token{{
+				Loc:  loc,
+				Kind: css_lexer.TOpenParen,
+				Text: "(",
+				Children: &[]css_ast.Token{
+					{Loc: loc, Kind: css_lexer.TIdent, Text: "prefers-color-scheme"},
+					{Loc: loc, Kind: css_lexer.TColon, Text: ":"},
+					{Loc: loc, Kind: css_lexer.TIdent, Text: "dark", Whitespace: darkWhitespace},
+				},
+			}},
+			Rules: []css_ast.Rule{
+				p.lightDarkToggle(loc, decl, lightDarkLightVar, false),
+				p.lightDarkToggle(loc, decl, lightDarkDarkVar, true),
+			},
+		}
+
+		// The nested "@media" rule may need to be lowered
+		p.nestingIsPresent = true
+	}
+	return
+}
+
+// Generates either "--esbuild-light: initial" or "--esbuild-light: ;"
+func (p *parser) lightDarkToggle(loc logger.Loc, decl *css_ast.RDeclaration, name string, isActive bool) css_ast.Rule {
+	// An empty custom property must still contain whitespace to be valid in
+	// older browsers, so use an explicit whitespace token in that case
+	value := css_ast.Token{Loc: loc, Kind: css_lexer.TWhitespace}
+	if isActive {
+		value = css_ast.Token{Loc: loc, Kind: css_lexer.TIdent, Text: "initial"}
+		if !p.options.minifyWhitespace {
+			value.Whitespace = css_ast.WhitespaceBefore
+		}
+	}
+
+	return css_ast.Rule{Loc: loc, Data: &css_ast.RDeclaration{
+		KeyText:   name,
+		KeyRange:  decl.KeyRange,
+		Value:     []css_ast.Token{value},
+		Important: decl.Important,
+	}}
+}
@@ -0,0 +1,408 @@
+package css_parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// A media feature written using range syntax. For example, this is what
+// "(400px <= width < 800px)" looks like:
+//
+//	mediaFeatureRange{
+//	  name:  "width",
+//	  lower: mediaRangeBound{value: 400px},
+//	  upper: mediaRangeBound{value: 800px, isExclusive: true},
+//	}
+//
+// Reference: https://drafts.csswg.org/mediaqueries-4/#mq-range-context
+type mediaFeatureRange struct {
+	name  string
+	loc   logger.Loc
+	exact []css_ast.Token // For "(width = 400px)"
+	lower mediaRangeBound
+	upper mediaRangeBound
+}
+
+type mediaRangeBound struct {
+	value       []css_ast.Token // This is nil if there's no bound
+	isExclusive bool
+}
+
+type mediaRangeOp uint8
+
+const (
+	mediaRangeNone mediaRangeOp = iota
+	mediaRangeLT
+	mediaRangeLE
+	mediaRangeGT
+	mediaRangeGE
+	mediaRangeEQ
+)
+
+type mediaFeatureKind uint8
+
+const (
+	mediaFeatureLength mediaFeatureKind = iota
+	mediaFeatureInteger
+	mediaFeatureRatio
+	mediaFeatureResolution
+)
+
+// These are the media features that have "min-" and "max-" prefixed forms
+var rangeMediaFeatures = map[string]mediaFeatureKind{
+	"aspect-ratio":        mediaFeatureRatio,
+	"color":               mediaFeatureInteger,
+	"color-index":         mediaFeatureInteger,
+	"device-aspect-ratio": mediaFeatureRatio,
+	"device-height":       mediaFeatureLength,
+	"device-width":        mediaFeatureLength,
+	"height":              mediaFeatureLength,
+	"monochrome":          mediaFeatureInteger,
+	"resolution":          mediaFeatureResolution,
+	"width":               mediaFeatureLength,
+}
+
+// Returns the comparison operator starting at the given token and the number
+// of tokens that it uses
+func parseMediaRangeOp(tokens []css_ast.Token, i int) (mediaRangeOp, int) {
+	t := tokens[i]
+	switch {
+	case t.Kind == css_lexer.TDelimEquals:
+		return mediaRangeEQ, 1
+
+	case t.Kind == css_lexer.TDelim && t.Text == "<", t.Kind == css_lexer.TDelimGreaterThan:
+		op := mediaRangeLT
+		if t.Kind == css_lexer.TDelimGreaterThan {
+			op = mediaRangeGT
+		}
+
+		// Whitespace isn't allowed between "<" and "="
+		if i+1 < len(tokens) && tokens[i+1].Kind == css_lexer.TDelimEquals &&
+			(t.Whitespace&css_ast.WhitespaceAfter) == 0 && (tokens[i+1].Whitespace&css_ast.WhitespaceBefore) == 0 {
+			return op + 1, 2
+		}
+		return op, 1
+	}
+
+	return mediaRangeNone, 0
+}
+
+func mediaFeatureName(tokens []css_ast.Token) (string, bool) {
+	if len(tokens) == 1 && tokens[0].Kind == css_lexer.TIdent {
+		name := strings.ToLower(tokens[0].Text)
+		if _, ok := rangeMediaFeatures[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Parses the contents of a parenthesized media feature
+func parseMediaFeatureRange(tokens []css_ast.Token) (r mediaFeatureRange, ok bool) {
+	var operands [][]css_ast.Token
+	var ops []mediaRangeOp
+	start := 0
+
+	// Split the tokens at each comparison operator
+	for i := 0; i < len(tokens); {
+		if op, n := parseMediaRangeOp(tokens, i); op != mediaRangeNone {
+			operands = append(operands, tokens[start:i])
+			ops = append(ops, op)
+			i += n
+			start = i
+		} else {
+			i++
+		}
+	}
+	operands = append(operands, tokens[start:])
+	for _, operand := range operands {
+		if len(operand) == 0 {
+			return
+		}
+	}
+
+	switch len(ops) {
+	case 1:
+		if name, isName := mediaFeatureName(operands[0]); isName {
+			// "(width >= 400px)"
+			r.name = name
+			r.loc = operands[0][0].Loc
+			r.setBound(ops[0], operands[1])
+		} else if name, isName := mediaFeatureName(operands[1]); isName {
+			// "(400px <= width)"
+			r.name = name
+			r.loc = operands[1][0].Loc
+			r.setBound(flipMediaRangeOp(ops[0]), operands[0])
+		} else {
+			return
+		}
+
+	case 2:
+		// "(400px <= width < 800px)"
+		name, isName := mediaFeatureName(operands[1])
+		if !isName {
+			return
+		}
+		isLess := (ops[0] == mediaRangeLT || ops[0] == mediaRangeLE) && (ops[1] == mediaRangeLT || ops[1] == mediaRangeLE)
+		isGreater := (ops[0] == mediaRangeGT || ops[0] == mediaRangeGE) && (ops[1] == mediaRangeGT || ops[1] == mediaRangeGE)
+		if !isLess && !isGreater {
+			return
+		}
+		r.name = name
+		r.loc = operands[1][0].Loc
+		r.setBound(flipMediaRangeOp(ops[0]), operands[0])
+		r.setBound(ops[1], operands[2])
+
+	default:
+		return
+	}
+
+	ok = true
+	return
+}
+
+// "400px < width" is the same as "width > 400px"
+func flipMediaRangeOp(op mediaRangeOp) mediaRangeOp {
+	switch op {
+	case mediaRangeLT:
+		return mediaRangeGT
+	case mediaRangeLE:
+		return mediaRangeGE
+	case mediaRangeGT:
+		return mediaRangeLT
+	case mediaRangeGE:
+		return mediaRangeLE
+	}
+	return op
+}
+
+// The operator is relative to the feature name (i.e. "width <op> value")
+func (r *mediaFeatureRange) setBound(op mediaRangeOp, value []css_ast.Token) {
+	switch op {
+	case mediaRangeEQ:
+		r.exact = value
+	case mediaRangeGT, mediaRangeGE:
+		r.lower = mediaRangeBound{value: value, isExclusive: op == mediaRangeGT}
+	case mediaRangeLT, mediaRangeLE:
+		r.upper = mediaRangeBound{value: value, isExclusive: op == mediaRangeLT}
+	}
+}
+
+// Replaces media features in range syntax with the equivalent "min-" and
+// "max-" prefixed media features if range syntax isn't supported:
+//
+//	"(width >= 400px)" => "(min-width: 400px)"
+//	"(400px < width <= 800px)" => "(min-width: 400.001px) and (max-width: 800px)"
+//
+// Media features that can't be converted are left alone with a warning.
+func (p *parser) lowerMediaQueryRanges(tokens []css_ast.Token) []css_ast.Token {
+	if !p.options.unsupportedCSSFeatures.Has(compat.MediaRange) {
+		return tokens
+	}
+	return p.lowerMediaQueryRangesHelper(tokens, true)
+}
+
+func (p *parser) lowerMediaQueryRangesHelper(tokens []css_ast.Token, isTopLevel bool) []css_ast.Token {
+	// Two media features can only be joined using a top-level "and" if this
+	// media query doesn't also use "or" (e.g. "(a) or (b) and (c)" is invalid)
+	var queryHasOr []bool
+	if isTopLevel {
+		hasOr := false
+		for _, t := range tokens {
+			if t.Kind == css_lexer.TComma {
+				queryHasOr = append(queryHasOr, hasOr)
+				hasOr = false
+			} else if t.Kind == css_lexer.TIdent && strings.EqualFold(t.Text, "or") {
+				hasOr = true
+			}
+		}
+		queryHasOr = append(queryHasOr, hasOr)
+	}
+
+	result := make([]css_ast.Token, 0, len(tokens))
+	queryIndex := 0
+
+	for i, t := range tokens {
+		if t.Kind == css_lexer.TComma {
+			queryIndex++
+		}
+
+		if t.Kind == css_lexer.TOpenParen && t.Children != nil {
+			if r, ok := parseMediaFeatureRange(*t.Children); ok {
+				if features, ok := p.mediaFeatureRangeToMinMax(r); ok {
+					// "not (400px < width < 800px)" => "not ((min-width: 400.001px) and (max-width: 799.999px))"
+					isAfterNot := i > 0 && tokens[i-1].Kind == css_lexer.TIdent && strings.EqualFold(tokens[i-1].Text, "not")
+					if len(features) > 1 && (!isTopLevel || queryHasOr[queryIndex] || isAfterNot) {
+						children := features
+						features = []css_ast.Token{{
+							Loc:      t.Loc,
+							Kind:     css_lexer.TOpenParen,
+							Text:     "(",
+							Children: &children,
+						}}
+					}
+					features[0].Whitespace |= t.Whitespace & css_ast.WhitespaceBefore
+					features[len(features)-1].Whitespace |= t.Whitespace & css_ast.WhitespaceAfter
+					result = append(result, features...)
+					continue
+				}
+				p.warnAboutUnsupportedMediaRange(t, r)
+			} else {
+				// "((width > 400px) or (height > 400px))"
+				children := p.lowerMediaQueryRangesHelper(*t.Children, false)
+				t.Children = &children
+			}
+		}
+
+		result = append(result, t)
+	}
+
+	return result
+}
+
+func (p *parser) warnAboutUnsupportedMediaRange(t css_ast.Token, r mediaFeatureRange) {
+	text := "Transforming this media query range is not supported in the configured target environment"
+	if p.options.originalTargetEnv != "" {
+		text = fmt.Sprintf("%s (%s)", text, p.options.originalTargetEnv)
+	}
+	var note string
+	if rangeMediaFeatures[r.name] == mediaFeatureRatio {
+		note = fmt.Sprintf("Exclusive bounds for %q can't be converted to \"min-%s\" or \"max-%s\" because ratios can't be adjusted by a small amount.", r.name, r.name, r.name)
+	} else {
+		note = fmt.Sprintf("Exclusive bounds for %q can only be converted to \"min-%s\" or \"max-%s\" if they are a single number or dimension.", r.name, r.name, r.name)
+	}
+	p.log.AddIDWithNotes(logger.MsgID_CSS_UnsupportedMediaRange, logger.Warning, &p.tracker, rangeOfParens(p.source.Contents, t.Loc), text, []logger.MsgData{{Text: note}})
+}
+
+// Media features don't contain strings or comments in practice, so the end
+// of the media feature can be found by counting parentheses
+func rangeOfParens(text string, loc logger.Loc) logger.Range {
+	depth := 0
+	for i := int(loc.Start); i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return logger.Range{Loc: loc, Len: int32(i+1) - loc.Start}
+			}
+		}
+	}
+	return logger.Range{Loc: loc, Len: 1}
+}
+
+func (p *parser) mediaFeatureRangeToMinMax(r mediaFeatureRange) ([]css_ast.Token, bool) {
+	// "(width = 400px)" => "(width: 400px)"
+	if r.exact != nil {
+		return []css_ast.Token{p.mediaFeature(r.loc, r.name, r.exact)}, true
+	}
+
+	var features []css_ast.Token
+
+	if r.lower.value != nil {
+		value := r.lower.value
+		if r.lower.isExclusive {
+			var ok bool
+			if value, ok = adjustExclusiveMediaRangeBound(r.name, value, 1); !ok {
+				return nil, false
+			}
+		}
+		features = append(features, p.mediaFeature(r.loc, "min-"+r.name, value))
+	}
+
+	if r.upper.value != nil {
+		value := r.upper.value
+		if r.upper.isExclusive {
+			var ok bool
+			if value, ok = adjustExclusiveMediaRangeBound(r.name, value, -1); !ok {
+				return nil, false
+			}
+		}
+		if features != nil {
+			features = append(features, css_ast.Token{
+				Loc:        r.loc,
+				Kind:       css_lexer.TIdent,
+				Text:       "and",
+				Whitespace: css_ast.WhitespaceBefore | css_ast.WhitespaceAfter,
+			})
+		}
+		features = append(features, p.mediaFeature(r.loc, "max-"+r.name, value))
+	}
+
+	return features, true
+}
+
+// Generates "(name: value)"
+func (p *parser) mediaFeature(loc logger.Loc, name string, value []css_ast.Token) css_ast.Token {
+	children := make([]css_ast.Token, 0, len(value)+2)
+	children = append(children,
+		css_ast.Token{Loc: loc, Kind: css_lexer.TIdent, Text: name},
+		css_ast.Token{Loc: loc, Kind: css_lexer.TColon, Text: ":"},
+	)
+	children = append(children, value...)
+	children[2].Whitespace &= ^css_ast.WhitespaceBefore
+	if !p.options.minifyWhitespace {
+		children[2].Whitespace |= css_ast.WhitespaceBefore
+	}
+	children[len(children)-1].Whitespace &= ^css_ast.WhitespaceAfter
+
+	return css_ast.Token{
+		Loc:      loc,
+		Kind:     css_lexer.TOpenParen,
+		Text:     "(",
+		Children: &children,
+	}
+}
+
+// The "min-" and "max-" prefixed media features are inclusive, so exclusive
+// bounds are adjusted by the smallest reasonable amount. This is what other
+// CSS tools do too. Integers can be adjusted exactly but other values can't,
+// and ratios and math functions aren't adjusted at all.
+func adjustExclusiveMediaRangeBound(name string, value []css_ast.Token, direction int) ([]css_ast.Token, bool) {
+	if len(value) != 1 {
+		return nil, false
+	}
+	t := value[0]
+
+	switch rangeMediaFeatures[name] {
+	case mediaFeatureInteger:
+		if t.Kind == css_lexer.TNumber {
+			if n, err := strconv.Atoi(t.Text); err == nil {
+				t.Text = strconv.Itoa(n + direction)
+				return []css_ast.Token{t}, true
+			}
+		}
+
+	case mediaFeatureLength, mediaFeatureResolution:
+		number := ""
+		unit := ""
+		if t.Kind == css_lexer.TDimension {
+			number = t.DimensionValue()
+			unit = t.DimensionUnit()
+		} else if t.Kind == css_lexer.TNumber && t.Text == "0" && rangeMediaFeatures[name] == mediaFeatureLength {
+			// Unitless zero is the only valid unitless length
+			number = "0"
+			unit = "px"
+		} else {
+			break
+		}
+		if n, err := strconv.ParseFloat(number, 64); err == nil {
+			// Round to avoid floating-point error (e.g. "400.00100000000003")
+			n = math.Round((n+float64(direction)*0.001)*1e6) / 1e6
+			text := strconv.FormatFloat(n, 'f', -1, 64)
+			t.Kind = css_lexer.TDimension
+			t.Text = text + unit
+			t.UnitOffset = uint16(len(text))
+			return []css_ast.Token{t}, true
+		}
+	}
+
+	return nil, false
+}
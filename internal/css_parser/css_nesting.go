@@ -9,20 +9,39 @@ import (
 	"github.com/evanw/esbuild/internal/logger"
 )
 
+// Lower CSS nesting and "@scope" if they're not supported
+func (p *parser) lowerTopLevelRule(rule css_ast.Rule, results []css_ast.Rule) []css_ast.Rule {
+	lowerAtScope := p.atScopeIsPresent && p.options.unsupportedCSSFeatures.Has(compat.AtScope)
+
+	// Any "@scope" rules nested inside of style rules must be moved to the top
+	// level before they can be lowered, which the nesting transform does
+	start := len(results)
+	if p.nestingIsPresent && (lowerAtScope || p.options.unsupportedCSSFeatures.Has(compat.Nesting)) {
+		results = p.lowerNestingInRule(rule, results)
+	} else {
+		results = append(results, rule)
+	}
+
+	if lowerAtScope {
+		results = append(results[:start], p.lowerAtScopeInRules(results[start:])...)
+	}
+	return results
+}
+
+func scope(loc logger.Loc) css_ast.ComplexSelector {
+	return css_ast.ComplexSelector{
+		Selectors: []css_ast.CompoundSelector{{
+			SubclassSelectors: []css_ast.SubclassSelector{{
+				Range: logger.Range{Loc: loc},
+				Data:  &css_ast.SSPseudoClass{Name: "scope"},
+			}},
+		}},
+	}
+}
+
 func (p *parser) lowerNestingInRule(rule css_ast.Rule, results []css_ast.Rule) []css_ast.Rule {
 	switch r := rule.Data.(type) {
 	case *css_ast.RSelector:
-		scope := func(loc logger.Loc) css_ast.ComplexSelector {
-			return css_ast.ComplexSelector{
-				Selectors: []css_ast.CompoundSelector{{
-					SubclassSelectors: []css_ast.SubclassSelector{{
-						Range: logger.Range{Loc: loc},
-						Data:  &css_ast.SSPseudoClass{Name: "scope"},
-					}},
-				}},
-			}
-		}
-
 		parentSelectors := make([]css_ast.ComplexSelector, 0, len(r.Selectors))
 		for i, sel := range r.Selectors {
 			// Top-level "&" should be replaced with ":scope" to avoid recursion.
@@ -84,11 +103,31 @@ func (p *parser) lowerNestingInRule(rule css_ast.Rule, results []css_ast.Rule) [
 			rules = p.lowerNestingInRule(child, rules)
 		}
 		r.Rules = rules
+
+	case *css_ast.RAtScope:
+		// Top-level "&" should be replaced with ":scope" here too (see above)
+		p.substituteAmpersandsInSelectorList(r.Start, scope)
+		p.substituteAmpersandsInSelectorList(r.End, scope)
+		var rules []css_ast.Rule
+		for _, child := range r.Rules {
+			rules = p.lowerNestingInRule(child, rules)
+		}
+		r.Rules = rules
 	}
 
 	return append(results, rule)
 }
 
+func (p *parser) substituteAmpersandsInSelectorList(list []css_ast.ComplexSelector, replacementFn func(logger.Loc) css_ast.ComplexSelector) {
+	for i, sel := range list {
+		substituted := make([]css_ast.CompoundSelector, 0, len(sel.Selectors))
+		for _, x := range sel.Selectors {
+			substituted = p.substituteAmpersandsInCompoundSelector(x, replacementFn, substituted, keepLeadingCombinator)
+		}
+		list[i] = css_ast.ComplexSelector{Selectors: substituted}
+	}
+}
+
 // Lower all children and filter out ones that become empty
 func (p *parser) lowerNestingInRulesAndReturnRemaining(rules []css_ast.Rule, context *lowerNestingContext) []css_ast.Rule {
 	n := 0
@@ -333,6 +372,49 @@ func (p *parser) lowerNestingInRuleWithContext(rule css_ast.Rule, context *lower
 		r.Rules = childContext.loweredRules
 		context.loweredRules = append(context.loweredRules, rule)
 		return css_ast.Rule{}
+
+	case *css_ast.RAtScope:
+		// "div { @scope (&.foo) { color: red } }" => "@scope (div.foo) { color: red }"
+		// "div { @scope { color: red } }" => "@scope (div) { color: red }"
+		if r.Start == nil {
+			r.Start = make([]css_ast.ComplexSelector, len(context.parentSelectors))
+			for i, sel := range context.parentSelectors {
+				r.Start[i] = sel.CloneWithoutLeadingCombinator()
+			}
+		} else if p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) && len(context.parentSelectors) > 1 {
+			// Avoid generating ":is" if it's not supported
+			// "a, b { @scope (&.foo) {} }" => "@scope (a.foo, b.foo) {}"
+			start := make([]css_ast.ComplexSelector, 0, len(r.Start)*len(context.parentSelectors))
+			for _, sel := range r.Start {
+				if sel.IsRelative() {
+					start = append(start, sel)
+					continue
+				}
+				for _, parent := range context.parentSelectors {
+					clone := []css_ast.ComplexSelector{sel.CloneWithoutLeadingCombinator()}
+					p.substituteAmpersandsInSelectorList(clone, p.multipleComplexSelectorsToSingleComplexSelector([]css_ast.ComplexSelector{parent}))
+					start = append(start, clone[0])
+				}
+			}
+			r.Start = start
+		} else {
+			p.substituteAmpersandsInSelectorList(r.Start, p.multipleComplexSelectorsToSingleComplexSelector(context.parentSelectors))
+		}
+
+		// Everywhere else inside "@scope", "&" refers to the scoping root instead
+		// of to the parent style rule. So the body doesn't inherit the parent
+		// selectors and is lowered as if it were at the top level.
+		p.substituteAmpersandsInSelectorList(r.End, scope)
+		var rules []css_ast.Rule
+		for _, child := range r.Rules {
+			rules = p.lowerNestingInRule(child, rules)
+		}
+		r.Rules = rules
+
+		if len(r.Rules) > 0 {
+			context.loweredRules = append(context.loweredRules, rule)
+		}
+		return css_ast.Rule{}
 	}
 
 	return rule
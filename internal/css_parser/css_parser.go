@@ -16,33 +16,35 @@ import (
 // support for parsing https://drafts.csswg.org/css-nesting-1/.
 
 type parser struct {
-	log               logger.Log
-	source            logger.Source
-	tokens            []css_lexer.Token
-	allComments       []logger.Range
-	legalComments     []css_lexer.Comment
-	stack             []css_lexer.T
-	importRecords     []ast.ImportRecord
-	symbols           []ast.Symbol
-	composes          map[ast.Ref]*css_ast.Composes
-	localSymbols      []ast.LocRef
-	localScope        map[string]ast.LocRef
-	globalScope       map[string]ast.LocRef
-	nestingWarnings   map[logger.Loc]struct{}
-	tracker           logger.LineColumnTracker
-	enclosingAtMedia  [][]css_ast.Token
-	layersPreImport   [][]string
-	layersPostImport  [][]string
-	enclosingLayer    []string
-	anonLayerCount    int
-	index             int
-	legalCommentIndex int
-	inSelectorSubtree int
-	prevError         logger.Loc
-	options           Options
-	nestingIsPresent  bool
-	makeLocalSymbols  bool
-	hasSeenAtImport   bool
+	log                   logger.Log
+	source                logger.Source
+	tokens                []css_lexer.Token
+	allComments           []logger.Range
+	legalComments         []css_lexer.Comment
+	stack                 []css_lexer.T
+	importRecords         []ast.ImportRecord
+	symbols               []ast.Symbol
+	composes              map[ast.Ref]*css_ast.Composes
+	localSymbols          []ast.LocRef
+	localScope            map[string]ast.LocRef
+	globalScope           map[string]ast.LocRef
+	localCustomProperties map[string]bool
+	nestingWarnings       map[logger.Loc]struct{}
+	tracker               logger.LineColumnTracker
+	enclosingAtMedia      [][]css_ast.Token
+	layersPreImport       [][]string
+	layersPostImport      [][]string
+	enclosingLayer        []string
+	anonLayerCount        int
+	index                 int
+	legalCommentIndex     int
+	inSelectorSubtree     int
+	prevError             logger.Loc
+	options               Options
+	nestingIsPresent      bool
+	atScopeIsPresent      bool
+	makeLocalSymbols      bool
+	hasSeenAtImport       bool
 }
 
 type Options struct {
@@ -142,6 +144,7 @@ func Parse(log logger.Log, source logger.Source, options Options) css_ast.AST {
 		parseSelectors: true,
 	})
 	p.expect(css_lexer.TEndOfFile)
+	p.bindCustomPropertySymbols(rules)
 	return css_ast.AST{
 		Rules:                rules,
 		CharFreq:             p.computeCharacterFrequency(),
@@ -377,6 +380,7 @@ loop:
 	for {
 		if context.isTopLevel {
 			p.nestingIsPresent = false
+			p.atScopeIsPresent = false
 		}
 
 		// If there are any legal comments immediately before the current token,
@@ -446,9 +450,9 @@ loop:
 				}
 			}
 
-			// Lower CSS nesting if it's not supported (but only at the top level)
-			if p.nestingIsPresent && p.options.unsupportedCSSFeatures.Has(compat.Nesting) && context.isTopLevel {
-				rules = p.lowerNestingInRule(rule, rules)
+			// Lower CSS nesting and "@scope" if they're not supported (but only at the top level)
+			if context.isTopLevel {
+				rules = p.lowerTopLevelRule(rule, rules)
 			} else {
 				rules = append(rules, rule)
 			}
@@ -486,9 +490,9 @@ loop:
 			rule = p.parseQualifiedRule(parseQualifiedRuleOpts{isTopLevel: context.isTopLevel})
 		}
 
-		// Lower CSS nesting if it's not supported (but only at the top level)
-		if p.nestingIsPresent && p.options.unsupportedCSSFeatures.Has(compat.Nesting) && context.isTopLevel {
-			rules = p.lowerNestingInRule(rule, rules)
+		// Lower CSS nesting and "@scope" if they're not supported (but only at the top level)
+		if context.isTopLevel {
+			rules = p.lowerTopLevelRule(rule, rules)
 		} else {
 			rules = append(rules, rule)
 		}
@@ -621,6 +625,11 @@ next:
 				}
 			}
 
+		case *css_ast.RAtScope:
+			if len(r.Rules) == 0 {
+				continue
+			}
+
 		case *css_ast.RKnownAt:
 			if len(r.Rules) == 0 && atKnownRuleCanBeRemovedIfEmpty[r.AtToken] {
 				continue
@@ -1106,6 +1115,10 @@ var specialAtRules = map[string]atRuleKind{
 	// Anchor Positioning
 	// Reference: https://drafts.csswg.org/css-anchor-position-1/#at-ruledef-position-try
 	"position-try": atRuleDeclarations,
+
+	// Documentation: https://developer.mozilla.org/en-US/docs/Web/CSS/@property
+	// Reference: https://drafts.css-houdini.org/css-properties-values-api/#at-property-rule
+	"property": atRuleDeclarations,
 }
 
 var atKnownRuleCanBeRemovedIfEmpty = map[string]bool{
@@ -1246,6 +1259,8 @@ abortRuleParser:
 						}
 					}
 
+					conditions.Media = p.lowerMediaQueryRanges(conditions.Media)
+
 					// Remove leading and trailing whitespace
 					if len(conditions.Layers) > 0 {
 						conditions.Layers[0].Whitespace &= ^(css_ast.WhitespaceBefore | css_ast.WhitespaceAfter)
@@ -1523,6 +1538,31 @@ abortRuleParser:
 			p.unexpected()
 		}
 
+	case "scope":
+		// Reference: https://drafts.csswg.org/css-cascade-6/#scoped-styles
+		if rule, ok := p.parseAtScope(atRange.Loc); ok {
+			return rule
+		}
+
+		// Otherwise there's some kind of syntax error, so parse it as a generic rule
+		p.index = preludeStart
+
+	case "custom-media":
+		if rule, ok := p.parseAtCustomMedia(atRange, context); ok {
+			return rule
+		}
+
+		// Otherwise there's some kind of syntax error, so parse it as a generic rule
+		p.index = preludeStart
+
+	case "custom-selector":
+		if rule, ok := p.parseAtCustomSelector(atRange, context); ok {
+			return rule
+		}
+
+		// Otherwise there's some kind of syntax error, so parse it as a generic rule
+		p.index = preludeStart
+
 	default:
 		if kind == atRuleUnknown && lowerAtToken == "namespace" {
 			// CSS namespaces are a weird feature that appears to only really be
@@ -1605,6 +1645,11 @@ prelude:
 			}
 		}
 
+		// Handle local names and validation for "@property"
+		if lowerAtToken == "property" {
+			p.processAtProperty(atRange, prelude, rules)
+		}
+
 		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RKnownAt{AtToken: atToken, Prelude: prelude, Rules: rules, CloseBraceLoc: closeBraceLoc}}
 
 	case atRuleInheritContext:
@@ -1616,6 +1661,7 @@ prelude:
 		// Push the "@media" conditions
 		isAtMedia := lowerAtToken == "media"
 		if isAtMedia {
+			prelude = p.lowerMediaQueryRanges(prelude)
 			p.enclosingAtMedia = append(p.enclosingAtMedia, prelude)
 		}
 
@@ -1673,6 +1719,69 @@ prelude:
 	}
 }
 
+func (p *parser) parseAtScope(loc logger.Loc) (css_ast.Rule, bool) {
+	// Save and restore the local symbol state in case there are any bare
+	// ":global" or ":local" annotations. The effect of these should be scoped
+	// to within the selector list.
+	local := p.makeLocalSymbols
+	scope := css_ast.RAtScope{}
+
+	// Parse the optional "(<scope-start>)"
+	p.eat(css_lexer.TWhitespace)
+	if p.peek(css_lexer.TOpenParen) {
+		matchingLoc := p.current().Range.Loc
+		p.advance()
+		p.eat(css_lexer.TWhitespace)
+		list, ok := p.parseSelectorList(parseSelectorOpts{
+			stopOnCloseParen:     true,
+			noLeadingCombinator:  true,
+			keepLeadingAmpersand: true,
+		})
+		p.makeLocalSymbols = local
+		if !ok || !p.expectWithMatchingLoc(css_lexer.TCloseParen, matchingLoc) {
+			return css_ast.Rule{}, false
+		}
+		scope.Start = list
+		p.eat(css_lexer.TWhitespace)
+	}
+
+	// Parse the optional "to (<scope-end>)"
+	if p.peek(css_lexer.TIdent) && strings.EqualFold(p.decoded(), "to") {
+		p.advance()
+		p.eat(css_lexer.TWhitespace)
+		matchingLoc := p.current().Range.Loc
+		if !p.expect(css_lexer.TOpenParen) {
+			return css_ast.Rule{}, false
+		}
+		p.eat(css_lexer.TWhitespace)
+		list, ok := p.parseSelectorList(parseSelectorOpts{
+			stopOnCloseParen:     true,
+			keepLeadingAmpersand: true,
+		})
+		p.makeLocalSymbols = local
+		if !ok || !p.expectWithMatchingLoc(css_lexer.TCloseParen, matchingLoc) {
+			return css_ast.Rule{}, false
+		}
+		scope.End = list
+		p.eat(css_lexer.TWhitespace)
+	}
+
+	// The block is parsed like the body of a style rule. Scoped style rules are
+	// allowed to use relative selectors, and declarations apply to the scoping
+	// root as if they were in a ":where(:scope)" rule.
+	matchingLoc := p.current().Range.Loc
+	if !p.eat(css_lexer.TOpenBrace) {
+		return css_ast.Rule{}, false
+	}
+	p.atScopeIsPresent = true
+	scope.Rules = p.parseListOfDeclarations(listOfDeclarationsOpts{})
+	closeBraceLoc := p.current().Range.Loc
+	if p.expectWithMatchingLoc(css_lexer.TCloseBrace, matchingLoc) {
+		scope.CloseBraceLoc = closeBraceLoc
+	}
+	return css_ast.Rule{Loc: loc, Data: &scope}, true
+}
+
 func (p *parser) expectValidLayerNameIdent() (string, bool) {
 	r := p.current().Range
 	text := p.decoded()
@@ -1697,6 +1806,7 @@ type convertTokensOpts struct {
 	allowImports         bool
 	verbatimWhitespace   bool
 	isInsideCalcFunction bool
+	isInsideImageSet     bool
 }
 
 func (p *parser) convertTokensHelper(tokens []css_lexer.Token, close css_lexer.T, opts convertTokensOpts) ([]css_ast.Token, []css_lexer.Token) {
@@ -1809,6 +1919,25 @@ loop:
 				}
 			}
 
+		case css_lexer.TString:
+			// Each option in "image-set()" may be a bare string instead of "url()".
+			// Treat it like a URL token so the image is bundled like other URLs.
+			if opts.isInsideImageSet && (len(result) == 0 || result[len(result)-1].Kind == css_lexer.TComma) {
+				token.Kind = css_lexer.TURL
+				token.PayloadIndex = uint32(len(p.importRecords))
+				var flags ast.ImportRecordFlags
+				if !opts.allowImports {
+					flags |= ast.IsUnused
+				}
+				p.importRecords = append(p.importRecords, ast.ImportRecord{
+					Kind:  ast.ImportURL,
+					Path:  logger.Path{Text: token.Text},
+					Range: t.Range,
+					Flags: flags,
+				})
+				token.Text = ""
+			}
+
 		case css_lexer.TURL:
 			token.PayloadIndex = uint32(len(p.importRecords))
 			var flags ast.ImportRecordFlags
@@ -1834,6 +1963,7 @@ loop:
 			if strings.EqualFold(token.Text, "calc") {
 				nestedOpts.isInsideCalcFunction = true
 			}
+			nestedOpts.isInsideImageSet = strings.EqualFold(token.Text, "image-set") || strings.EqualFold(token.Text, "-webkit-image-set")
 			nested, tokens = p.convertTokensHelper(tokens, css_lexer.TCloseParen, nestedOpts)
 			token.Children = &nested
 
@@ -15,8 +15,10 @@ type parseSelectorOpts struct {
 	pseudoClassKind        css_ast.PseudoClassKind
 	isDeclarationContext   bool
 	stopOnCloseParen       bool
+	stopOnSemicolon        bool
 	onlyOneComplexSelector bool
 	noLeadingCombinator    bool
+	keepLeadingAmpersand   bool
 }
 
 func (p *parser) parseSelectorList(opts parseSelectorOpts) (list []css_ast.ComplexSelector, ok bool) {
@@ -69,7 +71,8 @@ func (p *parser) parseSelectorList(opts parseSelectorOpts) (list []css_ast.Compl
 		}
 	}
 
-	if p.options.minifySyntax {
+	// Note: "&" has a different meaning in "@scope" preludes, so it can't be removed there
+	if p.options.minifySyntax && !opts.keepLeadingAmpersand {
 		for i := 1; i < len(list); i++ {
 			if analyzeLeadingAmpersand(list[i], opts.isDeclarationContext) != cannotRemoveLeadingAmpersand {
 				list[i].Selectors = list[i].Selectors[1:]
@@ -286,6 +289,8 @@ func (p *parser) parseComplexSelector(opts parseComplexSelectorOpts) (result css
 	stop := css_lexer.TOpenBrace
 	if opts.stopOnCloseParen {
 		stop = css_lexer.TCloseParen
+	} else if opts.stopOnSemicolon {
+		stop = css_lexer.TSemicolon
 	}
 	for {
 		p.eat(css_lexer.TWhitespace)
@@ -727,6 +727,49 @@ func TestLowerColor(t *testing.T) {
 	expectPrintedLowerMangle(t, "a { color: hwb(0.75turn 20% 40% / 0.75) }", "a {\n  color: rgba(102, 51, 153, .75);\n}\n", "")
 }
 
+func TestLightDark(t *testing.T) {
+	lightDark := compat.LightDark
+	expectPrinted(t, "a { color: light-dark(red, blue) }", "a {\n  color: light-dark(red, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color: light-dark(red, blue) }",
+		"a {\n  color: var(--esbuild-light, red) var(--esbuild-dark, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color: LIGHT-DARK(red, blue) }",
+		"a {\n  color: var(--esbuild-light, red) var(--esbuild-dark, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { border: 1px solid light-dark(red, blue) }",
+		"a {\n  border: 1px solid var(--esbuild-light, red) var(--esbuild-dark, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { background: linear-gradient(light-dark(red, blue), green) }",
+		"a {\n  background: linear-gradient(var(--esbuild-light, red) var(--esbuild-dark, blue), green);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { --x: light-dark(red, blue) }",
+		"a {\n  --x: var(--esbuild-light, red) var(--esbuild-dark, blue) ;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color: light-dark(red) }", "a {\n  color: light-dark(red);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color: light-dark(red, green, blue) }", "a {\n  color: light-dark(red, green, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark|compat.HexRGBA, "a { color: light-dark(#f008, #00f8) }",
+		"a {\n  color: var(--esbuild-light, rgba(255, 0, 0, .533)) var(--esbuild-dark, rgba(0, 0, 255, .533));\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark|compat.ColorFunctions, "a { color: light-dark(black, color(display-p3 0 1 0)) }",
+		"a {\n  color: var(--esbuild-light, black) var(--esbuild-dark, #01f74f);\n}\n", "")
+
+	expectPrinted(t, "a { color-scheme: light dark }", "a {\n  color-scheme: light dark;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: light }",
+		"a {\n  color-scheme: light;\n  --esbuild-light: initial;\n  --esbuild-dark: ;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: normal }",
+		"a {\n  color-scheme: normal;\n  --esbuild-light: initial;\n  --esbuild-dark: ;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: only dark }",
+		"a {\n  color-scheme: only dark;\n  --esbuild-light: ;\n  --esbuild-dark: initial;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: inherit }", "a {\n  color-scheme: inherit;\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: var(--scheme) }", "a {\n  color-scheme: var(--scheme);\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "a { color-scheme: light dark !important; color: red }",
+		"a {\n  color-scheme: light dark !important;\n  --esbuild-light: initial !important;\n  --esbuild-dark: !important;\n  color: red;\n"+
+			"  @media (prefers-color-scheme: dark) {\n    --esbuild-light: !important;\n    --esbuild-dark: initial !important;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, lightDark, "@page { color-scheme: light dark }",
+		"@page {\n  color-scheme: light dark;\n  --esbuild-light: initial;\n  --esbuild-dark: ;\n}\n", "")
+	expectPrintedLower(t, ":root { color-scheme: dark light }",
+		":root {\n  color-scheme: dark light;\n  --esbuild-light: initial;\n  --esbuild-dark: ;\n}\n"+
+			"@media (prefers-color-scheme: dark) {\n  :root {\n    --esbuild-light: ;\n    --esbuild-dark: initial;\n  }\n}\n", "")
+	expectPrintedLowerMinify(t, ":root { color-scheme: light dark } a { color: light-dark(red, blue) }",
+		":root{color-scheme:light dark;--esbuild-light:initial;--esbuild-dark: }"+
+			"@media (prefers-color-scheme:dark){:root{--esbuild-light: ;--esbuild-dark:initial}}"+
+			"a{color:var(--esbuild-light,red) var(--esbuild-dark,blue)}", "")
+}
+
 func TestBackground(t *testing.T) {
 	expectPrinted(t, "a { background: #11223344 }", "a {\n  background: #11223344;\n}\n", "")
 	expectPrintedMangle(t, "a { background: #11223344 }", "a {\n  background: #1234;\n}\n", "")
@@ -1776,6 +1819,175 @@ func TestAtLayer(t *testing.T) {
 		"<stdin>: WARNING: \"@charset\" must be the first rule in the file\n<stdin>: NOTE: This rule cannot come before a \"@charset\" rule\n")
 }
 
+func TestAtScope(t *testing.T) {
+	expectPrinted(t, "@scope (.a) to (.b) { img { color: red } }", "@scope (.a) to (.b) {\n  img {\n    color: red;\n  }\n}\n", "")
+	expectPrinted(t, "@scope { :scope { color: red } }", "@scope {\n  :scope {\n    color: red;\n  }\n}\n", "")
+	expectPrinted(t, "@scope to (.b) { color: red }", "@scope to (.b) {\n  color: red;\n}\n", "")
+	expectPrinted(t, "@scope (.a, .b > .c) { color: red; .d { color: blue } }", "@scope (.a, .b > .c) {\n  color: red;\n  .d {\n    color: blue;\n  }\n}\n", "")
+	expectPrinted(t, "@scope (&.a) { & > b { color: red } }", "@scope (&.a) {\n  & > b {\n    color: red;\n  }\n}\n", "")
+	expectPrinted(t, "@scope (.a) { @media screen { b { color: red } } }", "@scope (.a) {\n  @media screen {\n    b {\n      color: red;\n    }\n  }\n}\n", "")
+	expectPrinted(t, "@scope (.a) {}", "@scope (.a) {\n}\n", "")
+	expectPrinted(t, "@scope (.a) to .b { color: red }", "@scope (.a) to .b {\n  color: red {\n  }\n}\n",
+		"<stdin>: WARNING: Expected \"(\" but found \".\"\n<stdin>: WARNING: Expected identifier but found whitespace\n<stdin>: WARNING: Unexpected \"}\"\n")
+
+	expectPrintedMinify(t, "@scope (.a) to (.b) { img { color: red } }", "@scope(.a)to (.b){img{color:red}}", "")
+	expectPrintedMinify(t, "@scope to (.b) { color: red }", "@scope to (.b){color:red}", "")
+	expectPrintedMinify(t, "@scope (&.a) to (& > b) { c { color: red } }", "@scope(&.a)to (&>b){c{color:red}}", "")
+	expectPrintedMangle(t, "@scope (.a) {}", "", "")
+	expectPrintedMangle(t, "@media screen { @scope (.a) { color: red } @scope (.a) { color: red } }",
+		"@media screen {\n  @scope (.a) {\n    color: red;\n  }\n}\n", "")
+
+	// Scope selectors are renamed when using local CSS
+	expectPrintedLocal(t, "@scope (:global(.a)) to (:local(.b)) { .c:global(.d) { color: red } }",
+		"@scope (.a) to (.b) {\n  .c.d {\n    color: red;\n  }\n}\n", "")
+
+	// "&" refers to the parent style rule in the prelude but to the scoping root in the body
+	expectPrintedLowerUnsupported(t, compat.Nesting, ".card { @scope (&.x) to (img) { color: red } }",
+		"@scope (.card.x) to (img) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting, ".card { @scope { :scope { color: red } } }",
+		"@scope (.card) {\n  :scope {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting, ".card { @scope { & img { color: red } } }",
+		"@scope (.card) {\n  :scope img {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting, ".card, .box { @scope (& > .x) { .y { color: red } } }",
+		"@scope (:is(.card, .box) > .x) {\n  .y {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting|compat.IsPseudoClass, ".card, .box { @scope (& > .x) { .y { color: red } } }",
+		"@scope (.card > .x, .box > .x) {\n  .y {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting, "@scope (.a) { .b { .c { color: red } } }",
+		"@scope (.a) {\n  .b .c {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.Nesting, "@scope (.a) { .b { & + & { color: red } } }",
+		"@scope (.a) {\n  .b + .b {\n    color: red;\n  }\n}\n", "")
+
+	// Lowering "@scope" turns the scoping root and limit into regular selectors
+	atScope := compat.AtScope
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) { color: red }", ":where(.a) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) to (.b) { img { color: red } }",
+		":where(.a) img:where(:not(:where(.a) .b, :where(.a) .b *)) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) to (.b) { img::before { color: red } }",
+		":where(.a) img:where(:not(:where(.a) .b, :where(.a) .b *))::before {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) to (:scope > .b) { :scope { color: red } }",
+		":where(.a):where(:not(:where(.a) > .b, :where(.a) > .b *)) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a, .b) { > .c { color: red } }", ":where(.a, .b) > .c {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) { .b, :scope.c, :is(:scope .d) { color: red } }",
+		":where(.a) .b,\n:where(.a).c,\n:is(:where(.a) .d) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope { .a { color: red } }", "@scope {\n  .a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) { @media screen { color: red; .b { color: blue } } }",
+		"@media screen {\n  :where(.a) {\n    color: red;\n  }\n  :where(.a) .b {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) { @scope (.b) to (.c) { .d { color: red } } }",
+		":where(.a) :where(.b) .d:where(:not(:where(.b) .c, :where(.b) .c *)) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@scope (.a) { color: red; .b { color: blue } color: green }",
+		":where(.a) {\n  color: red;\n}\n:where(.a) .b {\n  color: blue;\n}\n:where(.a) {\n  color: green;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, ".card { @scope (&.x) to (img) { color: red; .y { color: blue } } }",
+		":where(.card.x):where(:not(:where(.card.x) img, :where(.card.x) img *)) {\n  color: red;\n}\n"+
+			":where(.card.x) .y:where(:not(:where(.card.x) img, :where(.card.x) img *)) {\n  color: blue;\n}\n", "")
+	expectPrintedLowerUnsupported(t, atScope, "@layer x { @scope (.a) { .b { color: red } } }",
+		"@layer x {\n  :where(.a) .b {\n    color: red;\n  }\n}\n", "")
+
+	// Avoid generating ":where" and ":not" with complex selectors if they're not supported
+	everything := compat.AtScope | compat.IsPseudoClass
+	expectPrintedLowerUnsupported(t, everything, "@scope (.a, .b) { .c { color: red } }", ".a .c,\n.b .c {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".a, .b { @scope (&.x) { .c { color: red } } }", ".a.x .c,\n.b.x .c {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "@scope (.a) to (.b) { .c { color: red } }", ".a .c {\n  color: red;\n}\n",
+		"<stdin>: WARNING: Transforming the scoping limit of \"@scope\" is not supported in the configured target environment\n"+
+			"NOTE: The scoping limit will be ignored because the configured target environment does not support complex selectors inside \":not()\".\n")
+}
+
+func TestAtCustomMediaAndSelector(t *testing.T) {
+	expectPrinted(t, "@custom-media --narrow (max-width: 30em);", "@custom-media --narrow (max-width: 30em);\n", "")
+	expectPrinted(t, "@custom-media --screens screen , print ;", "@custom-media --screens screen, print;\n", "")
+	expectPrinted(t, "@custom-selector :--heading h1, h2, h3;", "@custom-selector :--heading h1, h2, h3;\n", "")
+	expectPrinted(t, "@custom-selector :--x .a > .b:hover;", "@custom-selector :--x .a > .b:hover;\n", "")
+	expectPrinted(t, "@media (--narrow) { :--heading { color: red } }", "@media (--narrow) {\n  :--heading {\n    color: red;\n  }\n}\n", "")
+
+	expectPrintedMinify(t, "@custom-media --narrow (max-width: 30em);", "@custom-media --narrow (max-width: 30em);", "")
+	expectPrintedMinify(t, "@custom-selector :--heading h1, h2, h3;", "@custom-selector :--heading h1,h2,h3;", "")
+	expectPrintedMangle(t, "@custom-selector :--heading h1, h2, h1;", "@custom-selector :--heading h1, h2;\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@custom-media --narrow (width < 30em);", "@custom-media --narrow (max-width: 29.999em);\n", "")
+
+	expectPrinted(t, "@custom-media narrow (max-width: 30em);", "@custom-media narrow (max-width: 30em);\n",
+		"<stdin>: WARNING: Expected a custom media query name starting with \"--\"\n")
+	expectPrinted(t, "@custom-media --narrow;", "@custom-media --narrow;\n",
+		"<stdin>: WARNING: Expected a media query after \"--narrow\"\n")
+	expectPrinted(t, "a { @custom-media --narrow (max-width: 30em); }", "a {\n  @custom-media --narrow (max-width: 30em);\n}\n",
+		"<stdin>: WARNING: \"@custom-media\" is only valid at the top level\n")
+	expectPrinted(t, "@custom-selector --heading h1;", "@custom-selector --heading h1;\n",
+		"<stdin>: WARNING: Expected a custom selector name starting with \":--\"\n")
+	expectPrinted(t, "@custom-selector :--x > a;", "@custom-selector :--x > a;\n",
+		"<stdin>: WARNING: Unexpected \">\"\n")
+	expectPrinted(t, "@media screen { @custom-selector :--x a; }", "@media screen {\n  @custom-selector :--x a;\n}\n",
+		"<stdin>: WARNING: \"@custom-selector\" is only valid at the top level\n")
+}
+
+func TestAtProperty(t *testing.T) {
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false; initial-value: 0px }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n  initial-value: 0px;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"*\"; inherits: true }", "@property --x {\n  syntax: \"*\";\n  inherits: true;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<length> | auto\"; inherits: false; initial-value: auto }",
+		"@property --x {\n  syntax: \"<length> | auto\";\n  inherits: false;\n  initial-value: auto;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<length>+\"; inherits: false; initial-value: 1px 2px }",
+		"@property --x {\n  syntax: \"<length>+\";\n  inherits: false;\n  initial-value: 1px 2px;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<length>#\"; inherits: false; initial-value: 1px, 2px }",
+		"@property --x {\n  syntax: \"<length>#\";\n  inherits: false;\n  initial-value: 1px, 2px;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false; initial-value: calc(1px + 2px) }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n  initial-value: calc(1px + 2px);\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<color>\"; inherits: false; initial-value: rgb(255, 0, 0) }",
+		"@property --x {\n  syntax: \"<color>\";\n  inherits: false;\n  initial-value: rgb(255, 0, 0);\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<custom-ident>\"; inherits: false; initial-value: foo }",
+		"@property --x {\n  syntax: \"<custom-ident>\";\n  inherits: false;\n  initial-value: foo;\n}\n", "")
+	expectPrinted(t, "@property --x { syntax: \"<image>\"; inherits: false; initial-value: linear-gradient(red, blue) }",
+		"@property --x {\n  syntax: \"<image>\";\n  inherits: false;\n  initial-value: linear-gradient(red, blue);\n}\n", "")
+
+	// Invalid registrations are kept but generate warnings
+	expectPrinted(t, "@property x { syntax: \"*\"; inherits: true }", "@property x {\n  syntax: \"*\";\n  inherits: true;\n}\n",
+		"<stdin>: WARNING: Expected a custom property name after \"@property\"\n")
+	expectPrinted(t, "@property --x, --y { syntax: \"*\"; inherits: true }", "@property --x, --y {\n  syntax: \"*\";\n  inherits: true;\n}\n",
+		"<stdin>: WARNING: Expected a custom property name after \"@property\"\n")
+	expectPrinted(t, "@property --x { inherits: true }", "@property --x {\n  inherits: true;\n}\n",
+		"<stdin>: WARNING: \"@property\" rules must have a \"syntax\" descriptor\n")
+	expectPrinted(t, "@property --x { syntax: \"*\" }", "@property --x {\n  syntax: \"*\";\n}\n",
+		"<stdin>: WARNING: \"@property\" rules must have an \"inherits\" descriptor\n")
+	expectPrinted(t, "@property --x { syntax: <length>; inherits: false; initial-value: 0px }",
+		"@property --x {\n  syntax: <length>;\n  inherits: false;\n  initial-value: 0px;\n}\n",
+		"<stdin>: WARNING: The \"syntax\" descriptor must be a string\n")
+	expectPrinted(t, "@property --x { syntax: \"<length> | <foo>\"; inherits: false; initial-value: 0px }",
+		"@property --x {\n  syntax: \"<length> | <foo>\";\n  inherits: false;\n  initial-value: 0px;\n}\n",
+		"<stdin>: WARNING: \"<length> | <foo>\" is not a valid syntax for a custom property\n")
+	expectPrinted(t, "@property --x { syntax: \"<transform-list>+\"; inherits: false; initial-value: none }",
+		"@property --x {\n  syntax: \"<transform-list>+\";\n  inherits: false;\n  initial-value: none;\n}\n",
+		"<stdin>: WARNING: \"<transform-list>+\" is not a valid syntax for a custom property\n")
+	expectPrinted(t, "@property --x { syntax: \"inherit\"; inherits: false; initial-value: inherit }",
+		"@property --x {\n  syntax: \"inherit\";\n  inherits: false;\n  initial-value: inherit;\n}\n",
+		"<stdin>: WARNING: \"inherit\" is not a valid syntax for a custom property\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: maybe; initial-value: 0px }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: maybe;\n  initial-value: 0px;\n}\n",
+		"<stdin>: WARNING: The \"inherits\" descriptor must be either \"true\" or \"false\"\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false }", "@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n}\n",
+		"<stdin>: WARNING: \"@property\" rules must have an \"initial-value\" descriptor unless the syntax is \"*\"\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false; initial-value: 1em }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n  initial-value: 1em;\n}\n",
+		"<stdin>: WARNING: The \"initial-value\" descriptor must be computationally independent\n"+
+			"NOTE: This means it can't use relative units such as \"em\" or refer to other properties using \"var()\".\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false; initial-value: var(--y) }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n  initial-value: var(--y);\n}\n",
+		"<stdin>: WARNING: The \"initial-value\" descriptor must be computationally independent\n"+
+			"NOTE: This means it can't use relative units such as \"em\" or refer to other properties using \"var()\".\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>\"; inherits: false; initial-value: red }",
+		"@property --x {\n  syntax: \"<length>\";\n  inherits: false;\n  initial-value: red;\n}\n",
+		"<stdin>: WARNING: The \"initial-value\" descriptor doesn't match the syntax \"<length>\"\n")
+	expectPrinted(t, "@property --x { syntax: \"<length>#\"; inherits: false; initial-value: 1px 2px }",
+		"@property --x {\n  syntax: \"<length>#\";\n  inherits: false;\n  initial-value: 1px 2px;\n}\n",
+		"<stdin>: WARNING: The \"initial-value\" descriptor doesn't match the syntax \"<length>#\"\n")
+	expectPrinted(t, "@property --x { syntax: \"<integer>\"; inherits: false; initial-value: 1.5 }",
+		"@property --x {\n  syntax: \"<integer>\";\n  inherits: false;\n  initial-value: 1.5;\n}\n",
+		"<stdin>: WARNING: The \"initial-value\" descriptor doesn't match the syntax \"<integer>\"\n")
+
+	expectPrintedMangleMinify(t, "@property --x { syntax: \" <length> | auto \"; inherits: FALSE; initial-value: 0px }",
+		"@property --x{syntax:\"<length>|auto\";inherits:false;initial-value:0px}", "")
+	expectPrintedMangleMinify(t, "@property --x { syntax: \"<color>\"; inherits: true; initial-value: #ff0000 }",
+		"@property --x{syntax:\"<color>\";inherits:true;initial-value:red}", "")
+	expectPrintedMangleMinify(t, "@property --x { syntax: \"<length-percentage>+\"; inherits: true; initial-value: 0px }",
+		"@property --x{syntax:\"<length-percentage>+\";inherits:true;initial-value:0px}", "")
+}
+
 func TestEmptyRule(t *testing.T) {
 	expectPrinted(t, "div {}", "div {\n}\n", "")
 	expectPrinted(t, "@media screen {}", "@media screen {\n}\n", "")
@@ -2455,6 +2667,57 @@ func TestMangleAtMedia(t *testing.T) {
 	expectPrintedMangle(t, "@media screen { a { color: red } } @media screen { b { color: red } }", "@media screen {\n  a {\n    color: red;\n  }\n}\n@media screen {\n  b {\n    color: red;\n  }\n}\n", "")
 }
 
+func TestMediaRange(t *testing.T) {
+	expectPrinted(t, "@media (400px <= width < 800px) { a { color: red } }", "@media (400px <= width < 800px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width >= 400px) { a { color: red } }", "@media (min-width: 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width > 400px) { a { color: red } }", "@media (min-width: 400.001px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width <= 400px) { a { color: red } }", "@media (max-width: 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width < 400px) { a { color: red } }", "@media (max-width: 399.999px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width = 400px) { a { color: red } }", "@media (width: 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px <= width) { a { color: red } }", "@media (min-width: 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px < width) { a { color: red } }", "@media (min-width: 400.001px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px >= width) { a { color: red } }", "@media (max-width: 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px <= width < 800px) { a { color: red } }", "@media (min-width: 400px) and (max-width: 799.999px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (800px > WIDTH >= 400px) { a { color: red } }", "@media (min-width: 400px) and (max-width: 799.999px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media screen and (0 < width < 10em) { a { color: red } }", "@media screen and (min-width: 0.001px) and (max-width: 9.999em) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (2 < color < 8) { a { color: red } }", "@media (min-color: 3) and (max-color: 7) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (resolution > 2dppx) { a { color: red } }", "@media (min-resolution: 2.001dppx) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (aspect-ratio >= 16/9) { a { color: red } }", "@media (min-aspect-ratio: 16/9) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width >= 400px), print and (width < 800px) { a { color: red } }", "@media (min-width: 400px), print and (max-width: 799.999px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media not (400px <= width <= 800px) { a { color: red } }", "@media not ((min-width: 400px) and (max-width: 800px)) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px <= width <= 800px) or (print) { a { color: red } }", "@media ((min-width: 400px) and (max-width: 800px)) or (print) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media ((width > 400px) or (height > 400px)) { a { color: red } }", "@media ((min-width: 400.001px) or (min-height: 400.001px)) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@import \"foo.css\" screen and (width >= 400px);", "@import \"foo.css\" screen and (min-width: 400px);\n", "")
+
+	// These can't be converted
+	rangeWarning := "<stdin>: WARNING: Transforming this media query range is not supported in the configured target environment\n"
+	ratioNote := func(name string) string {
+		return fmt.Sprintf("NOTE: Exclusive bounds for %q can't be converted to \"min-%s\" or \"max-%s\" because ratios can't be adjusted by a small amount.\n", name, name, name)
+	}
+	valueNote := "NOTE: Exclusive bounds for \"width\" can only be converted to \"min-width\" or \"max-width\" if they are a single number or dimension.\n"
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (aspect-ratio > 16/9) { a { color: red } }", "@media (aspect-ratio > 16/9) {\n  a {\n    color: red;\n  }\n}\n",
+		rangeWarning+ratioNote("aspect-ratio"))
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (4/3 < device-aspect-ratio <= 16/9) { a { color: red } }", "@media (4/3 < device-aspect-ratio <= 16/9) {\n  a {\n    color: red;\n  }\n}\n",
+		rangeWarning+ratioNote("device-aspect-ratio"))
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width > calc(100px + 1em)) { a { color: red } }", "@media (width > calc(100px + 1em)) {\n  a {\n    color: red;\n  }\n}\n",
+		rangeWarning+valueNote)
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width >= calc(100px + 1em)) { a { color: red } }", "@media (min-width: calc(100px + 1em)) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (foo > 400px) { a { color: red } }", "@media (foo > 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (400px < width > 800px) { a { color: red } }", "@media (400px < width > 800px) {\n  a {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.MediaRange, "@media (width < = 400px) { a { color: red } }", "@media (width < = 400px) {\n  a {\n    color: red;\n  }\n}\n", "")
+
+	// Check minification
+	expectPrintedLowerMinifyUnsupported := func(contents string, expected string) {
+		t.Helper()
+		expectPrintedCommon(t, contents+" [lower, minify]", contents, expected, "", config.LoaderCSS, config.Options{
+			UnsupportedCSSFeatures: compat.MediaRange,
+			MinifyWhitespace:       true,
+		})
+	}
+	expectPrintedLowerMinifyUnsupported("@media (400px <= width < 800px) { a { color: red } }", "@media (min-width:400px) and (max-width:799.999px){a{color:red}}")
+	expectPrintedLowerMinifyUnsupported("@media not (400px<=width<=800px) { a { color: red } }", "@media not ((min-width:400px) and (max-width:800px)){a{color:red}}")
+}
+
 func TestFontWeight(t *testing.T) {
 	expectPrintedMangle(t, "a { font-weight: normal }", "a {\n  font-weight: 400;\n}\n", "")
 	expectPrintedMangle(t, "a { font-weight: bold }", "a {\n  font-weight: 700;\n}\n", "")
@@ -2655,6 +2918,51 @@ func TestPrefixInsertion(t *testing.T) {
 		"a {\n  before: value;\n  -ms-text-size-adjust: 2;\n  -webkit-text-size-adjust: 3;\n  text-size-adjust: 3;\n  after: value;\n}\n", "")
 }
 
+func TestImageSet(t *testing.T) {
+	expectPrinted(t, "a { background: image-set(\"a.png\" 1x, \"b.png\" 2x) }", "a {\n  background: image-set(url(a.png) 1x, url(b.png) 2x);\n}\n", "")
+	expectPrinted(t, "a { background: -webkit-image-set(\"a.png\" 1x) }", "a {\n  background: -webkit-image-set(url(a.png) 1x);\n}\n", "")
+	expectPrinted(t, "a { background: image-set(\"a.png\" type(\"image/png\")) }", "a {\n  background: image-set(url(a.png) type(\"image/png\"));\n}\n", "")
+	expectPrinted(t, "a { background: image-set(linear-gradient(red, blue) 1x) }", "a {\n  background: image-set(linear-gradient(red, blue) 1x);\n}\n", "")
+	expectPrinted(t, "a { background: \"a.png\" }", "a {\n  background: \"a.png\";\n}\n", "")
+
+	expectPrintedWithAllPrefixes(t, "a { background-image: image-set(\"a.png\" 1x, \"b.png\" 2x) }",
+		"a {\n  background-image: -webkit-image-set(url(a.png) 1x, url(b.png) 2x);\n  background-image: image-set(url(a.png) 1x, url(b.png) 2x);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: image-set(\"a.png\") no-repeat }",
+		"a {\n  background: -webkit-image-set(url(a.png) 1x) no-repeat;\n  background: image-set(url(a.png)) no-repeat;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { content: image-set(\"a.png\" 1dppx, \"b.png\" 192dpi, \"c.png\" 37.8dpcm) }",
+		"a {\n  content: -webkit-image-set(url(a.png) 1x, url(b.png) 2x, url(c.png) 1.000125x);\n  content: image-set(url(a.png) 1dppx, url(b.png) 192dpi, url(c.png) 37.8dpcm);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { list-style-image: image-set(\"a.avif\" type(\"image/avif\"), \"a.png\" type(\"image/png\")) }",
+		"a {\n  list-style-image: -webkit-image-set(url(a.png) 1x);\n  list-style-image: image-set(url(a.avif) type(\"image/avif\"), url(a.png) type(\"image/png\"));\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { cursor: image-set(\"a.avif\" type(\"image/avif\")), auto }",
+		"a {\n  cursor: image-set(url(a.avif) type(\"image/avif\")), auto;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { border-image-source: image-set(var(--a)) }",
+		"a {\n  border-image-source: image-set(var(--a));\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: url(a.png) }", "a {\n  background: url(a.png);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: -webkit-image-set(url(a.png) 1x); background: image-set(url(a.png) 1x) }",
+		"a {\n  background: -webkit-image-set(url(a.png) 1x);\n  background: image-set(url(a.png) 1x);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background-image: image-set(\"a.png\" 1x) !important }",
+		"a {\n  background-image: -webkit-image-set(url(a.png) 1x) !important;\n  background-image: image-set(url(a.png) 1x) !important;\n}\n", "")
+
+	// Safari 14 to 16 support "image-set()" but not "type()" or units other than "x"
+	for _, engine := range []compat.Engine{compat.Safari, compat.IOS} {
+		constraints := map[compat.Engine]compat.Semver{engine: {Parts: []int{16}}}
+		options := config.Options{
+			UnsupportedCSSFeatures: compat.UnsupportedCSSFeatures(constraints),
+			CSSPrefixData:          compat.CSSPrefixData(constraints),
+		}
+		expectPrintedCommon(t, "image-set type "+engine.String(), "a { background: image-set(url(a.png) type(\"image/png\") 1x, \"b.png\" 2dppx) }",
+			"a {\n  background: -webkit-image-set(url(a.png) 1x, url(b.png) 2x);\n  background: image-set(url(a.png) type(\"image/png\") 1x, url(b.png) 2dppx);\n}\n", "", config.LoaderCSS, options)
+		expectPrintedCommon(t, "image-set dpi "+engine.String(), "a { cursor: image-set(\"a.png\" 96dpi), auto }",
+			"a {\n  cursor: -webkit-image-set(url(a.png) 1x), auto;\n  cursor: image-set(url(a.png) 96dpi), auto;\n}\n", "", config.LoaderCSS, options)
+		expectPrintedCommon(t, "image-set x "+engine.String(), "a { background: image-set(\"a.png\" 1x, \"b.png\" 2x) }",
+			"a {\n  background: image-set(url(a.png) 1x, url(b.png) 2x);\n}\n", "", config.LoaderCSS, options)
+	}
+	expectPrintedLowerUnsupported(t, compat.ImageSetOptions, "a { background: image-set(\"a.png\" type(\"image/png\")) }",
+		"a {\n  background: -webkit-image-set(url(a.png) 1x);\n  background: image-set(url(a.png) type(\"image/png\"));\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ImageSetOptions, "a { background: image-set(\"a.avif\" type(\"image/avif\")) }",
+		"a {\n  background: image-set(url(a.avif) type(\"image/avif\"));\n}\n", "")
+}
+
 func TestNthChild(t *testing.T) {
 	for _, nth := range []string{"nth-child", "nth-last-child"} {
 		expectPrinted(t, ":"+nth+"(x) {}", ":"+nth+"(x) {\n}\n", "<stdin>: WARNING: Unexpected \"x\"\n")
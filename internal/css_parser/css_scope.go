@@ -0,0 +1,278 @@
+package css_parser
+
+import (
+	"fmt"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Lowering "@scope" rewrites each style rule inside of it to select relative
+// to the scoping root instead. For example:
+//
+//	@scope (.card) to (.content) {
+//	  img { border: none }
+//	}
+//
+// becomes this:
+//
+//	:where(.card) img:where(:not(:where(.card) .content, :where(.card) .content *)) {
+//	  border: none;
+//	}
+//
+// This is only an approximation. Real "@scope" rules prefer the declarations
+// from the closest scoping root when specificity is equal ("scope proximity"),
+// which can't be expressed using regular selectors. And ":scope" is treated
+// as having zero specificity here because ":where()" is used for the root.
+func (p *parser) lowerAtScopeInRules(rules []css_ast.Rule) []css_ast.Rule {
+	results := make([]css_ast.Rule, 0, len(rules))
+
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RKnownAt:
+			if r.Rules != nil {
+				r.Rules = p.lowerAtScopeInRules(r.Rules)
+			}
+
+		case *css_ast.RAtLayer:
+			if r.Rules != nil {
+				r.Rules = p.lowerAtScopeInRules(r.Rules)
+			}
+
+		case *css_ast.RAtScope:
+			// "@scope {}" without a prelude is scoped to the parent element of the
+			// "<style>" tag, which isn't something that a selector can refer to
+			if r.Start == nil {
+				r.Rules = p.lowerAtScopeInRules(r.Rules)
+				break
+			}
+			results = append(results, p.lowerAtScope(rule.Loc, r)...)
+			continue
+		}
+
+		results = append(results, rule)
+	}
+
+	return results
+}
+
+func (p *parser) lowerAtScope(loc logger.Loc, r *css_ast.RAtScope) []css_ast.Rule {
+	// Lower any nested "@scope" rules first so that they select relative to
+	// this scoping root too
+	rules := p.lowerAtScopeInRules(r.Rules)
+
+	// "@scope (.a, .b) { .c {} }" => ":where(.a, .b) .c {}"
+	roots := r.Start
+	if !p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+		roots = []css_ast.ComplexSelector{{
+			Selectors: []css_ast.CompoundSelector{{
+				SubclassSelectors: []css_ast.SubclassSelector{{
+					Range: logger.Range{Loc: loc},
+					Data: &css_ast.SSPseudoClassWithSelectorList{
+						Kind:      css_ast.PseudoClassWhere,
+						Selectors: r.Start,
+					},
+				}},
+			}},
+		}}
+	}
+
+	// "@scope (.a) to (.b) { .c {} }" => ":where(.a) .c:where(:not(:where(.a) .b, :where(.a) .b *)) {}"
+	var limit *css_ast.SubclassSelector
+	if r.End != nil {
+		if p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+			text := "Transforming the scoping limit of \"@scope\" is not supported in the configured target environment"
+			if p.options.originalTargetEnv != "" {
+				text = fmt.Sprintf("%s (%s)", text, p.options.originalTargetEnv)
+			}
+			p.log.AddIDWithNotes(logger.MsgID_CSS_UnsupportedAtScope, logger.Warning, &p.tracker, r.End[0].Selectors[0].Range(), text, []logger.MsgData{{
+				Text: "The scoping limit will be ignored because the configured target environment does not support complex selectors inside \":not()\"."}})
+		} else {
+			var limits []css_ast.ComplexSelector
+			for _, root := range roots {
+				for _, end := range r.End {
+					sel := p.scopeComplexSelector(end, root, nil)
+					descendants := css_ast.ComplexSelector{Selectors: make([]css_ast.CompoundSelector, 0, len(sel.Selectors)+1)}
+					for _, compound := range sel.Selectors {
+						descendants.Selectors = append(descendants.Selectors, compound.Clone())
+					}
+					descendants.Selectors = append(descendants.Selectors, css_ast.CompoundSelector{
+						TypeSelector: &css_ast.NamespacedName{Name: css_ast.NameToken{Kind: css_lexer.TDelimAsterisk, Text: "*"}},
+					})
+					limits = append(limits, sel, descendants)
+				}
+			}
+			// The ":not()" is wrapped in ":where()" so that the scoping limit
+			// doesn't contribute to specificity
+			limit = &css_ast.SubclassSelector{
+				Range: logger.Range{Loc: loc},
+				Data: &css_ast.SSPseudoClassWithSelectorList{
+					Kind: css_ast.PseudoClassWhere,
+					Selectors: []css_ast.ComplexSelector{{
+						Selectors: []css_ast.CompoundSelector{{
+							SubclassSelectors: []css_ast.SubclassSelector{{
+								Range: logger.Range{Loc: loc},
+								Data: &css_ast.SSPseudoClassWithSelectorList{
+									Kind:      css_ast.PseudoClassNot,
+									Selectors: limits,
+								},
+							}},
+						}},
+					}},
+				},
+			}
+		}
+	}
+
+	return p.applyScopeToRules(rules, roots, limit)
+}
+
+func (p *parser) applyScopeToRules(rules []css_ast.Rule, roots []css_ast.ComplexSelector, limit *css_ast.SubclassSelector) []css_ast.Rule {
+	results := make([]css_ast.Rule, 0, len(rules))
+	var decls []css_ast.Rule
+
+	// Declarations directly inside "@scope" apply to the scoping root
+	flushDecls := func() {
+		if len(decls) > 0 {
+			selectors := make([]css_ast.ComplexSelector, 0, len(roots))
+			for _, root := range roots {
+				selectors = append(selectors, p.scopeComplexSelector(scope(decls[0].Loc), root, limit))
+			}
+			results = append(results, css_ast.Rule{Loc: decls[0].Loc, Data: &css_ast.RSelector{Selectors: selectors, Rules: decls}})
+			decls = nil
+		}
+	}
+
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RDeclaration, *css_ast.RBadDeclaration:
+			decls = append(decls, rule)
+			continue
+
+		case *css_ast.RSelector:
+			selectors := make([]css_ast.ComplexSelector, 0, len(r.Selectors)*len(roots))
+			for _, sel := range r.Selectors {
+				for _, root := range roots {
+					selectors = append(selectors, p.scopeComplexSelector(sel, root, limit))
+				}
+			}
+			r.Selectors = selectors
+
+		case *css_ast.RKnownAt:
+			if r.Rules != nil {
+				r.Rules = p.applyScopeToRules(r.Rules, roots, limit)
+			}
+
+		case *css_ast.RAtLayer:
+			if r.Rules != nil {
+				r.Rules = p.applyScopeToRules(r.Rules, roots, limit)
+			}
+		}
+
+		flushDecls()
+		results = append(results, rule)
+	}
+
+	flushDecls()
+	return results
+}
+
+// Rewrites a selector inside of "@scope" to select relative to the scoping
+// root instead. Both ":scope" and "&" refer to the scoping root, and selectors
+// that don't mention either are implicitly descendants of the scoping root.
+func (p *parser) scopeComplexSelector(sel css_ast.ComplexSelector, root css_ast.ComplexSelector, limit *css_ast.SubclassSelector) css_ast.ComplexSelector {
+	clone := css_ast.ComplexSelector{Selectors: make([]css_ast.CompoundSelector, 0, len(sel.Selectors)+1)}
+	hasRoot := false
+	for _, compound := range sel.Selectors {
+		compound = compound.Clone()
+		if replaceScopeWithAmpersand(&compound) {
+			hasRoot = true
+		}
+		clone.Selectors = append(clone.Selectors, compound)
+	}
+
+	// ".c" => "& .c"
+	// "> .c" => "& > .c"
+	if !hasRoot {
+		loc := sel.Selectors[0].Range().Loc
+		clone.Selectors = append([]css_ast.CompoundSelector{{NestingSelectorLoc: ast.MakeIndex32(uint32(loc.Start))}}, clone.Selectors...)
+	}
+
+	substituted := make([]css_ast.CompoundSelector, 0, len(clone.Selectors)+len(root.Selectors))
+	replacementFn := func(logger.Loc) css_ast.ComplexSelector {
+		return root.CloneWithoutLeadingCombinator()
+	}
+	for _, compound := range clone.Selectors {
+		substituted = p.substituteAmpersandsInCompoundSelector(compound, replacementFn, substituted, keepLeadingCombinator)
+	}
+
+	// The scoping limit must go before any pseudo-element since nothing is
+	// allowed to come after a pseudo-element
+	if limit != nil {
+		last := &substituted[len(substituted)-1]
+		index := len(last.SubclassSelectors)
+		for i, ss := range last.SubclassSelectors {
+			if pseudo, ok := ss.Data.(*css_ast.SSPseudoClass); ok && isPseudoElement(pseudo) {
+				index = i
+				break
+			}
+		}
+		subclassSelectors := make([]css_ast.SubclassSelector, 0, len(last.SubclassSelectors)+1)
+		subclassSelectors = append(subclassSelectors, last.SubclassSelectors[:index]...)
+		subclassSelectors = append(subclassSelectors, css_ast.SubclassSelector{Range: limit.Range, Data: limit.Data.Clone()})
+		subclassSelectors = append(subclassSelectors, last.SubclassSelectors[index:]...)
+		last.SubclassSelectors = subclassSelectors
+	}
+
+	return css_ast.ComplexSelector{Selectors: substituted}
+}
+
+// Replaces ":scope" with "&" in a compound selector that has already been
+// cloned. Returns true if the selector refers to the scoping root.
+func replaceScopeWithAmpersand(sel *css_ast.CompoundSelector) bool {
+	hasRoot := sel.HasNestingSelector()
+
+	n := 0
+	for _, ss := range sel.SubclassSelectors {
+		switch s := ss.Data.(type) {
+		case *css_ast.SSPseudoClass:
+			if s.Name == "scope" && s.Args == nil && !s.IsElement {
+				if !sel.HasNestingSelector() {
+					sel.NestingSelectorLoc = ast.MakeIndex32(uint32(ss.Range.Loc.Start))
+				}
+				hasRoot = true
+				continue
+			}
+
+		case *css_ast.SSPseudoClassWithSelectorList:
+			for i := range s.Selectors {
+				for j := range s.Selectors[i].Selectors {
+					if replaceScopeWithAmpersand(&s.Selectors[i].Selectors[j]) {
+						hasRoot = true
+					}
+				}
+			}
+		}
+		sel.SubclassSelectors[n] = ss
+		n++
+	}
+	sel.SubclassSelectors = sel.SubclassSelectors[:n]
+
+	return hasRoot
+}
+
+func isPseudoElement(pseudo *css_ast.SSPseudoClass) bool {
+	if pseudo.IsElement {
+		return true
+	}
+
+	// These pseudo-elements can also be written with a single colon
+	switch pseudo.Name {
+	case "before", "after", "first-line", "first-letter":
+		return pseudo.Args == nil
+	}
+	return false
+}
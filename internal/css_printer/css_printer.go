@@ -298,7 +298,12 @@ func (p *printer) printRule(rule css_ast.Rule, indent int32, omitTrailingSemicol
 		p.printRuleBlock(r.Rules, indent, r.CloseBraceLoc)
 
 	case *css_ast.RDeclaration:
-		p.printIdent(r.KeyText, identNormal, canDiscardWhitespaceAfter)
+		if r.KeySymbol.IsValid() {
+			ref := ast.Ref{SourceIndex: p.options.InputSourceIndex, InnerIndex: r.KeySymbol.GetIndex()}
+			p.printSymbol(r.KeyRange.Loc, ref, identNormal, canDiscardWhitespaceAfter)
+		} else {
+			p.printIdent(r.KeyText, identNormal, canDiscardWhitespaceAfter)
+		}
 		p.print(":")
 		hasWhitespaceAfter := p.printTokens(r.Value, printTokensOpts{
 			indent:        indent,
@@ -344,6 +349,47 @@ func (p *printer) printRule(rule css_ast.Rule, indent int32, omitTrailingSemicol
 			p.printRuleBlock(r.Rules, indent, r.CloseBraceLoc)
 		}
 
+	case *css_ast.RAtScope:
+		p.print("@scope")
+		if r.Start != nil {
+			if !p.options.MinifyWhitespace {
+				p.print(" ")
+			}
+			p.print("(")
+			p.printComplexSelectors(r.Start, indent, layoutSingleLine)
+			p.print(")")
+		}
+		if r.End != nil {
+			if r.Start == nil || !p.options.MinifyWhitespace {
+				p.print(" ")
+			}
+
+			// Note: The space before "(" is required because "to(" is a function token
+			p.print("to (")
+			p.printComplexSelectors(r.End, indent, layoutSingleLine)
+			p.print(")")
+		}
+		if !p.options.MinifyWhitespace {
+			p.print(" ")
+		}
+		p.printRuleBlock(r.Rules, indent, r.CloseBraceLoc)
+
+	case *css_ast.RAtCustomMedia:
+		// Note: The space after the name is required because "--name(" is a function token
+		p.print("@custom-media ")
+		p.printIdent(r.Name, identNormal, mayNeedWhitespaceAfter)
+		p.print(" ")
+		p.printTokens(r.Query, printTokensOpts{})
+		p.print(";")
+
+	case *css_ast.RAtCustomSelector:
+		// Note: The space after the name is required to avoid merging it with the selector
+		p.print("@custom-selector :")
+		p.printIdent(r.Name, identNormal, mayNeedWhitespaceAfter)
+		p.print(" ")
+		p.printComplexSelectors(r.Selectors, indent, layoutSingleLine)
+		p.print(";")
+
 	default:
 		panic("Internal error")
 	}
@@ -27,6 +27,11 @@ type Entry struct {
 	mutex    sync.Mutex
 	kind     EntryKind
 	needStat bool
+
+	// Entries that come from an overlay don't exist in the underlying file
+	// system, so they must not be recorded for watch mode. Otherwise watch
+	// mode would think the directory had changed when it checks it again.
+	isOverlay bool
 }
 
 func (e *Entry) Kind(fs FS) EntryKind {
@@ -73,6 +78,38 @@ type accessedEntries struct {
 	mutex sync.Mutex
 }
 
+// This returns a non-empty path if the current entries in a directory differ
+// from the entries that were observed during the build. The returned path is
+// either the directory itself or the entry that appeared or disappeared.
+func (accessed *accessedEntries) findChange(fs FS, dir string, names []string) string {
+	accessed.mutex.Lock()
+	defer accessed.mutex.Unlock()
+	if allEntries := accessed.allEntries; allEntries != nil {
+		// Check all entries
+		if len(names) != len(allEntries) {
+			return dir
+		}
+		sort.Strings(names)
+		for i, s := range names {
+			if s != allEntries[i] {
+				return dir
+			}
+		}
+	} else {
+		// Check individual entries
+		lookup := make(map[string]string, len(names))
+		for _, name := range names {
+			lookup[strings.ToLower(name)] = name
+		}
+		for name, wasPresent := range accessed.wasPresent {
+			if originalName, isPresent := lookup[name]; wasPresent != isPresent {
+				return fs.Join(dir, originalName)
+			}
+		}
+	}
+	return ""
+}
+
 type DirEntries struct {
 	data            map[string]*Entry
 	accessedEntries *accessedEntries
@@ -95,7 +132,7 @@ func (entries DirEntries) Get(query string) (*Entry, *DifferentCase) {
 		entry := entries.data[key]
 
 		// Track whether this specific entry was present or absent for watch mode
-		if accessed := entries.accessedEntries; accessed != nil {
+		if accessed := entries.accessedEntries; accessed != nil && (entry == nil || !entry.isOverlay) {
 			accessed.mutex.Lock()
 			accessed.wasPresent[key] = entry != nil
 			accessed.mutex.Unlock()
@@ -135,10 +172,17 @@ func (entries DirEntries) SortedKeys() (keys []string) {
 		}
 		sort.Strings(keys)
 
-		// Track the exact set of all entries for watch mode
+		// Track the exact set of all entries for watch mode. Entries that come
+		// from an overlay are left out since they aren't in the real directory.
 		if entries.accessedEntries != nil {
+			tracked := make([]string, 0, len(keys))
+			for _, key := range keys {
+				if !entries.data[strings.ToLower(key)].isOverlay {
+					tracked = append(tracked, key)
+				}
+			}
 			entries.accessedEntries.mutex.Lock()
-			entries.accessedEntries.allEntries = keys
+			entries.accessedEntries.allEntries = tracked
 			entries.accessedEntries.mutex.Unlock()
 		}
 
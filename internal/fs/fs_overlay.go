@@ -0,0 +1,167 @@
+package fs
+
+// This implements the "FS" interface by wrapping another "FS" interface and
+// replacing or adding some files with in-memory contents. This is similar to
+// the "-overlay" flag in the Go compiler. It lets people embedding esbuild
+// substitute unsaved editor buffers or generated files into a build without
+// writing them to disk first.
+//
+// Overlay files never change during the lifetime of the file system, so they
+// are not tracked for watch mode. Directories that only exist because of an
+// overlay file are also synthesized here.
+
+import (
+	"strings"
+	"syscall"
+)
+
+type overlayFS struct {
+	inner FS
+	files map[string]string
+
+	// This maps each directory that is an ancestor of an overlay file to the
+	// overlay entries that it contains (either files or other directories)
+	dirs map[string]map[string]EntryKind
+}
+
+func OverlayFS(inner FS, overlay map[string]string) FS {
+	if len(overlay) == 0 {
+		return inner
+	}
+
+	files := make(map[string]string, len(overlay))
+	dirs := make(map[string]map[string]EntryKind)
+
+	for path, contents := range overlay {
+		// Relative paths are relative to the current working directory
+		path, _ = inner.Abs(path)
+		files[path] = contents
+
+		// Build the directory map
+		kind := FileEntry
+		for {
+			dir := inner.Dir(path)
+			if dir == path {
+				break
+			}
+			entries, ok := dirs[dir]
+			if !ok {
+				entries = make(map[string]EntryKind)
+				dirs[dir] = entries
+			}
+			entries[inner.Base(path)] = kind
+			kind = DirEntry
+			path = dir
+		}
+	}
+
+	return &overlayFS{
+		inner: inner,
+		files: files,
+		dirs:  dirs,
+	}
+}
+
+func (fs *overlayFS) ReadDirectory(path string) (entries DirEntries, canonicalError error, originalError error) {
+	entries, canonicalError, originalError = fs.inner.ReadDirectory(path)
+
+	overlay, ok := fs.dirs[fs.inner.Join(path)]
+	if !ok {
+		return
+	}
+
+	// A directory that only exists in the overlay is not an error
+	if canonicalError == syscall.ENOENT {
+		entries, canonicalError, originalError = MakeEmptyDirEntries(path), nil, nil
+	} else if canonicalError != nil {
+		return
+	}
+
+	// Don't mutate the inner map since it may be cached
+	merged := DirEntries{
+		dir:             entries.dir,
+		data:            make(map[string]*Entry, len(entries.data)+len(overlay)),
+		accessedEntries: entries.accessedEntries,
+	}
+	for key, entry := range entries.data {
+		merged.data[key] = entry
+	}
+	for name, kind := range overlay {
+		key := strings.ToLower(name)
+		_, isInInner := entries.data[key]
+		merged.data[key] = &Entry{
+			dir:       path,
+			base:      name,
+			kind:      kind,
+			isOverlay: !isInInner,
+		}
+	}
+	return merged, nil, nil
+}
+
+func (fs *overlayFS) ReadFile(path string) (contents string, canonicalError error, originalError error) {
+	if contents, ok := fs.files[fs.inner.Join(path)]; ok {
+		return contents, nil, nil
+	}
+	return fs.inner.ReadFile(path)
+}
+
+func (fs *overlayFS) OpenFile(path string) (result OpenedFile, canonicalError error, originalError error) {
+	if contents, ok := fs.files[fs.inner.Join(path)]; ok {
+		return &InMemoryOpenedFile{Contents: []byte(contents)}, nil, nil
+	}
+	return fs.inner.OpenFile(path)
+}
+
+func (fs *overlayFS) ModKey(path string) (ModKey, error) {
+	// Overlay files are already in memory so there's nothing to gain from
+	// caching them. Returning an error here means they are always re-read.
+	if _, ok := fs.files[fs.inner.Join(path)]; ok {
+		return ModKey{}, modKeyUnusable
+	}
+	return fs.inner.ModKey(path)
+}
+
+func (fs *overlayFS) IsAbs(path string) bool {
+	return fs.inner.IsAbs(path)
+}
+
+func (fs *overlayFS) Abs(path string) (string, bool) {
+	return fs.inner.Abs(path)
+}
+
+func (fs *overlayFS) Dir(path string) string {
+	return fs.inner.Dir(path)
+}
+
+func (fs *overlayFS) Base(path string) string {
+	return fs.inner.Base(path)
+}
+
+func (fs *overlayFS) Ext(path string) string {
+	return fs.inner.Ext(path)
+}
+
+func (fs *overlayFS) Join(parts ...string) string {
+	return fs.inner.Join(parts...)
+}
+
+func (fs *overlayFS) Cwd() string {
+	return fs.inner.Cwd()
+}
+
+func (fs *overlayFS) Rel(base string, target string) (string, bool) {
+	return fs.inner.Rel(base, target)
+}
+
+func (fs *overlayFS) EvalSymlinks(path string) (string, bool) {
+	return fs.inner.EvalSymlinks(path)
+}
+
+func (fs *overlayFS) kind(dir string, base string) (symlink string, kind EntryKind) {
+	return fs.inner.kind(dir, base)
+}
+
+func (fs *overlayFS) WatchData() WatchData {
+	return fs.inner.WatchData()
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -447,6 +446,11 @@ func (fs *realFS) kind(dir string, base string) (symlink string, kind EntryKind)
 }
 
 func (fs *realFS) WatchData() WatchData {
+	// Several builds may share this file system object and may still be
+	// running, so don't read the watch data while it's being written to
+	fs.watchMutex.Lock()
+	defer fs.watchMutex.Unlock()
+
 	paths := make(map[string]func() string)
 
 	for path, data := range fs.watchData {
@@ -483,32 +487,7 @@ func (fs *realFS) WatchData() WatchData {
 				if err != nil {
 					return path
 				}
-				data.accessedEntries.mutex.Lock()
-				defer data.accessedEntries.mutex.Unlock()
-				if allEntries := data.accessedEntries.allEntries; allEntries != nil {
-					// Check all entries
-					if len(names) != len(allEntries) {
-						return path
-					}
-					sort.Strings(names)
-					for i, s := range names {
-						if s != allEntries[i] {
-							return path
-						}
-					}
-				} else {
-					// Check individual entries
-					lookup := make(map[string]string, len(names))
-					for _, name := range names {
-						lookup[strings.ToLower(name)] = name
-					}
-					for name, wasPresent := range data.accessedEntries.wasPresent {
-						if originalName, isPresent := lookup[name]; wasPresent != isPresent {
-							return fs.Join(path, originalName)
-						}
-					}
-				}
-				return ""
+				return data.accessedEntries.findChange(fs, path, names)
 			}
 
 		case stateFileMissing:
@@ -0,0 +1,299 @@
+package fs
+
+// This is an implementation of the "FS" interface that reads from a file
+// system provided by the host application instead of from the real file
+// system. This lets people embedding esbuild do hermetic builds from things
+// like an in-memory map, a git tree, or a zip archive without writing any
+// temporary files to disk.
+//
+// Paths in a virtual file system always use Unix-style forward slashes, even
+// on Windows. This makes builds from a virtual file system reproducible across
+// platforms.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type VirtualFileSystem interface {
+	// This should return the names of all entries in the directory
+	ReadDir(path string) ([]string, error)
+	ReadFile(path string) ([]byte, error)
+	Stat(path string) (VirtualFileInfo, error)
+}
+
+type VirtualFileInfo struct {
+	// If this is zero, the file system cache will always re-read the file
+	ModTime time.Time
+	Size    int64
+	IsDir   bool
+}
+
+type virtualFS struct {
+	backend VirtualFileSystem
+
+	// This stores data that will end up being returned by "WatchData()"
+	watchData map[string]virtualWatchData
+	fp        goFilepath
+
+	watchMutex sync.Mutex
+}
+
+type virtualWatchData struct {
+	accessedEntries *accessedEntries
+	fileContents    string
+	isDir           bool
+	isMissing       bool
+}
+
+type VirtualFSOptions struct {
+	Backend       VirtualFileSystem
+	AbsWorkingDir string
+	WantWatchData bool
+}
+
+func VirtualFS(options VirtualFSOptions) (FS, error) {
+	fp := goFilepath{
+		cwd:           options.AbsWorkingDir,
+		pathSeparator: '/',
+	}
+
+	// There is no process working directory to fall back to here
+	if fp.cwd == "" {
+		fp.cwd = "/"
+	} else if !fp.isAbs(fp.cwd) {
+		return nil, fmt.Errorf("The working directory %q is not an absolute path", fp.cwd)
+	} else {
+		fp.cwd = fp.clean(fp.cwd)
+	}
+
+	// Only allocate memory for watch data if necessary
+	var watchData map[string]virtualWatchData
+	if options.WantWatchData {
+		watchData = make(map[string]virtualWatchData)
+	}
+
+	return &virtualFS{
+		backend:   options.Backend,
+		watchData: watchData,
+		fp:        fp,
+	}, nil
+}
+
+func canonicalizeVirtualError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// The host application may return its own error types, so use the standard
+	// library to detect a missing file instead of comparing against "ENOENT"
+	if os.IsNotExist(err) {
+		return syscall.ENOENT
+	}
+	return err
+}
+
+func (fs *virtualFS) readdir(dir string) (names []string, canonicalError error, originalError error) {
+	info, originalError := fs.backend.Stat(dir)
+	if canonicalError = canonicalizeVirtualError(originalError); canonicalError != nil {
+		return
+	}
+
+	// Report reading a file as a directory the same way the real file system
+	// does so that path resolution keeps going instead of failing
+	if !info.IsDir {
+		return nil, syscall.ENOTDIR, syscall.ENOTDIR
+	}
+
+	names, originalError = fs.backend.ReadDir(dir)
+	canonicalError = canonicalizeVirtualError(originalError)
+	return
+}
+
+func (fs *virtualFS) ReadDirectory(dir string) (entries DirEntries, canonicalError error, originalError error) {
+	names, canonicalError, originalError := fs.readdir(dir)
+	entries = DirEntries{dir: dir, data: make(map[string]*Entry)}
+
+	if canonicalError == nil {
+		for _, name := range names {
+			// Call "stat" lazily for performance, like the real file system does
+			entries.data[strings.ToLower(name)] = &Entry{
+				dir:      dir,
+				base:     name,
+				needStat: true,
+			}
+		}
+	}
+
+	// Store data for watch mode
+	if fs.watchData != nil {
+		defer fs.watchMutex.Unlock()
+		fs.watchMutex.Lock()
+		data := virtualWatchData{isDir: true, isMissing: canonicalError != nil}
+		if canonicalError == nil {
+			entries.accessedEntries = &accessedEntries{wasPresent: make(map[string]bool)}
+			data.accessedEntries = entries.accessedEntries
+		}
+		fs.watchData[dir] = data
+	}
+
+	if canonicalError != nil {
+		entries.data = nil
+	}
+	return entries, canonicalError, originalError
+}
+
+func (fs *virtualFS) ReadFile(path string) (contents string, canonicalError error, originalError error) {
+	buffer, originalError := fs.backend.ReadFile(path)
+	canonicalError = canonicalizeVirtualError(originalError)
+
+	// Allocate the string once
+	fileContents := string(buffer)
+
+	// Store data for watch mode
+	if fs.watchData != nil {
+		defer fs.watchMutex.Unlock()
+		fs.watchMutex.Lock()
+		fs.watchData[path] = virtualWatchData{
+			fileContents: fileContents,
+			isMissing:    canonicalError != nil,
+		}
+	}
+
+	return fileContents, canonicalError, originalError
+}
+
+func (fs *virtualFS) OpenFile(path string) (OpenedFile, error, error) {
+	buffer, originalError := fs.backend.ReadFile(path)
+	if canonicalError := canonicalizeVirtualError(originalError); canonicalError != nil {
+		return nil, canonicalError, originalError
+	}
+	return &InMemoryOpenedFile{Contents: buffer}, nil, nil
+}
+
+func (fs *virtualFS) ModKey(path string) (ModKey, error) {
+	info, err := fs.backend.Stat(path)
+	if err != nil {
+		return ModKey{}, canonicalizeVirtualError(err)
+	}
+
+	// We can't detect changes if the file system doesn't provide a time
+	if info.ModTime.IsZero() {
+		return ModKey{}, modKeyUnusable
+	}
+
+	return ModKey{
+		size:       info.Size,
+		mtime_sec:  info.ModTime.Unix(),
+		mtime_nsec: int64(info.ModTime.Nanosecond()),
+	}, nil
+}
+
+func (fs *virtualFS) IsAbs(p string) bool {
+	return fs.fp.isAbs(p)
+}
+
+func (fs *virtualFS) Abs(p string) (string, bool) {
+	abs, err := fs.fp.abs(p)
+	return abs, err == nil
+}
+
+func (fs *virtualFS) Dir(p string) string {
+	return fs.fp.dir(p)
+}
+
+func (fs *virtualFS) Base(p string) string {
+	return fs.fp.base(p)
+}
+
+func (fs *virtualFS) Ext(p string) string {
+	return fs.fp.ext(p)
+}
+
+func (fs *virtualFS) Join(parts ...string) string {
+	return fs.fp.clean(fs.fp.join(parts))
+}
+
+func (fs *virtualFS) Cwd() string {
+	return fs.fp.cwd
+}
+
+func (fs *virtualFS) Rel(base string, target string) (string, bool) {
+	if rel, err := fs.fp.rel(base, target); err == nil {
+		return rel, true
+	}
+	return "", false
+}
+
+func (fs *virtualFS) EvalSymlinks(path string) (string, bool) {
+	// Virtual file systems don't have symbolic links
+	return "", false
+}
+
+func (fs *virtualFS) kind(dir string, base string) (symlink string, kind EntryKind) {
+	info, err := fs.backend.Stat(fs.fp.join([]string{dir, base}))
+	if err != nil {
+		return
+	}
+	if info.IsDir {
+		kind = DirEntry
+	} else {
+		kind = FileEntry
+	}
+	return
+}
+
+func (fs *virtualFS) WatchData() WatchData {
+	paths := make(map[string]func() string)
+
+	for path, data := range fs.watchData {
+		// Each closure below needs its own copy of these loop variables
+		path := path
+		data := data
+
+		// There are no modification keys to compare against, so watch mode
+		// compares the current contents against what was observed instead
+		switch {
+		case data.isDir && data.isMissing:
+			paths[path] = func() string {
+				if _, err, _ := fs.readdir(path); err == nil {
+					return path
+				}
+				return ""
+			}
+
+		case data.isDir:
+			paths[path] = func() string {
+				names, err, _ := fs.readdir(path)
+				if err != nil {
+					return path
+				}
+				return data.accessedEntries.findChange(fs, path, names)
+			}
+
+		case data.isMissing:
+			paths[path] = func() string {
+				if info, err := fs.backend.Stat(path); err == nil && !info.IsDir {
+					return path
+				}
+				return ""
+			}
+
+		default:
+			paths[path] = func() string {
+				if buffer, err := fs.backend.ReadFile(path); err != nil || string(buffer) != data.fileContents {
+					return path
+				}
+				return ""
+			}
+		}
+	}
+
+	return WatchData{
+		Paths: paths,
+	}
+}
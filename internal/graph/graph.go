@@ -30,6 +30,7 @@ const (
 	entryPointNone entryPointKind = iota
 	entryPointUserSpecified
 	entryPointDynamicImport
+	entryPointWorker
 )
 
 type LinkerFile struct {
@@ -55,6 +56,7 @@ type LinkerFile struct {
 	// Note that dynamically-imported files are allowed to also be specified by
 	// the user as top-level entry points, so some dynamically-imported files
 	// may be "entryPointUserSpecified" instead of "entryPointDynamicImport".
+	// Workers use "entryPointWorker" because they are loaded by themselves.
 	entryPointKind entryPointKind
 
 	// This is true if this file has been marked as live by the tree shaking
@@ -70,6 +72,10 @@ func (f *LinkerFile) IsUserSpecifiedEntryPoint() bool {
 	return f.entryPointKind == entryPointUserSpecified
 }
 
+func (f *LinkerFile) IsWorkerEntryPoint() bool {
+	return f.entryPointKind == entryPointWorker
+}
+
 // Note: This is not guarded by a mutex. Make sure this isn't called from a
 // parallel part of the code.
 func (f *LinkerFile) LineColumnTracker() *logger.LineColumnTracker {
@@ -95,6 +101,11 @@ type EntryPoint struct {
 	// "outbase" directory, which is computed as the lowest common ancestor of
 	// all automatically generated output paths.
 	OutputPathWasAutoGenerated bool
+
+	// This is true for files that were only referenced by a worker constructor
+	// such as "new Worker(new URL('./worker.js', import.meta.url))". These are
+	// only added as separate entry points when code splitting is disabled.
+	IsWorker bool
 }
 
 type LinkerGraph struct {
@@ -135,13 +146,18 @@ func CloneLinkerGraph(
 
 	// Mark all entry points so we don't add them again for import() expressions
 	for _, entryPoint := range entryPoints {
-		files[entryPoint.SourceIndex].entryPointKind = entryPointUserSpecified
+		if entryPoint.IsWorker {
+			files[entryPoint.SourceIndex].entryPointKind = entryPointWorker
+		} else {
+			files[entryPoint.SourceIndex].entryPointKind = entryPointUserSpecified
+		}
 	}
 
 	// Clone various things since we may mutate them later. Do this in parallel
 	// for a speedup (around ~2x faster for this function in the three.js
 	// benchmark on a 6-core laptop).
 	var dynamicImportEntryPoints []uint32
+	var workerEntryPoints []uint32
 	var dynamicImportEntryPointsMutex sync.Mutex
 	waitGroup := sync.WaitGroup{}
 	waitGroup.Add(len(reachableFiles))
@@ -182,12 +198,17 @@ func CloneLinkerGraph(
 				// Clone the import records
 				repr.AST.ImportRecords = append([]ast.ImportRecord{}, repr.AST.ImportRecords...)
 
-				// Add dynamic imports as additional entry points if code splitting is active
+				// Add dynamic imports and workers as additional entry points if code
+				// splitting is active
 				if codeSplitting {
 					for importRecordIndex := range repr.AST.ImportRecords {
-						if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() && record.Kind == ast.ImportDynamic {
+						if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() &&
+							(record.Kind == ast.ImportDynamic || record.Kind == ast.ImportWorker) {
 							dynamicImportEntryPointsMutex.Lock()
 							dynamicImportEntryPoints = append(dynamicImportEntryPoints, record.SourceIndex.GetIndex())
+							if record.Kind == ast.ImportWorker {
+								workerEntryPoints = append(workerEntryPoints, record.SourceIndex.GetIndex())
+							}
 							dynamicImportEntryPointsMutex.Unlock()
 
 							// Remove import assertions for dynamic imports of additional
@@ -263,6 +284,13 @@ func CloneLinkerGraph(
 		}
 	}
 
+	// Workers are loaded by themselves instead of by another chunk
+	for _, sourceIndex := range workerEntryPoints {
+		if otherFile := &files[sourceIndex]; otherFile.entryPointKind == entryPointDynamicImport {
+			otherFile.entryPointKind = entryPointWorker
+		}
+	}
+
 	// Make sure to add dynamic entry points in a deterministic order
 	sort.Ints(stableEntryPoints)
 	for _, stableIndex := range stableEntryPoints {
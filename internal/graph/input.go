@@ -15,6 +15,7 @@ import (
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/resolver"
 	"github.com/evanw/esbuild/internal/sourcemap"
+	"github.com/evanw/esbuild/internal/xxhash"
 )
 
 type InputFile struct {
@@ -43,6 +44,63 @@ type OutputFile struct {
 	AbsPath      string
 	Contents     []byte
 	IsExecutable bool
+
+	// This is a Subresource Integrity value such as "sha384-..." if integrity
+	// hashes were requested. It may contain multiple space-separated hashes.
+	Integrity string
+
+	// These are the sizes of the contents after gzip and brotli compression if
+	// "CompressedSizes" is present. They are zero for source map files.
+	GzipBytes   int
+	BrotliBytes int
+
+	// If "NeedsManifest" is present, this will be filled out for the output
+	// files of entry point chunks. It will be assembled into the manifest later.
+	ManifestEntry *ManifestEntry
+
+	// This is the source index of the entry point if this is the JS output file
+	// for an entry point chunk. It's used to find the output paths of workers.
+	EntryPointSourceIndex ast.Index32
+
+	// If "StreamOutputFile" is present, the contents have already been passed
+	// to it and "Contents" is nil. Only the size and hash of the contents remain.
+	IsStreamed   bool
+	StreamedSize int
+	StreamedHash uint64
+}
+
+// The size and hash of the contents, which remain after they are streamed out
+func (f *OutputFile) SizeAndHash() (int, uint64) {
+	if f.IsStreamed {
+		return f.StreamedSize, f.StreamedHash
+	}
+	hasher := xxhash.New()
+	hasher.Write(f.Contents)
+	return len(f.Contents), hasher.Sum64()
+}
+
+// Pass the contents to "StreamOutputFile" if present and then release them
+func (f *OutputFile) Stream(stream func(absPath string, contents []byte, isExecutable bool)) {
+	if stream == nil || f.IsStreamed {
+		return
+	}
+	f.StreamedSize, f.StreamedHash = f.SizeAndHash()
+	stream(f.AbsPath, f.Contents, f.IsExecutable)
+	f.IsStreamed = true
+	f.Contents = nil
+}
+
+// This describes everything needed to load an entry point from a server-side
+// rendered page (the JS file, its CSS file, and the chunks it imports)
+type ManifestEntry struct {
+	EntryPoint string // The pretty path of the entry point
+
+	// These are absolute paths of output files
+	CSSBundle string
+	Imports   []string // All statically-imported chunks, including indirect ones
+
+	// These are pretty paths of other entry points
+	DynamicImports []string
 }
 
 type SideEffects struct {
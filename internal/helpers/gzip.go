@@ -0,0 +1,23 @@
+package helpers
+
+import "compress/gzip"
+
+// This returns the size of the contents after compressing them with gzip at
+// the default compression level. It's used to show how many bytes will be
+// sent over the network when the file is served with gzip compression.
+func GzipSize(contents []byte) int {
+	counter := byteCounter{}
+	w := gzip.NewWriter(&counter)
+	w.Write(contents)
+	w.Close()
+	return counter.count
+}
+
+type byteCounter struct {
+	count int
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.count += len(p)
+	return len(p), nil
+}
@@ -481,6 +481,7 @@ func (*EYield) isExpr()                {}
 func (*EIf) isExpr()                   {}
 func (*ERequireString) isExpr()        {}
 func (*ERequireResolveString) isExpr() {}
+func (*EImportPath) isExpr()           {}
 func (*EImportString) isExpr()         {}
 func (*EImportCall) isExpr()           {}
 
@@ -907,6 +908,13 @@ type EImportString struct {
 	CloseParenLoc     logger.Loc
 }
 
+// This is a string containing the path of an import record. It's used for the
+// "./file" in "new URL('./file', import.meta.url)" so that the linker can
+// substitute in the final path of the file that the import record refers to.
+type EImportPath struct {
+	ImportRecordIndex uint32
+}
+
 type EImportCall struct {
 	Expr          Expr
 	OptionsOrNil  Expr
@@ -344,10 +344,24 @@ const (
 	// - Full JS number syntax
 	TSConfigJSON
 
+	// JSON with comments, which is used for VS Code's configuration files:
+	// https://code.visualstudio.com/docs/languages/json#_json-with-comments
+	// - Comments
+	// - Trailing commas
+	JSONC
+
+	// Specification: https://spec.json5.org/
+	JSON5
+
 	// This is used by the JavaScript lexer
 	NotJSON
 )
 
+// JSON and JSONC have the same syntax for strings and numbers
+func (json JSONFlavor) hasStrictValues() bool {
+	return json == JSON || json == JSONC
+}
+
 func NewLexerJSON(log logger.Log, source logger.Source, json JSONFlavor, errorSuffix string) Lexer {
 	lexer := Lexer{
 		log:               log,
@@ -1241,7 +1255,7 @@ func (lexer *Lexer) Next() {
 				lexer.Token = TMinusMinus
 			default:
 				lexer.Token = TMinus
-				if lexer.json == JSON && lexer.codePoint != '.' && (lexer.codePoint < '0' || lexer.codePoint > '9') {
+				if lexer.json.hasStrictValues() && lexer.codePoint != '.' && (lexer.codePoint < '0' || lexer.codePoint > '9') {
 					lexer.Unexpected()
 				}
 			}
@@ -1470,7 +1484,7 @@ func (lexer *Lexer) Next() {
 					lexer.step()
 
 					// Handle Windows CRLF
-					if lexer.codePoint == '\r' && lexer.json != JSON {
+					if lexer.codePoint == '\r' && !lexer.json.hasStrictValues() {
 						lexer.step()
 						if lexer.codePoint == '\n' {
 							lexer.step()
@@ -1521,7 +1535,7 @@ func (lexer *Lexer) Next() {
 					// Non-ASCII strings need the slow path
 					if lexer.codePoint >= 0x80 {
 						needsSlowPath = true
-					} else if lexer.json == JSON && lexer.codePoint < 0x20 {
+					} else if lexer.json.hasStrictValues() && lexer.codePoint < 0x20 {
 						lexer.SyntaxError()
 					}
 				}
@@ -1545,7 +1559,7 @@ func (lexer *Lexer) Next() {
 				lexer.decodedStringLiteralOrNil = copy
 			}
 
-			if quote == '\'' && (lexer.json == JSON || lexer.json == TSConfigJSON) {
+			if quote == '\'' && (lexer.json.hasStrictValues() || lexer.json == TSConfigJSON) {
 				lexer.addRangeError(lexer.Range(), "JSON strings must use double quotes")
 			}
 
@@ -2026,7 +2040,12 @@ func (lexer *Lexer) parseNumericLiteralOrDot() {
 	}
 
 	// None of these are allowed in JSON
-	if lexer.json == JSON && (first == '.' || base != 0 || underscoreCount > 0 || isMissingDigitAfterDot) {
+	if lexer.json.hasStrictValues() && (first == '.' || base != 0 || underscoreCount > 0 || isMissingDigitAfterDot) {
+		lexer.Unexpected()
+	}
+
+	// JSON5 only adds hexadecimal integers and leading or trailing decimal points
+	if lexer.json == JSON5 && ((base != 0 && base != 16) || underscoreCount > 0) {
 		lexer.Unexpected()
 	}
 }
@@ -2243,7 +2262,7 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				continue
 
 			case 'v':
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
 					return nil, false, start + i - width2
 				}
 
@@ -2252,7 +2271,12 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 
 			case '0', '1', '2', '3', '4', '5', '6', '7':
 				octalStart := i - 2
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
+					return nil, false, start + i - width2
+				}
+
+				// JSON5 only allows "\0" when it's not followed by another digit
+				if lexer.json == JSON5 && (c2 != '0' || (i < len(text) && text[i] >= '0' && text[i] <= '9')) {
 					return nil, false, start + i - width2
 				}
 
@@ -2286,13 +2310,16 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				}
 
 			case '8', '9':
+				if lexer.json == JSON5 {
+					return nil, false, start + i - width2
+				}
 				c = c2
 
 				// Forbid the invalid octal literals "\8" and "\9"
 				lexer.LegacyOctalLoc = logger.Loc{Start: int32(start + i - 2)}
 
 			case 'x':
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
 					return nil, false, start + i - width2
 				}
 
@@ -2323,7 +2350,7 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				i += width3
 
 				if c3 == '{' {
-					if lexer.json == JSON {
+					if lexer.json.hasStrictValues() {
 						return nil, false, start + i - width2
 					}
 
@@ -2387,7 +2414,7 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				c = value
 
 			case '\r':
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
 					return nil, false, start + i - width2
 				}
 
@@ -2399,7 +2426,7 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				continue
 
 			case '\n', '\u2028', '\u2029':
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
 					return nil, false, start + i - width2
 				}
 
@@ -2407,7 +2434,7 @@ func (lexer *Lexer) tryToDecodeEscapeSequences(start int, text string, reportErr
 				continue
 
 			default:
-				if lexer.json == JSON {
+				if lexer.json.hasStrictValues() {
 					switch c2 {
 					case '"', '\\', '/':
 
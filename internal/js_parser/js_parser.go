@@ -392,6 +392,7 @@ type parser struct {
 
 type globPatternImport struct {
 	assertOrWith     *ast.ImportAssertOrWith
+	importMeta       *ast.ImportMetaGlob
 	parts            []helpers.GlobPart
 	name             string
 	approximateRange logger.Range
@@ -7623,6 +7624,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		p.lexer.Next()
 		stmt := js_ast.SImport{}
 		wasOriginallyBareImport := false
+		phase := ast.EvaluationPhase
 
 		// "export import foo = bar"
 		// "import foo = bar" in a namespace
@@ -7688,6 +7690,37 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			stmt.DefaultName = &ast.LocRef{Loc: p.lexer.Loc(), Ref: p.storeNameInRef(defaultName)}
 			p.lexer.Next()
 
+			// "import defer * as ns from 'path'"
+			if defaultName.String == "defer" && p.lexer.Token == js_lexer.TAsterisk && opts.isModuleScope {
+				phase = ast.DeferPhase
+				stmt.DefaultName = nil
+				p.lexer.Next()
+				p.lexer.ExpectContextualKeyword("as")
+				stmt.NamespaceRef = p.storeNameInRef(p.lexer.Identifier)
+				starLoc := p.lexer.Loc()
+				stmt.StarNameLoc = &starLoc
+				p.lexer.Expect(js_lexer.TIdentifier)
+				p.lexer.ExpectContextualKeyword("from")
+				break syntaxBeforePath
+			}
+
+			// "import source x from 'path'"
+			if defaultName.String == "source" && p.lexer.Token == js_lexer.TIdentifier && opts.isModuleScope {
+				name := p.lexer.Identifier
+				nameLoc := p.lexer.Loc()
+				p.lexer.Next()
+
+				// "import source from 'path'"
+				if name.String == "from" && (p.lexer.Token == js_lexer.TStringLiteral || p.lexer.Token == js_lexer.TNoSubstitutionTemplateLiteral) {
+					break syntaxBeforePath
+				}
+
+				phase = ast.SourcePhase
+				stmt.DefaultName = &ast.LocRef{Loc: nameLoc, Ref: p.storeNameInRef(name)}
+				p.lexer.ExpectContextualKeyword("from")
+				break syntaxBeforePath
+			}
+
 			if p.options.ts.Parse {
 				// Skip over type-only imports
 				if defaultName.String == "type" {
@@ -7802,6 +7835,13 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			flags |= ast.WasOriginallyBareImport
 		}
 		stmt.ImportRecordIndex = p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith, flags)
+		p.importRecords[stmt.ImportRecordIndex].Phase = phase
+
+		// Source phase imports can't be transformed into anything else, but they
+		// are implemented by the bundler for WebAssembly modules
+		if phase == ast.SourcePhase && p.options.mode != config.ModeBundle {
+			p.markSyntaxFeature(compat.ImportSource, p.esmImportStatementKeyword)
+		}
 
 		if stmt.StarNameLoc != nil {
 			name := p.loadNameFromRef(stmt.NamespaceRef)
@@ -7834,10 +7874,14 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			}
 		}
 
-		// Track the items for this namespace
-		p.importItemsForNamespace[stmt.NamespaceRef] = namespaceImportItems{
-			entries:           itemRefs,
-			importRecordIndex: stmt.ImportRecordIndex,
+		// Track the items for this namespace. Property accesses off of a deferred
+		// namespace must not be turned into direct references to the imported
+		// symbols since each property access may trigger evaluation.
+		if phase != ast.DeferPhase {
+			p.importItemsForNamespace[stmt.NamespaceRef] = namespaceImportItems{
+				entries:           itemRefs,
+				importRecordIndex: stmt.ImportRecordIndex,
+			}
 		}
 
 		// Import statements anywhere in the file disable top-level const
@@ -14451,6 +14495,20 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		}), exprOut{}
 
 	case *js_ast.ECall:
+		// Recognize "import.meta.glob('./pages/*.js')". This must happen before the
+		// target is visited because visiting "import.meta" replaces it with an empty
+		// object when the output format or the target doesn't support it.
+		if p.options.mode == config.ModeBundle && !p.isControlFlowDead && e.OptionalChain == js_ast.OptionalChainNone {
+			if dot, ok := e.Target.Data.(*js_ast.EDot); ok && dot.Name == "glob" && dot.OptionalChain == js_ast.OptionalChainNone {
+				if _, ok := dot.Target.Data.(*js_ast.EImportMeta); ok {
+					if value := p.handleImportMetaGlob(e.Args); value.Data != nil {
+						value.Loc = expr.Loc
+						return value, exprOut{}
+					}
+				}
+			}
+		}
+
 		p.callTarget = e.Target.Data
 
 		// Track ".then().catch()" chains
@@ -14759,6 +14817,18 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				}
 			}
 
+			// Recognize "navigator.serviceWorker.register(new URL('./sw.js', import.meta.url))"
+			if p.options.mode == config.ModeBundle && len(e.Args) > 0 && t.Name == "register" && p.isServiceWorkerContainer(t.Target) {
+				p.markNewURLAsWorker(e.Args)
+			}
+
+			// Recognize "import.meta.resolve('./image.png')"
+			if p.options.mode == config.ModeBundle && len(e.Args) == 1 && t.Name == "resolve" && t.OptionalChain == js_ast.OptionalChainNone {
+				if _, ok := t.Target.Data.(*js_ast.EImportMeta); ok {
+					p.maybeImportRelativeURL(&e.Args[0], ast.ImportURL)
+				}
+			}
+
 			// Recognize "Object.create()" calls
 			if couldBeObjectCreate && t.Name == "create" {
 				if id, ok := t.Target.Data.(*js_ast.EIdentifier); ok {
@@ -14999,7 +15069,23 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		e.Target = p.visitExpr(e.Target)
 		p.warnAboutImportNamespaceCall(e.Target, exprKindNew)
 
+		// Recognize "new URL('./image.png', import.meta.url)". This must happen
+		// before the arguments are visited because visiting "import.meta" replaces
+		// it with an empty object when the output format or the target doesn't
+		// support it. In that case the URL of the current script is used instead.
+		argsToSkip := 0
+		if p.options.mode == config.ModeBundle && p.maybeImportNewURL(e) {
+			argsToSkip = 1
+			if base, ok := p.valueForImportMetaURL(e.Args[1]); ok {
+				e.Args[1] = base
+				argsToSkip = 2
+			}
+		}
+
 		for i, arg := range e.Args {
+			if i < argsToSkip {
+				continue
+			}
 			arg = p.visitExpr(arg)
 			if _, ok := arg.Data.(*js_ast.ESpread); ok {
 				hasSpread = true
@@ -15012,6 +15098,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			e.Args = js_ast.InlineSpreadsOfArrayLiterals(e.Args)
 		}
 
+		// Recognize "new Worker(new URL('./worker.js', import.meta.url))"
+		if p.options.mode == config.ModeBundle && len(e.Args) > 0 && p.isWorkerConstructor(e.Target) {
+			p.markNewURLAsWorker(e.Args)
+		}
+
 		p.maybeMarkKnownGlobalConstructorAsPure(e)
 
 	case *js_ast.EArrow:
@@ -15835,6 +15926,22 @@ func (p *parser) handleGlobPattern(expr js_ast.Expr, kind ast.ImportKind, prefix
 		return js_ast.Expr{}
 	}
 
+	ref := p.globPatternRef(parts, kind, prefix, assertOrWith, nil, approximateRange)
+	p.recordUsage(ref)
+	return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: ref}},
+		Args:   []js_ast.Expr{expr},
+	}}
+}
+
+func (p *parser) globPatternRef(
+	parts []helpers.GlobPart,
+	kind ast.ImportKind,
+	prefix string,
+	assertOrWith *ast.ImportAssertOrWith,
+	importMeta *ast.ImportMetaGlob,
+	approximateRange logger.Range,
+) ast.Ref {
 	ref := ast.InvalidRef
 
 	// Don't generate duplicate glob imports
@@ -15855,6 +15962,11 @@ outer:
 			}
 		}
 
+		// Check the "import.meta.glob()" options
+		if (importMeta == nil) != (globPattern.importMeta == nil) || (importMeta != nil && *importMeta != *globPattern.importMeta) {
+			continue
+		}
+
 		// Check the import assertions/attributes
 		if assertOrWith == nil {
 			if globPattern.assertOrWith != nil {
@@ -15912,6 +16024,7 @@ outer:
 
 		p.globPatternImports = append(p.globPatternImports, globPatternImport{
 			assertOrWith:     assertOrWith,
+			importMeta:       importMeta,
 			parts:            parts,
 			name:             name,
 			approximateRange: approximateRange,
@@ -15920,11 +16033,85 @@ outer:
 		})
 	}
 
+	return ref
+}
+
+// This turns "import.meta.glob('./pages/*.js', { eager: true })" into a
+// reference to an object that maps each matching path to its module. The
+// object is generated by the bundler once it knows which files match.
+func (p *parser) handleImportMetaGlob(args []js_ast.Expr) js_ast.Expr {
+	if len(args) < 1 || len(args) > 2 {
+		return js_ast.Expr{}
+	}
+
+	// Parse the glob pattern
+	str, ok := args[0].Data.(*js_ast.EString)
+	if !ok {
+		p.log.AddError(&p.tracker, logger.Range{Loc: args[0].Loc},
+			"The first argument to \"import.meta.glob()\" must be a string literal")
+		return js_ast.Expr{}
+	}
+	text := helpers.UTF16ToString(str.Value)
+	r := p.source.RangeOfString(args[0].Loc)
+	if !strings.HasPrefix(text, "./") && !strings.HasPrefix(text, "../") {
+		p.log.AddError(&p.tracker, r,
+			fmt.Sprintf("The glob pattern %q must start with \"./\" or \"../\"", text))
+		return js_ast.Expr{}
+	}
+
+	// Parse the options
+	kind := ast.ImportDynamic
+	importMeta := &ast.ImportMetaGlob{}
+	if len(args) > 1 {
+		object, ok := args[1].Data.(*js_ast.EObject)
+		if !ok {
+			p.log.AddError(&p.tracker, logger.Range{Loc: args[1].Loc},
+				"The second argument to \"import.meta.glob()\" must be an object literal")
+			return js_ast.Expr{}
+		}
+		for _, property := range object.Properties {
+			key, ok := property.Key.Data.(*js_ast.EString)
+			if !ok || property.Kind != js_ast.PropertyField || property.Flags.Has(js_ast.PropertyIsComputed) {
+				p.log.AddError(&p.tracker, logger.Range{Loc: property.Loc},
+					"Expected a property with a literal value in the options to \"import.meta.glob()\"")
+				return js_ast.Expr{}
+			}
+			keyRange := p.source.RangeOfString(property.Key.Loc)
+			switch name := helpers.UTF16ToString(key.Value); name {
+			case "eager":
+				value, ok := property.ValueOrNil.Data.(*js_ast.EBoolean)
+				if !ok {
+					p.log.AddError(&p.tracker, keyRange, "The \"eager\" option must be a boolean literal")
+					return js_ast.Expr{}
+				}
+				if value.Value {
+					kind = ast.ImportStmt
+				}
+
+			case "import", "query":
+				value, ok := property.ValueOrNil.Data.(*js_ast.EString)
+				if !ok {
+					p.log.AddError(&p.tracker, keyRange, fmt.Sprintf("The %q option must be a string literal", name))
+					return js_ast.Expr{}
+				}
+				if name == "import" {
+					importMeta.Import = helpers.UTF16ToString(value.Value)
+				} else if query := helpers.UTF16ToString(value.Value); query != "" && !strings.HasPrefix(query, "?") {
+					importMeta.Query = "?" + query
+				} else {
+					importMeta.Query = query
+				}
+
+			default:
+				p.log.AddError(&p.tracker, keyRange, fmt.Sprintf("Unsupported option %q for \"import.meta.glob()\"", name))
+				return js_ast.Expr{}
+			}
+		}
+	}
+
+	ref := p.globPatternRef(helpers.ParseGlobPattern(text), kind, "globImportMeta", nil, importMeta, r)
 	p.recordUsage(ref)
-	return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
-		Target: js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: ref}},
-		Args:   []js_ast.Expr{expr},
-	}}
+	return js_ast.Expr{Loc: args[0].Loc, Data: &js_ast.EIdentifier{Ref: ref}}
 }
 
 type globPart struct {
@@ -16100,6 +16287,119 @@ func (p *parser) warnAboutImportNamespaceCall(target js_ast.Expr, kind importNam
 	}
 }
 
+func (p *parser) isUnboundIdentifier(expr js_ast.Expr, name string) bool {
+	if id, ok := expr.Data.(*js_ast.EIdentifier); ok {
+		symbol := &p.symbols[id.Ref.InnerIndex]
+		return symbol.Kind == ast.SymbolUnbound && symbol.OriginalName == name
+	}
+	return false
+}
+
+func (p *parser) isWorkerConstructor(target js_ast.Expr) bool {
+	return p.isUnboundIdentifier(target, "Worker") || p.isUnboundIdentifier(target, "SharedWorker")
+}
+
+func (p *parser) isServiceWorkerContainer(target js_ast.Expr) bool {
+	if dot, ok := target.Data.(*js_ast.EDot); ok && dot.Name == "serviceWorker" && dot.OptionalChain == js_ast.OptionalChainNone {
+		return p.isUnboundIdentifier(dot.Target, "navigator")
+	}
+	return false
+}
+
+// This turns the relative path in "new URL('./file', import.meta.url)" into
+// an import record so that the bundler can substitute in the final path. It
+// must be called before the arguments have been visited.
+func (p *parser) maybeImportNewURL(e *js_ast.ENew) bool {
+	if len(e.Args) == 2 && p.isUnboundIdentifier(e.Target, "URL") {
+		if dot, ok := e.Args[1].Data.(*js_ast.EDot); ok && dot.Name == "url" && dot.OptionalChain == js_ast.OptionalChainNone {
+			if _, ok := dot.Target.Data.(*js_ast.EImportMeta); ok {
+				p.maybeImportRelativeURL(&e.Args[0], ast.ImportURL)
+				_, ok := e.Args[0].Data.(*js_ast.EImportPath)
+				return ok
+			}
+		}
+	}
+	return false
+}
+
+// The "new URL()" expression passed to a worker constructor was already
+// visited and turned into a reference to an asset. Now we know that it's a
+// reference to a worker instead.
+func (p *parser) markNewURLAsWorker(args []js_ast.Expr) {
+	if e, ok := args[0].Data.(*js_ast.ENew); ok && len(e.Args) == 2 && p.isUnboundIdentifier(e.Target, "URL") {
+		if path, ok := e.Args[0].Data.(*js_ast.EImportPath); ok {
+			record := &p.importRecords[path.ImportRecordIndex]
+			record.Kind = ast.ImportWorker
+			if len(args) > 1 && isModuleWorkerOptions(args[1]) {
+				record.Flags |= ast.IsModuleWorker
+			}
+		}
+	}
+}
+
+// Returns true for "{ type: 'module' }"
+func isModuleWorkerOptions(expr js_ast.Expr) bool {
+	if object, ok := expr.Data.(*js_ast.EObject); ok {
+		for _, property := range object.Properties {
+			if key, ok := property.Key.Data.(*js_ast.EString); ok && property.Kind == js_ast.PropertyField && helpers.UTF16EqualsString(key.Value, "type") {
+				value, ok := property.ValueOrNil.Data.(*js_ast.EString)
+				return ok && helpers.UTF16EqualsString(value.Value, "module")
+			}
+		}
+	}
+	return false
+}
+
+// When "import.meta" isn't available, "import.meta.url" would be undefined
+// and "new URL()" would throw. Substitute the URL of the current script
+// instead, unless the user has provided their own replacement with a define.
+func (p *parser) valueForImportMetaURL(expr js_ast.Expr) (js_ast.Expr, bool) {
+	if !p.options.unsupportedJSFeatures.Has(compat.ImportMeta) && p.options.outputFormat.KeepESMImportExportSyntax() {
+		return js_ast.Expr{}, false
+	}
+	for _, define := range p.options.defines.DotDefines["url"] {
+		if p.isDotOrIndexDefineMatch(expr, define.Parts) {
+			return js_ast.Expr{}, false
+		}
+	}
+	for _, define := range p.options.defines.DotDefines["meta"] {
+		if p.isDotOrIndexDefineMatch(expr.Data.(*js_ast.EDot).Target, define.Parts) {
+			return js_ast.Expr{}, false
+		}
+	}
+
+	// CommonJS: "require('url').pathToFileURL(__filename)"
+	if p.options.outputFormat == config.FormatCommonJS {
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+			Target: js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EDot{
+				Target: js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+					Target: p.valueToSubstituteForRequire(expr.Loc),
+					Args:   []js_ast.Expr{{Loc: expr.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("url")}}},
+				}},
+				Name:    "pathToFileURL",
+				NameLoc: expr.Loc,
+			}},
+			Args: []js_ast.Expr{{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: p.findSymbol(expr.Loc, "__filename").ref}}},
+			Kind: js_ast.TargetWasOriginallyPropertyAccess,
+		}}, true
+	}
+
+	// Everything else: the URL of the script that's currently running
+	return p.importFromRuntime(expr.Loc, "__scriptURL"), true
+}
+
+// Relative paths are resolved relative to the current file. Other URLs are
+// left alone since they don't refer to anything that we could bundle.
+func (p *parser) maybeImportRelativeURL(arg *js_ast.Expr, kind ast.ImportKind) {
+	if str, ok := arg.Data.(*js_ast.EString); ok && !p.isControlFlowDead {
+		if path := helpers.UTF16ToString(str.Value); strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+			importRecordIndex := p.addImportRecord(kind, p.source.RangeOfString(arg.Loc), path, nil, 0)
+			p.importRecordsForCurrentPart = append(p.importRecordsForCurrentPart, importRecordIndex)
+			arg.Data = &js_ast.EImportPath{ImportRecordIndex: importRecordIndex}
+		}
+	}
+}
+
 func (p *parser) maybeMarkKnownGlobalConstructorAsPure(e *js_ast.ENew) {
 	if id, ok := e.Target.Data.(*js_ast.EIdentifier); ok {
 		if symbol := p.symbols[id.Ref.InnerIndex]; symbol.Kind == ast.SymbolUnbound {
@@ -16710,7 +17010,9 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 					}
 				}
 
-				if s.StarNameLoc != nil {
+				// A deferred namespace is a separate object that is created by the
+				// linker, so it isn't bound to the namespace of the imported module
+				if s.StarNameLoc != nil && record.Phase != ast.DeferPhase {
 					p.namedImports[s.NamespaceRef] = js_ast.NamedImport{
 						AliasIsStar:       true,
 						AliasLoc:          *s.StarNameLoc,
@@ -17800,6 +18102,7 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 			Parts:       glob.parts,
 			ExportAlias: glob.name,
 			Kind:        glob.kind,
+			ImportMeta:  glob.importMeta,
 		}
 	}
 
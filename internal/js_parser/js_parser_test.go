@@ -6244,6 +6244,29 @@ func TestImportAttributes(t *testing.T) {
 		"<stdin>: WARNING: The \"assert\" keyword is not supported in the configured target environment\nNOTE: Did you mean to use \"with\" instead of \"assert\"?\n")
 }
 
+func TestImportPhase(t *testing.T) {
+	expectPrinted(t, "import defer * as ns from 'x'; ns.y", "import defer * as ns from \"x\";\nns.y;\n")
+	expectPrinted(t, "import defer * as ns from 'x' with {type: 'json'}", "import defer * as ns from \"x\" with { type: \"json\" };\n")
+	expectPrinted(t, "import defer from 'x'; defer", "import defer from \"x\";\ndefer;\n")
+	expectPrinted(t, "import defer, * as ns from 'x'", "import defer, * as ns from \"x\";\n")
+	expectParseError(t, "import defer x from 'x'", "<stdin>: ERROR: Expected \"from\" but found \"x\"\n")
+	expectParseError(t, "import defer {x} from 'x'", "<stdin>: ERROR: Expected \"from\" but found \"{\"\n")
+	expectParseError(t, "{ import defer * as ns from 'x' }", "<stdin>: ERROR: Unexpected \"defer\"\n")
+
+	expectPrinted(t, "import source x from 'x'; x", "import source x from \"x\";\nx;\n")
+	expectPrinted(t, "import source from from 'x'; from", "import source from from \"x\";\nfrom;\n")
+	expectPrinted(t, "import source from 'x'; source", "import source from \"x\";\nsource;\n")
+	expectPrinted(t, "import source, {x} from 'x'", "import source, { x } from \"x\";\n")
+	expectParseError(t, "import source * as ns from 'x'", "<stdin>: ERROR: Expected \"from\" but found \"*\"\n")
+	expectParseError(t, "import source {x} from 'x'", "<stdin>: ERROR: Expected \"from\" but found \"{\"\n")
+	expectParseError(t, "import source x, {y} from 'x'", "<stdin>: ERROR: Expected \"from\" but found \",\"\n")
+
+	expectPrintedMangle(t, "import defer * as ns from 'x'; ns.y", "import defer * as ns from \"x\";\nns.y;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportDefer, "import defer * as ns from 'x'; ns", "import * as ns from \"x\";\nns;\n")
+	expectParseErrorWithUnsupportedFeatures(t, compat.ImportSource, "import source x from 'x'",
+		"<stdin>: ERROR: Source phase imports are not available in the configured target environment\n")
+}
+
 func TestES5(t *testing.T) {
 	// Do not generate "let" when emulating block-level function declarations and targeting ES5
 	expectPrintedTarget(t, 2015, "if (1) function f() {}", "if (1) {\n  let f = function() {\n  };\n  var f = f;\n}\n")
@@ -2,6 +2,7 @@ package js_parser
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/helpers"
@@ -36,6 +37,10 @@ func (p *jsonParser) parseMaybeTrailingComma(closeToken js_lexer.T) bool {
 func (p *jsonParser) parseExpr() js_ast.Expr {
 	loc := p.lexer.Loc()
 
+	if p.isInfinityOrNaN() {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: p.parseNumber()}}
+	}
+
 	switch p.lexer.Token {
 	case js_lexer.TFalse:
 		p.lexer.Next()
@@ -55,15 +60,19 @@ func (p *jsonParser) parseExpr() js_ast.Expr {
 		return js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: value}}
 
 	case js_lexer.TNumericLiteral:
-		value := p.lexer.Number
-		p.lexer.Next()
-		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: value}}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: p.parseNumber()}}
 
 	case js_lexer.TMinus:
 		p.lexer.Next()
-		value := p.lexer.Number
-		p.lexer.Expect(js_lexer.TNumericLiteral)
-		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: -value}}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: -p.parseNumber()}}
+
+	case js_lexer.TPlus:
+		// JSON5 allows an explicit "+" sign
+		if p.options.Flavor != js_lexer.JSON5 {
+			p.lexer.Unexpected()
+		}
+		p.lexer.Next()
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: p.parseNumber()}}
 
 	case js_lexer.TOpenBracket:
 		p.lexer.Next()
@@ -117,10 +126,17 @@ func (p *jsonParser) parseExpr() js_ast.Expr {
 				}
 			}
 
-			keyString := p.lexer.StringLiteral()
+			var keyString []uint16
 			keyRange := p.lexer.Range()
+			if p.options.Flavor == js_lexer.JSON5 && p.lexer.IsIdentifierOrKeyword() {
+				// JSON5 allows unquoted keys
+				keyString = helpers.StringToUTF16(p.lexer.Identifier.String)
+				p.lexer.Next()
+			} else {
+				keyString = p.lexer.StringLiteral()
+				p.lexer.Expect(js_lexer.TStringLiteral)
+			}
 			key := js_ast.Expr{Loc: keyRange.Loc, Data: &js_ast.EString{Value: keyString}}
-			p.lexer.Expect(js_lexer.TStringLiteral)
 
 			// Warn about duplicate keys
 			if !p.suppressWarningsAboutWeirdCode {
@@ -171,6 +187,27 @@ func (p *jsonParser) parseExpr() js_ast.Expr {
 	}
 }
 
+func (p *jsonParser) isInfinityOrNaN() bool {
+	// JSON5 allows "Infinity" and "NaN"
+	return p.options.Flavor == js_lexer.JSON5 && p.lexer.Token == js_lexer.TIdentifier &&
+		(p.lexer.Identifier.String == "Infinity" || p.lexer.Identifier.String == "NaN")
+}
+
+func (p *jsonParser) parseNumber() float64 {
+	if p.isInfinityOrNaN() {
+		name := p.lexer.Identifier.String
+		p.lexer.Next()
+		if name == "NaN" {
+			return math.NaN()
+		}
+		return math.Inf(1)
+	}
+
+	value := p.lexer.Number
+	p.lexer.Expect(js_lexer.TNumericLiteral)
+	return value
+}
+
 type JSONOptions struct {
 	UnsupportedJSFeatures compat.JSFeature
 	Flavor                js_lexer.JSONFlavor
@@ -6,17 +6,23 @@ import (
 
 	"github.com/evanw/esbuild/internal/ast"
 	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/js_printer"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/test"
 )
 
 func expectParseErrorJSON(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseErrorJSONFlavor(t, js_lexer.JSON, contents, expected)
+}
+
+func expectParseErrorJSONFlavor(t *testing.T, flavor js_lexer.JSONFlavor, contents string, expected string) {
 	t.Helper()
 	t.Run(contents, func(t *testing.T) {
 		t.Helper()
 		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
-		ParseJSON(log, test.SourceForTest(contents), JSONOptions{})
+		ParseJSON(log, test.SourceForTest(contents), JSONOptions{Flavor: flavor})
 		msgs := log.Done()
 		text := ""
 		for _, msg := range msgs {
@@ -31,15 +37,20 @@ func expectParseErrorJSON(t *testing.T, contents string, expected string) {
 // bundles, not JSON bundles.
 func expectPrintedJSON(t *testing.T, contents string, expected string) {
 	t.Helper()
-	expectPrintedJSONWithWarning(t, contents, "", expected)
+	expectPrintedJSONFlavor(t, js_lexer.JSON, contents, "", expected)
 }
 
 func expectPrintedJSONWithWarning(t *testing.T, contents string, warning string, expected string) {
+	t.Helper()
+	expectPrintedJSONFlavor(t, js_lexer.JSON, contents, warning, expected)
+}
+
+func expectPrintedJSONFlavor(t *testing.T, flavor js_lexer.JSONFlavor, contents string, warning string, expected string) {
 	t.Helper()
 	t.Run(contents, func(t *testing.T) {
 		t.Helper()
 		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
-		expr, ok := ParseJSON(log, test.SourceForTest(contents), JSONOptions{})
+		expr, ok := ParseJSON(log, test.SourceForTest(contents), JSONOptions{Flavor: flavor})
 		msgs := log.Done()
 		text := ""
 		for _, msg := range msgs {
@@ -197,3 +208,39 @@ func TestJSONComments(t *testing.T) {
 	expectParseErrorJSON(t, "{}/*comment*/", "<stdin>: ERROR: JSON does not support comments\n")
 	expectParseErrorJSON(t, "{}//comment\n", "<stdin>: ERROR: JSON does not support comments\n")
 }
+
+func TestJSONC(t *testing.T) {
+	expectPrintedJSONFlavor(t, js_lexer.JSONC, "/*comment*/{\"x\":0,}//comment\n", "", "({x:0})")
+	expectPrintedJSONFlavor(t, js_lexer.JSONC, "[1,2,]", "", "[1,2]")
+	expectParseErrorJSONFlavor(t, js_lexer.JSONC, "{x:0}", "<stdin>: ERROR: Expected string in JSON but found \"x\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSONC, "'x'", "<stdin>: ERROR: JSON strings must use double quotes\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSONC, "0x1", "<stdin>: ERROR: Unexpected \"0x1\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSONC, "NaN", "<stdin>: ERROR: Unexpected \"NaN\" in JSON\n")
+}
+
+func TestJSON5(t *testing.T) {
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "/*comment*/{x:0,}//comment\n", "", "({x:0})")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "{x:0,'y':1,\"z\":2,if:3,$_:4}", "", "({x:0,y:1,z:2,if:3,$_:4})")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "'x\"y'", "", "'x\"y'")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "'a\\\nb'", "", "\"ab\"")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "\"\\x41\\v\"", "", "\"A\\v\"")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "0x1F", "", "31")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "-0xA", "", "-10")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "+1", "", "1")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, ".5", "", ".5")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "5.", "", "5")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "[Infinity,-Infinity,+Infinity,NaN,-NaN]", "", "[Infinity,-Infinity,Infinity,NaN,NaN]")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "{1:0}", "<stdin>: ERROR: Expected string in JSON but found \"1\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "undefined", "<stdin>: ERROR: Unexpected \"undefined\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "-undefined", "<stdin>: ERROR: Expected number in JSON but found \"undefined\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "0b1", "<stdin>: ERROR: Unexpected \"0b1\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "0o1", "<stdin>: ERROR: Unexpected \"0o1\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "1_2", "<stdin>: ERROR: Unexpected \"1_2\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "0n", "<stdin>: ERROR: Unexpected \"0n\" in JSON\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "[1,,2]", "<stdin>: ERROR: Unexpected \",\" in JSON\n")
+	expectPrintedJSONFlavor(t, js_lexer.JSON5, "'\\0'", "", "\"\\0\"")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "'\\1'", "<stdin>: ERROR: Syntax error \"1\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "'\\00'", "<stdin>: ERROR: Syntax error \"0\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "'\\08'", "<stdin>: ERROR: Syntax error \"0\"\n")
+	expectParseErrorJSONFlavor(t, js_lexer.JSON5, "'\\9'", "<stdin>: ERROR: Syntax error \"9\"\n")
+}
@@ -255,6 +255,10 @@ func (p *printer) printJSXTag(tagOrNil js_ast.Expr) {
 		p.print(helpers.UTF16ToString(e.Value))
 
 	case *js_ast.EIdentifier:
+		if p.crossChunkImportAlias(e.Ref) != nil {
+			p.printExpr(tagOrNil, js_ast.LLowest, 0)
+			break
+		}
 		name := p.renamer.NameForSymbol(e.Ref)
 		p.addSourceMappingForName(tagOrNil.Loc, name, e.Ref)
 		p.print(name)
@@ -272,6 +276,60 @@ func (p *printer) printJSXTag(tagOrNil js_ast.Expr) {
 	}
 }
 
+// When code splitting with a format that doesn't have "import" statements,
+// symbols from other chunks are accessed as properties of the namespace
+// object for the chunk they were imported from
+func (p *printer) crossChunkImportAlias(ref ast.Ref) *ast.NamespaceAlias {
+	if p.options.CrossChunkImportAliases != nil {
+		if alias, ok := p.options.CrossChunkImportAliases[ast.FollowSymbols(p.symbols, ref)]; ok {
+			return &alias
+		}
+	}
+	return nil
+}
+
+func (p *printer) namespaceAliasForSymbol(ref ast.Ref) *ast.NamespaceAlias {
+	if symbol := p.symbols.Get(ref); symbol.NamespaceAlias != nil {
+		return symbol.NamespaceAlias
+	}
+	return p.crossChunkImportAlias(ref)
+}
+
+func (p *printer) printNamespaceAlias(loc logger.Loc, ref ast.Ref, namespaceAlias ast.NamespaceAlias, preferQuotedKey bool, wrap bool) {
+	if wrap {
+		p.print("(0,")
+		p.printSpace()
+	}
+	p.printSpaceBeforeIdentifier()
+	p.addSourceMapping(loc)
+	p.printIdentifier(p.renamer.NameForSymbol(namespaceAlias.NamespaceRef))
+	alias := namespaceAlias.Alias
+	if !preferQuotedKey && p.canPrintIdentifier(alias) {
+		p.print(".")
+		p.addSourceMappingForName(loc, alias, ref)
+		p.printIdentifier(alias)
+	} else {
+		p.print("[")
+		p.addSourceMappingForName(loc, alias, ref)
+		p.printQuotedUTF8(alias, printQuotedAllowBacktick)
+		p.print("]")
+	}
+	if wrap {
+		p.print(")")
+	}
+}
+
+// Runtime helpers may have been placed in another chunk
+func (p *printer) printRuntimeHelper(ref ast.Ref) {
+	if namespaceAlias := p.crossChunkImportAlias(ref); namespaceAlias != nil {
+		p.printIdentifier(p.renamer.NameForSymbol(namespaceAlias.NamespaceRef))
+		p.print(".")
+		p.printIdentifier(namespaceAlias.Alias)
+		return
+	}
+	p.printIdentifier(p.renamer.NameForSymbol(ref))
+}
+
 type printer struct {
 	symbols                ast.SymbolMap
 	astHelpers             js_ast.HelperContext
@@ -971,7 +1029,7 @@ func (p *printer) printDecorators(decorators []js_ast.Decorator, defaultMode pri
 					break
 				}
 
-				if symbol.NamespaceAlias != nil && isCallTarget && e.WasOriginallyIdentifier {
+				if isCallTarget && e.WasOriginallyIdentifier && p.namespaceAliasForSymbol(ref) != nil {
 					// "@((0, import_ns.fn)())"
 					break
 				}
@@ -1213,7 +1271,7 @@ func (p *printer) printProperty(property js_ast.Property) {
 			if !p.options.UnsupportedFeatures.Has(compat.ObjectExtensions) && property.ValueOrNil.Data != nil && !p.willPrintExprCommentsAtLoc(property.ValueOrNil.Loc) {
 				switch e := property.ValueOrNil.Data.(type) {
 				case *js_ast.EIdentifier:
-					if name == p.renamer.NameForSymbol(e.Ref) {
+					if name == p.renamer.NameForSymbol(e.Ref) && p.crossChunkImportAlias(e.Ref) == nil {
 						if property.InitializerOrNil.Data != nil {
 							p.printSpace()
 							p.print("=")
@@ -1226,7 +1284,7 @@ func (p *printer) printProperty(property js_ast.Property) {
 				case *js_ast.EImportIdentifier:
 					// Make sure we're not using a property access instead of an identifier
 					ref := ast.FollowSymbols(p.symbols, e.Ref)
-					if symbol := p.symbols.Get(ref); symbol.NamespaceAlias == nil && name == p.renamer.NameForSymbol(ref) &&
+					if p.namespaceAliasForSymbol(ref) == nil && name == p.renamer.NameForSymbol(ref) &&
 						p.options.ConstValues[ref].Kind == js_ast.ConstValueNone {
 						if property.InitializerOrNil.Data != nil {
 							p.printSpace()
@@ -1251,7 +1309,7 @@ func (p *printer) printProperty(property js_ast.Property) {
 			if !p.options.UnsupportedFeatures.Has(compat.ObjectExtensions) && property.ValueOrNil.Data != nil && !p.willPrintExprCommentsAtLoc(property.ValueOrNil.Loc) {
 				switch e := property.ValueOrNil.Data.(type) {
 				case *js_ast.EIdentifier:
-					if canUseShorthandProperty(key.Value, p.renamer.NameForSymbol(e.Ref), property.Flags) {
+					if canUseShorthandProperty(key.Value, p.renamer.NameForSymbol(e.Ref), property.Flags) && p.crossChunkImportAlias(e.Ref) == nil {
 						if p.options.AddSourceMappings {
 							p.addSourceMappingForName(property.Key.Loc, helpers.UTF16ToString(key.Value), e.Ref)
 						}
@@ -1268,7 +1326,7 @@ func (p *printer) printProperty(property js_ast.Property) {
 				case *js_ast.EImportIdentifier:
 					// Make sure we're not using a property access instead of an identifier
 					ref := ast.FollowSymbols(p.symbols, e.Ref)
-					if symbol := p.symbols.Get(ref); symbol.NamespaceAlias == nil && canUseShorthandProperty(key.Value, p.renamer.NameForSymbol(ref), property.Flags) &&
+					if p.namespaceAliasForSymbol(ref) == nil && canUseShorthandProperty(key.Value, p.renamer.NameForSymbol(ref), property.Flags) &&
 						p.options.ConstValues[ref].Kind == js_ast.ConstValueNone {
 						if p.options.AddSourceMappings {
 							p.addSourceMappingForName(property.Key.Loc, helpers.UTF16ToString(key.Value), ref)
@@ -1390,6 +1448,22 @@ func (p *printer) printQuotedUTF16(data []uint16, flags printQuotedFlags) {
 	p.print(c)
 }
 
+func (p *printer) printPreloadDeps(deps []string) {
+	p.print(",")
+	p.printSpace()
+	p.print("[")
+	for i, dep := range deps {
+		if i > 0 {
+			p.print(",")
+			p.printSpace()
+		}
+		p.printQuotedUTF8(dep, printQuotedNoWrap)
+	}
+	p.print("],")
+	p.printSpace()
+	p.print("import.meta.url)")
+}
+
 func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_ast.L, flags printExprFlags, closeParenLoc logger.Loc) {
 	record := &p.importRecords[importRecordIndex]
 
@@ -1406,14 +1480,14 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 			wrapWithToESM := record.Flags.Has(ast.WrapWithToESM)
 			if wrapWithToESM {
 				p.printSpaceBeforeIdentifier()
-				p.printIdentifier(p.renamer.NameForSymbol(p.options.ToESMRef))
+				p.printRuntimeHelper(p.options.ToESMRef)
 				p.print("(")
 			}
 
 			// Potentially substitute our own "__require" stub for "require"
 			p.printSpaceBeforeIdentifier()
 			if record.Flags.Has(ast.CallRuntimeRequire) {
-				p.printIdentifier(p.renamer.NameForSymbol(p.options.RuntimeRequireRef))
+				p.printRuntimeHelper(p.options.RuntimeRequireRef)
 			} else {
 				p.print("require")
 			}
@@ -1452,7 +1526,54 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 
 		// External "import()"
 		kind := ast.ImportDynamic
-		if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
+		isChunk := record.Flags.Has(ast.ContainsUniqueKey)
+		if isChunk && p.options.OutputFormat == config.FormatIIFE {
+			// Other chunks are loaded through the chunk loader:
+			//
+			//   "__load(path)"
+			//
+			p.printSpaceBeforeIdentifier()
+			p.printRuntimeHelper(p.options.ChunkLoaderRef)
+			p.print("(")
+			p.printExprCommentsAtLoc(record.Range.Loc)
+			p.printPath(importRecordIndex, kind)
+			if closeParenLoc.Start > record.Range.Loc.Start {
+				p.addSourceMapping(closeParenLoc)
+			}
+			p.print(")")
+			return
+		}
+		useImportCall := !p.options.UnsupportedFeatures.Has(compat.DynamicImport) &&
+			!(isChunk && p.options.OutputFormat == config.FormatCommonJS)
+		if useImportCall {
+			// Preload the dependencies of this chunk while it's being fetched:
+			//
+			//   "__preload(() => import(path), [deps], import.meta.url)"
+			//
+			if deps := p.options.DynamicImportPreloads[record.Path.Text]; record.Flags.Has(ast.PreloadDependencies) && len(deps) > 0 {
+				p.printSpaceBeforeIdentifier()
+				p.printRuntimeHelper(p.options.PreloadRef)
+				p.print("(")
+				if p.options.UnsupportedFeatures.Has(compat.Arrow) {
+					p.print("function()")
+					p.printSpace()
+					p.print("{")
+					p.printSpace()
+					p.print("return ")
+					defer func() {
+						p.print(";")
+						p.printSpace()
+						p.print("}")
+						p.printPreloadDeps(deps)
+					}()
+				} else {
+					p.print("()")
+					p.printSpace()
+					p.print("=>")
+					p.printSpace()
+					defer p.printPreloadDeps(deps)
+				}
+			}
 			p.printSpaceBeforeIdentifier()
 			p.print("import(")
 		} else {
@@ -1465,7 +1586,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 			// Wrap this with a call to "__toESM()" if this is a CommonJS file
 			if record.Flags.Has(ast.WrapWithToESM) {
 				p.printSpaceBeforeIdentifier()
-				p.printIdentifier(p.renamer.NameForSymbol(p.options.ToESMRef))
+				p.printRuntimeHelper(p.options.ToESMRef)
 				p.print("(")
 				defer func() {
 					if p.moduleType.IsESM() {
@@ -1480,7 +1601,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 			// Potentially substitute our own "__require" stub for "require"
 			p.printSpaceBeforeIdentifier()
 			if record.Flags.Has(ast.CallRuntimeRequire) {
-				p.printIdentifier(p.renamer.NameForSymbol(p.options.RuntimeRequireRef))
+				p.printRuntimeHelper(p.options.RuntimeRequireRef)
 			} else {
 				p.print("require")
 			}
@@ -1489,8 +1610,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 		}
 		isMultiLine := p.willPrintExprCommentsAtLoc(record.Range.Loc) ||
 			p.willPrintExprCommentsAtLoc(closeParenLoc) ||
-			(record.AssertOrWith != nil &&
-				!p.options.UnsupportedFeatures.Has(compat.DynamicImport) &&
+			(record.AssertOrWith != nil && useImportCall &&
 				(!p.options.UnsupportedFeatures.Has(compat.ImportAssertions) ||
 					!p.options.UnsupportedFeatures.Has(compat.ImportAttributes)) &&
 				p.willPrintExprCommentsAtLoc(record.AssertOrWith.OuterOpenBraceLoc))
@@ -1501,7 +1621,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 		}
 		p.printExprCommentsAtLoc(record.Range.Loc)
 		p.printPath(importRecordIndex, kind)
-		if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
+		if useImportCall {
 			p.printImportCallAssertOrWith(record.AssertOrWith, isMultiLine)
 		}
 		if isMultiLine {
@@ -1556,7 +1676,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 	wrapWithToESM := record.Flags.Has(ast.WrapWithToESM)
 	if wrapWithToESM {
 		p.printSpaceBeforeIdentifier()
-		p.printIdentifier(p.renamer.NameForSymbol(p.options.ToESMRef))
+		p.printRuntimeHelper(p.options.ToESMRef)
 		p.print("(")
 	}
 
@@ -1573,7 +1693,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 		// Wrap this with a call to "__toCommonJS()" if this is an ESM file
 		wrapWithTpCJS := record.Flags.Has(ast.WrapWithToCJS)
 		if wrapWithTpCJS {
-			p.printIdentifier(p.renamer.NameForSymbol(p.options.ToCommonJSRef))
+			p.printRuntimeHelper(p.options.ToCommonJSRef)
 			p.print("(")
 		}
 		p.printIdentifier(p.renamer.NameForSymbol(meta.ExportsRef))
@@ -2468,6 +2588,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			p.print(")")
 		}
 
+	case *js_ast.EImportPath:
+		p.printPath(e.ImportRecordIndex, p.importRecords[e.ImportRecordIndex].Kind)
+
 	case *js_ast.EImportString:
 		p.addSourceMapping(expr.Loc)
 		p.printRequireOrImportExpr(e.ImportRecordIndex, level, flags, e.CloseParenLoc)
@@ -3053,6 +3176,14 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		p.printNumber(e.Value, level)
 
 	case *js_ast.EIdentifier:
+		// Only generated code (e.g. runtime helpers) uses identifiers instead of
+		// imports to reference symbols from other files, so the value of "this"
+		// doesn't matter for calls and there's no need for "(0, import_chunk.fn)"
+		if namespaceAlias := p.crossChunkImportAlias(e.Ref); namespaceAlias != nil {
+			p.printNamespaceAlias(expr.Loc, e.Ref, *namespaceAlias, false, false)
+			break
+		}
+
 		name := p.renamer.NameForSymbol(e.Ref)
 		wrap := len(p.js) == p.forOfInitStart && (name == "let" ||
 			((flags&isFollowedByOf) != 0 && (flags&isInsideForAwait) == 0 && name == "async"))
@@ -3076,29 +3207,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 
 		if symbol.ImportItemStatus == ast.ImportItemMissing {
 			p.printUndefined(expr.Loc, level)
-		} else if symbol.NamespaceAlias != nil {
+		} else if namespaceAlias := p.namespaceAliasForSymbol(ref); namespaceAlias != nil {
 			wrap := p.callTarget == e && e.WasOriginallyIdentifier
-			if wrap {
-				p.print("(0,")
-				p.printSpace()
-			}
-			p.printSpaceBeforeIdentifier()
-			p.addSourceMapping(expr.Loc)
-			p.printIdentifier(p.renamer.NameForSymbol(symbol.NamespaceAlias.NamespaceRef))
-			alias := symbol.NamespaceAlias.Alias
-			if !e.PreferQuotedKey && p.canPrintIdentifier(alias) {
-				p.print(".")
-				p.addSourceMappingForName(expr.Loc, alias, ref)
-				p.printIdentifier(alias)
-			} else {
-				p.print("[")
-				p.addSourceMappingForName(expr.Loc, alias, ref)
-				p.printQuotedUTF8(alias, printQuotedAllowBacktick)
-				p.print("]")
-			}
-			if wrap {
-				p.print(")")
-			}
+			p.printNamespaceAlias(expr.Loc, ref, *namespaceAlias, e.PreferQuotedKey, wrap)
 		} else if value := p.options.ConstValues[ref]; value.Kind != js_ast.ConstValueNone {
 			// Handle inlined constants
 			p.printExpr(js_ast.ConstValueToExpr(expr.Loc, value), level, flags)
@@ -4622,6 +4733,21 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 		p.print("import")
 		p.printSpace()
 
+		switch p.importRecords[s.ImportRecordIndex].Phase {
+		case ast.DeferPhase:
+			// Deferred evaluation only affects timing, so it's ok to drop it
+			if !p.options.UnsupportedFeatures.Has(compat.ImportDefer) {
+				p.printSpaceBeforeIdentifier()
+				p.print("defer")
+				p.printSpace()
+			}
+
+		case ast.SourcePhase:
+			p.printSpaceBeforeIdentifier()
+			p.print("source")
+			p.printSpace()
+		}
+
 		if s.DefaultName != nil {
 			p.printSpaceBeforeIdentifier()
 			name := p.renamer.NameForSymbol(s.DefaultName.Ref)
@@ -4827,9 +4953,22 @@ type Options struct {
 	// us do binary search on to figure out what line a given AST node came from
 	LineOffsetTables []sourcemap.LineOffsetTable
 
+	// This maps the unique key of a chunk to the unique keys of the other chunks
+	// that should be preloaded when that chunk is loaded with "import()"
+	DynamicImportPreloads map[string][]string
+
+	// When code splitting with the "cjs" or "iife" formats, symbols imported
+	// from other chunks map to a property on that chunk's namespace object
+	CrossChunkImportAliases map[ast.Ref]ast.NamespaceAlias
+
+	// When code splitting with the "iife" format, "import()" expressions that
+	// refer to other chunks call this function instead
+	ChunkLoaderRef ast.Ref
+
 	ToCommonJSRef       ast.Ref
 	ToESMRef            ast.Ref
 	RuntimeRequireRef   ast.Ref
+	PreloadRef          ast.Ref
 	UnsupportedFeatures compat.JSFeature
 	Indent              int
 	LineLimit           int
@@ -733,6 +733,11 @@ func TestImport(t *testing.T) {
 	expectPrinted(t, "import(/* webpackFoo: 1 */ 'path', { type: 'module' } /* webpackBar:2 */ );", "import(\n  /* webpackFoo: 1 */\n  \"path\",\n  { type: \"module\" }\n  /* webpackBar:2 */\n);\n")
 	expectPrinted(t, "import(new URL('path', /* webpackFoo: these can go anywhere */ import.meta.url))",
 		"import(new URL(\n  \"path\",\n  /* webpackFoo: these can go anywhere */\n  import.meta.url\n));\n")
+
+	expectPrinted(t, "import defer * as ns from 'path'; ns.x", "import defer * as ns from \"path\";\nns.x;\n")
+	expectPrinted(t, "import source x from 'path'; x", "import source x from \"path\";\nx;\n")
+	expectPrintedMinify(t, "import defer * as ns from 'path'; ns.x", "import defer*as ns from\"path\";ns.x;")
+	expectPrintedMinify(t, "import source x from 'path'; x", "import source x from\"path\";x;")
 }
 
 func TestExportDefault(t *testing.T) {
@@ -32,6 +32,7 @@ import (
 	"github.com/evanw/esbuild/internal/helpers"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/js_parser"
 	"github.com/evanw/esbuild/internal/js_printer"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/renamer"
@@ -71,6 +72,13 @@ type linkerContext struct {
 	// We may need to refer to the "__esm" and/or "__commonJS" runtime symbols
 	cjsRuntimeRef ast.Ref
 	esmRuntimeRef ast.Ref
+
+	// These are computed lazily since they're only needed for some options
+	isFileShared          []bool
+	mayNeedToPreloadCache map[uint32]bool
+
+	// This is only used when code splitting with the "iife" format
+	chunkLoader string
 }
 
 type partRange struct {
@@ -112,7 +120,7 @@ type chunkInfo struct {
 	waitForIsolatedHash func() []byte
 
 	// Other fields relating to the output file for this chunk
-	jsonMetadataChunkCallback func(finalOutputSize int) helpers.Joiner
+	jsonMetadataChunkCallback func(jsonMetadataForContents string) helpers.Joiner
 	outputSourceMap           sourcemap.SourceMapPieces
 
 	// When this chunk is initially generated in isolation, the output pieces
@@ -126,6 +134,10 @@ type chunkInfo struct {
 	isEntryPoint  bool
 
 	isExecutable bool
+
+	// This is set for chunks created from the "ManualChunks" option. It's used
+	// as the "[name]" placeholder for the chunk's output path.
+	manualChunkName string
 }
 
 type chunkImport struct {
@@ -139,6 +151,7 @@ const (
 	outputPieceNone outputPieceIndexKind = iota
 	outputPieceAssetIndex
 	outputPieceChunkIndex
+	outputPieceWorkerIndex
 )
 
 // This is a chunk of source code followed by a reference to another chunk. For
@@ -182,6 +195,22 @@ type chunkReprJS struct {
 	importsFromOtherChunks map[uint32]crossChunkImportItemArray
 	crossChunkPrefixStmts  []js_ast.Stmt
 	crossChunkSuffixStmts  []js_ast.Stmt
+	crossChunkExportStmts  []js_ast.Stmt
+
+	// For code splitting with formats that don't have "import" statements.
+	// Imported symbols are printed as property accesses on generated namespace
+	// symbols, and the IIFE format also wraps each chunk in a function that is
+	// called by the chunk loader.
+	crossChunkImportAliases map[ast.Ref]ast.NamespaceAlias
+	crossChunkBindingRefs   []ast.Ref
+	chunkDependencyRefs     []ast.Ref
+	chunkExportsRef         ast.Ref
+	chunkLoaderRef          ast.Ref
+
+	// This maps the unique key of each chunk that this chunk loads using
+	// "import()" to the unique keys of the chunks to preload alongside it. It's
+	// only populated when module preloading is enabled.
+	dynamicImportPreloads map[string][]string
 
 	cssChunkIndex uint32
 	hasCSSChunk   bool
@@ -259,6 +288,44 @@ func Link(
 	}
 	timer.End("Clone linker graph")
 
+	// When code splitting is disabled, workers are linked separately before the
+	// files that reference them. Turn references to workers into references to
+	// their output files instead of bundling the worker code into this file.
+	if !c.options.CodeSplitting {
+		for _, sourceIndex := range c.graph.ReachableFiles {
+			if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+				for i := range repr.AST.ImportRecords {
+					if record := &repr.AST.ImportRecords[i]; record.Kind == ast.ImportWorker && record.SourceIndex.IsValid() {
+						if otherSourceIndex := record.SourceIndex.GetIndex(); otherSourceIndex == entryPoints[0].SourceIndex {
+							// A worker that spawns itself refers to the only chunk in this link
+							record.Path.Text = fmt.Sprintf("%sC%08d", c.uniqueKeyPrefix, 0)
+							record.Flags |= ast.ShouldNotBeExternalInMetafile | ast.ContainsUniqueKey
+						} else if c.options.WorkerOutputPaths[otherSourceIndex] != "" {
+							record.Path.Text = fmt.Sprintf("%sW%08d", c.uniqueKeyPrefix, otherSourceIndex)
+							record.Flags |= ast.ShouldNotBeExternalInMetafile | ast.ContainsUniqueKey
+						}
+						record.SourceIndex = ast.Index32{}
+					}
+				}
+			}
+		}
+	} else if c.options.OutputFormat == config.FormatESModule {
+		// Worker chunks may import code shared with other chunks, which only works
+		// if the worker is a module worker
+		for _, sourceIndex := range c.graph.ReachableFiles {
+			if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+				for _, record := range repr.AST.ImportRecords {
+					if record.Kind == ast.ImportWorker && record.SourceIndex.IsValid() && !record.Flags.Has(ast.IsModuleWorker) {
+						c.log.AddIDWithNotes(logger.MsgID_None, logger.Warning, c.graph.Files[sourceIndex].LineColumnTracker(), record.Range,
+							"This worker should be created with \"{ type: 'module' }\" because code splitting generates workers that are ES modules",
+							[]logger.MsgData{{Text: "Workers that aren't module workers can't use \"import\" statements, so the worker will fail to load " +
+								"if it shares code with another chunk."}})
+					}
+				}
+			}
+		}
+	}
+
 	// Use a smaller version of these functions if we don't need profiler names
 	runtimeRepr := c.graph.Files[runtime.SourceIndex].InputFile.Repr.(*graph.JSRepr)
 	if c.options.ProfilerNames {
@@ -269,8 +336,9 @@ func Link(
 		c.esmRuntimeRef = runtimeRepr.AST.NamedExports["__esmMin"].Ref
 	}
 
+	// Note: This includes entry points for dynamic imports when code splitting
 	var additionalFiles []graph.OutputFile
-	for _, entryPoint := range entryPoints {
+	for _, entryPoint := range c.graph.EntryPoints() {
 		file := &c.graph.Files[entryPoint.SourceIndex].InputFile
 		switch repr := file.Repr.(type) {
 		case *graph.JSRepr:
@@ -284,10 +352,11 @@ func Link(
 
 			// Entry points with ES6 exports must generate an exports object when
 			// targeting non-ES6 formats. Note that the IIFE format only needs this
-			// when the global name is present, since that's the only way the exports
-			// can actually be observed externally.
+			// when the global name is present or when code splitting is enabled,
+			// since that's the only way the exports can actually be observed
+			// externally.
 			if repr.AST.ExportKeyword.Len > 0 && (options.OutputFormat == config.FormatCommonJS ||
-				(options.OutputFormat == config.FormatIIFE && len(options.GlobalName) > 0)) {
+				(options.OutputFormat == config.FormatIIFE && (len(options.GlobalName) > 0 || options.CodeSplitting))) {
 				repr.AST.UsesExportsRef = true
 				repr.Meta.ForceIncludeExportsForEntryPoint = true
 			}
@@ -297,7 +366,9 @@ func Link(
 			// here. Other uses of the copy loader will automatically be included
 			// along with the corresponding bundled chunk but that doesn't happen
 			// for entry points.
-			additionalFiles = append(additionalFiles, file.AdditionalFiles...)
+			if c.graph.Files[entryPoint.SourceIndex].IsUserSpecifiedEntryPoint() {
+				additionalFiles = append(additionalFiles, file.AdditionalFiles...)
+			}
 		}
 	}
 
@@ -328,6 +399,9 @@ func Link(
 
 	c.computeChunks()
 	c.computeCrossChunkDependencies()
+	if c.canPreloadDynamicImports() {
+		c.computeDynamicImportPreloads()
+	}
 
 	// Merge mangled properties before chunks are generated since the names must
 	// be consistent across all chunks, or the generated code will break
@@ -491,8 +565,22 @@ func (c *linkerContext) mangleLocalCSS(usedLocalNames map[string]bool) {
 	if c.options.MinifyIdentifiers {
 		minifier := ast.DefaultNameMinifierCSS.ShuffleByCharFreq(freq)
 		nextName := 0
+		nextCustomPropertyName := 0
 
 		for _, symbolCount := range sorted {
+			// Custom property names must keep their "--" prefix. They don't share
+			// a namespace with other names so they are numbered separately.
+			if symbol := c.graph.Symbols.Get(symbolCount.Ref); strings.HasPrefix(symbol.OriginalName, "--") {
+				name := "--" + minifier.NumberToMinifiedName(nextCustomPropertyName)
+				for globalNames[name] || usedLocalNames[name] {
+					nextCustomPropertyName++
+					name = "--" + minifier.NumberToMinifiedName(nextCustomPropertyName)
+				}
+				mangledProps[symbolCount.Ref] = name
+				usedLocalNames[name] = true
+				continue
+			}
+
 			name := minifier.NumberToMinifiedName(nextName)
 			for globalNames[name] || usedLocalNames[name] {
 				nextName++
@@ -508,7 +596,13 @@ func (c *linkerContext) mangleLocalCSS(usedLocalNames map[string]bool) {
 
 		for _, symbolCount := range sorted {
 			symbol := c.graph.Symbols.Get(symbolCount.Ref)
-			name := fmt.Sprintf("%s_%s", c.graph.Files[symbolCount.Ref.SourceIndex].InputFile.Source.IdentifierName, symbol.OriginalName)
+			var name string
+			if strings.HasPrefix(symbol.OriginalName, "--") {
+				// Custom property names must keep their "--" prefix
+				name = fmt.Sprintf("--%s_%s", c.graph.Files[symbolCount.Ref.SourceIndex].InputFile.Source.IdentifierName, symbol.OriginalName[2:])
+			} else {
+				name = fmt.Sprintf("%s_%s", c.graph.Files[symbolCount.Ref.SourceIndex].InputFile.Source.IdentifierName, symbol.OriginalName)
+			}
 
 			// If the name is already in use, generate a new name by appending a number
 			if globalNames[name] || usedLocalNames[name] {
@@ -609,6 +703,9 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 	// in general uncomputable at this point because paths have hashes that
 	// include information about chunk dependencies, and chunk dependencies
 	// can be cyclic due to dynamic imports).
+	if c.options.OutputFormat == config.FormatIIFE && c.options.CodeSplitting {
+		c.chunkLoader = c.generateChunkLoader()
+	}
 	generateWaitGroup := sync.WaitGroup{}
 	generateWaitGroup.Add(len(c.chunks))
 	for chunkIndex := range c.chunks {
@@ -701,12 +798,8 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 				}
 
 				// Write the external legal comments file
-				outputFiles = append(outputFiles, graph.OutputFile{
-					AbsPath:  c.fs.Join(c.options.AbsOutputDir, finalRelPathForLegalComments),
-					Contents: chunk.externalLegalComments,
-					JSONMetadataChunk: fmt.Sprintf(
-						"{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      \"bytes\": %d\n    }", len(chunk.externalLegalComments)),
-				})
+				outputFiles = append(outputFiles, c.generateOutputFileWithoutInputs(
+					c.fs.Join(c.options.AbsOutputDir, finalRelPathForLegalComments), chunk.externalLegalComments))
 			}
 
 			// Generate the optional source map for this chunk
@@ -738,35 +831,47 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 				// Potentially write the external source map file
 				switch c.options.SourceMap {
 				case config.SourceMapLinkedWithComment, config.SourceMapInlineAndExternal, config.SourceMapExternalWithoutComment:
-					outputFiles = append(outputFiles, graph.OutputFile{
-						AbsPath:  c.fs.Join(c.options.AbsOutputDir, finalRelPathForSourceMap),
-						Contents: outputSourceMap,
-						JSONMetadataChunk: fmt.Sprintf(
-							"{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      \"bytes\": %d\n    }", len(outputSourceMap)),
-					})
+					outputFiles = append(outputFiles, c.generateOutputFileWithoutInputs(
+						c.fs.Join(c.options.AbsOutputDir, finalRelPathForSourceMap), outputSourceMap))
 				}
 			}
 
 			// Finalize the output contents
-			outputContents := outputContentsJoiner.Done()
+			outputFile := graph.OutputFile{
+				AbsPath:      c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
+				Contents:     outputContentsJoiner.Done(),
+				IsExecutable: chunk.isExecutable,
+			}
+			bundler.ComputeOutputFileHashes(&outputFile, c.options)
 
 			// Path substitution for the JSON metadata
-			var jsonMetadataChunk string
 			if c.options.NeedsMetafile {
-				jsonMetadataChunkPieces := c.breakJoinerIntoPieces(chunk.jsonMetadataChunkCallback(len(outputContents)))
+				jsonMetadataForContents := bundler.JSONMetadataForContents(&outputFile, c.options.ASCIIOnly)
+				jsonMetadataChunkPieces := c.breakJoinerIntoPieces(chunk.jsonMetadataChunkCallback(jsonMetadataForContents))
 				jsonMetadataChunkBytes, _ := c.substituteFinalPaths(jsonMetadataChunkPieces, func(finalRelPathForImport string) string {
 					return resolver.PrettyPath(c.fs, logger.Path{Text: c.fs.Join(c.options.AbsOutputDir, finalRelPathForImport), Namespace: "file"})
 				})
-				jsonMetadataChunk = string(jsonMetadataChunkBytes.Done())
+				outputFile.JSONMetadataChunk = string(jsonMetadataChunkBytes.Done())
+			}
+
+			// Describe how to load this chunk if it's an entry point
+			if c.options.NeedsManifest && chunk.isEntryPoint {
+				outputFile.ManifestEntry = c.generateManifestEntry(chunkIndex)
+			}
+
+			// Remember which entry point this is for so workers can be found later
+			if _, ok := chunk.chunkRepr.(*chunkReprJS); ok && chunk.isEntryPoint {
+				outputFile.EntryPointSourceIndex = ast.MakeIndex32(chunk.sourceIndex)
 			}
 
 			// Generate the output file for this chunk
-			outputFiles = append(outputFiles, graph.OutputFile{
-				AbsPath:           c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
-				Contents:          outputContents,
-				JSONMetadataChunk: jsonMetadataChunk,
-				IsExecutable:      chunk.isExecutable,
-			})
+			outputFiles = append(outputFiles, outputFile)
+
+			// Send the output files for this chunk out now if they are being
+			// streamed so their contents don't need to be kept around
+			for i := range outputFiles {
+				outputFiles[i].Stream(c.options.StreamOutputFile)
+			}
 
 			results[chunkIndex] = outputFiles
 			resultsWaitGroup.Done()
@@ -782,12 +887,79 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 	}
 	outputFiles := make([]graph.OutputFile, 0, outputFilesLen)
 	outputFiles = append(outputFiles, additionalFiles...)
+	for i := range outputFiles {
+		outputFiles[i].Stream(c.options.StreamOutputFile)
+	}
 	for _, result := range results {
 		outputFiles = append(outputFiles, result...)
 	}
 	return outputFiles
 }
 
+// This is for output files such as source maps that don't correspond to any
+// input files (at least as far as the metafile is concerned)
+func (c *linkerContext) generateOutputFileWithoutInputs(absPath string, contents []byte) graph.OutputFile {
+	outputFile := graph.OutputFile{
+		AbsPath:  absPath,
+		Contents: contents,
+	}
+	bundler.ComputeOutputFileHashes(&outputFile, c.options)
+	if c.options.NeedsMetafile {
+		outputFile.JSONMetadataChunk = "{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      " +
+			bundler.JSONMetadataForContents(&outputFile, c.options.ASCIIOnly)
+	}
+	return outputFile
+}
+
+// This must be called after the final paths of all chunks have been computed
+func (c *linkerContext) generateManifestEntry(chunkIndex int) *graph.ManifestEntry {
+	chunk := &c.chunks[chunkIndex]
+
+	// The CSS chunk for a JS entry point is described by the JS chunk's entry
+	if _, ok := chunk.chunkRepr.(*chunkReprCSS); ok {
+		if _, ok := c.graph.Files[chunk.sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			return nil
+		}
+	}
+
+	entry := &graph.ManifestEntry{
+		EntryPoint: c.graph.Files[chunk.sourceIndex].InputFile.Source.PrettyPath,
+	}
+
+	if chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS); ok && chunkRepr.hasCSSChunk {
+		entry.CSSBundle = c.fs.Join(c.options.AbsOutputDir, c.chunks[chunkRepr.cssChunkIndex].finalRelPath)
+	}
+
+	// The server needs to preload every chunk that will be loaded before this
+	// entry point can be evaluated, so static imports are followed transitively.
+	// Dynamic imports are only followed one level deep since those chunks are
+	// entry points themselves and have their own entries in the manifest.
+	visited := make(map[uint32]bool)
+	var visit func(uint32)
+	visit = func(chunkIndex uint32) {
+		for _, chunkImport := range c.chunks[chunkIndex].crossChunkImports {
+			if chunkImport.importKind == ast.ImportDynamic || visited[chunkImport.chunkIndex] {
+				continue
+			}
+			visited[chunkImport.chunkIndex] = true
+			entry.Imports = append(entry.Imports, c.fs.Join(c.options.AbsOutputDir, c.chunks[chunkImport.chunkIndex].finalRelPath))
+			visit(chunkImport.chunkIndex)
+		}
+	}
+	visited[uint32(chunkIndex)] = true
+	visit(uint32(chunkIndex))
+
+	for _, chunkImport := range chunk.crossChunkImports {
+		if chunkImport.importKind == ast.ImportDynamic {
+			if other := &c.chunks[chunkImport.chunkIndex]; other.isEntryPoint {
+				entry.DynamicImports = append(entry.DynamicImports, c.graph.Files[other.sourceIndex].InputFile.Source.PrettyPath)
+			}
+		}
+	}
+
+	return entry
+}
+
 // Given a set of output pieces (i.e. a buffer already divided into the spans
 // between import paths), substitute the final import paths in and then join
 // everything into a single byte buffer.
@@ -837,6 +1009,13 @@ func (c *linkerContext) substituteFinalPaths(
 			shift.Before.AdvanceString(chunk.uniqueKey)
 			shift.After.AdvanceString(importPath)
 			shifts = append(shifts, shift)
+
+		case outputPieceWorkerIndex:
+			importPath := modifyPath(c.workerRelPath(piece.index))
+			j.AddString(importPath)
+			shift.Before.AdvanceString(fmt.Sprintf("%sW%08d", c.uniqueKeyPrefix, piece.index))
+			shift.After.AdvanceString(importPath)
+			shifts = append(shifts, shift)
 		}
 	}
 
@@ -868,12 +1047,25 @@ func (c *linkerContext) accurateFinalByteCount(output intermediateOutput, chunkF
 			chunk := c.chunks[piece.index]
 			importPath := c.pathBetweenChunks(chunkFinalRelDir, chunk.finalRelPath)
 			count += len(importPath)
+
+		case outputPieceWorkerIndex:
+			importPath := c.pathBetweenChunks(chunkFinalRelDir, c.workerRelPath(piece.index))
+			count += len(importPath)
 		}
 	}
 
 	return count
 }
 
+// Workers are only referenced this way when code splitting is disabled, in
+// which case they have already been linked and their output paths are known
+func (c *linkerContext) workerRelPath(sourceIndex uint32) string {
+	relPath, _ := c.fs.Rel(c.options.AbsOutputDir, c.options.WorkerOutputPaths[sourceIndex])
+
+	// Make sure to always use forward slashes, even on Windows
+	return strings.ReplaceAll(relPath, "\\", "/")
+}
+
 func (c *linkerContext) pathBetweenChunks(fromRelDir string, toRelPath string) string {
 	// Join with the public path if it has been configured
 	if c.options.PublicPath != "" {
@@ -900,6 +1092,120 @@ func (c *linkerContext) pathBetweenChunks(fromRelDir string, toRelPath string) s
 	return relPath
 }
 
+// A chunk loaded by "import()" can't be evaluated until all of the chunks it
+// statically imports (directly or indirectly) have been loaded. The browser
+// only discovers these one level at a time, so list all of them up front along
+// with the entry point's CSS chunk so they can be fetched in parallel. Chunks
+// that have already been loaded by the importing chunk are left out.
+func (c *linkerContext) computeDynamicImportPreloads() {
+	staticImportsCache := make(map[uint32][]uint32)
+	staticImports := func(chunkIndex uint32) []uint32 {
+		if result, ok := staticImportsCache[chunkIndex]; ok {
+			return result
+		}
+		var result []uint32
+		visited := map[uint32]bool{chunkIndex: true}
+		var visit func(uint32)
+		visit = func(chunkIndex uint32) {
+			for _, chunkImport := range c.chunks[chunkIndex].crossChunkImports {
+				if chunkImport.importKind != ast.ImportDynamic && !visited[chunkImport.chunkIndex] {
+					visited[chunkImport.chunkIndex] = true
+					result = append(result, chunkImport.chunkIndex)
+					visit(chunkImport.chunkIndex)
+				}
+			}
+		}
+		visit(chunkIndex)
+		staticImportsCache[chunkIndex] = result
+		return result
+	}
+
+	for chunkIndex := range c.chunks {
+		chunk := &c.chunks[chunkIndex]
+		chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS)
+		if !ok {
+			continue
+		}
+
+		var isLoaded map[uint32]bool
+		for _, chunkImport := range chunk.crossChunkImports {
+			if chunkImport.importKind != ast.ImportDynamic {
+				continue
+			}
+			target := &c.chunks[chunkImport.chunkIndex]
+			targetRepr, ok := target.chunkRepr.(*chunkReprJS)
+			if !ok {
+				continue
+			}
+
+			// This chunk and everything it statically imports are already loaded
+			if isLoaded == nil {
+				isLoaded = map[uint32]bool{uint32(chunkIndex): true}
+				for _, otherChunkIndex := range staticImports(uint32(chunkIndex)) {
+					isLoaded[otherChunkIndex] = true
+				}
+			}
+
+			var deps []string
+			for _, otherChunkIndex := range staticImports(chunkImport.chunkIndex) {
+				if !isLoaded[otherChunkIndex] {
+					deps = append(deps, c.chunks[otherChunkIndex].uniqueKey)
+				}
+			}
+			if targetRepr.hasCSSChunk {
+				deps = append(deps, c.chunks[targetRepr.cssChunkIndex].uniqueKey)
+			}
+			if len(deps) > 0 {
+				if chunkRepr.dynamicImportPreloads == nil {
+					chunkRepr.dynamicImportPreloads = make(map[string][]string)
+				}
+				chunkRepr.dynamicImportPreloads[target.uniqueKey] = deps
+			}
+		}
+	}
+}
+
+// Whether an "import()" expression has anything to preload isn't known until
+// chunks have been computed. But "__preload" must be pulled in before tree
+// shaking, so this conservatively checks whether the imported entry point could
+// end up depending on another chunk. That can only happen if it imports CSS or
+// if some of its code is reachable from another entry point or is assigned to a
+// manual chunk. The runtime is ignored because the importing chunk must have
+// already loaded it to be able to call "__preload".
+func (c *linkerContext) mayNeedToPreloadDynamicImport(sourceIndex uint32) bool {
+	if result, ok := c.mayNeedToPreloadCache[sourceIndex]; ok {
+		return result
+	}
+	isShared := c.filesReachableFromMultipleEntryPoints()
+	visited := make(map[uint32]bool)
+	var visit func(uint32) bool
+	visit = func(sourceIndex uint32) bool {
+		if visited[sourceIndex] || sourceIndex == runtime.SourceIndex {
+			return false
+		}
+		visited[sourceIndex] = true
+		if _, ok := c.manualChunkIndexForFile(sourceIndex); ok || isShared[sourceIndex] {
+			return true
+		}
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok || repr.CSSSourceIndex.IsValid() {
+			return true
+		}
+		for _, record := range repr.AST.ImportRecords {
+			if record.SourceIndex.IsValid() && !c.isExternalDynamicImport(&record, sourceIndex) && visit(record.SourceIndex.GetIndex()) {
+				return true
+			}
+		}
+		return false
+	}
+	result := visit(sourceIndex)
+	if c.mayNeedToPreloadCache == nil {
+		c.mayNeedToPreloadCache = make(map[uint32]bool)
+	}
+	c.mayNeedToPreloadCache[sourceIndex] = result
+	return result
+}
+
 func (c *linkerContext) computeCrossChunkDependencies() {
 	c.timer.Begin("Compute cross-chunk dependencies")
 	defer c.timer.End("Compute cross-chunk dependencies")
@@ -1111,6 +1417,12 @@ func (c *linkerContext) computeCrossChunkDependencies() {
 		}
 	}
 
+	// Allocate a new unbound symbol called "Object" in case we need it later
+	objectRef := ast.InvalidRef
+	if !c.options.OutputFormat.KeepESMImportExportSyntax() {
+		objectRef = c.graph.GenerateNewSymbol(runtime.SourceIndex, ast.SymbolUnbound, "Object")
+	}
+
 	// Generate cross-chunk exports. These must be computed before cross-chunk
 	// imports because of export alias renaming, which must consider all export
 	// aliases simultaneously to avoid collisions.
@@ -1142,6 +1454,65 @@ func (c *linkerContext) computeCrossChunkDependencies() {
 				}}}
 			}
 
+		case config.FormatCommonJS, config.FormatIIFE:
+			// Chunks without "export" statements expose their exports as getters
+			// on an exports object. For CommonJS this is "module.exports" and for
+			// IIFE this is an object that's passed in by the chunk loader.
+			var target js_ast.Expr
+			if c.options.OutputFormat == config.FormatCommonJS {
+				target = js_ast.Expr{Data: &js_ast.EDot{
+					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: c.unboundModuleRef}},
+					Name:   "exports",
+				}}
+			} else {
+				chunkRepr.chunkExportsRef = c.graph.GenerateNewSymbol(runtime.SourceIndex, ast.SymbolOther, "exports")
+				chunkRepr.chunkLoaderRef = c.graph.GenerateNewSymbol(runtime.SourceIndex, ast.SymbolOther, "__load")
+				chunkRepr.crossChunkBindingRefs = append(chunkRepr.crossChunkBindingRefs, chunkRepr.chunkExportsRef, chunkRepr.chunkLoaderRef)
+				target = js_ast.Expr{Data: &js_ast.EIdentifier{Ref: chunkRepr.chunkExportsRef}}
+			}
+
+			r := renamer.ExportRenamer{}
+			var properties []js_ast.Property
+			for _, export := range c.sortedCrossChunkExportItems(chunkMetas[chunkIndex].exports) {
+				var alias string
+				if c.options.MinifyIdentifiers {
+					alias = r.NextMinifiedName()
+				} else {
+					alias = r.NextRenamedName(c.graph.Symbols.Get(export.Ref).OriginalName)
+				}
+				var getter js_ast.Expr
+				body := js_ast.FnBody{Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Data: &js_ast.SReturn{
+					ValueOrNil: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: export.Ref}}}}}}}
+				if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
+					getter = js_ast.Expr{Data: &js_ast.EFunction{Fn: js_ast.Fn{Body: body}}}
+				} else {
+					getter = js_ast.Expr{Data: &js_ast.EArrow{PreferExpr: true, Body: body}}
+				}
+				properties = append(properties, js_ast.Property{
+					Key: js_ast.Expr{Data: &js_ast.EString{Value: helpers.StringToUTF16(alias)}},
+					ValueOrNil: js_ast.Expr{Data: &js_ast.EObject{Properties: []js_ast.Property{{
+						Key:        js_ast.Expr{Data: &js_ast.EString{Value: helpers.StringToUTF16("get")}},
+						ValueOrNil: getter,
+					}}}},
+				})
+				chunkRepr.exportsToOtherChunks[export.Ref] = alias
+			}
+
+			// These are defined before anything else in the chunk is evaluated so
+			// that import cycles between chunks can still observe these bindings.
+			// The getters keep the bindings live, which is what ESM would do.
+			if len(properties) > 0 {
+				// "Object.defineProperties(exports, {a: {get: () => a}});"
+				chunkRepr.crossChunkExportStmts = []js_ast.Stmt{{Data: &js_ast.SExpr{Value: js_ast.Expr{Data: &js_ast.ECall{
+					Kind: js_ast.TargetWasOriginallyPropertyAccess,
+					Target: js_ast.Expr{Data: &js_ast.EDot{
+						Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: objectRef}},
+						Name:   "defineProperties",
+					}},
+					Args: []js_ast.Expr{target, {Data: &js_ast.EObject{Properties: properties, IsSingleLine: len(properties) == 1}}},
+				}}}}}
+			}
+
 		default:
 			panic("Internal error")
 		}
@@ -1184,13 +1555,64 @@ func (c *linkerContext) computeCrossChunkDependencies() {
 					}})
 				}
 
+			case config.FormatCommonJS:
+				importRecordIndex := uint32(len(chunk.crossChunkImports))
+				chunk.crossChunkImports = append(chunk.crossChunkImports, chunkImport{
+					importKind: ast.ImportRequire,
+					chunkIndex: crossChunkImport.chunkIndex,
+				})
+				value := js_ast.Expr{Data: &js_ast.ERequireString{ImportRecordIndex: importRecordIndex}}
+				if len(crossChunkImport.sortedImportItems) > 0 {
+					// "var import_chunk = require('./chunk.js');"
+					namespaceRef := c.generateCrossChunkNamespace(chunkRepr, crossChunkImport)
+					crossChunkPrefixStmts = append(crossChunkPrefixStmts, js_ast.Stmt{Data: &js_ast.SLocal{
+						Kind: js_ast.LocalVar,
+						Decls: []js_ast.Decl{{
+							Binding:    js_ast.Binding{Data: &js_ast.BIdentifier{Ref: namespaceRef}},
+							ValueOrNil: value,
+						}},
+					}})
+				} else {
+					// "require('./chunk.js');"
+					crossChunkPrefixStmts = append(crossChunkPrefixStmts, js_ast.Stmt{Data: &js_ast.SExpr{Value: value}})
+				}
+
+			case config.FormatIIFE:
+				// The chunk loader passes the exports object of each imported chunk
+				// as an argument to this chunk in the same order as these imports
+				chunk.crossChunkImports = append(chunk.crossChunkImports, chunkImport{
+					importKind: ast.ImportStmt,
+					chunkIndex: crossChunkImport.chunkIndex,
+				})
+				chunkRepr.chunkDependencyRefs = append(chunkRepr.chunkDependencyRefs,
+					c.generateCrossChunkNamespace(chunkRepr, crossChunkImport))
+
 			default:
 				panic("Internal error")
 			}
 		}
 
-		chunkRepr.crossChunkPrefixStmts = crossChunkPrefixStmts
+		// Exports must be defined before any imports are evaluated
+		chunkRepr.crossChunkPrefixStmts = append(chunkRepr.crossChunkExportStmts, crossChunkPrefixStmts...)
+	}
+}
+
+// Formats without "import" statements access symbols from other chunks as
+// properties on a namespace object for that chunk. This allocates a symbol
+// for that namespace object and redirects the imported symbols through it.
+func (c *linkerContext) generateCrossChunkNamespace(chunkRepr *chunkReprJS, crossChunkImport crossChunkImport) ast.Ref {
+	namespaceRef := c.graph.GenerateNewSymbol(runtime.SourceIndex, ast.SymbolOther, "import_chunk")
+	chunkRepr.crossChunkBindingRefs = append(chunkRepr.crossChunkBindingRefs, namespaceRef)
+	if len(crossChunkImport.sortedImportItems) > 0 && chunkRepr.crossChunkImportAliases == nil {
+		chunkRepr.crossChunkImportAliases = make(map[ast.Ref]ast.NamespaceAlias)
 	}
+	for _, item := range crossChunkImport.sortedImportItems {
+		chunkRepr.crossChunkImportAliases[ast.FollowSymbols(c.graph.Symbols, item.ref)] = ast.NamespaceAlias{
+			NamespaceRef: namespaceRef,
+			Alias:        item.exportAlias,
+		}
+	}
+	return namespaceRef
 }
 
 type crossChunkImport struct {
@@ -1381,12 +1803,38 @@ func (c *linkerContext) scanImportsAndExports() {
 							additionalFiles = append(additionalFiles, otherFile.InputFile.AdditionalFiles...)
 						}
 					}
+
+					// There's no way to represent an external source phase import with "require()"
+					if record.Phase == ast.SourcePhase && !c.options.OutputFormat.KeepESMImportExportSyntax() {
+						c.log.AddError(file.LineColumnTracker(), record.Range, fmt.Sprintf(
+							"Source phase imports of external modules are not supported with the %q output format", c.options.OutputFormat.String()))
+					}
 					continue
 				}
 
 				otherFile := &c.graph.Files[record.SourceIndex.GetIndex()]
 				otherRepr := otherFile.InputFile.Repr.(*graph.JSRepr)
 
+				// Inline URLs for assets referenced with "new URL()" or
+				// "import.meta.resolve()" into the JavaScript file
+				if record.Kind == ast.ImportURL {
+					record.Path.Text = otherRepr.AST.URLForCSS
+					record.Path.Namespace = ""
+					record.SourceIndex = ast.Index32{}
+					if otherFile.InputFile.Loader == config.LoaderEmpty {
+						record.Flags |= ast.WasLoadedWithEmptyLoader
+					} else {
+						record.Flags |= ast.ShouldNotBeExternalInMetafile
+					}
+					if strings.Contains(otherRepr.AST.URLForCSS, c.uniqueKeyPrefix) {
+						record.Flags |= ast.ContainsUniqueKey
+					}
+
+					// Copy the additional files to the output directory
+					additionalFiles = append(additionalFiles, otherFile.InputFile.AdditionalFiles...)
+					continue
+				}
+
 				switch record.Kind {
 				case ast.ImportStmt:
 					// Importing using ES6 syntax from a file without any ES6 syntax
@@ -1415,6 +1863,17 @@ func (c *linkerContext) scanImportsAndExports() {
 						otherRepr.AST.ExportsKind = js_ast.ExportsCommonJS
 					}
 
+					// Files that are imported with "import defer" must be wrapped so that
+					// they can be lazily-evaluated, just like with require()
+					if record.Phase == ast.DeferPhase {
+						if otherRepr.AST.ExportsKind == js_ast.ExportsESM {
+							otherRepr.Meta.Wrap = graph.WrapESM
+						} else {
+							otherRepr.Meta.Wrap = graph.WrapCJS
+							otherRepr.AST.ExportsKind = js_ast.ExportsCommonJS
+						}
+					}
+
 				case ast.ImportRequire:
 					// Files that are imported with require() must be wrapped so that
 					// they can be lazily-evaluated
@@ -1453,6 +1912,16 @@ func (c *linkerContext) scanImportsAndExports() {
 	}
 	c.timer.End("Step 1")
 
+	// Code splitting moves code that's shared between entry points into
+	// separate chunks, which are evaluated before the code that imports them.
+	// This can change the order in which modules are evaluated. To avoid that,
+	// optionally wrap every module that could end up in a shared chunk in a
+	// lazily-evaluated closure. The wrapper is then invoked at the location of
+	// the original import, which preserves the original evaluation order.
+	if c.options.PreserveEvalOrder && c.options.CodeSplitting {
+		c.wrapModulesInSharedChunks()
+	}
+
 	// Step 2: Propagate dynamic export status for export star statements that
 	// are re-exports from a module whose exports are not statically analyzable.
 	// In this case the export star must be evaluated at run time instead of at
@@ -1877,13 +2346,32 @@ func (c *linkerContext) scanImportsAndExports() {
 			toESMUses := uint32(0)
 			toCommonJSUses := uint32(0)
 			runtimeRequireUses := uint32(0)
+			preloadUses := uint32(0)
+			importDeferUses := uint32(0)
 
 			// Imports of wrapped files must depend on the wrapper
 			for _, importRecordIndex := range part.ImportRecordIndices {
 				record := &repr.AST.ImportRecords[importRecordIndex]
 
+				// Deferred imports will be wrapped in a call to "__importDefer"
+				if c.isLoweredDeferredImport(record) {
+					importDeferUses++
+				}
+
 				// Don't follow external imports (this includes import() expressions)
 				if !record.SourceIndex.IsValid() || c.isExternalDynamicImport(record, sourceIndex) {
+					// Cross-chunk "import()" expressions may preload the chunk's dependencies
+					if record.Kind == ast.ImportDynamic && record.SourceIndex.IsValid() && c.canPreloadDynamicImports() &&
+						c.mayNeedToPreloadDynamicImport(record.SourceIndex.GetIndex()) {
+						record.Flags |= ast.PreloadDependencies
+						preloadUses++
+					}
+
+					// Workers and "new URL()" are only ever turned into paths
+					if record.Kind == ast.ImportWorker || record.Kind == ast.ImportURL {
+						continue
+					}
+
 					// This is an external import. Check if it will be a "require()" call.
 					if record.Kind == ast.ImportRequire || !c.options.OutputFormat.KeepESMImportExportSyntax() ||
 						(record.Kind == ast.ImportDynamic && c.options.UnsupportedJSFeatures.Has(compat.DynamicImport)) {
@@ -1938,7 +2426,7 @@ func (c *linkerContext) scanImportsAndExports() {
 					// This must be done for "require()" and "import()" expressions
 					// but does not need to be done for "import" statements since
 					// those just cause us to reference the exports directly.
-					if otherRepr.Meta.Wrap == graph.WrapESM && record.Kind != ast.ImportStmt {
+					if otherRepr.Meta.Wrap == graph.WrapESM && (record.Kind != ast.ImportStmt || record.Phase == ast.DeferPhase) {
 						c.graph.GenerateSymbolImportAndUse(sourceIndex, uint32(partIndex), otherRepr.AST.ExportsRef, 1, otherSourceIndex)
 
 						// If this is a "require()" call, then we should add the
@@ -1977,6 +2465,14 @@ func (c *linkerContext) scanImportsAndExports() {
 			// code for node, then substitute a "__require" wrapper for "require".
 			c.graph.GenerateRuntimeSymbolImportAndUse(sourceIndex, uint32(partIndex), "__require", runtimeRequireUses)
 
+			// If there are cross-chunk "import()" expressions and module preloading
+			// is enabled, then they will be wrapped in a call to "__preload"
+			c.graph.GenerateRuntimeSymbolImportAndUse(sourceIndex, uint32(partIndex), "__preload", preloadUses)
+
+			// If there are deferred imports that can't be represented natively, then
+			// the namespace object will be created by "__importDefer"
+			c.graph.GenerateRuntimeSymbolImportAndUse(sourceIndex, uint32(partIndex), "__importDefer", importDeferUses)
+
 			// If there's an ES6 export star statement of a non-ES6 module, then we're
 			// going to need the "__reExport" symbol from the runtime
 			reExportUses := uint32(0)
@@ -2885,6 +3381,58 @@ func (c *linkerContext) maybeCorrectObviousTypo(repr *graph.JSRepr, name string,
 	}
 }
 
+func (c *linkerContext) wrapModulesInSharedChunks() {
+	isShared := c.filesReachableFromMultipleEntryPoints()
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if _, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); !ok || sourceIndex == runtime.SourceIndex {
+			continue
+		}
+
+		// Files in manual chunks are moved out of their entry point chunks too
+		if _, ok := c.manualChunkIndexForFile(sourceIndex); ok || isShared[sourceIndex] {
+			c.recursivelyWrapDependencies(sourceIndex)
+		}
+	}
+}
+
+// Find the first entry point that can reach each file without crossing a code
+// splitting boundary. Files that are reachable from a second entry point will
+// be placed into a shared chunk. This is done before tree shaking, so it's an
+// overestimate of which files will actually end up in a shared chunk.
+func (c *linkerContext) filesReachableFromMultipleEntryPoints() []bool {
+	if c.isFileShared != nil {
+		return c.isFileShared
+	}
+	firstEntryPoint := make([]int, len(c.graph.Files))
+	isShared := make([]bool, len(c.graph.Files))
+	var visit func(sourceIndex uint32, entryPointIndex int)
+	visit = func(sourceIndex uint32, entryPointIndex int) {
+		if firstEntryPoint[sourceIndex] == entryPointIndex+1 || isShared[sourceIndex] {
+			return
+		}
+		if firstEntryPoint[sourceIndex] != 0 {
+			isShared[sourceIndex] = true
+		} else {
+			firstEntryPoint[sourceIndex] = entryPointIndex + 1
+		}
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			return
+		}
+		for _, record := range repr.AST.ImportRecords {
+			if record.SourceIndex.IsValid() && !c.isExternalDynamicImport(&record, sourceIndex) {
+				visit(record.SourceIndex.GetIndex(), entryPointIndex)
+			}
+		}
+	}
+	for i, entryPoint := range c.graph.EntryPoints() {
+		visit(entryPoint.SourceIndex, i)
+	}
+	c.isFileShared = isShared
+	return isShared
+}
+
 func (c *linkerContext) recursivelyWrapDependencies(sourceIndex uint32) {
 	repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
 	if repr.Meta.DidWrapDependencies {
@@ -3259,11 +3807,19 @@ func (c *linkerContext) markFileLiveForTreeShaking(sourceIndex uint32) {
 	}
 }
 
+// The preload helper uses "import.meta.url" to resolve the paths of the chunks
+// it preloads, so this only works when generating ES modules
+func (c *linkerContext) canPreloadDynamicImports() bool {
+	return c.options.ModulePreload && c.options.CodeSplitting &&
+		c.options.OutputFormat == config.FormatESModule &&
+		!c.options.UnsupportedJSFeatures.Has(compat.DynamicImport)
+}
+
 func (c *linkerContext) isExternalDynamicImport(record *ast.ImportRecord, sourceIndex uint32) bool {
 	return c.options.CodeSplitting &&
-		record.Kind == ast.ImportDynamic &&
+		(record.Kind == ast.ImportDynamic || record.Kind == ast.ImportWorker) &&
 		c.graph.Files[record.SourceIndex.GetIndex()].IsEntryPoint() &&
-		record.SourceIndex.GetIndex() != sourceIndex
+		(record.SourceIndex.GetIndex() != sourceIndex || record.Kind == ast.ImportWorker)
 }
 
 func (c *linkerContext) markPartLiveForTreeShaking(sourceIndex uint32, partIndex uint32) {
@@ -3922,9 +4478,26 @@ func (c *linkerContext) computeChunks() {
 	}
 
 	// Figure out which JS files are in which chunk
+	manualChunks := make([]chunkInfo, len(c.options.ManualChunks))
 	for _, sourceIndex := range c.graph.ReachableFiles {
 		if file := &c.graph.Files[sourceIndex]; file.IsLive {
 			if _, ok := file.InputFile.Repr.(*graph.JSRepr); ok {
+				// Files assigned to a manual chunk go there instead. The chunk is
+				// considered to belong to every entry point that any of its files
+				// belong to, so each of those entry points will import it.
+				if manualIndex, ok := c.manualChunkIndexForFile(sourceIndex); ok {
+					chunk := &manualChunks[manualIndex]
+					if chunk.filesWithPartsInChunk == nil {
+						chunk.entryBits = helpers.NewBitSet(uint(len(c.graph.EntryPoints())))
+						chunk.filesWithPartsInChunk = make(map[uint32]bool)
+						chunk.chunkRepr = &chunkReprJS{}
+						chunk.manualChunkName = c.options.ManualChunks[manualIndex].Name
+					}
+					chunk.entryBits.Or(file.EntryBits)
+					chunk.filesWithPartsInChunk[uint32(sourceIndex)] = true
+					continue
+				}
+
 				key := file.EntryBits.String()
 				chunk, ok := jsChunks[key]
 				if !ok {
@@ -3938,6 +4511,15 @@ func (c *linkerContext) computeChunks() {
 		}
 	}
 
+	// Optionally merge small shared chunks together to reduce the chunk count
+	manualChunkCount := 0
+	for _, chunk := range manualChunks {
+		if chunk.filesWithPartsInChunk != nil {
+			manualChunkCount++
+		}
+	}
+	c.mergeSmallChunks(jsChunks, manualChunkCount)
+
 	// Sort the chunks for determinism. This matters because we use chunk indices
 	// as sorting keys in a few places.
 	sortedChunks := make([]chunkInfo, 0, len(jsChunks)+len(cssChunks))
@@ -3954,6 +4536,12 @@ func (c *linkerContext) computeChunks() {
 		}
 		sortedChunks = append(sortedChunks, chunk)
 	}
+	for _, chunk := range manualChunks {
+		// Manual chunks are already sorted by name, and empty ones are omitted
+		if chunk.filesWithPartsInChunk != nil {
+			sortedChunks = append(sortedChunks, chunk)
+		}
+	}
 	sortedKeys = sortedKeys[:0]
 	for key := range cssChunks {
 		sortedKeys = append(sortedKeys, key)
@@ -4024,7 +4612,7 @@ func (c *linkerContext) computeChunks() {
 				template = c.options.ChunkPathTemplate
 			}
 
-			if c.options.AbsOutputFile != "" {
+			if c.options.AbsOutputFile != "" && file.IsUserSpecifiedEntryPoint() {
 				// If the output path was configured explicitly, use it verbatim
 				dir = "/"
 				base = c.fs.Base(c.options.AbsOutputFile)
@@ -4053,6 +4641,9 @@ func (c *linkerContext) computeChunks() {
 		} else {
 			dir = "/"
 			base = "chunk"
+			if chunk.manualChunkName != "" {
+				base = chunk.manualChunkName
+			}
 			ext = stdExt
 			template = c.options.ChunkPathTemplate
 		}
@@ -4070,6 +4661,208 @@ func (c *linkerContext) computeChunks() {
 	c.chunks = sortedChunks
 }
 
+// Merging one chunk into another means that every entry point that loads
+// either of them now loads both of them. This is only done when the code that
+// an entry point wouldn't otherwise have loaded is free of side effects (along
+// with everything it imports), so merging chunks changes what code is
+// downloaded but not what code is evaluated. Entry point chunks and manual
+// chunks are never merged.
+func (c *linkerContext) mergeSmallChunks(jsChunks map[string]chunkInfo, manualChunkCount int) {
+	if !c.options.CodeSplitting || (c.options.MinChunkSize <= 0 && c.options.MaxChunks <= 0) {
+		return
+	}
+
+	type candidate struct {
+		key              string
+		size             int
+		isSideEffectFree bool
+		isRemoved        bool
+		cannotBeMerged   bool
+	}
+
+	var candidates []candidate
+	for key, chunk := range jsChunks {
+		if !chunk.isEntryPoint {
+			size := 0
+			for sourceIndex := range chunk.filesWithPartsInChunk {
+				size += len(c.graph.Files[sourceIndex].InputFile.Source.Contents)
+			}
+			candidates = append(candidates, candidate{
+				key:              key,
+				size:             size,
+				isSideEffectFree: c.areFilesAndDependenciesSideEffectFree(chunk.filesWithPartsInChunk),
+			})
+		}
+	}
+	if len(candidates) < 2 {
+		return
+	}
+
+	// Sort for determinism
+	sort.Slice(candidates, func(i int, j int) bool {
+		return candidates[i].key < candidates[j].key
+	})
+
+	entryPointCount := uint(len(c.graph.EntryPoints()))
+	countMissingBits := func(bits helpers.BitSet, from helpers.BitSet) (count int) {
+		for bit := uint(0); bit < entryPointCount; bit++ {
+			if bits.HasBit(bit) && !from.HasBit(bit) {
+				count++
+			}
+		}
+		return
+	}
+
+	chunkCount := len(jsChunks) + manualChunkCount
+	for {
+		// Pick the smallest chunk that's too small, or the smallest chunk if there
+		// are too many chunks
+		tooManyChunks := c.options.MaxChunks > 0 && chunkCount > c.options.MaxChunks
+		from := -1
+		for i, it := range candidates {
+			if !it.isRemoved && !it.cannotBeMerged && (tooManyChunks || it.size < c.options.MinChunkSize) &&
+				(from == -1 || it.size < candidates[from].size) {
+				from = i
+			}
+		}
+		if from == -1 {
+			break
+		}
+		fromChunk := jsChunks[candidates[from].key]
+
+		// Find the chunk to merge it into that causes the fewest extra bytes to
+		// be downloaded by entry points that don't need them
+		into := -1
+		intoCost := 0
+		for i, it := range candidates {
+			if i == from || it.isRemoved {
+				continue
+			}
+			intoChunk := jsChunks[it.key]
+			extraForFrom := countMissingBits(intoChunk.entryBits, fromChunk.entryBits)
+			extraForInto := countMissingBits(fromChunk.entryBits, intoChunk.entryBits)
+			if (extraForFrom > 0 && !candidates[from].isSideEffectFree) || (extraForInto > 0 && !it.isSideEffectFree) {
+				continue
+			}
+			cost := extraForFrom*candidates[from].size + extraForInto*it.size
+			if into == -1 || cost < intoCost {
+				into = i
+				intoCost = cost
+			}
+		}
+		if into == -1 {
+			candidates[from].cannotBeMerged = true
+			continue
+		}
+
+		// Merge the chunks. Note that the entry bits must be cloned since they
+		// may be shared with a file.
+		intoChunk := jsChunks[candidates[into].key]
+		entryBits := helpers.NewBitSet(entryPointCount)
+		entryBits.Or(intoChunk.entryBits)
+		entryBits.Or(fromChunk.entryBits)
+		intoChunk.entryBits = entryBits
+		for sourceIndex := range fromChunk.filesWithPartsInChunk {
+			intoChunk.filesWithPartsInChunk[sourceIndex] = true
+		}
+		jsChunks[candidates[into].key] = intoChunk
+		delete(jsChunks, candidates[from].key)
+		candidates[into].size += candidates[from].size
+		candidates[into].isSideEffectFree = candidates[into].isSideEffectFree && candidates[from].isSideEffectFree
+		candidates[from].isRemoved = true
+		chunkCount--
+
+		// A chunk that couldn't be merged before may be able to be merged now
+		for i := range candidates {
+			candidates[i].cannotBeMerged = false
+		}
+	}
+}
+
+// Returns true if evaluating these files (and every file they import) doesn't
+// have side effects. Wrapped files are considered to be free of side effects
+// because their code doesn't run until the wrapper is called.
+func (c *linkerContext) areFilesAndDependenciesSideEffectFree(files map[uint32]bool) bool {
+	visited := make(map[uint32]bool)
+	var visit func(sourceIndex uint32) bool
+	visit = func(sourceIndex uint32) bool {
+		if visited[sourceIndex] {
+			return true
+		}
+		visited[sourceIndex] = true
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok || sourceIndex == runtime.SourceIndex {
+			return true
+		}
+		if repr.Meta.Wrap == graph.WrapNone {
+			for _, part := range repr.AST.Parts {
+				if part.IsLive && !part.CanBeRemovedIfUnused {
+					return false
+				}
+			}
+		}
+		for _, record := range repr.AST.ImportRecords {
+			if record.SourceIndex.IsValid() && !c.isExternalDynamicImport(&record, sourceIndex) && !visit(record.SourceIndex.GetIndex()) {
+				return false
+			}
+		}
+		return true
+	}
+	for sourceIndex := range files {
+		if !visit(sourceIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// Entry points always stay in their own chunks, so they are never put into a
+// manual chunk even if they match one of its patterns
+func (c *linkerContext) manualChunkIndexForFile(sourceIndex uint32) (int, bool) {
+	if len(c.options.ManualChunks) == 0 || !c.options.CodeSplitting {
+		return 0, false
+	}
+	file := &c.graph.Files[sourceIndex]
+	keyPath := file.InputFile.Source.KeyPath
+	if file.IsEntryPoint() || keyPath.Namespace != "file" {
+		return 0, false
+	}
+
+	// Use "/" as the path separator on all platforms
+	absPath := strings.ReplaceAll(keyPath.Text, "\\", "/")
+
+	// Find the path within the innermost "node_modules" directory, if any
+	packagePath := ""
+	if i := strings.LastIndex(absPath, "/node_modules/"); i != -1 {
+		packagePath = absPath[i+len("/node_modules/"):]
+	}
+
+	for i, manualChunk := range c.options.ManualChunks {
+		if manualChunk.ExactPaths[absPath] {
+			return i, true
+		}
+		for _, pattern := range manualChunk.PathPatterns {
+			if matchesWildcardPattern(absPath, pattern) {
+				return i, true
+			}
+		}
+		if packagePath != "" {
+			for _, pattern := range manualChunk.PackagePatterns {
+				if matchesWildcardPattern(packagePath, pattern) {
+					return i, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func matchesWildcardPattern(text string, pattern config.WildcardPattern) bool {
+	return len(text) >= len(pattern.Prefix)+len(pattern.Suffix) &&
+		strings.HasPrefix(text, pattern.Prefix) &&
+		strings.HasSuffix(text, pattern.Suffix)
+}
+
 type chunkOrder struct {
 	sourceIndex uint32
 	distance    uint32
@@ -4151,7 +4944,7 @@ func (c *linkerContext) findImportedPartsInJSOrder(chunk *chunkInfo) (js []uint3
 		file := &c.graph.Files[sourceIndex]
 
 		if repr, ok := file.InputFile.Repr.(*graph.JSRepr); ok {
-			isFileInThisChunk := chunk.entryBits.Equals(file.EntryBits)
+			isFileInThisChunk := chunk.filesWithPartsInChunk[sourceIndex]
 
 			// Wrapped files can't be split because they are all inside the wrapper
 			canFileBeSplit := repr.Meta.Wrap == graph.WrapNone
@@ -4214,6 +5007,13 @@ func (c *linkerContext) findImportedPartsInJSOrder(chunk *chunkInfo) (js []uint3
 	return
 }
 
+// Deferred imports of files in the bundle are always implemented using a
+// wrapper since bundled code can't be deferred natively. Deferred imports of
+// external files are only lowered if they need to become "require()" calls.
+func (c *linkerContext) isLoweredDeferredImport(record *ast.ImportRecord) bool {
+	return record.Phase == ast.DeferPhase && (record.SourceIndex.IsValid() || !c.options.OutputFormat.KeepESMImportExportSyntax())
+}
+
 func (c *linkerContext) shouldRemoveImportExportStmt(
 	sourceIndex uint32,
 	stmtList *stmtList,
@@ -4224,6 +5024,42 @@ func (c *linkerContext) shouldRemoveImportExportStmt(
 	repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
 	record := &repr.AST.ImportRecords[importRecordIndex]
 
+	// Replace a deferred import with "var ns = __importDefer(() => require())"
+	if c.isLoweredDeferredImport(record) {
+		body := js_ast.FnBody{Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SReturn{
+			ValueOrNil: js_ast.Expr{Loc: record.Range.Loc, Data: &js_ast.ERequireString{ImportRecordIndex: importRecordIndex}}}}}}}
+		var init js_ast.Expr
+		if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
+			init = js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{Body: body}}}
+		} else {
+			init = js_ast.Expr{Loc: loc, Data: &js_ast.EArrow{PreferExpr: true, Body: body}}
+		}
+		// Modules with top-level await can't be evaluated synchronously, so they
+		// are evaluated eagerly instead (which is also what the specification
+		// does for asynchronous modules in a deferred import's module graph)
+		if record.SourceIndex.IsValid() {
+			otherFile := &c.graph.Files[record.SourceIndex.GetIndex()]
+			otherRepr := otherFile.InputFile.Repr.(*graph.JSRepr)
+			if otherRepr.Meta.Wrap == graph.WrapESM && otherRepr.Meta.IsAsyncOrHasAsyncDependency && otherFile.IsLive {
+				value := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: otherRepr.AST.WrapperRef}}}}
+				stmtList.insideWrapperPrefix = append(stmtList.insideWrapperPrefix, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.EAwait{Value: value}}}})
+			}
+		}
+
+		importDeferRef := c.graph.Files[runtime.SourceIndex].InputFile.Repr.(*graph.JSRepr).AST.ModuleScope.Members["__importDefer"].Ref
+		stmtList.insideWrapperPrefix = append(stmtList.insideWrapperPrefix, js_ast.Stmt{
+			Loc: loc,
+			Data: &js_ast.SLocal{Decls: []js_ast.Decl{{
+				Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: namespaceRef}},
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+					Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: importDeferRef}},
+					Args:   []js_ast.Expr{init},
+				}},
+			}}},
+		})
+		return true
+	}
+
 	// Is this an external import?
 	if !record.SourceIndex.IsValid() {
 		// Keep the "import" statement if "import" statements are supported
@@ -4640,6 +5476,10 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 	toCommonJSRef ast.Ref,
 	toESMRef ast.Ref,
 	runtimeRequireRef ast.Ref,
+	preloadRef ast.Ref,
+	chunkLoaderRef ast.Ref,
+	dynamicImportPreloads map[string][]string,
+	crossChunkImportAliases map[ast.Ref]ast.NamespaceAlias,
 	result *compileResultJS,
 	dataForSourceMaps []bundler.DataForSourceMap,
 ) {
@@ -4938,6 +5778,10 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		ToCommonJSRef:                toCommonJSRef,
 		ToESMRef:                     toESMRef,
 		RuntimeRequireRef:            runtimeRequireRef,
+		PreloadRef:                   preloadRef,
+		ChunkLoaderRef:               chunkLoaderRef,
+		DynamicImportPreloads:        dynamicImportPreloads,
+		CrossChunkImportAliases:      crossChunkImportAliases,
 		TSEnums:                      c.graph.TSEnums,
 		ConstValues:                  c.graph.ConstValues,
 		LegalComments:                c.options.LegalComments,
@@ -4970,6 +5814,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 	r renamer.Renamer,
 	toCommonJSRef ast.Ref,
 	toESMRef ast.Ref,
+	crossChunkImportAliases map[ast.Ref]ast.NamespaceAlias,
 	sourceIndex uint32,
 ) (result compileResultJS) {
 	file := &c.graph.Files[sourceIndex]
@@ -4988,7 +5833,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 
 	case config.FormatIIFE:
 		if repr.Meta.Wrap == graph.WrapCJS {
-			if len(c.options.GlobalName) > 0 {
+			if len(c.options.GlobalName) > 0 || c.options.CodeSplitting {
 				// "return require_foo();"
 				stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Data: &js_ast.ECall{
 					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
@@ -5284,6 +6129,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 		UnsupportedFeatures:          c.options.UnsupportedJSFeatures,
 		RequireOrImportMetaForSource: c.requireOrImportMetaForSource,
 		MangledProps:                 c.mangledProps,
+		CrossChunkImportAliases:      crossChunkImportAliases,
 	}
 	result.PrintResult = js_printer.Print(tree, c.graph.Symbols, r, printOptions)
 	return
@@ -5326,16 +6172,27 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 	}
 	timer.End("Compute reserved names")
 
-	// Make sure imports get a chance to be renamed too
+	// Make sure imports get a chance to be renamed too. Formats without
+	// "import" statements access imports through namespace objects instead,
+	// so those namespace objects are what need to be renamed in that case.
 	var sortedImportsFromOtherChunks stableRefArray
-	for _, imports := range chunk.chunkRepr.(*chunkReprJS).importsFromOtherChunks {
-		for _, item := range imports {
-			sortedImportsFromOtherChunks = append(sortedImportsFromOtherChunks, stableRef{
-				StableSourceIndex: c.graph.StableSourceIndices[item.ref.SourceIndex],
-				Ref:               item.ref,
-			})
+	chunkRepr := chunk.chunkRepr.(*chunkReprJS)
+	if c.options.OutputFormat.KeepESMImportExportSyntax() {
+		for _, imports := range chunkRepr.importsFromOtherChunks {
+			for _, item := range imports {
+				sortedImportsFromOtherChunks = append(sortedImportsFromOtherChunks, stableRef{
+					StableSourceIndex: c.graph.StableSourceIndices[item.ref.SourceIndex],
+					Ref:               item.ref,
+				})
+			}
 		}
 	}
+	for _, ref := range chunkRepr.crossChunkBindingRefs {
+		sortedImportsFromOtherChunks = append(sortedImportsFromOtherChunks, stableRef{
+			StableSourceIndex: c.graph.StableSourceIndices[ref.SourceIndex],
+			Ref:               ref,
+		})
+	}
 	sort.Sort(sortedImportsFromOtherChunks)
 
 	// Minification uses frequency analysis to give shorter names to more frequent symbols
@@ -5556,6 +6413,7 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	toCommonJSRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__toCommonJS"].Ref)
 	toESMRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__toESM"].Ref)
 	runtimeRequireRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__require"].Ref)
+	preloadRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__preload"].Ref)
 	r := c.renameSymbolsInChunk(chunk, chunkRepr.filesInChunkInOrder, timer)
 	dataForSourceMaps := c.dataForSourceMaps()
 
@@ -5588,6 +6446,10 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			toCommonJSRef,
 			toESMRef,
 			runtimeRequireRef,
+			preloadRef,
+			chunkRepr.chunkLoaderRef,
+			chunkRepr.dynamicImportPreloads,
+			chunkRepr.crossChunkImportAliases,
 			compileResult,
 			dataForSourceMaps,
 		)
@@ -5604,13 +6466,14 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			indent++
 		}
 		printOptions := js_printer.Options{
-			Indent:            indent,
-			OutputFormat:      c.options.OutputFormat,
-			MinifyIdentifiers: c.options.MinifyIdentifiers,
-			MinifyWhitespace:  c.options.MinifyWhitespace,
-			MinifySyntax:      c.options.MinifySyntax,
-			LineLimit:         c.options.LineLimit,
-			NeedsMetafile:     c.options.NeedsMetafile,
+			Indent:                  indent,
+			OutputFormat:            c.options.OutputFormat,
+			MinifyIdentifiers:       c.options.MinifyIdentifiers,
+			MinifyWhitespace:        c.options.MinifyWhitespace,
+			MinifySyntax:            c.options.MinifySyntax,
+			LineLimit:               c.options.LineLimit,
+			NeedsMetafile:           c.options.NeedsMetafile,
+			CrossChunkImportAliases: chunkRepr.crossChunkImportAliases,
 		}
 		crossChunkImportRecords := make([]ast.ImportRecord, len(chunk.crossChunkImports))
 		for i, chunkImport := range chunk.crossChunkImports {
@@ -5638,6 +6501,7 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			r,
 			toCommonJSRef,
 			toESMRef,
+			chunkRepr.crossChunkImportAliases,
 			chunk.sourceIndex,
 		)
 	}
@@ -5698,7 +6562,42 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	}
 
 	// Optionally wrap with an IIFE
-	if c.options.OutputFormat == config.FormatIIFE {
+	if c.options.OutputFormat == config.FormatIIFE && c.options.CodeSplitting {
+		// With code splitting, each chunk is instead wrapped in a function that
+		// is registered with the chunk loader, which calls it once all of the
+		// chunks it depends on have been loaded and evaluated
+		indent = "  "
+		text := ""
+		if file := &c.graph.Files[chunk.sourceIndex]; chunk.isEntryPoint && (file.IsUserSpecifiedEntryPoint() || file.IsWorkerEntryPoint()) {
+			text = c.chunkLoader
+		}
+		text += "(self.esbuildChunks" + space + "=" + space + "self.esbuildChunks" + space + "||" + space + "[]).push([self.document" + space + "&&" + space + "document.currentScript.src," + space + "["
+		params := []string{r.NameForSymbol(chunkRepr.chunkExportsRef), r.NameForSymbol(chunkRepr.chunkLoaderRef)}
+		for _, chunkImport := range chunk.crossChunkImports {
+			if chunkImport.importKind == ast.ImportStmt {
+				if len(params) > 2 {
+					text += "," + space
+				}
+				uniqueKey := c.chunks[chunkImport.chunkIndex].uniqueKey
+				text += "\"" + uniqueKey + "\""
+				params = append(params, r.NameForSymbol(chunkRepr.chunkDependencyRefs[len(params)-2]))
+				if c.options.NeedsMetafile {
+					jsonMetadataImports = append(jsonMetadataImports, fmt.Sprintf("\n        {\n          \"path\": %s,\n          \"kind\": %s\n        }",
+						helpers.QuoteForJSON(uniqueKey, c.options.ASCIIOnly),
+						helpers.QuoteForJSON(ast.ImportStmt.StringForMetafile(), c.options.ASCIIOnly)))
+				}
+			}
+		}
+		text += "],"
+		if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
+			text += space + "function(" + strings.Join(params, ","+space) + ")" + space + "{" + newline
+		} else {
+			text += space + "(" + strings.Join(params, ","+space) + ")" + space + "=>" + space + "{" + newline
+		}
+		prevOffset.AdvanceString(text)
+		j.AddString(text)
+		newlineBeforeComment = false
+	} else if c.options.OutputFormat == config.FormatIIFE {
 		var text string
 		indent = "  "
 		if len(c.options.GlobalName) > 0 {
@@ -5893,7 +6792,14 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	}
 
 	// Optionally wrap with an IIFE
-	if c.options.OutputFormat == config.FormatIIFE {
+	if c.options.OutputFormat == config.FormatIIFE && c.options.CodeSplitting {
+		// Entry points are evaluated as soon as they are registered
+		if file := &c.graph.Files[chunk.sourceIndex]; chunk.isEntryPoint && (file.IsUserSpecifiedEntryPoint() || file.IsWorkerEntryPoint()) {
+			j.AddString("}," + space + "1]);" + newline)
+		} else {
+			j.AddString("}]);" + newline)
+		}
+	} else if c.options.OutputFormat == config.FormatIIFE {
 		j.AddString("})();" + newline)
 	}
 
@@ -5933,7 +6839,7 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			}
 			pieces[i] = outputs
 		}
-		chunk.jsonMetadataChunkCallback = func(finalOutputSize int) helpers.Joiner {
+		chunk.jsonMetadataChunkCallback = func(jsonMetadataForContents string) helpers.Joiner {
 			finalRelDir := c.fs.Dir(chunk.finalRelPath)
 			for i, sourceIndex := range metaOrder {
 				if i > 0 {
@@ -5950,7 +6856,8 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			if len(metaOrder) > 0 {
 				jMeta.AddString("\n      ")
 			}
-			jMeta.AddString(fmt.Sprintf("},\n      \"bytes\": %d\n    }", finalOutputSize))
+			jMeta.AddString("},\n      ")
+			jMeta.AddString(jsonMetadataForContents)
 			return jMeta
 		}
 	}
@@ -5960,6 +6867,32 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	chunkWaitGroup.Done()
 }
 
+// The chunk loader is parsed and printed separately from the rest of the
+// runtime because it's included in every entry point chunk
+func (c *linkerContext) generateChunkLoader() string {
+	log := logger.NewDeferLog(logger.DeferLogAll, nil)
+	tree, ok := js_parser.Parse(log, runtime.ChunkLoaderSource(), js_parser.OptionsFromConfig(&config.Options{
+		UnsupportedJSFeatures: c.options.UnsupportedJSFeatures,
+		MinifySyntax:          c.options.MinifySyntax,
+	}))
+	if !ok || log.HasErrors() {
+		msgs := "Internal error: failed to parse chunk loader:\n"
+		for _, msg := range log.Done() {
+			msgs += msg.String(logger.OutputOptions{IncludeSource: true}, logger.TerminalInfo{})
+		}
+		panic(msgs[:len(msgs)-1])
+	}
+
+	symbols := ast.NewSymbolMap(1)
+	symbols.SymbolsForSource[runtime.SourceIndex] = tree.Symbols
+	return string(js_printer.Print(tree, symbols, renamer.NewNoOpRenamer(symbols), js_printer.Options{
+		MinifyWhitespace:    c.options.MinifyWhitespace,
+		MinifySyntax:        c.options.MinifySyntax,
+		UnsupportedFeatures: c.options.UnsupportedJSFeatures,
+		ASCIIOnly:           c.options.ASCIIOnly,
+	}).JS)
+}
+
 func (c *linkerContext) generateGlobalNamePrefix() string {
 	var text string
 	globalName := c.options.GlobalName
@@ -6068,6 +7001,20 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 	// in parallel, and must be done from the last rule to the first rule.
 	timer.Begin("Prepare CSS ASTs")
 	asts := make([]css_ast.AST, len(chunkRepr.importsInChunkInOrder))
+
+	// Gather "@custom-media" and "@custom-selector" definitions in bundle order
+	customDefs := css_parser.CustomDefinitions{IgnoreUndefined: c.options.Mode != config.ModeBundle}
+	for _, entry := range chunkRepr.importsInChunkInOrder {
+		switch entry.kind {
+		case cssImportSourceIndex:
+			file := &c.graph.Files[entry.sourceIndex]
+			customDefs.AddDefinitionsFromRules(&file.InputFile.Source, file.InputFile.Repr.(*graph.CSSRepr).AST.Rules)
+
+		case cssImportExternalPath:
+			customDefs.IgnoreUndefined = true
+		}
+	}
+
 	var remover css_parser.DuplicateRuleRemover
 	if c.options.MinifySyntax {
 		remover = css_parser.MakeDuplicateRuleMangler(c.graph.Symbols)
@@ -6162,6 +7109,9 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 				rules = append(rules, rule)
 			}
 
+			// Substitute "@custom-media" and "@custom-selector" references
+			rules = css_parser.SubstituteCustomDefinitions(c.log, &file.InputFile.Source, rules, &customDefs, css_parser.OptionsFromConfig(file.InputFile.Loader, c.options))
+
 			rules, ast.ImportRecords = wrapRulesWithConditions(rules, ast.ImportRecords, entry.conditions, entry.conditionImportRecords)
 
 			// Remove top-level duplicate rules across files
@@ -6375,7 +7325,7 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 		for i, compileResult := range compileResults {
 			pieces[i] = c.breakOutputIntoPieces(compileResult.CSS)
 		}
-		chunk.jsonMetadataChunkCallback = func(finalOutputSize int) helpers.Joiner {
+		chunk.jsonMetadataChunkCallback = func(jsonMetadataForContents string) helpers.Joiner {
 			finalRelDir := c.fs.Dir(chunk.finalRelPath)
 			isFirst := true
 			for i, compileResult := range compileResults {
@@ -6394,7 +7344,8 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 			if len(compileResults) > 0 {
 				jMeta.AddString("\n      ")
 			}
-			jMeta.AddString(fmt.Sprintf("},\n      \"bytes\": %d\n    }", finalOutputSize))
+			jMeta.AddString("},\n      ")
+			jMeta.AddString(jsonMetadataForContents)
 			return jMeta
 		}
 	}
@@ -6617,6 +7568,14 @@ func (c *linkerContext) appendIsolatedHashesForImportedChunks(
 		c.appendIsolatedHashesForImportedChunks(hash, chunkImport.chunkIndex, visited, visitedKey)
 	}
 
+	// Chunks can also be referenced without being imported (e.g. the CSS chunks
+	// that are preloaded by "import()" expressions)
+	for _, piece := range chunk.intermediateOutput.pieces {
+		if piece.kind == outputPieceChunkIndex {
+			c.appendIsolatedHashesForImportedChunks(hash, piece.index, visited, visitedKey)
+		}
+	}
+
 	// Mix in hashes for referenced asset paths (i.e. the "file" loader)
 	for _, piece := range chunk.intermediateOutput.pieces {
 		if piece.kind == outputPieceAssetIndex {
@@ -6634,6 +7593,13 @@ func (c *linkerContext) appendIsolatedHashesForImportedChunks(
 		}
 	}
 
+	// Mix in the paths of separately-linked workers, which contain their hashes
+	for _, piece := range chunk.intermediateOutput.pieces {
+		if piece.kind == outputPieceWorkerIndex {
+			hashWriteLengthPrefixed(hash, []byte(c.workerRelPath(piece.index)))
+		}
+	}
+
 	// Mix in the hash for this chunk
 	hash.Write(chunk.waitForIsolatedHash())
 }
@@ -6667,6 +7633,8 @@ func (c *linkerContext) breakOutputIntoPieces(output []byte) intermediateOutput
 					kind = outputPieceAssetIndex
 				case 'C':
 					kind = outputPieceChunkIndex
+				case 'W':
+					kind = outputPieceWorkerIndex
 				}
 				for j := 1; j < 9; j++ {
 					c := output[start+j]
@@ -6691,6 +7659,11 @@ func (c *linkerContext) breakOutputIntoPieces(output []byte) intermediateOutput
 				boundary = -1
 			}
 
+		case outputPieceWorkerIndex:
+			if c.options.WorkerOutputPaths[index] == "" {
+				boundary = -1
+			}
+
 		default:
 			boundary = -1
 		}
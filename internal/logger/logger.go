@@ -327,12 +327,20 @@ type PathFlags uint8
 const (
 	// This corresponds to a value of "false' in the "browser" package.json field
 	PathDisabled PathFlags = 1 << iota
+
+	// This path was imported with "import source" and evaluates to the source
+	// representation of the module instead of to the module's namespace object
+	PathSourcePhase
 )
 
 func (p Path) IsDisabled() bool {
 	return (p.Flags & PathDisabled) != 0
 }
 
+func (p Path) IsSourcePhase() bool {
+	return (p.Flags & PathSourcePhase) != 0
+}
+
 var noColorResult bool
 var noColorOnce sync.Once
 
@@ -926,6 +934,10 @@ type SummaryTableEntry struct {
 	Size        string
 	Bytes       int
 	IsSourceMap bool
+
+	// These are empty if compressed sizes weren't computed for this file
+	GzipSize   string
+	BrotliSize string
 }
 
 // This type is just so we can use Go's native sort function
@@ -995,6 +1007,8 @@ func PrintSummary(useColor UseColor, table SummaryTable, start *time.Time) {
 			hasSizeWarning := false
 			maxPath := 0
 			maxSize := 0
+			maxGzip := 0
+			maxBrotli := 0
 			for _, entry := range table {
 				path := len(entry.Dir) + len(entry.Base)
 				size := len(entry.Size) + spacingBetweenColumns
@@ -1004,6 +1018,12 @@ func PrintSummary(useColor UseColor, table SummaryTable, start *time.Time) {
 				if size > maxSize {
 					maxSize = size
 				}
+				if len(entry.GzipSize) > maxGzip {
+					maxGzip = len(entry.GzipSize)
+				}
+				if len(entry.BrotliSize) > maxBrotli {
+					maxBrotli = len(entry.BrotliSize)
+				}
 				if !entry.IsSourceMap && entry.Bytes >= sizeWarningThreshold {
 					hasSizeWarning = true
 				}
@@ -1015,6 +1035,12 @@ func PrintSummary(useColor UseColor, table SummaryTable, start *time.Time) {
 				layoutWidth = defaultTerminalWidth
 			}
 			layoutWidth -= 2 * len(margin)
+			compressedWidth := 0
+			if maxGzip > 0 {
+				// Add space for the compressed size columns
+				compressedWidth = len("  gzip ") + maxGzip + len("  brotli ") + maxBrotli
+				layoutWidth -= compressedWidth
+			}
 			if hasSizeWarning {
 				// Add space for the warning icon
 				layoutWidth -= 2
@@ -1066,7 +1092,23 @@ func PrintSummary(useColor UseColor, table SummaryTable, start *time.Time) {
 					}
 				}
 
-				sb.WriteString(fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s%s\n",
+				// Show the compressed sizes in aligned columns after the size
+				compressed := ""
+				if entry.GzipSize != "" {
+					compressed = fmt.Sprintf("%s  gzip %s%s  brotli %s%s%s",
+						colors.Dim,
+						strings.Repeat(" ", maxGzip-len(entry.GzipSize)),
+						entry.GzipSize,
+						strings.Repeat(" ", maxBrotli-len(entry.BrotliSize)),
+						entry.BrotliSize,
+						colors.Reset,
+					)
+				} else if sizeWarning != "" && compressedWidth > 0 {
+					// Keep the warning icons aligned with each other
+					compressed = strings.Repeat(" ", compressedWidth)
+				}
+
+				sb.WriteString(fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s%s%s%s%s\n",
 					margin,
 					colors.Dim,
 					dir,
@@ -1077,6 +1119,9 @@ func PrintSummary(useColor UseColor, table SummaryTable, start *time.Time) {
 					strings.Repeat(" ", spacer),
 					sizeColor,
 					entry.Size,
+					colors.Reset,
+					compressed,
+					sizeColor,
 					sizeWarning,
 					colors.Reset,
 				))
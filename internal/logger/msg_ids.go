@@ -47,15 +47,20 @@ const (
 	// CSS
 	MsgID_CSS_CSSSyntaxError
 	MsgID_CSS_InvalidAtCharset
+	MsgID_CSS_InvalidAtCustomMedia
+	MsgID_CSS_InvalidAtCustomSelector
 	MsgID_CSS_InvalidAtImport
 	MsgID_CSS_InvalidAtLayer
+	MsgID_CSS_InvalidAtProperty
 	MsgID_CSS_InvalidCalc
 	MsgID_CSS_JSCommentInCSS
 	MsgID_CSS_UndefinedComposesFrom
 	MsgID_CSS_UnsupportedAtCharset
 	MsgID_CSS_UnsupportedAtNamespace
+	MsgID_CSS_UnsupportedAtScope
 	MsgID_CSS_UnsupportedCSSProperty
 	MsgID_CSS_UnsupportedCSSNesting
+	MsgID_CSS_UnsupportedMediaRange
 
 	// Bundler
 	MsgID_Bundler_AmbiguousReexport
@@ -165,10 +170,16 @@ func StringToMsgIDs(str string, logLevel LogLevel, overrides map[MsgID]LogLevel)
 		overrides[MsgID_CSS_CSSSyntaxError] = logLevel
 	case "invalid-@charset":
 		overrides[MsgID_CSS_InvalidAtCharset] = logLevel
+	case "invalid-@custom-media":
+		overrides[MsgID_CSS_InvalidAtCustomMedia] = logLevel
+	case "invalid-@custom-selector":
+		overrides[MsgID_CSS_InvalidAtCustomSelector] = logLevel
 	case "invalid-@import":
 		overrides[MsgID_CSS_InvalidAtImport] = logLevel
 	case "invalid-@layer":
 		overrides[MsgID_CSS_InvalidAtLayer] = logLevel
+	case "invalid-@property":
+		overrides[MsgID_CSS_InvalidAtProperty] = logLevel
 	case "invalid-calc":
 		overrides[MsgID_CSS_InvalidCalc] = logLevel
 	case "js-comment-in-css":
@@ -179,10 +190,14 @@ func StringToMsgIDs(str string, logLevel LogLevel, overrides map[MsgID]LogLevel)
 		overrides[MsgID_CSS_UnsupportedAtCharset] = logLevel
 	case "unsupported-@namespace":
 		overrides[MsgID_CSS_UnsupportedAtNamespace] = logLevel
+	case "unsupported-@scope":
+		overrides[MsgID_CSS_UnsupportedAtScope] = logLevel
 	case "unsupported-css-property":
 		overrides[MsgID_CSS_UnsupportedCSSProperty] = logLevel
 	case "unsupported-css-nesting":
 		overrides[MsgID_CSS_UnsupportedCSSNesting] = logLevel
+	case "unsupported-media-range":
+		overrides[MsgID_CSS_UnsupportedMediaRange] = logLevel
 
 	// Bundler
 	case "ambiguous-reexport":
@@ -297,10 +312,16 @@ func MsgIDToString(id MsgID) string {
 		return "css-syntax-error"
 	case MsgID_CSS_InvalidAtCharset:
 		return "invalid-@charset"
+	case MsgID_CSS_InvalidAtCustomMedia:
+		return "invalid-@custom-media"
+	case MsgID_CSS_InvalidAtCustomSelector:
+		return "invalid-@custom-selector"
 	case MsgID_CSS_InvalidAtImport:
 		return "invalid-@import"
 	case MsgID_CSS_InvalidAtLayer:
 		return "invalid-@layer"
+	case MsgID_CSS_InvalidAtProperty:
+		return "invalid-@property"
 	case MsgID_CSS_InvalidCalc:
 		return "invalid-calc"
 	case MsgID_CSS_JSCommentInCSS:
@@ -311,10 +332,14 @@ func MsgIDToString(id MsgID) string {
 		return "unsupported-@charset"
 	case MsgID_CSS_UnsupportedAtNamespace:
 		return "unsupported-@namespace"
+	case MsgID_CSS_UnsupportedAtScope:
+		return "unsupported-@scope"
 	case MsgID_CSS_UnsupportedCSSProperty:
 		return "unsupported-css-property"
 	case MsgID_CSS_UnsupportedCSSNesting:
 		return "unsupported-css-nesting"
+	case MsgID_CSS_UnsupportedMediaRange:
+		return "unsupported-media-range"
 
 	// Bundler
 	case MsgID_Bundler_AmbiguousReexport:
@@ -2207,7 +2207,7 @@ func (r resolverQuery) loadPackageImports(importPath string, dirInfoPackageJSON
 	// The condition set is determined by the kind of import
 	conditions := r.esmConditionsDefault
 	switch r.kind {
-	case ast.ImportStmt, ast.ImportDynamic:
+	case ast.ImportStmt, ast.ImportDynamic, ast.ImportWorker:
 		conditions = r.esmConditionsImport
 	case ast.ImportRequire, ast.ImportRequireResolve:
 		conditions = r.esmConditionsRequire
@@ -2253,7 +2253,7 @@ func (r resolverQuery) esmResolveAlgorithm(esmPackageName string, esmPackageSubp
 	// The condition set is determined by the kind of import
 	conditions := r.esmConditionsDefault
 	switch r.kind {
-	case ast.ImportStmt, ast.ImportDynamic:
+	case ast.ImportStmt, ast.ImportDynamic, ast.ImportWorker:
 		conditions = r.esmConditionsImport
 	case ast.ImportRequire, ast.ImportRequireResolve:
 		conditions = r.esmConditionsRequire
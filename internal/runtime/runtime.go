@@ -138,6 +138,30 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 			throw new Error('Module not found in bundle: ' + path)
 		}
 
+		// This is used as the base URL for "new URL(path, import.meta.url)" when
+		// "import.meta" isn't available. Scripts only know their own URL while they
+		// are first being evaluated, so it's captured then. Workers use their own URL.
+		export var __scriptURL = /* @__PURE__ */ (() => typeof document !== 'undefined'
+			? (document.currentScript || {}).src || document.baseURI
+			: typeof location !== 'undefined' ? location.href : void 0)()
+
+		// This is used to fetch the chunks that a code-split "import()" depends on
+		// in parallel instead of discovering them one level at a time. Stylesheets
+		// are awaited before the import happens to avoid a flash of unstyled content.
+		var __preloaded = {}
+		export var __preload = (load, deps, base) => typeof document === 'undefined' ? load() :
+			Promise.all(deps.map(dep => {
+				var href = new URL(dep, base).href
+				return __preloaded[href] || (__preloaded[href] = new Promise(resolve => {
+					var link = document.createElement('link'), isCSS = /\.css$/.test(href)
+					link.rel = isCSS ? 'stylesheet' : 'modulepreload'
+					link.href = href
+					if (isCSS) link.onload = link.onerror = resolve
+					document.head.appendChild(link)
+					if (!isCSS) resolve()
+				}))
+			})).then(load)
+
 		// For object rest patterns
 		export var __restKey = key => typeof key === 'symbol' ? key : key + ''
 		export var __objRest = (source, exclude) => {
@@ -483,6 +507,32 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 					method('return'),
 					it)
 
+		// This is for "import defer * as ns from 'path'" when bundling. The module
+		// is only evaluated once a property of the namespace object is accessed.
+		export var __importDefer = (init, ns) => {
+			var get = () => ns || (ns = init())
+			return new Proxy(__create(null), {
+				get: (_, key) => key === Symbol.toStringTag ? 'Deferred Module' : get()[key],
+				has: (_, key) => key in get(),
+				ownKeys: () => Reflect.ownKeys(get()),
+				getOwnPropertyDescriptor: (_, key) => {
+					var desc = __getOwnPropDesc(get(), key)
+					if (desc) desc.configurable = true
+					return desc
+				},
+				set: () => false,
+				defineProperty: () => false,
+				deleteProperty: () => false,
+			})
+		}
+
+		// This is for CSS files imported with "with { type: 'css' }"
+		export var __toCSSStyleSheet = css => {
+			var sheet = new CSSStyleSheet()
+			sheet.replaceSync(css)
+			return sheet
+		}
+
 		// This is for the "binary" loader (custom code is ~2x faster than "atob")
 		export var __toBinaryNode = base64 => new Uint8Array(Buffer.from(base64, 'base64'))
 		export var __toBinary = /* @__PURE__ */ (() => {
@@ -547,6 +597,81 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 	}
 }
 
+// Code splitting with the "iife" format uses a small chunk loader that is
+// included at the top of every entry point chunk. Chunks register themselves
+// with the loader by pushing onto a global array. Each chunk is identified by
+// its own URL and lists the URLs of the chunks it imports relative to itself.
+// The loader fetches any missing chunks (using script tags, or "importScripts"
+// inside a worker) and then evaluates each chunk after the chunks it imports,
+// passing their exports objects as arguments. The loader is only installed
+// once even if many entry point chunks are present on the same page.
+//
+// This is parsed separately from the rest of the runtime because it must be
+// present in every entry point chunk. It's still lowered for the configured
+// target, so it must not use syntax that would need other runtime helpers.
+func ChunkLoaderSource() logger.Source {
+	text := `
+		(chunks => {
+			if (chunks.load) return
+			var defined = {}, scripts = {}, evaluated = {}, current
+
+			// Workers don't have a DOM but they can load scripts synchronously
+			var loadScript = url => scripts[url] || (scripts[url] = defined[url] ? Promise.resolve() :
+				self.document ? new Promise((resolve, reject) => {
+					var script = document.createElement('script')
+					script.src = url
+					script.onload = resolve
+					script.onerror = () => reject(new Error('Failed to load chunk ' + url))
+					document.head.appendChild(script)
+				}) : Promise.resolve().then(() => {
+					current = url
+					try {
+						importScripts(url)
+					} finally {
+						current = void 0
+					}
+				}))
+
+			var loadAll = (url, seen) => {
+				if (!seen[url]) {
+					seen[url] = 1
+					return loadScript(url).then(() => Promise.all(defined[url][1].map(dep => loadAll(new URL(dep, url).href, seen))))
+				}
+			}
+
+			var evaluate = url => {
+				var exports = evaluated[url], chunk = defined[url]
+				if (!exports) {
+					exports = evaluated[url] = {}
+					exports = evaluated[url] = chunk[2].apply(void 0, [exports, path => load(new URL(path, url).href)]
+						.concat(chunk[1].map(dep => evaluate(new URL(dep, url).href)))) || exports
+				}
+				return exports
+			}
+
+			var load = chunks.load = url => Promise.resolve(loadAll(url, {})).then(() => evaluate(url))
+
+			// Chunks without a DOM don't know their own URL, so use the URL of the
+			// script being imported (or of the worker itself for the entry point)
+			var define = chunks.push = chunk => {
+				chunk[0] = chunk[0] || current || location.href
+				defined[chunk[0]] = chunk
+				if (chunk[3]) load(chunk[0])
+			}
+
+			chunks.forEach(define)
+		})(self.esbuildChunks = self.esbuildChunks || [])
+	`
+
+	return logger.Source{
+		Index:          SourceIndex,
+		KeyPath:        logger.Path{Text: "<runtime>"},
+		PrettyPath:     "<runtime>",
+		IdentifierName: "runtime",
+		Contents:       text,
+	}
+}
+
 // The TypeScript decorator transform behaves similar to the official
 // TypeScript compiler.
 //
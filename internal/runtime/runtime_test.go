@@ -13,13 +13,15 @@ import (
 func TestUnsupportedFeatures(t *testing.T) {
 	for key, feature := range compat.StringToJSFeature {
 		t.Run(key, func(t *testing.T) {
-			source := runtime.Source(feature)
 			log := logger.NewDeferLog(logger.DeferLogAll, nil)
 
-			js_parser.Parse(log, source, js_parser.OptionsFromConfig(&config.Options{
+			js_parser.Parse(log, runtime.Source(feature), js_parser.OptionsFromConfig(&config.Options{
 				UnsupportedJSFeatures: feature,
 				TreeShaking:           true,
 			}))
+			js_parser.Parse(log, runtime.ChunkLoaderSource(), js_parser.OptionsFromConfig(&config.Options{
+				UnsupportedJSFeatures: feature,
+			}))
 
 			if log.HasErrors() {
 				msgs := "Internal error: failed to parse runtime:\n"
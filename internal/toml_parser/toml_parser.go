@@ -0,0 +1,934 @@
+// This package parses TOML files into the same object representation that the
+// JSON loader uses, so that the top-level keys can become named exports. Date
+// and time values don't have a JavaScript equivalent that is also pure data, so
+// they become strings containing the original RFC 3339 text. The format is
+// described here: https://toml.io/en/v1.0.0
+package toml_parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type Options struct {
+	UnsupportedJSFeatures compat.JSFeature
+}
+
+type tableKind uint8
+
+const (
+	// Created by a "[a.b]" header as a parent of another table
+	tableImplicit tableKind = iota
+
+	// Created by a "[a]" header
+	tableExplicit
+
+	// Created by a dotted key such as "a.b = 1"
+	tableDotted
+)
+
+type table struct {
+	entries map[string]*entry
+	keys    []string
+	loc     logger.Loc
+	kind    tableKind
+}
+
+// Each entry is exactly one of a value, a table, or an array of tables.
+// Inline tables and static arrays are values because they can't be extended.
+type entry struct {
+	value    js_ast.Expr
+	table    *table
+	tables   []*table
+	keyRange logger.Range
+}
+
+type key struct {
+	parts  []string
+	ranges []logger.Range
+}
+
+type parser struct {
+	log     logger.Log
+	tracker logger.LineColumnTracker
+	options Options
+	text    string
+	root    *table
+	current *table
+	pos     int
+}
+
+type parseError struct{}
+
+func Parse(log logger.Log, source logger.Source, options Options) (result js_ast.Expr, ok bool) {
+	root := newTable(logger.Loc{}, tableExplicit)
+	p := &parser{
+		log:     log,
+		tracker: logger.MakeLineColumnTracker(&source),
+		options: options,
+		text:    source.Contents,
+		root:    root,
+		current: root,
+	}
+
+	// Syntax errors are reported by panicking, which is caught here
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isParseError := r.(parseError); isParseError {
+				result = js_ast.Expr{}
+				ok = false
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	// Skip a byte order mark
+	if strings.HasPrefix(p.text, "\uFEFF") {
+		p.pos = 3
+	}
+
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.pos >= len(p.text) {
+			break
+		}
+		if p.text[p.pos] == '[' {
+			p.parseTableHeader()
+		} else {
+			p.parseKeyValue(p.current)
+		}
+		p.expectEndOfLine()
+	}
+
+	return p.tableToExpr(root, false), true
+}
+
+func newTable(loc logger.Loc, kind tableKind) *table {
+	return &table{entries: make(map[string]*entry), loc: loc, kind: kind}
+}
+
+func (t *table) add(name string, e *entry) {
+	t.entries[name] = e
+	t.keys = append(t.keys, name)
+}
+
+func (p *parser) fail(r logger.Range, text string) {
+	p.log.AddError(&p.tracker, r, text)
+	panic(parseError{})
+}
+
+func (p *parser) failRedefined(r logger.Range, text string, name string, original logger.Range) {
+	p.log.AddErrorWithNotes(&p.tracker, r, text,
+		[]logger.MsgData{p.tracker.MsgData(original, fmt.Sprintf("The original definition of %q is here:", name))})
+	panic(parseError{})
+}
+
+func (p *parser) loc() logger.Loc {
+	return logger.Loc{Start: int32(p.pos)}
+}
+
+func (p *parser) rangeFrom(start int) logger.Range {
+	return logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: int32(p.pos - start)}
+}
+
+func (p *parser) peek(offset int) byte {
+	if i := p.pos + offset; i < len(p.text) {
+		return p.text[i]
+	}
+	return 0
+}
+
+// Returns the range and the text of the thing at the current position for use
+// in error messages
+func (p *parser) currentToken() (logger.Range, string) {
+	if p.pos >= len(p.text) {
+		return logger.Range{Loc: p.loc()}, "end of file"
+	}
+	if c := p.text[p.pos]; c == '\n' || (c == '\r' && p.peek(1) == '\n') {
+		return logger.Range{Loc: p.loc()}, "end of line"
+	}
+	end := p.pos
+	if strings.IndexByte(",=[]{}", p.text[end]) >= 0 {
+		end++
+	} else {
+		for end < len(p.text) {
+			c := p.text[end]
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' || strings.IndexByte(",=[]{}", c) >= 0 {
+				break
+			}
+			_, width := utf8.DecodeRuneInString(p.text[end:])
+			end += width
+		}
+	}
+	return logger.Range{Loc: p.loc(), Len: int32(end - p.pos)}, fmt.Sprintf("%q", p.text[p.pos:end])
+}
+
+func (p *parser) unexpected() {
+	r, text := p.currentToken()
+	p.fail(r, fmt.Sprintf("Unexpected %s in TOML", text))
+}
+
+func (p *parser) expected(what string) {
+	r, text := p.currentToken()
+	p.fail(r, fmt.Sprintf("Expected %s in TOML but found %s", what, text))
+}
+
+func (p *parser) skipWhitespaceAndComments(newlines bool) {
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case ' ', '\t':
+			p.pos++
+
+		case '#':
+			for p.pos < len(p.text) && p.text[p.pos] != '\n' && p.text[p.pos] != '\r' {
+				p.pos++
+			}
+
+		case '\r':
+			if !newlines || p.peek(1) != '\n' {
+				return
+			}
+			p.pos += 2
+
+		case '\n':
+			if !newlines {
+				return
+			}
+			p.pos++
+
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) expectEndOfLine() {
+	p.skipWhitespaceAndComments(false)
+	if p.pos < len(p.text) && p.text[p.pos] != '\n' && !(p.text[p.pos] == '\r' && p.peek(1) == '\n') {
+		p.expected("end of line")
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
+
+func (p *parser) parseKey() key {
+	var k key
+	for {
+		p.skipWhitespaceAndComments(false)
+		start := p.pos
+		var part string
+
+		switch p.peek(0) {
+		case '"':
+			if strings.HasPrefix(p.text[p.pos:], "\"\"\"") {
+				p.fail(logger.Range{Loc: p.loc(), Len: 3}, "Multi-line strings cannot be used as keys in TOML")
+			}
+			part = p.parseBasicString()
+
+		case '\'':
+			if strings.HasPrefix(p.text[p.pos:], "'''") {
+				p.fail(logger.Range{Loc: p.loc(), Len: 3}, "Multi-line strings cannot be used as keys in TOML")
+			}
+			part = p.parseLiteralString()
+
+		default:
+			for p.pos < len(p.text) && isBareKeyChar(p.text[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				p.expected("key")
+			}
+			part = p.text[start:p.pos]
+		}
+
+		k.parts = append(k.parts, part)
+		k.ranges = append(k.ranges, p.rangeFrom(start))
+		p.skipWhitespaceAndComments(false)
+		if p.peek(0) != '.' {
+			return k
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseTableHeader() {
+	start := p.pos
+	isArray := p.peek(1) == '['
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+
+	k := p.parseKey()
+	if isArray {
+		if !strings.HasPrefix(p.text[p.pos:], "]]") {
+			p.expected("\"]]\"")
+		}
+		p.pos += 2
+	} else {
+		if p.peek(0) != ']' {
+			p.expected("\"]\"")
+		}
+		p.pos++
+	}
+	headerRange := p.rangeFrom(start)
+
+	// Walk down to the parent of the table being defined. Tables that don't
+	// exist yet are created implicitly, and arrays of tables refer to their
+	// most recently defined table.
+	t := p.root
+	last := len(k.parts) - 1
+	for i, part := range k.parts[:last] {
+		e := t.entries[part]
+		if e == nil {
+			child := newTable(headerRange.Loc, tableImplicit)
+			t.add(part, &entry{table: child, keyRange: k.ranges[i]})
+			t = child
+		} else if e.table != nil {
+			t = e.table
+		} else if e.tables != nil {
+			t = e.tables[len(e.tables)-1]
+		} else {
+			name := strings.Join(k.parts[:i+1], ".")
+			p.failRedefined(k.ranges[i], fmt.Sprintf("Cannot redefine %q as a table", name), name, e.keyRange)
+		}
+	}
+
+	name := strings.Join(k.parts, ".")
+	part := k.parts[last]
+	e := t.entries[part]
+
+	if isArray {
+		if e == nil {
+			e = &entry{tables: []*table{}, keyRange: k.ranges[last]}
+			t.add(part, e)
+		} else if e.tables == nil {
+			p.failRedefined(k.ranges[last], fmt.Sprintf("Cannot redefine %q as an array of tables", name), name, e.keyRange)
+		}
+		child := newTable(headerRange.Loc, tableExplicit)
+		e.tables = append(e.tables, child)
+		p.current = child
+		return
+	}
+
+	if e == nil {
+		child := newTable(headerRange.Loc, tableExplicit)
+		t.add(part, &entry{table: child, keyRange: k.ranges[last]})
+		p.current = child
+	} else if e.table != nil && e.table.kind == tableImplicit {
+		e.table.kind = tableExplicit
+		e.table.loc = headerRange.Loc
+		e.keyRange = k.ranges[last]
+		p.current = e.table
+	} else if e.table != nil || e.tables != nil {
+		p.failRedefined(k.ranges[last], fmt.Sprintf("Duplicate table %q", name), name, e.keyRange)
+	} else {
+		p.failRedefined(k.ranges[last], fmt.Sprintf("Cannot redefine %q as a table", name), name, e.keyRange)
+	}
+}
+
+func (p *parser) parseKeyValue(t *table) {
+	k := p.parseKey()
+	if p.peek(0) != '=' {
+		p.expected("\"=\"")
+	}
+	p.pos++
+	p.skipWhitespaceAndComments(false)
+	value := p.parseValue()
+
+	// Dotted keys create and extend tables, but only tables that were also
+	// created by dotted keys
+	last := len(k.parts) - 1
+	for i, part := range k.parts[:last] {
+		e := t.entries[part]
+		if e == nil {
+			child := newTable(k.ranges[i].Loc, tableDotted)
+			t.add(part, &entry{table: child, keyRange: k.ranges[i]})
+			t = child
+		} else if e.table != nil && e.table.kind == tableDotted {
+			t = e.table
+		} else {
+			name := strings.Join(k.parts[:i+1], ".")
+			p.failRedefined(k.ranges[i], fmt.Sprintf("Cannot redefine %q as a table", name), name, e.keyRange)
+		}
+	}
+
+	part := k.parts[last]
+	if e := t.entries[part]; e != nil {
+		name := strings.Join(k.parts, ".")
+		p.failRedefined(k.ranges[last], fmt.Sprintf("Duplicate key %q", name), name, e.keyRange)
+	}
+	t.add(part, &entry{value: value, keyRange: k.ranges[last]})
+}
+
+func (p *parser) parseValue() js_ast.Expr {
+	loc := p.loc()
+
+	switch c := p.peek(0); {
+	case c == '"':
+		var text string
+		if strings.HasPrefix(p.text[p.pos:], "\"\"\"") {
+			text = p.parseMultiLineString('"')
+		} else {
+			text = p.parseBasicString()
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(text)}}
+
+	case c == '\'':
+		var text string
+		if strings.HasPrefix(p.text[p.pos:], "'''") {
+			text = p.parseMultiLineString('\'')
+		} else {
+			text = p.parseLiteralString()
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(text)}}
+
+	case c == '[':
+		return p.parseArray()
+
+	case c == '{':
+		return p.parseInlineTable()
+
+	case c == 't' || c == 'f':
+		word := p.scanWord()
+		if word != "true" && word != "false" {
+			p.fail(p.rangeFrom(int(loc.Start)), fmt.Sprintf("Unexpected %q in TOML", word))
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: word == "true"}}
+
+	case c == '+' || c == '-' || c == 'i' || c == 'n' || (c >= '0' && c <= '9'):
+		if text, ok := p.scanDateTime(); ok {
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(text)}}
+		}
+		word := p.scanWord()
+		value, isInteger, ok := parseNumber(word)
+		if !ok {
+			if c == 'i' || c == 'n' {
+				p.fail(p.rangeFrom(int(loc.Start)), fmt.Sprintf("Unexpected %q in TOML", word))
+			}
+			p.fail(p.rangeFrom(int(loc.Start)), fmt.Sprintf("Invalid number %q in TOML", word))
+		}
+
+		// TOML integers are 64-bit but JavaScript numbers are doubles
+		if isInteger && math.Abs(value) > maxSafeInteger {
+			p.log.AddID(logger.MsgID_None, logger.Warning, &p.tracker, p.rangeFrom(int(loc.Start)),
+				fmt.Sprintf("The TOML integer %s is outside the range that JavaScript numbers can represent exactly", word))
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: value}}
+
+	default:
+		p.expected("value")
+		return js_ast.Expr{}
+	}
+}
+
+// Scans the characters that can appear in a boolean or a number
+func (p *parser) scanWord() string {
+	start := p.pos
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		if !isBareKeyChar(c) && c != '+' && c != '.' {
+			break
+		}
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+// This is "Number.MAX_SAFE_INTEGER" in JavaScript
+const maxSafeInteger = 1<<53 - 1
+
+// This also returns whether the number was written as an integer
+func parseNumber(text string) (value float64, isInteger bool, ok bool) {
+	sign := 1.0
+	digits := text
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		if digits[0] == '-' {
+			sign = -1
+		}
+		digits = digits[1:]
+	}
+
+	switch digits {
+	case "inf":
+		return math.Inf(int(sign)), false, true
+	case "nan":
+		return math.NaN(), false, true
+	}
+
+	// Hexadecimal, octal, and binary integers can't have a sign
+	if len(digits) > 2 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'o' || digits[1] == 'b') {
+		if len(digits) != len(text) {
+			return 0, false, false
+		}
+		base := 16
+		if digits[1] == 'o' {
+			base = 8
+		} else if digits[1] == 'b' {
+			base = 2
+		}
+		clean, ok := removeUnderscores(digits[2:], true)
+		if !ok {
+			return 0, false, false
+		}
+		value, err := strconv.ParseInt(clean, base, 64)
+		if err != nil {
+			return 0, false, false
+		}
+		return float64(value), true, true
+	}
+
+	// Decimal integers and floats can't have leading zeros
+	i := 0
+	for i < len(digits) && (digits[i] >= '0' && digits[i] <= '9' || digits[i] == '_') {
+		i++
+	}
+	if i == 0 || (digits[0] == '0' && i > 1) {
+		return 0, false, false
+	}
+	isFloat := false
+	if i < len(digits) && digits[i] == '.' {
+		isFloat = true
+		i++
+		fractionStart := i
+		for i < len(digits) && (digits[i] >= '0' && digits[i] <= '9' || digits[i] == '_') {
+			i++
+		}
+		if i == fractionStart {
+			return 0, false, false
+		}
+	}
+	if i < len(digits) && (digits[i] == 'e' || digits[i] == 'E') {
+		isFloat = true
+		i++
+		if i < len(digits) && (digits[i] == '+' || digits[i] == '-') {
+			i++
+		}
+		exponentStart := i
+		for i < len(digits) && (digits[i] >= '0' && digits[i] <= '9' || digits[i] == '_') {
+			i++
+		}
+		if i == exponentStart {
+			return 0, false, false
+		}
+	}
+	if i != len(digits) {
+		return 0, false, false
+	}
+
+	clean, ok := removeUnderscores(text, false)
+	if !ok {
+		return 0, false, false
+	}
+	if isFloat {
+		value, err := strconv.ParseFloat(clean, 64)
+		if err != nil {
+			return 0, false, false
+		}
+		return value, false, true
+	}
+	integer, err := strconv.ParseInt(clean, 10, 64)
+	if err != nil {
+		return 0, false, false
+	}
+	return float64(integer), true, true
+}
+
+// Each underscore must be surrounded by at least one digit on each side
+func removeUnderscores(text string, isHex bool) (string, bool) {
+	if !strings.Contains(text, "_") {
+		return text, true
+	}
+	isDigit := func(i int) bool {
+		if i < 0 || i >= len(text) {
+			return false
+		}
+		c := text[i]
+		return (c >= '0' && c <= '9') || (isHex && ((c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')))
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (!isDigit(i-1) || !isDigit(i+1)) {
+			return "", false
+		}
+	}
+	return strings.Replace(text, "_", "", -1), true
+}
+
+// Dates and times become strings since they are just validated and otherwise
+// passed through unmodified
+func (p *parser) scanDateTime() (string, bool) {
+	text := p.text[p.pos:]
+	n := 0
+
+	if matchDate(text) {
+		n = 10
+		if n < len(text) && (text[n] == 'T' || text[n] == 't' || (text[n] == ' ' && len(text) > n+1 && text[n+1] >= '0' && text[n+1] <= '9')) {
+			if length := matchTime(text[n+1:]); length > 0 {
+				n += 1 + length
+				n += matchOffset(text[n:])
+			} else if text[n] != ' ' {
+				return "", false
+			}
+		}
+	} else if length := matchTime(text); length > 0 {
+		n = length
+	} else {
+		return "", false
+	}
+
+	// The date or time must not be followed by anything else that looks like a value
+	if n < len(text) && (isBareKeyChar(text[n]) || text[n] == '.' || text[n] == ':' || text[n] == '+') {
+		return "", false
+	}
+	p.pos += n
+	return text[:n], true
+}
+
+func matchDigits(text string, count int, max int) bool {
+	if len(text) < count {
+		return false
+	}
+	value := 0
+	for i := 0; i < count; i++ {
+		if text[i] < '0' || text[i] > '9' {
+			return false
+		}
+		value = value*10 + int(text[i]-'0')
+	}
+	return value <= max
+}
+
+// Matches "YYYY-MM-DD"
+func matchDate(text string) bool {
+	if len(text) < 10 || text[4] != '-' || text[7] != '-' ||
+		!matchDigits(text, 4, 9999) || !matchDigits(text[5:], 2, 12) || !matchDigits(text[8:], 2, 31) {
+		return false
+	}
+	year, _ := strconv.Atoi(text[:4])
+	month, _ := strconv.Atoi(text[5:7])
+	day, _ := strconv.Atoi(text[8:10])
+	daysInMonth := [...]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+	if month == 0 || day == 0 {
+		return false
+	}
+	if month == 2 && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return day <= 29
+	}
+	return day <= daysInMonth[month-1]
+}
+
+// Matches "HH:MM:SS" with optional fractional seconds
+func matchTime(text string) int {
+	if len(text) < 8 || text[2] != ':' || text[5] != ':' ||
+		!matchDigits(text, 2, 23) || !matchDigits(text[3:], 2, 59) || !matchDigits(text[6:], 2, 60) {
+		return 0
+	}
+	n := 8
+	if n+1 < len(text) && text[n] == '.' && text[n+1] >= '0' && text[n+1] <= '9' {
+		n++
+		for n < len(text) && text[n] >= '0' && text[n] <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// Matches "Z" or "+HH:MM" or "-HH:MM"
+func matchOffset(text string) int {
+	if text != "" && (text[0] == 'Z' || text[0] == 'z') {
+		return 1
+	}
+	if len(text) >= 6 && (text[0] == '+' || text[0] == '-') && text[3] == ':' &&
+		matchDigits(text[1:], 2, 23) && matchDigits(text[4:], 2, 59) {
+		return 6
+	}
+	return 0
+}
+
+// Control characters other than tab are not allowed in strings or comments
+func isControlChar(c byte) bool {
+	return (c < 0x20 && c != '\t') || c == 0x7F
+}
+
+func (p *parser) parseBasicString() string {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+
+	for {
+		if p.pos >= len(p.text) || p.text[p.pos] == '\n' || p.text[p.pos] == '\r' {
+			p.fail(p.rangeFrom(start), "Unterminated string literal")
+		}
+		c := p.text[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String()
+
+		case c == '\\':
+			p.parseEscape(&sb)
+
+		case isControlChar(c):
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, fmt.Sprintf("Invalid character %q in TOML string", c))
+
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseLiteralString() string {
+	start := p.pos
+	p.pos++
+
+	for {
+		if p.pos >= len(p.text) || p.text[p.pos] == '\n' || p.text[p.pos] == '\r' {
+			p.fail(p.rangeFrom(start), "Unterminated string literal")
+		}
+		c := p.text[p.pos]
+		if c == '\'' {
+			p.pos++
+			return p.text[start+1 : p.pos-1]
+		}
+		if isControlChar(c) {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, fmt.Sprintf("Invalid character %q in TOML string", c))
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseMultiLineString(quote byte) string {
+	start := p.pos
+	p.pos += 3
+	var sb strings.Builder
+
+	// A newline immediately after the opening delimiter is trimmed
+	if p.peek(0) == '\n' {
+		p.pos++
+	} else if p.peek(0) == '\r' && p.peek(1) == '\n' {
+		p.pos += 2
+	}
+
+	for {
+		if p.pos >= len(p.text) {
+			p.fail(logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: 3}, "Unterminated string literal")
+		}
+		c := p.text[p.pos]
+
+		switch {
+		case c == quote:
+			// Up to two quotes are allowed right before the closing delimiter
+			count := 0
+			for p.peek(count) == quote {
+				count++
+			}
+			if count < 3 {
+				sb.WriteString(p.text[p.pos : p.pos+count])
+				p.pos += count
+				continue
+			}
+			if count > 5 {
+				p.pos += 5
+				p.unexpected()
+			}
+			sb.WriteString(p.text[p.pos : p.pos+count-3])
+			p.pos += count
+			return sb.String()
+
+		case c == '\\' && quote == '"':
+			// A backslash at the end of a line trims all whitespace after it
+			end := p.pos + 1
+			for end < len(p.text) && (p.text[end] == ' ' || p.text[end] == '\t') {
+				end++
+			}
+			if end < len(p.text) && (p.text[end] == '\n' || p.text[end] == '\r') {
+				p.pos = end
+				for p.pos < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.pos]) >= 0 {
+					p.pos++
+				}
+				continue
+			}
+			p.parseEscape(&sb)
+
+		case c == '\r' && p.peek(1) == '\n':
+			sb.WriteByte('\n')
+			p.pos += 2
+
+		case c != '\n' && isControlChar(c):
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, fmt.Sprintf("Invalid character %q in TOML string", c))
+
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseEscape(sb *strings.Builder) {
+	start := p.pos
+	if start+1 >= len(p.text) {
+		p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Unterminated string literal")
+	}
+	p.pos += 2
+
+	switch p.text[p.pos-1 : p.pos] {
+	case "b":
+		sb.WriteByte('\b')
+	case "t":
+		sb.WriteByte('\t')
+	case "n":
+		sb.WriteByte('\n')
+	case "f":
+		sb.WriteByte('\f')
+	case "r":
+		sb.WriteByte('\r')
+	case "\"":
+		sb.WriteByte('"')
+	case "\\":
+		sb.WriteByte('\\')
+
+	case "u", "U":
+		count := 4
+		if p.text[p.pos-1] == 'U' {
+			count = 8
+		}
+		if p.pos+count <= len(p.text) {
+			if value, err := strconv.ParseUint(p.text[p.pos:p.pos+count], 16, 32); err == nil && utf8.ValidRune(rune(value)) {
+				p.pos += count
+				sb.WriteRune(rune(value))
+				return
+			}
+		}
+		p.pos = start + 2 + count
+		if p.pos > len(p.text) {
+			p.pos = len(p.text)
+		}
+		p.fail(p.rangeFrom(start), fmt.Sprintf("Invalid escape sequence %q in TOML", p.text[start:p.pos]))
+
+	default:
+		p.fail(p.rangeFrom(start), fmt.Sprintf("Invalid escape sequence %q in TOML", p.text[start:p.pos]))
+	}
+}
+
+func (p *parser) parseArray() js_ast.Expr {
+	loc := p.loc()
+	p.pos++
+	items := []js_ast.Expr{}
+	isSingleLine := true
+
+	for {
+		before := p.pos
+		p.skipWhitespaceAndComments(true)
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.peek(0) == ']' {
+			break
+		}
+		items = append(items, p.parseValue())
+
+		before = p.pos
+		p.skipWhitespaceAndComments(true)
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.peek(0) == ']' {
+			break
+		}
+		if p.peek(0) != ',' {
+			p.expected("\",\" or \"]\"")
+		}
+		p.pos++
+	}
+
+	closeBracketLoc := p.loc()
+	p.pos++
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{
+		Items:           items,
+		IsSingleLine:    isSingleLine,
+		CloseBracketLoc: closeBracketLoc,
+	}}
+}
+
+func (p *parser) parseInlineTable() js_ast.Expr {
+	loc := p.loc()
+	p.pos++
+	t := newTable(loc, tableDotted)
+	p.skipWhitespaceAndComments(false)
+
+	// Inline tables must be on a single line and can't have a trailing comma
+	if p.peek(0) != '}' {
+		for {
+			p.parseKeyValue(t)
+			p.skipWhitespaceAndComments(false)
+			if p.peek(0) == '}' {
+				break
+			}
+			if p.peek(0) != ',' {
+				p.expected("\",\" or \"}\"")
+			}
+			p.pos++
+			p.skipWhitespaceAndComments(false)
+			if p.peek(0) == '}' {
+				p.fail(logger.Range{Loc: logger.Loc{Start: int32(p.pos - 1)}, Len: 1}, "TOML does not support trailing commas in inline tables")
+			}
+		}
+	}
+
+	p.pos++
+	return p.tableToExpr(t, true)
+}
+
+func (p *parser) tableToExpr(t *table, isSingleLine bool) js_ast.Expr {
+	properties := make([]js_ast.Property, 0, len(t.keys))
+
+	for _, name := range t.keys {
+		e := t.entries[name]
+		var value js_ast.Expr
+		if e.table != nil {
+			value = p.tableToExpr(e.table, isSingleLine)
+		} else if e.tables != nil {
+			items := make([]js_ast.Expr, len(e.tables))
+			for i, child := range e.tables {
+				items[i] = p.tableToExpr(child, false)
+			}
+			value = js_ast.Expr{Loc: e.keyRange.Loc, Data: &js_ast.EArray{Items: items}}
+		} else {
+			value = e.value
+		}
+
+		property := js_ast.Property{
+			Kind:       js_ast.PropertyField,
+			Loc:        e.keyRange.Loc,
+			Key:        js_ast.Expr{Loc: e.keyRange.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(name)}},
+			ValueOrNil: value,
+		}
+
+		// The key "__proto__" must not be a string literal in JavaScript because
+		// that actually modifies the prototype of the object. This can be
+		// avoided by using a computed property key instead of a string literal.
+		if name == "__proto__" && !p.options.UnsupportedJSFeatures.Has(compat.ObjectExtensions) {
+			property.Flags |= js_ast.PropertyIsComputed
+		}
+
+		properties = append(properties, property)
+	}
+
+	return js_ast.Expr{Loc: t.loc, Data: &js_ast.EObject{
+		Properties:   properties,
+		IsSingleLine: isSingleLine,
+	}}
+}
@@ -0,0 +1,236 @@
+package toml_parser
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_printer"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectParseError(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expected)
+	})
+}
+
+func expectParseErrorLocation(t *testing.T, contents string, line int, column int, length int) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		if len(msgs) != 1 || msgs[0].Data.Location == nil {
+			t.Fatal("Expected a single error with a location")
+		}
+		loc := msgs[0].Data.Location
+		test.AssertEqual(t, loc.Line, line)
+		test.AssertEqual(t, loc.Column, column)
+		test.AssertEqual(t, loc.Length, length)
+	})
+}
+
+func expectPrinted(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		expr, ok := Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, "")
+		if !ok {
+			t.Fatal("Parse error")
+		}
+
+		// Insert this expression into a statement
+		tree := js_ast.AST{
+			Parts: []js_ast.Part{{Stmts: []js_ast.Stmt{{Data: &js_ast.SExpr{Value: expr}}}}},
+		}
+
+		js := js_printer.Print(tree, ast.SymbolMap{}, nil, js_printer.Options{
+			MinifyWhitespace: true,
+		}).JS
+
+		// Remove the trailing semicolon
+		if n := len(js); n > 1 && js[n-1] == ';' {
+			js = js[:n-1]
+		}
+
+		test.AssertEqualWithDiff(t, string(js), expected)
+	})
+}
+
+func TestTOMLKeys(t *testing.T) {
+	expectPrinted(t, "", "({})")
+	expectPrinted(t, "# comment\n", "({})")
+	expectPrinted(t, "a = 1\nb = 2", "({a:1,b:2})")
+	expectPrinted(t, "bare_key-1 = 1", "({\"bare_key-1\":1})")
+	expectPrinted(t, "\"quoted key\" = 1", "({\"quoted key\":1})")
+	expectPrinted(t, "'literal \\key' = 1", "({\"literal \\\\key\":1})")
+	expectPrinted(t, "\"\" = 1", "({\"\":1})")
+	expectPrinted(t, "1234 = 1", "({\"1234\":1})")
+	expectPrinted(t, "a.b . \"c\" = 1\na.d = 2", "({a:{b:{c:1},d:2}})")
+	expectPrinted(t, "__proto__ = 1", "({[\"__proto__\"]:1})")
+
+	expectParseError(t, "a", "<stdin>: ERROR: Expected \"=\" in TOML but found end of file\n")
+	expectParseError(t, "a = ", "<stdin>: ERROR: Expected value in TOML but found end of file\n")
+	expectParseError(t, "= 1", "<stdin>: ERROR: Expected key in TOML but found \"=\"\n")
+	expectParseError(t, "a b = 1", "<stdin>: ERROR: Expected \"=\" in TOML but found \"b\"\n")
+	expectParseError(t, "a = 1 b = 2", "<stdin>: ERROR: Expected end of line in TOML but found \"b\"\n")
+	expectParseError(t, "\"\"\"a\"\"\" = 1", "<stdin>: ERROR: Multi-line strings cannot be used as keys in TOML\n")
+	expectParseError(t, "a = 1\na = 2",
+		"<stdin>: ERROR: Duplicate key \"a\"\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "a = 1\na.b = 2",
+		"<stdin>: ERROR: Cannot redefine \"a\" as a table\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "a.b = 1\na.b.c = 2",
+		"<stdin>: ERROR: Cannot redefine \"a.b\" as a table\n<stdin>: NOTE: The original definition of \"a.b\" is here:\n")
+}
+
+func TestTOMLTables(t *testing.T) {
+	expectPrinted(t, "[a]\nx = 1\n[b]\ny = 2", "({a:{x:1},b:{y:2}})")
+	expectPrinted(t, "[a.b.c]\nx = 1\n[a]\ny = 2", "({a:{b:{c:{x:1}},y:2}})")
+	expectPrinted(t, "[ a . 'b' ]\nx = 1", "({a:{b:{x:1}}})")
+	expectPrinted(t, "[a]\nb.c = 1\n[a.b.d]\ne = 2", "({a:{b:{c:1,d:{e:2}}}})")
+	expectPrinted(t, "[[a]]\nx = 1\n[[a]]\nx = 2", "({a:[{x:1},{x:2}]})")
+	expectPrinted(t, "[[a]]\n[a.b]\nx = 1\n[[a]]\n[a.b]\nx = 2", "({a:[{b:{x:1}},{b:{x:2}}]})")
+	expectPrinted(t, "[[a.b]]\n[[a.b]]", "({a:{b:[{},{}]}})")
+
+	expectParseError(t, "[a]\n[a]",
+		"<stdin>: ERROR: Duplicate table \"a\"\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "[a.b]\n[a]\n[a.b]",
+		"<stdin>: ERROR: Duplicate table \"a.b\"\n<stdin>: NOTE: The original definition of \"a.b\" is here:\n")
+	expectParseError(t, "a.b = 1\n[a]",
+		"<stdin>: ERROR: Duplicate table \"a\"\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "[a]\nb.c = 1\n[a.b]",
+		"<stdin>: ERROR: Duplicate table \"a.b\"\n<stdin>: NOTE: The original definition of \"a.b\" is here:\n")
+	expectParseError(t, "[a.b]\n[a]\nb.c = 1",
+		"<stdin>: ERROR: Cannot redefine \"b\" as a table\n<stdin>: NOTE: The original definition of \"b\" is here:\n")
+	expectParseError(t, "a = 1\n[a]",
+		"<stdin>: ERROR: Cannot redefine \"a\" as a table\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "a = {}\n[a.b]",
+		"<stdin>: ERROR: Cannot redefine \"a\" as a table\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "a = []\n[[a]]",
+		"<stdin>: ERROR: Cannot redefine \"a\" as an array of tables\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "[a]\n[[a]]",
+		"<stdin>: ERROR: Cannot redefine \"a\" as an array of tables\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "[[a]]\n[a]",
+		"<stdin>: ERROR: Duplicate table \"a\"\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+	expectParseError(t, "[a", "<stdin>: ERROR: Expected \"]\" in TOML but found end of file\n")
+	expectParseError(t, "[[a]", "<stdin>: ERROR: Expected \"]]\" in TOML but found \"]\"\n")
+	expectParseError(t, "[a] b = 1", "<stdin>: ERROR: Expected end of line in TOML but found \"b\"\n")
+}
+
+func TestTOMLStrings(t *testing.T) {
+	expectPrinted(t, "a = \"x\\ty\\n\\\"\\\\\\u00E9\\U0001F600\"", "({a:'x\ty\\n\"\\\\\u00e9\U0001f600'})")
+	expectPrinted(t, "a = 'C:\\path\\\"x\"'", "({a:'C:\\\\path\\\\\"x\"'})")
+	expectPrinted(t, "a = \"\"\"\nline 1\nline 2\"\"\"", "({a:\"line 1\\nline 2\"})")
+	expectPrinted(t, "a = \"\"\"\r\nline 1\r\nline 2\"\"\"", "({a:\"line 1\\nline 2\"})")
+	expectPrinted(t, "a = \"\"\"one \\\n    two \\\n\n    three\"\"\"", "({a:\"one two three\"})")
+	expectPrinted(t, "a = \"\"\"\"quoted\"\"\"\"", "({a:'\"quoted\"'})")
+	expectPrinted(t, "a = \"\"\"x\"\"\"\"\"", "({a:'x\"\"'})")
+	expectPrinted(t, "a = '''\n\\n is not an escape\n'''", "({a:\"\\\\n is not an escape\\n\"})")
+	expectPrinted(t, "a = ''''x'''", "({a:\"'x\"})")
+
+	expectParseError(t, "a = \"x", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "a = \"x\ny\"", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "a = 'x", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "a = \"\"\"x", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "a = \"\\", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "a = \"\\x41\"", "<stdin>: ERROR: Invalid escape sequence \"\\\\x\" in TOML\n")
+	expectParseError(t, "a = \"\\uD800\"", "<stdin>: ERROR: Invalid escape sequence \"\\\\uD800\" in TOML\n")
+	expectParseError(t, "a = \"\\u12\"", "<stdin>: ERROR: Invalid escape sequence \"\\\\u12\\\"\" in TOML\n")
+	expectParseError(t, "a = \"\x01\"", "<stdin>: ERROR: Invalid character '\\x01' in TOML string\n")
+	expectParseError(t, "a = \"\"\"x\"\"\"\"\"\"", "<stdin>: ERROR: Unexpected \"\\\"\" in TOML\n")
+}
+
+func TestTOMLNumbers(t *testing.T) {
+	expectPrinted(t, "a = [0, +1, -1, 1_000, -0]", "({a:[0,1,-1,1e3,0]})")
+	expectPrinted(t, "a = [0xDEAD_beef, 0o755, 0b1101]", "({a:[3735928559,493,13]})")
+	expectPrinted(t, "a = [1.5, -0.01, 5e+22, 1e06, -2E-2, 6.626e-34, 9_224.617_445]", "({a:[1.5,-.01,5e22,1e6,-.02,6626e-37,9224.617445]})")
+	expectPrinted(t, "a = [inf, +inf, -inf, nan, +nan, -nan]", "({a:[Infinity,Infinity,-Infinity,NaN,NaN,NaN]})")
+	expectPrinted(t, "a = [9007199254740991, -9007199254740991]", "({a:[9007199254740991,-9007199254740991]})")
+
+	expectParseError(t, "a = 01", "<stdin>: ERROR: Invalid number \"01\" in TOML\n")
+	expectParseError(t, "a = 1__0", "<stdin>: ERROR: Invalid number \"1__0\" in TOML\n")
+	expectParseError(t, "a = _1", "<stdin>: ERROR: Expected value in TOML but found \"_1\"\n")
+	expectParseError(t, "a = 1_", "<stdin>: ERROR: Invalid number \"1_\" in TOML\n")
+	expectParseError(t, "a = 1e_5", "<stdin>: ERROR: Invalid number \"1e_5\" in TOML\n")
+	expectParseError(t, "a = 1.", "<stdin>: ERROR: Invalid number \"1.\" in TOML\n")
+	expectParseError(t, "a = .1", "<stdin>: ERROR: Expected value in TOML but found \".1\"\n")
+	expectParseError(t, "a = 1.e5", "<stdin>: ERROR: Invalid number \"1.e5\" in TOML\n")
+	expectParseError(t, "a = -0x1", "<stdin>: ERROR: Invalid number \"-0x1\" in TOML\n")
+	expectParseError(t, "a = 0x", "<stdin>: ERROR: Invalid number \"0x\" in TOML\n")
+	expectParseError(t, "a = 0o8", "<stdin>: ERROR: Invalid number \"0o8\" in TOML\n")
+	expectParseError(t, "a = 9223372036854775808", "<stdin>: ERROR: Invalid number \"9223372036854775808\" in TOML\n")
+	expectParseError(t, "a = infinity", "<stdin>: ERROR: Unexpected \"infinity\" in TOML\n")
+	expectParseError(t, "a = no", "<stdin>: ERROR: Unexpected \"no\" in TOML\n")
+
+	expectParseError(t, "a = 9007199254740993",
+		"<stdin>: WARNING: The TOML integer 9007199254740993 is outside the range that JavaScript numbers can represent exactly\n")
+	expectParseError(t, "a = -9_007_199_254_740_993",
+		"<stdin>: WARNING: The TOML integer -9_007_199_254_740_993 is outside the range that JavaScript numbers can represent exactly\n")
+	expectParseError(t, "a = 9223372036854775807",
+		"<stdin>: WARNING: The TOML integer 9223372036854775807 is outside the range that JavaScript numbers can represent exactly\n")
+	expectParseError(t, "a = 0x7FFF_FFFF_FFFF_FFFF",
+		"<stdin>: WARNING: The TOML integer 0x7FFF_FFFF_FFFF_FFFF is outside the range that JavaScript numbers can represent exactly\n")
+	expectParseError(t, "a = 1e300", "")
+}
+
+func TestTOMLBooleansAndDates(t *testing.T) {
+	expectPrinted(t, "a = true\nb = false", "({a:true,b:false})")
+	expectPrinted(t, "a = 1979-05-27T07:32:00Z", "({a:\"1979-05-27T07:32:00Z\"})")
+	expectPrinted(t, "a = 1979-05-27T00:32:00.999999-07:00", "({a:\"1979-05-27T00:32:00.999999-07:00\"})")
+	expectPrinted(t, "a = 1979-05-27 07:32:00", "({a:\"1979-05-27 07:32:00\"})")
+	expectPrinted(t, "a = 1979-05-27 # comment", "({a:\"1979-05-27\"})")
+	expectPrinted(t, "a = 2024-02-29", "({a:\"2024-02-29\"})")
+	expectPrinted(t, "a = 07:32:00.5", "({a:\"07:32:00.5\"})")
+
+	expectParseError(t, "a = True", "<stdin>: ERROR: Expected value in TOML but found \"True\"\n")
+	expectParseError(t, "a = truthy", "<stdin>: ERROR: Unexpected \"truthy\" in TOML\n")
+	expectParseError(t, "a = 2023-02-29", "<stdin>: ERROR: Invalid number \"2023-02-29\" in TOML\n")
+	expectParseError(t, "a = 1979-05-27T25:00:00", "<stdin>: ERROR: Invalid number \"1979-05-27T25\" in TOML\n")
+	expectParseError(t, "a = 07:32", "<stdin>: ERROR: Invalid number \"07\" in TOML\n")
+}
+
+func TestTOMLArraysAndInlineTables(t *testing.T) {
+	expectPrinted(t, "a = []", "({a:[]})")
+	expectPrinted(t, "a = [1, \"x\", [true], {b = 2}]", "({a:[1,\"x\",[true],{b:2}]})")
+	expectPrinted(t, "a = [\n  1, # one\n  2, # two\n]", "({a:[1,2]})")
+	expectPrinted(t, "a = {}", "({a:{}})")
+	expectPrinted(t, "a = { b = 1, c.d = 2, c.e = 3 }", "({a:{b:1,c:{d:2,e:3}}})")
+	expectPrinted(t, "[[a]]\nb = { c = [{ d = 1 }] }", "({a:[{b:{c:[{d:1}]}}]})")
+
+	expectParseError(t, "a = [1 2]", "<stdin>: ERROR: Expected \",\" or \"]\" in TOML but found \"2\"\n")
+	expectParseError(t, "a = [1,,2]", "<stdin>: ERROR: Expected value in TOML but found \",\"\n")
+	expectParseError(t, "a = [", "<stdin>: ERROR: Expected value in TOML but found end of file\n")
+	expectParseError(t, "a = { b = 1, }", "<stdin>: ERROR: TOML does not support trailing commas in inline tables\n")
+	expectParseError(t, "a = { b = 1\n}", "<stdin>: ERROR: Expected \",\" or \"}\" in TOML but found end of line\n")
+	expectParseError(t, "a = { b = 1, b = 2 }",
+		"<stdin>: ERROR: Duplicate key \"b\"\n<stdin>: NOTE: The original definition of \"b\" is here:\n")
+	expectParseError(t, "a = { b = 1 }\na.c = 2",
+		"<stdin>: ERROR: Cannot redefine \"a\" as a table\n<stdin>: NOTE: The original definition of \"a\" is here:\n")
+}
+
+func TestTOMLErrorLocations(t *testing.T) {
+	expectParseErrorLocation(t, "a = 1\nb = \"x\\qy\"", 2, 6, 2)
+	expectParseErrorLocation(t, "[table]\nkey = 1\nkey = 2", 3, 0, 3)
+	expectParseErrorLocation(t, "[a]\n[a]", 2, 1, 1)
+	expectParseErrorLocation(t, "a = 1_", 1, 4, 2)
+	expectParseErrorLocation(t, "a = [1, 2 3]", 1, 10, 1)
+}
@@ -0,0 +1,212 @@
+// This package reads the import and export sections of a WebAssembly binary.
+// It doesn't validate or compile any code. It only extracts enough information
+// to link the module into the module graph like a JavaScript module. The
+// binary format is described here: https://webassembly.github.io/spec/core/binary/modules.html
+package wasm_parser
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+type ExternalKind uint8
+
+const (
+	KindFunction ExternalKind = iota
+	KindTable
+	KindMemory
+	KindGlobal
+	KindTag
+)
+
+func (kind ExternalKind) String() string {
+	switch kind {
+	case KindFunction:
+		return "function"
+	case KindTable:
+		return "table"
+	case KindMemory:
+		return "memory"
+	case KindGlobal:
+		return "global"
+	case KindTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Kind   ExternalKind
+}
+
+type Export struct {
+	Name string
+	Kind ExternalKind
+}
+
+type Module struct {
+	Imports []Import
+	Exports []Export
+}
+
+const (
+	sectionImport = 2
+	sectionExport = 7
+)
+
+type parser struct {
+	contents string
+	offset   int
+}
+
+type parseError struct {
+	text string
+}
+
+// The returned error text is meant to be shown to the user
+func Parse(contents string) (module Module, errorText string) {
+	p := parser{contents: contents}
+
+	// Any malformed data is reported by panicking, which is caught here
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(parseError); ok {
+				module = Module{}
+				errorText = err.text
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	if len(contents) < 8 || contents[:4] != "\x00asm" {
+		return Module{}, "Expected the WebAssembly magic number \"\\0asm\""
+	}
+	if version := contents[4:8]; version != "\x01\x00\x00\x00" {
+		return Module{}, fmt.Sprintf("Unsupported WebAssembly version %d",
+			uint32(version[0])|uint32(version[1])<<8|uint32(version[2])<<16|uint32(version[3])<<24)
+	}
+	p.offset = 8
+
+	for p.offset < len(p.contents) {
+		id := p.byte()
+		size := int(p.u32())
+		end := p.offset + size
+		if end > len(p.contents) {
+			p.fail("Unexpected end of section")
+		}
+
+		switch id {
+		case sectionImport:
+			for i, n := 0, p.u32(); i < int(n); i++ {
+				imp := Import{Module: p.name(), Name: p.name()}
+				imp.Kind = p.externalKind()
+				switch imp.Kind {
+				case KindFunction:
+					p.u32() // typeidx
+				case KindTable:
+					p.byte() // reftype
+					p.limits()
+				case KindMemory:
+					p.limits()
+				case KindGlobal:
+					p.byte() // valtype
+					p.byte() // mut
+				case KindTag:
+					p.byte() // attribute
+					p.u32()  // typeidx
+				}
+				module.Imports = append(module.Imports, imp)
+			}
+
+		case sectionExport:
+			for i, n := 0, p.u32(); i < int(n); i++ {
+				exp := Export{Name: p.name()}
+				exp.Kind = p.externalKind()
+				p.u32() // index
+				module.Exports = append(module.Exports, exp)
+			}
+		}
+
+		if id == sectionImport || id == sectionExport {
+			if p.offset != end {
+				p.fail("Section size mismatch")
+			}
+		}
+		p.offset = end
+	}
+
+	return
+}
+
+func (p *parser) fail(text string) {
+	panic(parseError{text: fmt.Sprintf("%s at offset %d", text, p.offset)})
+}
+
+func (p *parser) byte() byte {
+	if p.offset >= len(p.contents) {
+		p.fail("Unexpected end of file")
+	}
+	c := p.contents[p.offset]
+	p.offset++
+	return c
+}
+
+// This is an unsigned LEB128-encoded integer
+func (p *parser) u64() uint64 {
+	var value uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= 64 {
+			p.fail("Invalid integer")
+		}
+		c := p.byte()
+		value |= uint64(c&0x7F) << shift
+		if c&0x80 == 0 {
+			return value
+		}
+	}
+}
+
+func (p *parser) u32() uint32 {
+	value := p.u64()
+	if value > 0xFFFFFFFF {
+		p.fail("Invalid integer")
+	}
+	return uint32(value)
+}
+
+func (p *parser) name() string {
+	n := int(p.u32())
+	if n > len(p.contents)-p.offset {
+		p.fail("Unexpected end of file")
+	}
+	text := p.contents[p.offset : p.offset+n]
+	if !utf8.ValidString(text) {
+		p.fail("Invalid UTF-8 name")
+	}
+	p.offset += n
+	return text
+}
+
+func (p *parser) externalKind() ExternalKind {
+	kind := p.byte()
+	if kind > byte(KindTag) {
+		p.fail(fmt.Sprintf("Invalid external kind %d", kind))
+	}
+	return ExternalKind(kind)
+}
+
+func (p *parser) limits() {
+	// Bit 0 means there's a maximum. Other bits are for the "threads" and
+	// "memory64" proposals and don't change how the limits are encoded.
+	flags := p.byte()
+	if flags > 7 {
+		p.fail(fmt.Sprintf("Invalid limits flags %d", flags))
+	}
+	p.u64()
+	if flags&1 != 0 {
+		p.u64()
+	}
+}
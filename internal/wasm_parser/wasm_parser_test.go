@@ -0,0 +1,74 @@
+package wasm_parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectParsed(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(fmt.Sprintf("%q", contents), func(t *testing.T) {
+		t.Helper()
+		module, errorText := Parse(contents)
+		if errorText != "" {
+			test.AssertEqualWithDiff(t, errorText, "")
+			return
+		}
+		var sb strings.Builder
+		for _, imp := range module.Imports {
+			sb.WriteString(fmt.Sprintf("import %s %q from %q\n", imp.Kind, imp.Name, imp.Module))
+		}
+		for _, exp := range module.Exports {
+			sb.WriteString(fmt.Sprintf("export %s %q\n", exp.Kind, exp.Name))
+		}
+		test.AssertEqualWithDiff(t, sb.String(), expected)
+	})
+}
+
+func expectParseError(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(fmt.Sprintf("%q", contents), func(t *testing.T) {
+		t.Helper()
+		_, errorText := Parse(contents)
+		test.AssertEqualWithDiff(t, errorText, expected)
+	})
+}
+
+func TestParse(t *testing.T) {
+	expectParsed(t, "\x00asm\x01\x00\x00\x00", "")
+
+	// Imports of every kind
+	expectParsed(t, "\x00asm\x01\x00\x00\x00\x02\x25\x05"+
+		"\x01a\x01f\x00\x00"+
+		"\x01a\x01t\x01\x70\x00\x01"+
+		"\x01b\x01m\x02\x01\x01\x02"+
+		"\x01b\x01g\x03\x7F\x00"+
+		"\x01c\x01e\x04\x00\x00",
+		`import function "f" from "a"
+import table "t" from "a"
+import memory "m" from "b"
+import global "g" from "b"
+import tag "e" from "c"
+`)
+
+	// Exports, with other sections skipped
+	expectParsed(t, "\x00asm\x01\x00\x00\x00\x01\x04\x01\x60\x00\x00\x07\x12\x02\x03add\x00\x00\x07my-name\x03\x80\x01",
+		`export function "add"
+export global "my-name"
+`)
+}
+
+func TestParseErrors(t *testing.T) {
+	expectParseError(t, "", "Expected the WebAssembly magic number \"\\0asm\"")
+	expectParseError(t, "\x00ASM\x01\x00\x00\x00", "Expected the WebAssembly magic number \"\\0asm\"")
+	expectParseError(t, "\x00asm\x02\x00\x00\x00", "Unsupported WebAssembly version 2")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07", "Unexpected end of file at offset 9")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07\x05\x01", "Unexpected end of section at offset 10")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07\x05\x01\x01a\x05\x00", "Invalid external kind 5 at offset 14")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07\x06\x01\x01a\x00\x00\x00", "Section size mismatch at offset 15")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07\x05\x01\x01\xFF\x00\x00", "Invalid UTF-8 name at offset 12")
+	expectParseError(t, "\x00asm\x01\x00\x00\x00\x07\x80\x80\x80\x80\x80\x01", "Invalid integer at offset 15")
+}
@@ -0,0 +1,1432 @@
+// This package parses YAML files into the same object representation that the
+// JSON loader uses, so that the top-level keys can become named exports. It
+// supports a single document containing block and flow collections, all scalar
+// styles, anchors and aliases, and "<<" merge keys. Plain scalars are resolved
+// using the YAML 1.2 core schema. The format is described here: https://yaml.org/spec/1.2.2/
+package yaml_parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type Options struct {
+	UnsupportedJSFeatures compat.JSFeature
+}
+
+type parser struct {
+	log     logger.Log
+	source  logger.Source
+	tracker logger.LineColumnTracker
+	options Options
+	anchors map[string]js_ast.Expr
+	text    string
+	pos     int
+
+	aliasExpansionNodes int
+}
+
+type parseError struct{}
+
+// Where a block node appears affects which kinds of nodes may start on the
+// same line as the thing before it
+type blockContext uint8
+
+const (
+	blockDocument blockContext = iota
+	blockSequenceEntry
+	blockMappingValue
+)
+
+type scalar struct {
+	text    string
+	r       logger.Range
+	isPlain bool
+}
+
+// Tags and anchors that come before a node
+type properties struct {
+	tag         string
+	anchor      string
+	tagRange    logger.Range
+	anchorRange logger.Range
+}
+
+// The key of a mapping entry along with any properties attached to it
+type mappingKey struct {
+	scalar
+	props properties
+}
+
+func Parse(log logger.Log, source logger.Source, options Options) (result js_ast.Expr, ok bool) {
+	p := &parser{
+		log:     log,
+		source:  source,
+		tracker: logger.MakeLineColumnTracker(&source),
+		options: options,
+		anchors: make(map[string]js_ast.Expr),
+		text:    source.Contents,
+	}
+
+	// Syntax errors are reported by panicking, which is caught here
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isParseError := r.(parseError); isParseError {
+				result = js_ast.Expr{}
+				ok = false
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	// Skip a byte order mark
+	if strings.HasPrefix(p.text, "\uFEFF") {
+		p.pos = 3
+	}
+
+	// Skip over directives such as "%YAML 1.2"
+	p.skipToNextToken()
+	for p.peek(0) == '%' && p.column() == 0 {
+		p.skipToLineEnd()
+		p.skipToNextToken()
+	}
+
+	if p.isDocumentMarker("---") {
+		p.pos += 3
+	}
+	result = p.parseBlockNode(-1, blockDocument)
+
+	p.skipToNextToken()
+	if p.isDocumentMarker("...") {
+		p.pos += 3
+		p.skipToNextToken()
+	}
+	if p.pos < len(p.text) {
+		if p.isDocumentMarker("---") {
+			p.fail(logger.Range{Loc: p.loc(), Len: 3}, "Multiple documents in a single YAML file are not supported")
+		}
+		p.unexpected()
+	}
+	return result, true
+}
+
+func (p *parser) fail(r logger.Range, text string) {
+	p.log.AddError(&p.tracker, r, text)
+	panic(parseError{})
+}
+
+func (p *parser) loc() logger.Loc {
+	return logger.Loc{Start: int32(p.pos)}
+}
+
+func (p *parser) rangeFrom(start int) logger.Range {
+	return logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: int32(p.pos - start)}
+}
+
+func (p *parser) peek(offset int) byte {
+	if i := p.pos + offset; i < len(p.text) {
+		return p.text[i]
+	}
+	return 0
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *parser) atLineEnd() bool {
+	return p.pos >= len(p.text) || isBreak(p.text[p.pos])
+}
+
+func isBreak(c byte) bool {
+	return c == '\n' || c == '\r'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
+
+// Returns true if the character at the offset is whitespace or the end of
+// the file, or is a flow indicator when inside a flow collection
+func (p *parser) isBlankAt(offset int, inFlow bool) bool {
+	if p.pos+offset >= len(p.text) {
+		return true
+	}
+	c := p.text[p.pos+offset]
+	return isSpace(c) || isBreak(c) || (inFlow && isFlowIndicator(c))
+}
+
+func (p *parser) column() int {
+	start := p.pos
+	for start > 0 && !isBreak(p.text[start-1]) {
+		start--
+	}
+	return p.pos - start
+}
+
+func (p *parser) isDocumentMarker(marker string) bool {
+	return p.column() == 0 && strings.HasPrefix(p.text[p.pos:], marker) && p.isBlankAt(3, false)
+}
+
+// Returns the range and the text of the thing at the current position for use
+// in error messages
+func (p *parser) currentToken() (logger.Range, string) {
+	if p.atEnd() {
+		return logger.Range{Loc: p.loc()}, "end of file"
+	}
+	if p.atLineEnd() {
+		return logger.Range{Loc: p.loc()}, "end of line"
+	}
+	end := p.pos
+	if isFlowIndicator(p.text[end]) {
+		end++
+	} else {
+		for end < len(p.text) && !isSpace(p.text[end]) && !isBreak(p.text[end]) && !isFlowIndicator(p.text[end]) {
+			_, width := utf8.DecodeRuneInString(p.text[end:])
+			end += width
+		}
+	}
+	return logger.Range{Loc: p.loc(), Len: int32(end - p.pos)}, fmt.Sprintf("%q", p.text[p.pos:end])
+}
+
+func (p *parser) unexpected() {
+	r, text := p.currentToken()
+	p.fail(r, fmt.Sprintf("Unexpected %s in YAML", text))
+}
+
+func (p *parser) expected(what string) {
+	r, text := p.currentToken()
+	p.fail(r, fmt.Sprintf("Expected %s in YAML but found %s", what, text))
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.text) && isSpace(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) skipToLineEnd() {
+	for p.pos < len(p.text) && !isBreak(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) skipBreak() {
+	if p.peek(0) == '\r' && p.peek(1) == '\n' {
+		p.pos += 2
+	} else if p.pos < len(p.text) {
+		p.pos++
+	}
+}
+
+// A "#" only starts a comment at the start of a line or after whitespace
+func (p *parser) skipComment() {
+	if p.peek(0) == '#' && (p.pos == 0 || isSpace(p.text[p.pos-1]) || isBreak(p.text[p.pos-1])) {
+		p.skipToLineEnd()
+	}
+}
+
+// Skips whitespace, comments, and line breaks. Returns true if at least one
+// line break was skipped.
+func (p *parser) skipToNextToken() bool {
+	crossedNewline := false
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.atEnd() || !isBreak(p.text[p.pos]) {
+			return crossedNewline
+		}
+		p.skipBreak()
+		crossedNewline = true
+	}
+}
+
+// Nothing other than whitespace and a comment may come after a node in block
+// context on the same line
+func (p *parser) expectLineEnd() {
+	p.skipSpaces()
+	p.skipComment()
+	if !p.atLineEnd() {
+		p.unexpected()
+	}
+}
+
+// Tabs may be used to separate tokens but not to indent them
+func (p *parser) checkIndentation() {
+	for i := p.pos - 1; i >= 0 && !isBreak(p.text[i]); i-- {
+		if p.text[i] == '\t' {
+			p.fail(logger.Range{Loc: logger.Loc{Start: int32(i)}, Len: 1}, "YAML does not allow tabs for indentation")
+		}
+	}
+}
+
+// Parses a node in block context. If the node starts on a later line, it must
+// be indented more than "indent". The only exception is a block sequence that
+// is the value of a mapping entry, which may use the same indentation as the
+// mapping's keys.
+func (p *parser) parseBlockNode(indent int, context blockContext) js_ast.Expr {
+	loc := p.loc()
+	onNewLine := false
+	p.skipSpaces()
+	p.skipComment()
+	if p.atLineEnd() {
+		onNewLine = true
+		p.skipToNextToken()
+		if p.isEndOfBlockNode(indent, context) {
+			return js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+		}
+		p.checkIndentation()
+	}
+
+	// Properties must be parsed before we know what kind of node this is
+	propsLoc := p.loc()
+	props := p.parseProperties(false)
+	propsOnSameLine := true
+	if props.tag != "" || props.anchor != "" {
+		p.skipSpaces()
+		p.skipComment()
+		if p.atLineEnd() {
+			propsOnSameLine = false
+			onNewLine = true
+			p.skipToNextToken()
+			if p.isEndOfBlockNode(indent, context) {
+				return p.applyProperties(p.emptyScalar(propsLoc), js_ast.Expr{Loc: propsLoc, Data: js_ast.ENullShared}, props)
+			}
+			p.checkIndentation()
+		}
+	}
+
+	// Block collections may only start on the same line as the thing before
+	// them in certain situations (e.g. "- - x" and "- x: y")
+	allowsCollection := onNewLine || context != blockMappingValue
+	start := p.pos
+	column := p.column()
+	c := p.peek(0)
+
+	switch {
+	case c == '-' && p.isBlankAt(1, false):
+		if !allowsCollection {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, "A block sequence cannot start on the same line as a mapping key in YAML")
+		}
+		return p.applyProperties(scalar{}, p.parseBlockSequence(column), props)
+
+	case (c == '?' || c == ':') && p.isBlankAt(1, false):
+		p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Explicit mapping keys are not supported in YAML")
+
+	case c == '|' || c == '>':
+		s := p.parseBlockScalar(indent)
+		return p.applyProperties(s, p.stringExpr(s), props)
+	}
+
+	// Anything else can be followed by ":" on the same line, in which case it's
+	// actually the first key of a block mapping
+	var key mappingKey
+	var value js_ast.Expr
+	var s scalar
+	isScalar := false
+
+	switch c {
+	case '[', '{':
+		value = p.parseFlowCollection()
+
+	case '*':
+		value = p.parseAlias()
+
+	case '"', '\'':
+		s = p.parseQuotedScalar(indent)
+		isScalar = true
+
+	default:
+		s = p.parsePlainLine(false)
+		isScalar = true
+	}
+
+	p.skipSpaces()
+	if p.peek(0) == ':' && p.isBlankAt(1, false) {
+		if !isScalar {
+			p.fail(p.rangeFrom(start), "Only scalars can be used as mapping keys in YAML")
+		}
+		if !allowsCollection {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Mapping values are not allowed in this context in YAML")
+		}
+		key.scalar = s
+
+		// Properties on the same line as the first key belong to that key
+		// instead of to the mapping
+		if propsOnSameLine && (props.tag != "" || props.anchor != "") {
+			key.props = props
+			props = properties{}
+			end := p.pos
+			p.pos = int(propsLoc.Start)
+			column = p.column()
+			p.pos = end
+		}
+		return p.applyProperties(scalar{}, p.parseBlockMapping(column, key), props)
+	}
+
+	if isScalar {
+		if s.isPlain {
+			s = p.continuePlainScalar(s, indent, false)
+		}
+		value = p.scalarExpr(s, props)
+	} else {
+		value = p.applyProperties(scalar{}, value, props)
+	}
+
+	p.skipSpaces()
+	if p.peek(0) == ':' && p.isBlankAt(1, false) {
+		p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Mapping values are not allowed in this context in YAML")
+	}
+	p.expectLineEnd()
+	return value
+}
+
+// Returns true if the current token is not part of a block node that must be
+// indented more than "indent"
+func (p *parser) isEndOfBlockNode(indent int, context blockContext) bool {
+	if p.atEnd() || p.isDocumentMarker("---") || p.isDocumentMarker("...") {
+		return true
+	}
+	column := p.column()
+	if column == indent && context == blockMappingValue && p.peek(0) == '-' && p.isBlankAt(1, false) {
+		return false
+	}
+	return column <= indent
+}
+
+func (p *parser) parseBlockSequence(column int) js_ast.Expr {
+	loc := p.loc()
+	items := []js_ast.Expr{}
+
+	for {
+		p.pos++ // Skip the "-"
+		items = append(items, p.parseBlockNode(column, blockSequenceEntry))
+
+		p.skipToNextToken()
+		if p.atEnd() || p.isDocumentMarker("---") || p.isDocumentMarker("...") {
+			break
+		}
+		if next := p.column(); next < column {
+			break
+		} else if next > column {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Unexpected indentation in YAML")
+		}
+		if p.peek(0) != '-' || !p.isBlankAt(1, false) {
+			break
+		}
+		p.checkIndentation()
+	}
+
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items}}
+}
+
+func (p *parser) parseBlockMapping(column int, key mappingKey) js_ast.Expr {
+	m := newMappingBuilder(p)
+
+	for {
+		p.pos++ // Skip the ":"
+		value := p.parseBlockNode(column, blockMappingValue)
+		m.add(p.keyExpr(key), value)
+
+		p.skipToNextToken()
+		if p.atEnd() || p.isDocumentMarker("---") || p.isDocumentMarker("...") {
+			break
+		}
+		if next := p.column(); next < column {
+			break
+		} else if next > column {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Unexpected indentation in YAML")
+		}
+		p.checkIndentation()
+
+		// A block sequence may use the same indentation as its parent mapping
+		if p.peek(0) == '-' && p.isBlankAt(1, false) {
+			break
+		}
+
+		key = p.parseMappingKey(false)
+		p.skipSpaces()
+		if p.peek(0) != ':' || !p.isBlankAt(1, false) {
+			p.expected("\":\"")
+		}
+	}
+
+	return js_ast.Expr{Loc: m.loc, Data: &js_ast.EObject{Properties: m.properties}}
+}
+
+func (p *parser) parseMappingKey(inFlow bool) mappingKey {
+	var key mappingKey
+	key.props = p.parseProperties(inFlow)
+	start := p.pos
+
+	switch c := p.peek(0); {
+	case (c == '?' || c == ':') && p.isBlankAt(1, inFlow):
+		p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Explicit mapping keys are not supported in YAML")
+
+	case c == '[' || c == '{' || c == '*':
+		if c == '*' {
+			p.parseAlias()
+		} else {
+			p.parseFlowCollection()
+		}
+		p.fail(p.rangeFrom(start), "Only scalars can be used as mapping keys in YAML")
+
+	case c == '"' || c == '\'':
+		key.scalar = p.parseQuotedScalar(-1)
+
+	default:
+		key.scalar = p.parsePlainLine(inFlow)
+	}
+
+	return key
+}
+
+func (p *parser) parseProperties(inFlow bool) (props properties) {
+	for {
+		c := p.peek(0)
+		if c != '&' && c != '!' {
+			return
+		}
+		start := p.pos
+		p.pos++
+		for !p.isBlankAt(0, inFlow) {
+			p.pos++
+		}
+		r := p.rangeFrom(start)
+
+		if c == '&' {
+			if props.anchor != "" {
+				p.fail(r, "A YAML node can only have one anchor")
+			}
+			if r.Len == 1 {
+				p.fail(r, "Expected an anchor name in YAML")
+			}
+			props.anchor = p.text[start+1 : p.pos]
+			props.anchorRange = r
+		} else {
+			if props.tag != "" {
+				p.fail(r, "A YAML node can only have one tag")
+			}
+			props.tag = p.text[start:p.pos]
+			props.tagRange = r
+
+			// Normalize verbatim tags from the standard schema
+			if strings.HasPrefix(props.tag, "!<tag:yaml.org,2002:") && strings.HasSuffix(props.tag, ">") {
+				props.tag = "!!" + props.tag[len("!<tag:yaml.org,2002:"):len(props.tag)-1]
+			}
+			switch props.tag {
+			case "!", "!!str", "!!int", "!!float", "!!bool", "!!null", "!!map", "!!seq":
+			default:
+				p.fail(r, fmt.Sprintf("The YAML tag %q is not supported", props.tag))
+			}
+		}
+
+		p.skipSpaces()
+	}
+}
+
+func (p *parser) emptyScalar(loc logger.Loc) scalar {
+	return scalar{r: logger.Range{Loc: loc}, isPlain: true}
+}
+
+// Converts a scalar to a value, taking any tag into account
+func (p *parser) scalarExpr(s scalar, props properties) js_ast.Expr {
+	var value js_ast.Expr
+	if s.isPlain && props.tag != "!" && props.tag != "!!str" {
+		value = js_ast.Expr{Loc: s.r.Loc, Data: resolvePlainScalar(s.text)}
+	} else {
+		value = p.stringExpr(s)
+	}
+	return p.applyProperties(s, value, props)
+}
+
+func (p *parser) stringExpr(s scalar) js_ast.Expr {
+	return js_ast.Expr{Loc: s.r.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(s.text)}}
+}
+
+// Checks that the value matches its tag and then records its anchor
+func (p *parser) applyProperties(s scalar, value js_ast.Expr, props properties) js_ast.Expr {
+	if props.tag != "" {
+		ok := true
+		switch props.tag {
+		case "!", "!!str":
+			_, ok = value.Data.(*js_ast.EString)
+		case "!!int", "!!float":
+			if _, isString := value.Data.(*js_ast.EString); isString && s.isPlain {
+				ok = false
+			} else {
+				_, ok = value.Data.(*js_ast.ENumber)
+			}
+			if props.tag == "!!int" && ok {
+				number := value.Data.(*js_ast.ENumber).Value
+				ok = number == math.Trunc(number)
+			}
+		case "!!bool":
+			_, ok = value.Data.(*js_ast.EBoolean)
+		case "!!null":
+			_, ok = value.Data.(*js_ast.ENull)
+		case "!!map":
+			_, ok = value.Data.(*js_ast.EObject)
+		case "!!seq":
+			_, ok = value.Data.(*js_ast.EArray)
+		}
+		if !ok {
+			p.fail(props.tagRange, fmt.Sprintf("This value does not match the YAML tag %q", props.tag))
+		}
+	}
+
+	if props.anchor != "" {
+		p.anchors[props.anchor] = value
+	}
+	return value
+}
+
+func (p *parser) keyExpr(key mappingKey) js_ast.Expr {
+	// Keys are always strings in JavaScript, so the text of plain scalars is
+	// used as-is instead of being resolved
+	value := p.stringExpr(key.scalar)
+	if key.props.tag != "" {
+		if key.props.tag != "!" && key.props.tag != "!!str" {
+			p.scalarExpr(key.scalar, key.props)
+		}
+	}
+	if key.props.anchor != "" {
+		p.anchors[key.props.anchor] = p.scalarExpr(key.scalar, properties{tag: key.props.tag})
+	}
+	return value
+}
+
+// See https://yaml.org/spec/1.2.2/#1032-tag-resolution
+func resolvePlainScalar(text string) js_ast.E {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return js_ast.ENullShared
+	case "true", "True", "TRUE":
+		return &js_ast.EBoolean{Value: true}
+	case "false", "False", "FALSE":
+		return &js_ast.EBoolean{Value: false}
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return &js_ast.ENumber{Value: math.Inf(1)}
+	case "-.inf", "-.Inf", "-.INF":
+		return &js_ast.ENumber{Value: math.Inf(-1)}
+	case ".nan", ".NaN", ".NAN":
+		return &js_ast.ENumber{Value: math.NaN()}
+	}
+
+	if len(text) > 2 && text[0] == '0' && (text[1] == 'o' || text[1] == 'x') {
+		base := 8.0
+		if text[1] == 'x' {
+			base = 16
+		}
+		value := 0.0
+		for _, c := range text[2:] {
+			var digit float64
+			switch {
+			case c >= '0' && c <= '9':
+				digit = float64(c - '0')
+			case c >= 'a' && c <= 'f':
+				digit = float64(c - 'a' + 10)
+			case c >= 'A' && c <= 'F':
+				digit = float64(c - 'A' + 10)
+			default:
+				return &js_ast.EString{Value: helpers.StringToUTF16(text)}
+			}
+			if digit >= base {
+				return &js_ast.EString{Value: helpers.StringToUTF16(text)}
+			}
+			value = value*base + digit
+		}
+		return &js_ast.ENumber{Value: value}
+	}
+
+	if isCoreNumber(text) {
+		if value, err := strconv.ParseFloat(text, 64); err == nil || math.IsInf(value, 0) {
+			return &js_ast.ENumber{Value: value}
+		}
+	}
+
+	return &js_ast.EString{Value: helpers.StringToUTF16(text)}
+}
+
+// Matches "[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?"
+func isCoreNumber(text string) bool {
+	i := 0
+	if i < len(text) && (text[i] == '-' || text[i] == '+') {
+		i++
+	}
+	integerStart := i
+	for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+		i++
+	}
+	hasInteger := i > integerStart
+	if i < len(text) && text[i] == '.' {
+		i++
+		fractionStart := i
+		for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+			i++
+		}
+		if !hasInteger && i == fractionStart {
+			return false
+		}
+	} else if !hasInteger {
+		return false
+	}
+	if i < len(text) && (text[i] == 'e' || text[i] == 'E') {
+		i++
+		if i < len(text) && (text[i] == '-' || text[i] == '+') {
+			i++
+		}
+		exponentStart := i
+		for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+			i++
+		}
+		if i == exponentStart {
+			return false
+		}
+	}
+	return i == len(text)
+}
+
+func (p *parser) parseAlias() js_ast.Expr {
+	start := p.pos
+	p.pos++
+	for !p.isBlankAt(0, true) {
+		p.pos++
+	}
+	r := p.rangeFrom(start)
+	name := p.text[start+1 : p.pos]
+	value, ok := p.anchors[name]
+	if !ok {
+		p.fail(r, fmt.Sprintf("The YAML anchor %q has not been defined", name))
+	}
+	value = p.cloneExpr(value, r)
+	value.Loc = r.Loc
+	return value
+}
+
+// Expanding aliases can make the result exponentially larger than the input
+// (the "billion laughs" attack). Like other YAML parsers, the total number of
+// nodes that aliases may expand to is limited.
+const maxAliasExpansionNodes = 1000000
+
+// Aliases are copied instead of shared because later passes may mutate the tree
+func (p *parser) cloneExpr(expr js_ast.Expr, r logger.Range) js_ast.Expr {
+	p.aliasExpansionNodes++
+	if p.aliasExpansionNodes > maxAliasExpansionNodes {
+		p.fail(r, fmt.Sprintf("Expanding YAML aliases here would exceed the limit of %d nodes", maxAliasExpansionNodes))
+	}
+
+	switch e := expr.Data.(type) {
+	case *js_ast.EArray:
+		clone := *e
+		clone.Items = make([]js_ast.Expr, len(e.Items))
+		for i, item := range e.Items {
+			clone.Items[i] = p.cloneExpr(item, r)
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}
+
+	case *js_ast.EObject:
+		clone := *e
+		clone.Properties = make([]js_ast.Property, len(e.Properties))
+		for i, property := range e.Properties {
+			property.Key = p.cloneExpr(property.Key, r)
+			property.ValueOrNil = p.cloneExpr(property.ValueOrNil, r)
+			clone.Properties[i] = property
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}
+
+	case *js_ast.EString:
+		clone := *e
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}
+
+	case *js_ast.ENumber:
+		clone := *e
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}
+
+	case *js_ast.EBoolean:
+		clone := *e
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}
+	}
+	return expr
+}
+
+// Parses the part of a plain scalar that is on the current line
+func (p *parser) parsePlainLine(inFlow bool) scalar {
+	start := p.pos
+	c := p.peek(0)
+
+	// Indicator characters can't start a plain scalar, except for "-", "?", and
+	// ":" when they are immediately followed by something else
+	if p.atLineEnd() || strings.IndexByte(",[]{}#&*!|>'\"%@`", c) >= 0 ||
+		((c == '-' || c == '?' || c == ':') && p.isBlankAt(1, inFlow)) {
+		p.unexpected()
+	}
+
+	end := p.pos
+	for !p.atLineEnd() {
+		c := p.text[p.pos]
+		if (c == ':' && p.isBlankAt(1, inFlow)) || (inFlow && isFlowIndicator(c)) ||
+			(c == '#' && isSpace(p.text[p.pos-1])) {
+			break
+		}
+		p.pos++
+		if !isSpace(c) {
+			end = p.pos
+		}
+	}
+
+	p.pos = end
+	return scalar{text: p.text[start:end], r: p.rangeFrom(start), isPlain: true}
+}
+
+// Plain scalars can continue onto later lines that are indented more than
+// "indent". Line breaks are folded into spaces and empty lines become "\n".
+func (p *parser) continuePlainScalar(s scalar, indent int, inFlow bool) scalar {
+	text := s.text
+	start := int(s.r.Loc.Start)
+
+	for {
+		end := p.pos
+		p.skipSpaces()
+		if !p.atLineEnd() || p.atEnd() {
+			p.pos = end
+			break
+		}
+
+		breaks := 0
+		for !p.atEnd() && isBreak(p.peek(0)) {
+			p.skipBreak()
+			breaks++
+			p.skipSpaces()
+		}
+		if p.atLineEnd() || p.peek(0) == '#' || p.isDocumentMarker("---") || p.isDocumentMarker("...") ||
+			(!inFlow && p.column() <= indent) || (inFlow && isFlowIndicator(p.peek(0))) ||
+			(p.peek(0) == ':' && p.isBlankAt(1, inFlow)) {
+			p.pos = end
+			break
+		}
+		if !inFlow {
+			p.checkIndentation()
+		}
+
+		line := p.parsePlainLineContinuation(inFlow)
+		if breaks == 1 {
+			text += " "
+		} else {
+			text += strings.Repeat("\n", breaks-1)
+		}
+		text += line
+	}
+
+	return scalar{text: text, r: p.rangeFrom(start), isPlain: true}
+}
+
+func (p *parser) parsePlainLineContinuation(inFlow bool) string {
+	start := p.pos
+	end := p.pos
+	for !p.atLineEnd() {
+		c := p.text[p.pos]
+		if (c == ':' && p.isBlankAt(1, inFlow)) || (inFlow && isFlowIndicator(c)) ||
+			(c == '#' && isSpace(p.text[p.pos-1])) {
+			break
+		}
+		p.pos++
+		if !isSpace(c) {
+			end = p.pos
+		}
+	}
+	p.pos = end
+	return p.text[start:end]
+}
+
+// Folds a run of line breaks inside a quoted scalar. A single line break
+// becomes a space and each additional line break becomes a "\n".
+func (p *parser) foldQuotedLineBreaks(sb *strings.Builder, start int) {
+	breaks := 0
+	for isBreak(p.peek(0)) {
+		p.skipBreak()
+		breaks++
+		p.skipSpaces()
+		if p.isDocumentMarker("---") || p.isDocumentMarker("...") {
+			p.fail(logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: 1}, "Unterminated string literal")
+		}
+	}
+	if breaks == 1 {
+		sb.WriteByte(' ')
+	} else {
+		sb.WriteString(strings.Repeat("\n", breaks-1))
+	}
+}
+
+func (p *parser) parseQuotedScalar(indent int) scalar {
+	start := p.pos
+	quote := p.text[p.pos]
+	p.pos++
+	var sb strings.Builder
+
+	for {
+		if p.atEnd() {
+			p.fail(logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: 1}, "Unterminated string literal")
+		}
+		c := p.text[p.pos]
+
+		switch {
+		case c == quote:
+			if quote == '\'' && p.peek(1) == '\'' {
+				sb.WriteByte('\'')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return scalar{text: sb.String(), r: p.rangeFrom(start)}
+
+		case isSpace(c):
+			// Whitespace before a line break is removed
+			spaceStart := p.pos
+			p.skipSpaces()
+			if !p.atLineEnd() {
+				sb.WriteString(p.text[spaceStart:p.pos])
+			}
+
+		case isBreak(c):
+			p.foldQuotedLineBreaks(&sb, start)
+
+		case c == '\\' && quote == '"':
+			p.parseEscape(&sb, start)
+
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseEscape(sb *strings.Builder, stringStart int) {
+	start := p.pos
+	if start+1 >= len(p.text) {
+		p.fail(logger.Range{Loc: logger.Loc{Start: int32(stringStart)}, Len: 1}, "Unterminated string literal")
+	}
+	c := p.text[start+1]
+	p.pos += 2
+
+	switch c {
+	case '0':
+		sb.WriteByte(0)
+	case 'a':
+		sb.WriteByte('\a')
+	case 'b':
+		sb.WriteByte('\b')
+	case 't', '\t':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'v':
+		sb.WriteByte('\v')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'e':
+		sb.WriteByte(0x1B)
+	case ' ', '"', '/', '\\':
+		sb.WriteByte(c)
+	case 'N':
+		sb.WriteRune('\u0085')
+	case '_':
+		sb.WriteRune('\u00A0')
+	case 'L':
+		sb.WriteRune('\u2028')
+	case 'P':
+		sb.WriteRune('\u2029')
+
+	case '\r', '\n':
+		// An escaped line break is removed along with the leading whitespace on
+		// the next line, but any empty lines are still kept
+		p.pos--
+		p.skipBreak()
+		p.skipSpaces()
+		for isBreak(p.peek(0)) {
+			sb.WriteByte('\n')
+			p.skipBreak()
+			p.skipSpaces()
+		}
+
+	case 'x', 'u', 'U':
+		count := 2
+		if c == 'u' {
+			count = 4
+		} else if c == 'U' {
+			count = 8
+		}
+		if p.pos+count <= len(p.text) {
+			if value, err := strconv.ParseUint(p.text[p.pos:p.pos+count], 16, 32); err == nil && value <= utf8.MaxRune {
+				p.pos += count
+				sb.WriteRune(rune(value))
+				return
+			}
+		}
+		p.fail(p.rangeFrom(start), fmt.Sprintf("Invalid escape sequence %q in YAML", p.text[start:p.pos]))
+
+	default:
+		_, width := utf8.DecodeRuneInString(p.text[start+1:])
+		p.pos = start + 1 + width
+		p.fail(p.rangeFrom(start), fmt.Sprintf("Invalid escape sequence %q in YAML", p.text[start:p.pos]))
+	}
+}
+
+type chomping uint8
+
+const (
+	chompClip chomping = iota
+	chompStrip
+	chompKeep
+)
+
+// Parses a literal ("|") or folded (">") block scalar. The content must be
+// indented more than "indent".
+func (p *parser) parseBlockScalar(indent int) scalar {
+	start := p.pos
+	isFolded := p.text[p.pos] == '>'
+	p.pos++
+
+	// Parse the header
+	chomp := chompClip
+	explicitIndent := 0
+	for i := 0; i < 2; i++ {
+		c := p.peek(0)
+		if (c == '+' || c == '-') && chomp == chompClip {
+			if c == '+' {
+				chomp = chompKeep
+			} else {
+				chomp = chompStrip
+			}
+			p.pos++
+		} else if c >= '1' && c <= '9' && explicitIndent == 0 {
+			explicitIndent = int(c - '0')
+			p.pos++
+		}
+	}
+	p.expectLineEnd()
+	p.skipBreak()
+
+	contentIndent := -1
+	if explicitIndent > 0 {
+		contentIndent = explicitIndent
+		if indent > 0 {
+			contentIndent += indent
+		}
+	}
+
+	// Collect the lines with the content indentation removed. Empty lines are
+	// nil so that they can be distinguished from lines that only contain spaces.
+	var lines []*string
+	endsWithBreak := false
+	for !p.atEnd() {
+		lineStart := p.pos
+		spaces := 0
+		for p.peek(spaces) == ' ' {
+			spaces++
+		}
+		p.pos += spaces
+		isBlank := p.atLineEnd()
+
+		if contentIndent == -1 && !isBlank {
+			if spaces <= indent {
+				p.pos = lineStart
+				break
+			}
+			contentIndent = spaces
+		}
+		if (!isBlank && spaces < contentIndent) || p.isDocumentMarker("---") || p.isDocumentMarker("...") {
+			p.pos = lineStart
+			break
+		}
+
+		if isBlank && (contentIndent == -1 || spaces <= contentIndent) {
+			lines = append(lines, nil)
+		} else {
+			lineEnd := p.pos
+			p.skipToLineEnd()
+			line := p.text[lineStart+contentIndent : p.pos]
+			if lineEnd == p.pos {
+				line = p.text[lineStart+contentIndent : lineStart+spaces]
+			}
+			lines = append(lines, &line)
+		}
+		endsWithBreak = !p.atEnd()
+		p.skipBreak()
+	}
+
+	// Trailing empty lines are only kept with the "+" chomping indicator
+	lastContent := len(lines) - 1
+	for lastContent >= 0 && lines[lastContent] == nil {
+		lastContent--
+	}
+	trailingBreaks := len(lines) - 1 - lastContent
+	if lastContent >= 0 && (lastContent < len(lines)-1 || endsWithBreak) {
+		trailingBreaks++
+	}
+
+	var sb strings.Builder
+	if isFolded {
+		emptyLines := 0
+		prevIsMoreIndented := false
+		for i, line := range lines[:lastContent+1] {
+			if line == nil {
+				emptyLines++
+				continue
+			}
+			isMoreIndented := *line != "" && isSpace((*line)[0])
+			if i > emptyLines {
+				if prevIsMoreIndented || isMoreIndented {
+					emptyLines++
+				} else if emptyLines == 0 {
+					sb.WriteByte(' ')
+				}
+			}
+			sb.WriteString(strings.Repeat("\n", emptyLines))
+			sb.WriteString(*line)
+			emptyLines = 0
+			prevIsMoreIndented = isMoreIndented
+		}
+	} else {
+		for i, line := range lines[:lastContent+1] {
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+			if line != nil {
+				sb.WriteString(*line)
+			}
+		}
+	}
+
+	switch chomp {
+	case chompClip:
+		if trailingBreaks > 0 {
+			sb.WriteByte('\n')
+		}
+	case chompKeep:
+		sb.WriteString(strings.Repeat("\n", trailingBreaks))
+	}
+
+	return scalar{text: sb.String(), r: logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: 1}}
+}
+
+func (p *parser) skipFlowWhitespace() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if !isBreak(p.peek(0)) {
+			return
+		}
+		p.skipBreak()
+	}
+}
+
+func (p *parser) parseFlowCollection() js_ast.Expr {
+	if p.peek(0) == '[' {
+		return p.parseFlowSequence()
+	}
+	return p.parseFlowMapping()
+}
+
+func (p *parser) parseFlowNode() js_ast.Expr {
+	loc := p.loc()
+	props := p.parseProperties(true)
+	p.skipFlowWhitespace()
+
+	switch c := p.peek(0); c {
+	case '[', '{':
+		return p.applyProperties(scalar{}, p.parseFlowCollection(), props)
+
+	case '*':
+		return p.applyProperties(scalar{}, p.parseAlias(), props)
+
+	case '"', '\'':
+		return p.scalarExpr(p.parseQuotedScalar(-1), props)
+
+	case ',', ']', '}':
+		if props.tag == "" && props.anchor == "" {
+			p.unexpected()
+		}
+		return p.scalarExpr(p.emptyScalar(loc), props)
+
+	default:
+		s := p.parsePlainLine(true)
+		return p.scalarExpr(p.continuePlainScalar(s, -1, true), props)
+	}
+}
+
+func (p *parser) parseFlowSequence() js_ast.Expr {
+	loc := p.loc()
+	p.pos++
+	items := []js_ast.Expr{}
+	isSingleLine := true
+
+	for {
+		before := p.pos
+		p.skipFlowWhitespace()
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.atEnd() {
+			p.expected("\"]\"")
+		}
+		if p.peek(0) == ']' {
+			break
+		}
+
+		// A single "key: value" pair inside a flow sequence is a mapping
+		itemStart := p.pos
+		var item js_ast.Expr
+		if p.peek(0) == '?' && p.isBlankAt(1, true) {
+			p.fail(logger.Range{Loc: p.loc(), Len: 1}, "Explicit mapping keys are not supported in YAML")
+		}
+		key := p.tryParseFlowPairKey()
+		if key != nil {
+			m := newMappingBuilder(p)
+			m.loc = logger.Loc{Start: int32(itemStart)}
+			m.add(p.keyExpr(*key), p.parseFlowPairValue(']'))
+			item = js_ast.Expr{Loc: m.loc, Data: &js_ast.EObject{Properties: m.properties, IsSingleLine: true}}
+		} else {
+			item = p.parseFlowNode()
+		}
+		items = append(items, item)
+
+		before = p.pos
+		p.skipFlowWhitespace()
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.peek(0) == ']' {
+			break
+		}
+		if p.peek(0) != ',' {
+			p.expected("\",\" or \"]\"")
+		}
+		p.pos++
+	}
+
+	closeBracketLoc := p.loc()
+	p.pos++
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{
+		Items:           items,
+		IsSingleLine:    isSingleLine,
+		CloseBracketLoc: closeBracketLoc,
+	}}
+}
+
+// Returns the key if the next node is a scalar followed by ":". Otherwise the
+// position is left unchanged and nil is returned.
+func (p *parser) tryParseFlowPairKey() *mappingKey {
+	start := p.pos
+	if c := p.peek(0); c == '[' || c == '{' || c == '*' {
+		return nil
+	}
+	props := p.parseProperties(true)
+	if c := p.peek(0); c == '[' || c == '{' || c == '*' || c == ',' || c == ']' || c == '}' {
+		p.pos = start
+		return nil
+	}
+
+	// Speculatively parse the key and then check for a ":"
+	var key mappingKey
+	key.props = props
+	if c := p.peek(0); c == '"' || c == '\'' {
+		key.scalar = p.parseQuotedScalar(-1)
+	} else {
+		key.scalar = p.continuePlainScalar(p.parsePlainLine(true), -1, true)
+	}
+	p.skipFlowWhitespace()
+	if p.peek(0) == ':' && (!key.isPlain || p.isBlankAt(1, true)) {
+		return &key
+	}
+	p.pos = start
+	return nil
+}
+
+func (p *parser) parseFlowPairValue(closer byte) js_ast.Expr {
+	loc := p.loc()
+	p.pos++ // Skip the ":"
+	p.skipFlowWhitespace()
+	if c := p.peek(0); c == ',' || c == closer {
+		return js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+	}
+	return p.parseFlowNode()
+}
+
+func (p *parser) parseFlowMapping() js_ast.Expr {
+	m := newMappingBuilder(p)
+	p.pos++
+	isSingleLine := true
+
+	for {
+		before := p.pos
+		p.skipFlowWhitespace()
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.atEnd() {
+			p.expected("\"}\"")
+		}
+		if p.peek(0) == '}' {
+			break
+		}
+
+		key := p.parseMappingKey(true)
+		p.skipFlowWhitespace()
+		var value js_ast.Expr
+		if p.peek(0) == ':' && (!key.isPlain || p.isBlankAt(1, true)) {
+			value = p.parseFlowPairValue('}')
+		} else {
+			// A key without a value has a null value
+			value = js_ast.Expr{Loc: key.r.Loc, Data: js_ast.ENullShared}
+		}
+		m.add(p.keyExpr(key), value)
+
+		before = p.pos
+		p.skipFlowWhitespace()
+		if strings.ContainsAny(p.text[before:p.pos], "\r\n") {
+			isSingleLine = false
+		}
+		if p.peek(0) == '}' {
+			break
+		}
+		if p.peek(0) != ',' {
+			p.expected("\",\" or \"}\"")
+		}
+		p.pos++
+	}
+
+	closeBraceLoc := p.loc()
+	p.pos++
+	return js_ast.Expr{Loc: m.loc, Data: &js_ast.EObject{
+		Properties:    m.properties,
+		IsSingleLine:  isSingleLine,
+		CloseBraceLoc: closeBraceLoc,
+	}}
+}
+
+type mappingEntry struct {
+	keyRange logger.Range
+	index    int
+	isMerged bool
+}
+
+// This builds an object while checking for duplicate keys and handling "<<"
+// merge keys. Keys that come from a merge can be overridden by keys in the
+// mapping itself, regardless of the order in which they appear.
+type mappingBuilder struct {
+	p          *parser
+	entries    map[string]mappingEntry
+	properties []js_ast.Property
+	loc        logger.Loc
+}
+
+func newMappingBuilder(p *parser) *mappingBuilder {
+	return &mappingBuilder{p: p, entries: make(map[string]mappingEntry), loc: p.loc()}
+}
+
+func (m *mappingBuilder) add(key js_ast.Expr, value js_ast.Expr) {
+	p := m.p
+	name := helpers.UTF16ToString(key.Data.(*js_ast.EString).Value)
+	keyRange := p.keyRange(key.Loc)
+
+	if name == "<<" && p.text[key.Loc.Start] == '<' {
+		m.merge(keyRange, value)
+		return
+	}
+
+	if entry, ok := m.entries[name]; ok {
+		if !entry.isMerged {
+			p.log.AddErrorWithNotes(&p.tracker, keyRange, fmt.Sprintf("Duplicate key %q in YAML mapping", name),
+				[]logger.MsgData{p.tracker.MsgData(entry.keyRange, fmt.Sprintf("The original key %q is here:", name))})
+			panic(parseError{})
+		}
+		m.properties[entry.index].ValueOrNil = value
+		m.entries[name] = mappingEntry{keyRange: keyRange, index: entry.index}
+		return
+	}
+
+	m.entries[name] = mappingEntry{keyRange: keyRange, index: len(m.properties)}
+	m.properties = append(m.properties, m.makeProperty(key, name, value))
+}
+
+func (m *mappingBuilder) makeProperty(key js_ast.Expr, name string, value js_ast.Expr) js_ast.Property {
+	property := js_ast.Property{
+		Kind:       js_ast.PropertyField,
+		Loc:        key.Loc,
+		Key:        key,
+		ValueOrNil: value,
+	}
+
+	// The key "__proto__" must not be a string literal in JavaScript because
+	// that actually modifies the prototype of the object. This can be
+	// avoided by using a computed property key instead of a string literal.
+	if name == "__proto__" && !m.p.options.UnsupportedJSFeatures.Has(compat.ObjectExtensions) {
+		property.Flags |= js_ast.PropertyIsComputed
+	}
+	return property
+}
+
+// The value of a "<<" key must be a mapping or a sequence of mappings. Earlier
+// mappings in the sequence take precedence over later ones.
+func (m *mappingBuilder) merge(keyRange logger.Range, value js_ast.Expr) {
+	var sources []*js_ast.EObject
+	switch v := value.Data.(type) {
+	case *js_ast.EObject:
+		sources = append(sources, v)
+	case *js_ast.EArray:
+		for _, item := range v.Items {
+			if object, ok := item.Data.(*js_ast.EObject); ok {
+				sources = append(sources, object)
+			} else {
+				sources = nil
+				break
+			}
+		}
+	}
+	if sources == nil {
+		m.p.fail(keyRange, "The value of a YAML merge key must be a mapping or a sequence of mappings")
+	}
+
+	for _, source := range sources {
+		for _, property := range source.Properties {
+			name := helpers.UTF16ToString(property.Key.Data.(*js_ast.EString).Value)
+			if _, ok := m.entries[name]; !ok {
+				m.entries[name] = mappingEntry{keyRange: keyRange, index: len(m.properties), isMerged: true}
+				m.properties = append(m.properties, m.makeProperty(property.Key, name, m.p.cloneExpr(property.ValueOrNil, keyRange)))
+			}
+		}
+	}
+}
+
+// Key locations point at the start of a scalar, so this recovers the range
+func (p *parser) keyRange(loc logger.Loc) logger.Range {
+	start := int(loc.Start)
+	end := start
+	if start < len(p.text) && (p.text[start] == '"' || p.text[start] == '\'') {
+		return p.source.RangeOfString(loc)
+	}
+	for end < len(p.text) && !isBreak(p.text[end]) && !(p.text[end] == ':' && (end+1 >= len(p.text) || isSpace(p.text[end+1]) ||
+		isBreak(p.text[end+1]) || isFlowIndicator(p.text[end+1]))) && !isFlowIndicator(p.text[end]) {
+		end++
+	}
+	for end > start && isSpace(p.text[end-1]) {
+		end--
+	}
+	return logger.Range{Loc: loc, Len: int32(end - start)}
+}
@@ -0,0 +1,228 @@
+package yaml_parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_printer"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectParseError(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expected)
+	})
+}
+
+func expectParseErrorLocation(t *testing.T, contents string, line int, column int, length int) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		if len(msgs) != 1 || msgs[0].Data.Location == nil {
+			t.Fatal("Expected a single error with a location")
+		}
+		loc := msgs[0].Data.Location
+		test.AssertEqual(t, loc.Line, line)
+		test.AssertEqual(t, loc.Column, column)
+		test.AssertEqual(t, loc.Length, length)
+	})
+}
+
+func expectPrinted(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		expr, ok := Parse(log, test.SourceForTest(contents), Options{})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, "")
+		if !ok {
+			t.Fatal("Parse error")
+		}
+
+		// Insert this expression into a statement
+		tree := js_ast.AST{
+			Parts: []js_ast.Part{{Stmts: []js_ast.Stmt{{Data: &js_ast.SExpr{Value: expr}}}}},
+		}
+
+		js := js_printer.Print(tree, ast.SymbolMap{}, nil, js_printer.Options{
+			MinifyWhitespace: true,
+		}).JS
+
+		// Remove the trailing semicolon
+		if n := len(js); n > 1 && js[n-1] == ';' {
+			js = js[:n-1]
+		}
+
+		test.AssertEqualWithDiff(t, string(js), expected)
+	})
+}
+
+func TestYAMLScalars(t *testing.T) {
+	expectPrinted(t, "", "null")
+	expectPrinted(t, "# comment", "null")
+	expectPrinted(t, "~", "null")
+	expectPrinted(t, "null", "null")
+	expectPrinted(t, "[true, True, TRUE, false, False, FALSE]", "[true,true,true,false,false,false]")
+	expectPrinted(t, "[yes, no, on, off, tRUE]", "[\"yes\",\"no\",\"on\",\"off\",\"tRUE\"]")
+	expectPrinted(t, "[0, -1, +2, 012, 0o17, 0x1F, 0xg]", "[0,-1,2,12,15,31,\"0xg\"]")
+	expectPrinted(t, "[1.5, -.5, 1., 1e3, 6.02E+23, 1_000, 1.2.3]", "[1.5,-.5,1,1e3,602e21,\"1_000\",\"1.2.3\"]")
+	expectPrinted(t, "[.inf, -.Inf, +.INF, .nan, .NaN]", "[Infinity,-Infinity,Infinity,NaN,NaN]")
+	expectPrinted(t, "hello world", "\"hello world\"")
+	expectPrinted(t, "a:b # comment", "\"a:b\"")
+	expectPrinted(t, "a#b", "\"a#b\"")
+	expectPrinted(t, "-a", "\"-a\"")
+	expectPrinted(t, "'it''s \"quoted\"'", "`it's \"quoted\"`")
+	expectPrinted(t, "\"\\t\\n\\\\\\\"\\x41\\u00e9\\U0001F600\\_\\e\"", "'\t\\n\\\\\"A\u00e9\U0001f600\u00a0\\x1B'")
+	expectPrinted(t, "'123'", "\"123\"")
+	expectPrinted(t, "\"true\"", "\"true\"")
+
+	expectParseError(t, "\"\\q\"", "<stdin>: ERROR: Invalid escape sequence \"\\\\q\" in YAML\n")
+	expectParseError(t, "\"\\x4\"", "<stdin>: ERROR: Invalid escape sequence \"\\\\x\" in YAML\n")
+	expectParseError(t, "\"abc", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "'abc", "<stdin>: ERROR: Unterminated string literal\n")
+	expectParseError(t, "@foo", "<stdin>: ERROR: Unexpected \"@foo\" in YAML\n")
+	expectParseError(t, "`foo`", "<stdin>: ERROR: Unexpected \"`foo`\" in YAML\n")
+	expectParseError(t, "\"a\" b", "<stdin>: ERROR: Unexpected \"b\" in YAML\n")
+}
+
+func TestYAMLMultiLineScalars(t *testing.T) {
+	expectPrinted(t, "a\nb\n\nc", "\"a b\\nc\"")
+	expectPrinted(t, "key: a\n  b\n\n\n  c\nnext: d", "({key:\"a b\\n\\nc\",next:\"d\"})")
+	expectParseError(t, "key: a # comment\n  b", "<stdin>: ERROR: Unexpected indentation in YAML\n")
+	expectPrinted(t, "'a  \n  b\n\n  c'", "\"a b\\nc\"")
+	expectPrinted(t, "\"a  \n  b\\\n   c\"", "\"a bc\"")
+	expectPrinted(t, "\"a\\n\\\n  \\ b\"", "\"a\\n b\"")
+
+	expectPrinted(t, "|\n  a\n   b\n\n  c\n\n", "\"a\\n b\\n\\nc\\n\"")
+	expectPrinted(t, "|-\n  a\n  b\n\n", "\"a\\nb\"")
+	expectPrinted(t, "|+\n  a\n  b\n\n", "\"a\\nb\\n\\n\"")
+	expectPrinted(t, "|\n  a", "\"a\"")
+	expectPrinted(t, "|2\n   a\n  b\n", "\" a\\nb\\n\"")
+	expectPrinted(t, "key: |\n    a\n    b\nnext: c", "({key:\"a\\nb\\n\",next:\"c\"})")
+	expectPrinted(t, "- |\n  a\n- b", "[\"a\\n\",\"b\"]")
+	expectPrinted(t, ">\n  a\n  b\n\n  c\n    d\n  e\n", "\"a b\\nc\\n  d\\ne\\n\"")
+	expectPrinted(t, ">-\n\n  a\n  b\n", "\"\\na b\"")
+	expectPrinted(t, "key: > # comment\n  folded\n  text\n", "({key:\"folded text\\n\"})")
+	expectPrinted(t, "key: |\nnext: a", "({key:\"\",next:\"a\"})")
+
+	expectParseError(t, "|x\n  a", "<stdin>: ERROR: Unexpected \"x\" in YAML\n")
+}
+
+func TestYAMLBlockCollections(t *testing.T) {
+	expectPrinted(t, "a: 1\nb: 2", "({a:1,b:2})")
+	expectPrinted(t, "a:\n  b: 1\n  c:\n    d: 2\ne: 3", "({a:{b:1,c:{d:2}},e:3})")
+	expectPrinted(t, "a:\nb: ", "({a:null,b:null})")
+	expectPrinted(t, "\"quoted key\": 1\n'single': 2\n3: 4\nnull: 5", "({\"quoted key\":1,single:2,\"3\":4,null:5})")
+	expectPrinted(t, "- a\n- b\n-\n- - c\n  - d", "[\"a\",\"b\",null,[\"c\",\"d\"]]")
+	expectPrinted(t, "a:\n- 1\n- 2\nb: 3", "({a:[1,2],b:3})")
+	expectPrinted(t, "a:\n  - 1\n  - 2", "({a:[1,2]})")
+	expectPrinted(t, "- a: 1\n  b: 2\n- c: 3", "[{a:1,b:2},{c:3}]")
+	expectPrinted(t, "---\na: 1\n...\n", "({a:1})")
+	expectPrinted(t, "%YAML 1.2\n---\n- 1", "[1]")
+	expectPrinted(t, "--- # comment\n- 1", "[1]")
+	expectPrinted(t, "a: 1\r\nb:\r\n  - 2\r\n", "({a:1,b:[2]})")
+	expectPrinted(t, "__proto__: 1", "({[\"__proto__\"]:1})")
+
+	expectParseError(t, "a: 1\n  b: 2", "<stdin>: ERROR: Mapping values are not allowed in this context in YAML\n")
+	expectParseError(t, "a:\n    b: 1\n  c: 2", "<stdin>: ERROR: Unexpected indentation in YAML\n")
+	expectPrinted(t, "- a\n  - b", "[\"a - b\"]")
+	expectParseError(t, "a: b: c", "<stdin>: ERROR: Mapping values are not allowed in this context in YAML\n")
+	expectParseError(t, "a: - b", "<stdin>: ERROR: A block sequence cannot start on the same line as a mapping key in YAML\n")
+	expectParseError(t, "a: 1\n- b", "<stdin>: ERROR: Unexpected \"-\" in YAML\n")
+	expectParseError(t, "- a\nb: 1", "<stdin>: ERROR: Unexpected \"b:\" in YAML\n")
+	expectParseError(t, "a: 1\nb", "<stdin>: ERROR: Expected \":\" in YAML but found end of file\n")
+	expectParseError(t, "a:\n\tb: 1", "<stdin>: ERROR: YAML does not allow tabs for indentation\n")
+	expectParseError(t, "? a\n: b", "<stdin>: ERROR: Explicit mapping keys are not supported in YAML\n")
+	expectParseError(t, "[a]: b", "<stdin>: ERROR: Only scalars can be used as mapping keys in YAML\n")
+	expectParseError(t, "a: 1\na: 2",
+		"<stdin>: ERROR: Duplicate key \"a\" in YAML mapping\n<stdin>: NOTE: The original key \"a\" is here:\n")
+	expectParseError(t, "a: 1\n---\nb: 2", "<stdin>: ERROR: Multiple documents in a single YAML file are not supported\n")
+}
+
+func TestYAMLFlowCollections(t *testing.T) {
+	expectPrinted(t, "[]", "[]")
+	expectPrinted(t, "{}", "({})")
+	expectPrinted(t, "[a, 'b', \"c\", [d], {e: f},]", "[\"a\",\"b\",\"c\",[\"d\"],{e:\"f\"}]")
+	expectPrinted(t, "{a: 1, \"b\":2, c, d: , e: [x,y]}", "({a:1,b:2,c:null,d:null,e:[\"x\",\"y\"]})")
+	expectPrinted(t, "{\"a\": {\"b\": [1, 2.5, true, null]}}", "({a:{b:[1,2.5,true,null]}})")
+	expectPrinted(t, "[a: 1, b c: 2]", "[{a:1},{\"b c\":2}]")
+	expectPrinted(t, "[a:b, http://x]", "[\"a:b\",\"http://x\"]")
+	expectPrinted(t, "key: [\n  1, # one\n  2\n]", "({key:[1,2]})")
+	expectPrinted(t, "[multi\n  line, x]", "[\"multi line\",\"x\"]")
+
+	expectParseError(t, "[a", "<stdin>: ERROR: Expected \",\" or \"]\" in YAML but found end of file\n")
+	expectParseError(t, "{a: 1", "<stdin>: ERROR: Expected \",\" or \"}\" in YAML but found end of file\n")
+	expectParseError(t, "[a b c d, ,]", "<stdin>: ERROR: Unexpected \",\" in YAML\n")
+	expectParseError(t, "[a] b", "<stdin>: ERROR: Unexpected \"b\" in YAML\n")
+	expectParseError(t, "{a: 1 b: 2}", "<stdin>: ERROR: Expected \",\" or \"}\" in YAML but found \":\"\n")
+	expectParseError(t, "{a: 1, a: 2}",
+		"<stdin>: ERROR: Duplicate key \"a\" in YAML mapping\n<stdin>: NOTE: The original key \"a\" is here:\n")
+}
+
+func TestYAMLAnchorsAndTags(t *testing.T) {
+	expectPrinted(t, "a: &x 1\nb: *x", "({a:1,b:1})")
+	expectPrinted(t, "a: &x\n  b: 1\nc: *x", "({a:{b:1},c:{b:1}})")
+	expectPrinted(t, "- &x [1, 2]\n- *x", "[[1,2],[1,2]]")
+	expectPrinted(t, "&k key: value\nother: *k", "({key:\"value\",other:\"key\"})")
+	expectPrinted(t, "base: &base\n  a: 1\n  b: 2\nderived:\n  <<: *base\n  b: 3\n  c: 4", "({base:{a:1,b:2},derived:{a:1,b:3,c:4}})")
+	expectPrinted(t, "x: &x {a: 1}\ny: &y {a: 2, b: 2}\nz:\n  c: 3\n  <<: [*x, *y]", "({x:{a:1},y:{a:2,b:2},z:{c:3,a:1,b:2}})")
+	expectPrinted(t, "'<<': 1", "({\"<<\":1})")
+	expectPrinted(t, "[!!str 123, !!str, ! true, !!int 0x10, !!float 1, !!bool false, !!null ~]", "[\"123\",\"\",\"true\",16,1,false,null]")
+	expectPrinted(t, "!!map {a: !!seq [1]}", "({a:[1]})")
+	expectPrinted(t, "a: !!str\n  b", "({a:\"b\"})")
+
+	expectParseError(t, "*x", "<stdin>: ERROR: The YAML anchor \"x\" has not been defined\n")
+	expectParseError(t, "!custom 1", "<stdin>: ERROR: The YAML tag \"!custom\" is not supported\n")
+	expectParseError(t, "!!int 1.5", "<stdin>: ERROR: This value does not match the YAML tag \"!!int\"\n")
+	expectParseError(t, "!!int '1'", "<stdin>: ERROR: This value does not match the YAML tag \"!!int\"\n")
+	expectParseError(t, "!!bool yes", "<stdin>: ERROR: This value does not match the YAML tag \"!!bool\"\n")
+	expectParseError(t, "!!seq {}", "<stdin>: ERROR: This value does not match the YAML tag \"!!seq\"\n")
+	expectParseError(t, "a:\n  <<: 1", "<stdin>: ERROR: The value of a YAML merge key must be a mapping or a sequence of mappings\n")
+	expectParseError(t, "&a &b x", "<stdin>: ERROR: A YAML node can only have one anchor\n")
+}
+
+func TestYAMLAliasExpansionLimit(t *testing.T) {
+	// Each level refers to the previous level ten times
+	contents := "a0: &a0 [x, x, x, x, x, x, x, x, x, x]\n"
+	for i := 1; i <= 5; i++ {
+		contents += fmt.Sprintf("a%d: &a%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				contents += ", "
+			}
+			contents += fmt.Sprintf("*a%d", i-1)
+		}
+		contents += "]\n"
+	}
+
+	expectParseError(t, contents, "<stdin>: ERROR: Expanding YAML aliases here would exceed the limit of 1000000 nodes\n")
+	expectParseErrorLocation(t, contents, 6, 44, 3)
+}
+
+func TestYAMLErrorLocations(t *testing.T) {
+	expectParseErrorLocation(t, "a: 1\nb: \"x\\qy\"", 2, 5, 2)
+	expectParseErrorLocation(t, "a:\n  b: 1\n  b: 2", 3, 2, 1)
+	expectParseErrorLocation(t, "a:\n  - {b: 1\n    c: 2}", 3, 5, 1)
+	expectParseErrorLocation(t, "list:\n  - *missing", 2, 4, 8)
+	expectParseErrorLocation(t, "a: !custom 1", 1, 3, 7)
+}
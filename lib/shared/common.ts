@@ -249,8 +249,13 @@ function flagsForBuildOptions(
   let sourcemap = getFlag(options, keys, 'sourcemap', mustBeStringOrBoolean)
   let bundle = getFlag(options, keys, 'bundle', mustBeBoolean)
   let splitting = getFlag(options, keys, 'splitting', mustBeBoolean)
+  let modulePreload = getFlag(options, keys, 'modulePreload', mustBeBoolean)
+  let preserveEvalOrder = getFlag(options, keys, 'preserveEvalOrder', mustBeBoolean)
   let preserveSymlinks = getFlag(options, keys, 'preserveSymlinks', mustBeBoolean)
   let metafile = getFlag(options, keys, 'metafile', mustBeBoolean)
+  let integrity = getFlag(options, keys, 'integrity', mustBeArray)
+  let manifest = getFlag(options, keys, 'manifest', mustBeBoolean)
+  let compressedSizes = getFlag(options, keys, 'compressedSizes', mustBeBoolean)
   let outfile = getFlag(options, keys, 'outfile', mustBeString)
   let outdir = getFlag(options, keys, 'outdir', mustBeString)
   let outbase = getFlag(options, keys, 'outbase', mustBeString)
@@ -262,9 +267,13 @@ function flagsForBuildOptions(
   let external = getFlag(options, keys, 'external', mustBeArray)
   let packages = getFlag(options, keys, 'packages', mustBeString)
   let alias = getFlag(options, keys, 'alias', mustBeObject)
+  let manualChunks = getFlag(options, keys, 'manualChunks', mustBeObject)
+  let minChunkSize = getFlag(options, keys, 'minChunkSize', mustBeInteger)
+  let maxChunks = getFlag(options, keys, 'maxChunks', mustBeInteger)
   let loader = getFlag(options, keys, 'loader', mustBeObject)
   let outExtension = getFlag(options, keys, 'outExtension', mustBeObject)
   let publicPath = getFlag(options, keys, 'publicPath', mustBeString)
+  let wasmInline = getFlag(options, keys, 'wasmInline', mustBeBoolean)
   let entryNames = getFlag(options, keys, 'entryNames', mustBeString)
   let chunkNames = getFlag(options, keys, 'chunkNames', mustBeString)
   let assetNames = getFlag(options, keys, 'assetNames', mustBeString)
@@ -284,8 +293,13 @@ function flagsForBuildOptions(
   if (bundle) flags.push('--bundle')
   if (allowOverwrite) flags.push('--allow-overwrite')
   if (splitting) flags.push('--splitting')
+  if (modulePreload) flags.push('--module-preload')
+  if (preserveEvalOrder) flags.push('--preserve-eval-order')
   if (preserveSymlinks) flags.push('--preserve-symlinks')
   if (metafile) flags.push(`--metafile`)
+  if (manifest) flags.push(`--manifest`)
+  if (compressedSizes !== void 0) flags.push(`--compressed-sizes=${compressedSizes}`)
+  if (integrity) flags.push(`--integrity=${Array.from(integrity).map(what => validateStringValue(what, 'integrity')).join(',')}`)
   if (outfile) flags.push(`--outfile=${outfile}`)
   if (outdir) flags.push(`--outdir=${outdir}`)
   if (outbase) flags.push(`--outbase=${outbase}`)
@@ -301,6 +315,7 @@ function flagsForBuildOptions(
     flags.push(`--resolve-extensions=${values.join(',')}`)
   }
   if (publicPath) flags.push(`--public-path=${publicPath}`)
+  if (wasmInline) flags.push('--wasm-inline')
   if (entryNames) flags.push(`--entry-names=${entryNames}`)
   if (chunkNames) flags.push(`--chunk-names=${chunkNames}`)
   if (assetNames) flags.push(`--asset-names=${assetNames}`)
@@ -329,6 +344,22 @@ function flagsForBuildOptions(
       flags.push(`--alias:${old}=${validateStringValue(alias[old], 'alias', old)}`)
     }
   }
+  if (minChunkSize) flags.push(`--min-chunk-size=${minChunkSize}`)
+  if (maxChunks) flags.push(`--max-chunks=${maxChunks}`)
+  if (manualChunks) {
+    for (let name in manualChunks) {
+      if (name.indexOf('=') >= 0) throw new Error(`Invalid manual chunk name: ${name}`)
+      let patterns = manualChunks[name]
+      if (!Array.isArray(patterns)) throw new Error(`Expected value for manual chunk ${quote(name)} to be an array`)
+      let values: string[] = []
+      for (let pattern of patterns) {
+        let value = validateStringValue(pattern, 'manual chunk', name)
+        if (value.indexOf(',') >= 0) throw new Error(`Invalid manual chunk pattern: ${value}`)
+        values.push(value)
+      }
+      flags.push(`--manual-chunk:${name}=${values.join(',')}`)
+    }
+  }
   if (banner) {
     for (let type in banner) {
       if (type.indexOf('=') >= 0) throw new Error(`Invalid banner file type: ${type}`)
@@ -1817,7 +1848,7 @@ function sanitizeStringMap(map: Record<string, any>, property: string): Record<s
   return result
 }
 
-function convertOutputFiles({ path, contents, hash }: protocol.BuildOutputFile): types.OutputFile {
+function convertOutputFiles({ path, contents, hash, integrity }: protocol.BuildOutputFile): types.OutputFile {
   // The text is lazily-generated for performance reasons. If no one asks for
   // it, then it never needs to be generated.
   let text: string | null = null
@@ -1825,6 +1856,7 @@ function convertOutputFiles({ path, contents, hash }: protocol.BuildOutputFile):
     path,
     contents,
     hash,
+    integrity,
     get text() {
       // People want to be able to set "contents" and have esbuild automatically
       // derive "text" for them, so grab the contents off of this object instead
@@ -68,6 +68,7 @@ export interface BuildOutputFile {
   path: string
   contents: Uint8Array
   hash: string
+  integrity?: string
 }
 
 export interface PingRequest {
@@ -1,6 +1,6 @@
 export type Platform = 'browser' | 'node' | 'neutral'
 export type Format = 'iife' | 'cjs' | 'esm'
-export type Loader = 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'js' | 'json' | 'jsx' | 'local-css' | 'text' | 'ts' | 'tsx'
+export type Loader = 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'js' | 'json' | 'json5' | 'jsonc' | 'jsx' | 'local-css' | 'text' | 'toml' | 'ts' | 'tsx' | 'wasm' | 'yaml'
 export type LogLevel = 'verbose' | 'debug' | 'info' | 'warning' | 'error' | 'silent'
 export type Charset = 'ascii' | 'utf8'
 export type Drop = 'console' | 'debugger'
@@ -112,12 +112,28 @@ export interface BuildOptions extends CommonOptions {
   bundle?: boolean
   /** Documentation: https://esbuild.github.io/api/#splitting */
   splitting?: boolean
+  /** Preload the chunks that code-split "import()" expressions depend on in parallel */
+  modulePreload?: boolean
+  /** Lazily evaluate code in shared chunks so that code splitting doesn't change evaluation order */
+  preserveEvalOrder?: boolean
+  /** Put these package names or paths into code splitting chunks with these names */
+  manualChunks?: Record<string, string[]>
+  /** Merge shared chunks smaller than this many bytes into other chunks when it's safe to do so */
+  minChunkSize?: number
+  /** Merge shared chunks together when it's safe to do so until there are at most this many chunks */
+  maxChunks?: number
   /** Documentation: https://esbuild.github.io/api/#preserve-symlinks */
   preserveSymlinks?: boolean
   /** Documentation: https://esbuild.github.io/api/#outfile */
   outfile?: string
   /** Documentation: https://esbuild.github.io/api/#metafile */
   metafile?: boolean
+  /** Compute subresource integrity hashes for output files */
+  integrity?: ('sha256' | 'sha384' | 'sha512')[]
+  /** Write a "manifest.json" file to the output directory for server-side integrations */
+  manifest?: boolean
+  /** Include gzip and brotli sizes in the metafile (default true) */
+  compressedSizes?: boolean
   /** Documentation: https://esbuild.github.io/api/#outdir */
   outdir?: string
   /** Documentation: https://esbuild.github.io/api/#outbase */
@@ -146,6 +162,8 @@ export interface BuildOptions extends CommonOptions {
   outExtension?: { [ext: string]: string }
   /** Documentation: https://esbuild.github.io/api/#public-path */
   publicPath?: string
+  /** Embed WebAssembly modules in the output instead of loading them as files (instantiated synchronously unless the format is "esm") */
+  wasmInline?: boolean
   /** Documentation: https://esbuild.github.io/api/#entry-names */
   entryNames?: string
   /** Documentation: https://esbuild.github.io/api/#chunk-names */
@@ -213,6 +231,8 @@ export interface OutputFile {
   path: string
   contents: Uint8Array
   hash: string
+  /** Only when "integrity" is set */
+  integrity?: string
   /** "contents" as text (changes automatically with "contents") */
   readonly text: string
 }
@@ -391,6 +411,7 @@ export type ImportKind =
   | 'require-call'
   | 'dynamic-import'
   | 'require-resolve'
+  | 'worker'
 
   // CSS
   | 'import-rule'
@@ -492,6 +513,9 @@ export interface Metafile {
       exports: string[]
       entryPoint?: string
       cssBundle?: string
+      integrity?: string
+      gzipBytes?: number
+      brotliBytes?: number
     }
   }
 }
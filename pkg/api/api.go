@@ -77,6 +77,7 @@
 package api
 
 import (
+	"os"
 	"time"
 
 	"github.com/evanw/esbuild/internal/logger"
@@ -156,6 +157,11 @@ const (
 	LoaderText
 	LoaderTS
 	LoaderTSX
+	LoaderWasm
+	LoaderJSON5
+	LoaderJSONC
+	LoaderTOML
+	LoaderYAML
 )
 
 type Platform uint8
@@ -258,6 +264,22 @@ const (
 	DropDebugger
 )
 
+type Integrity uint8
+
+const (
+	IntegritySHA256 Integrity = 1 << iota
+	IntegritySHA384
+	IntegritySHA512
+)
+
+type CompressedSizes uint8
+
+const (
+	CompressedSizesDefault CompressedSizes = iota
+	CompressedSizesFalse
+	CompressedSizesTrue
+)
+
 type MangleQuoted uint8
 
 const (
@@ -312,8 +334,13 @@ type BuildOptions struct {
 	Bundle            bool              // Documentation: https://esbuild.github.io/api/#bundle
 	PreserveSymlinks  bool              // Documentation: https://esbuild.github.io/api/#preserve-symlinks
 	Splitting         bool              // Documentation: https://esbuild.github.io/api/#splitting
+	ModulePreload     bool              // Preload the dependencies of code-split "import()" expressions in parallel
+	PreserveEvalOrder bool              // Lazily evaluate code in shared chunks so that code splitting doesn't change evaluation order
 	Outfile           string            // Documentation: https://esbuild.github.io/api/#outfile
 	Metafile          bool              // Documentation: https://esbuild.github.io/api/#metafile
+	Integrity         Integrity         // Compute Subresource Integrity hashes for output files
+	Manifest          bool              // Generate a "manifest.json" file in the output directory for server-side integrations
+	CompressedSizes   CompressedSizes   // Report gzip and brotli sizes in the metafile and the build summary (on by default)
 	Outdir            string            // Documentation: https://esbuild.github.io/api/#outdir
 	Outbase           string            // Documentation: https://esbuild.github.io/api/#outbase
 	AbsWorkingDir     string            // Documentation: https://esbuild.github.io/api/#working-directory
@@ -330,6 +357,7 @@ type BuildOptions struct {
 	TsconfigRaw       string            // Documentation: https://esbuild.github.io/api/#tsconfig-raw
 	OutExtension      map[string]string // Documentation: https://esbuild.github.io/api/#out-extension
 	PublicPath        string            // Documentation: https://esbuild.github.io/api/#public-path
+	WasmInline        bool              // Embed WebAssembly modules in the output instead of loading them as files
 	Inject            []string          // Documentation: https://esbuild.github.io/api/#inject
 	Banner            map[string]string // Documentation: https://esbuild.github.io/api/#banner
 	Footer            map[string]string // Documentation: https://esbuild.github.io/api/#footer
@@ -339,13 +367,60 @@ type BuildOptions struct {
 	ChunkNames string // Documentation: https://esbuild.github.io/api/#chunk-names
 	AssetNames string // Documentation: https://esbuild.github.io/api/#asset-names
 
+	ManualChunks map[string][]string // Put modules matching these package names or paths into chunks with these names when splitting
+	MinChunkSize int                 // Merge shared chunks smaller than this many bytes into other chunks when it's safe to do so
+	MaxChunks    int                 // Merge shared chunks together when it's safe to do so until there are at most this many chunks
+
 	EntryPoints         []string     // Documentation: https://esbuild.github.io/api/#entry-points
 	EntryPointsAdvanced []EntryPoint // Documentation: https://esbuild.github.io/api/#entry-points
 
 	Stdin          *StdinOptions // Documentation: https://esbuild.github.io/api/#stdin
 	Write          bool          // Documentation: https://esbuild.github.io/api/#write
+	OutputWriter   OutputWriter  // Write output files here instead of to the file system (implies "Write")
 	AllowOverwrite bool          // Documentation: https://esbuild.github.io/api/#allow-overwrite
 	Plugins        []Plugin      // Documentation: https://esbuild.github.io/plugins/
+
+	FS      FileSystem        // Read input files from here instead of from the real file system
+	Overlay map[string]string // Replace or add input files at these paths with these contents
+}
+
+// This can be used to read input files from somewhere other than the real file
+// system, such as an in-memory map, a git tree, or a zip archive. Paths passed
+// to these methods are always absolute and always use forward slashes, even on
+// Windows. A missing file or directory should be reported using an error for
+// which "os.IsNotExist" returns true.
+type FileSystem interface {
+	ReadDir(path string) ([]string, error)
+	ReadFile(path string) ([]byte, error)
+	Stat(path string) (FileInfo, error)
+}
+
+type FileInfo struct {
+	ModTime time.Time // If this is zero, the file will be re-read on every rebuild
+	Size    int64
+	IsDir   bool
+}
+
+// This can be used to send output files somewhere other than the file system,
+// such as into a tar or zip archive or an in-memory map. All methods are called
+// by one goroutine at a time.
+//
+// Every build calls "WriteFile" for each output file and "RemoveFile" for each
+// output file of the previous build that is no longer generated. Then either
+// "Commit" is called if all of those calls succeeded and the build succeeded,
+// or "Discard" is called otherwise. Writers that buffer changes until "Commit"
+// is called can atomically replace the previous build's output files with the
+// new ones.
+//
+// Output files are passed to "WriteFile" as soon as they are generated and
+// their contents aren't kept in memory after that. So the "Contents" field of
+// each output file in the build result is nil when an output writer is used.
+// The order in which output files are passed to "WriteFile" is unspecified.
+type OutputWriter interface {
+	WriteFile(path string, contents []byte, mode os.FileMode) error
+	RemoveFile(path string) error
+	Commit() error
+	Discard()
 }
 
 type EntryPoint struct {
@@ -370,9 +445,15 @@ type BuildResult struct {
 }
 
 type OutputFile struct {
-	Path     string
-	Contents []byte
-	Hash     string
+	Path      string
+	Contents  []byte // This is nil when "OutputWriter" is set
+	Hash      string
+	Integrity string // Only when "Integrity" is set (e.g. "sha384-...")
+
+	// These are only used for the build summary
+	size        int
+	gzipBytes   int
+	brotliBytes int
 }
 
 // Documentation: https://esbuild.github.io/api/#build
@@ -384,12 +465,17 @@ func Build(options BuildOptions) BuildResult {
 		return BuildResult{Errors: errors}
 	}
 
-	result := ctx.Rebuild()
-
 	// Print a summary of the generated files to stderr. Except don't do
 	// this if the terminal is already being used for something else.
-	if ctx.args.logOptions.LogLevel <= logger.LevelInfo && !ctx.args.options.WriteToStdout {
-		printSummary(ctx.args.logOptions.Color, result.OutputFiles, start)
+	shouldPrintSummary := ctx.configs[0].logOptions.LogLevel <= logger.LevelInfo && !ctx.configs[0].options.WriteToStdout
+	if shouldPrintSummary && options.CompressedSizes != CompressedSizesFalse {
+		ctx.configs[0].options.CompressedSizes = true
+	}
+
+	result := ctx.Rebuild()
+
+	if shouldPrintSummary {
+		printSummary(ctx.configs[0].logOptions.Color, result.OutputFiles, start)
 	}
 
 	ctx.Dispose()
@@ -532,6 +618,32 @@ func Context(buildOptions BuildOptions) (BuildContext, *ContextError) {
 	return ctx, nil
 }
 
+type MultiBuildContext interface {
+	// Returns one result for each configuration, in the same order as the
+	// build options that were passed to "ContextMany"
+	Rebuild() []BuildResult
+
+	// These apply to all configurations at once. There is only one watcher and
+	// one dev server, and a change to any file rebuilds every configuration.
+	Watch(options WatchOptions) error
+	Serve(options ServeOptions) (ServeResult, error)
+
+	Cancel()
+	Dispose()
+}
+
+// This is like "Context" except that it creates a single context for several
+// sets of build options. The configurations share the same cache, so files
+// that are used by more than one configuration are only read from the file
+// system once and are only parsed once for each unique set of parser options.
+func ContextMany(buildOptions []BuildOptions) (MultiBuildContext, *ContextError) {
+	ctx, errors := contextManyImpl(buildOptions)
+	if ctx == nil {
+		return nil, &ContextError{Errors: errors}
+	}
+	return &multiContext{ctx}, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Plugin API
 
@@ -682,6 +794,7 @@ const (
 	ResolveCSSImportRule
 	ResolveCSSComposesFrom
 	ResolveCSSURLToken
+	ResolveJSWorker
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -36,7 +36,6 @@ import (
 	"github.com/evanw/esbuild/internal/linker"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/resolver"
-	"github.com/evanw/esbuild/internal/xxhash"
 )
 
 func validatePathTemplate(template string) []config.PathTemplate {
@@ -270,6 +269,10 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderJS
 	case LoaderJSON:
 		return config.LoaderJSON
+	case LoaderJSON5:
+		return config.LoaderJSON5
+	case LoaderJSONC:
+		return config.LoaderJSONC
 	case LoaderJSX:
 		return config.LoaderJSX
 	case LoaderLocalCSS:
@@ -278,10 +281,16 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderNone
 	case LoaderText:
 		return config.LoaderText
+	case LoaderTOML:
+		return config.LoaderTOML
 	case LoaderTS:
 		return config.LoaderTS
 	case LoaderTSX:
 		return config.LoaderTSX
+	case LoaderWasm:
+		return config.LoaderWasm
+	case LoaderYAML:
+		return config.LoaderYAML
 	default:
 		panic("Invalid loader")
 	}
@@ -450,6 +459,58 @@ func validateExternals(log logger.Log, fs fs.FS, paths []string) config.External
 	return result
 }
 
+// Manual chunk patterns are either package names or paths. Package names
+// match the package and everything inside it, and paths match the file and
+// everything inside it if it's a directory. Both can contain a "*" wildcard.
+func validateManualChunks(log logger.Log, fs fs.FS, manualChunks map[string][]string) []config.ManualChunk {
+	// Sort by name for determinism, since the first matching chunk is used
+	names := make([]string, 0, len(manualChunks))
+	for name := range manualChunks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]config.ManualChunk, 0, len(names))
+
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+			log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid manual chunk name: %q", name))
+			continue
+		}
+		chunk := config.ManualChunk{Name: name, ExactPaths: make(map[string]bool)}
+
+		for _, pattern := range manualChunks[name] {
+			index := strings.IndexByte(pattern, '*')
+			if index != -1 && strings.ContainsRune(pattern[index+1:], '*') {
+				log.AddError(nil, logger.Range{}, fmt.Sprintf("Manual chunk pattern %q cannot have more than one \"*\" wildcard", pattern))
+				continue
+			}
+
+			if resolver.IsPackagePath(pattern) && !fs.IsAbs(pattern) {
+				if index != -1 {
+					chunk.PackagePatterns = append(chunk.PackagePatterns, config.WildcardPattern{Prefix: pattern[:index], Suffix: pattern[index+1:]})
+				} else {
+					chunk.PackagePatterns = append(chunk.PackagePatterns, config.WildcardPattern{Prefix: pattern + "/"})
+				}
+				continue
+			}
+
+			if absPath := validatePath(log, fs, pattern, "manual chunk path"); absPath != "" {
+				absPath = strings.ReplaceAll(absPath, "\\", "/")
+				if absIndex := strings.IndexByte(absPath, '*'); absIndex != -1 {
+					chunk.PathPatterns = append(chunk.PathPatterns, config.WildcardPattern{Prefix: absPath[:absIndex], Suffix: absPath[absIndex+1:]})
+				} else {
+					chunk.ExactPaths[absPath] = true
+					chunk.PathPatterns = append(chunk.PathPatterns, config.WildcardPattern{Prefix: strings.TrimSuffix(absPath, "/") + "/"})
+				}
+			}
+		}
+
+		result = append(result, chunk)
+	}
+
+	return result
+}
+
 func validateAlias(log logger.Log, fs fs.FS, alias map[string]string) map[string]string {
 	valid := make(map[string]string, len(alias))
 
@@ -692,6 +753,19 @@ func validateLogOverrides(input map[string]LogLevel) (output map[logger.MsgID]lo
 	return
 }
 
+func validateIntegrity(value Integrity) (result config.IntegrityAlgorithms) {
+	if (value & IntegritySHA256) != 0 {
+		result |= config.IntegritySHA256
+	}
+	if (value & IntegritySHA384) != 0 {
+		result |= config.IntegritySHA384
+	}
+	if (value & IntegritySHA512) != 0 {
+		result |= config.IntegritySHA512
+	}
+	return
+}
+
 func validatePath(log logger.Log, fs fs.FS, relPath string, pathKind string) string {
 	if relPath == "" {
 		return ""
@@ -861,6 +935,83 @@ func cloneMangleCache(log logger.Log, mangleCache map[string]interface{}) map[st
 // Build API
 
 func contextImpl(buildOpts BuildOptions) (*internalContext, []Message) {
+	return contextManyImpl([]BuildOptions{buildOpts})
+}
+
+// Multiple configurations in the same context that read from the same input
+// file system share a single cache set. Files are only read once and are only
+// parsed once per unique set of parser options (the parse caches are keyed on
+// the parser options in addition to the path).
+// All configurations are rebuilt together, so there's only ever one watcher
+// and one dev server for the whole context.
+func contextManyImpl(allBuildOpts []BuildOptions) (*internalContext, []Message) {
+	if len(allBuildOpts) == 0 {
+		return nil, []Message{{Text: "At least one set of build options is required"}}
+	}
+
+	configs := make([]rebuildArgs, 0, len(allBuildOpts))
+	allCaches := make([]*cache.CacheSet, 0, len(allBuildOpts))
+	allInputFS := make([]inputFileSystem, 0, len(allBuildOpts))
+	var realFS fs.FS
+	var errors []Message
+
+	for _, buildOpts := range allBuildOpts {
+		// The file system cache is keyed only by path, so configurations that
+		// read files from different places must not share a cache set
+		var caches *cache.CacheSet
+		inputFS := newInputFileSystem(buildOpts)
+		for i, other := range allInputFS {
+			if inputFS.canShareWith(other) {
+				caches = allCaches[i]
+				break
+			}
+		}
+		if caches == nil {
+			caches = cache.MakeCacheSet()
+		}
+		allCaches = append(allCaches, caches)
+		allInputFS = append(allInputFS, inputFS)
+
+		args, argsFS, msgs := newRebuildArgs(buildOpts, caches)
+		if argsFS == nil {
+			errors = append(errors, msgs...)
+			continue
+		}
+		if realFS == nil {
+			realFS = argsFS
+		}
+		configs = append(configs, args)
+	}
+
+	// Refuse to create the context if any configuration has errors. Plugins for
+	// the configurations that were valid have already been set up at this point
+	// so they still need to be told that they are being disposed.
+	if len(errors) > 0 {
+		for _, args := range configs {
+			for _, fn := range args.onDisposeCallbacks {
+				go fn()
+			}
+		}
+		return nil, errors
+	}
+
+	return &internalContext{
+		configs:       configs,
+		realFS:        realFS,
+		absWorkingDir: configs[0].absWorkingDir,
+		latestHashes:  make([]map[string]string, len(configs)),
+	}, nil
+}
+
+func newInputFileSystem(buildOpts BuildOptions) inputFileSystem {
+	inputFS := inputFileSystem{overlay: cloneOverlay(buildOpts.Overlay)}
+	if buildOpts.FS != nil {
+		inputFS.backend = fileSystemAdapter{buildOpts.FS}
+	}
+	return inputFS
+}
+
+func newRebuildArgs(buildOpts BuildOptions, caches *cache.CacheSet) (rebuildArgs, fs.FS, []Message) {
 	logOptions := logger.OutputOptions{
 		IncludeSource: true,
 		MessageLimit:  buildOpts.LogLimit,
@@ -871,7 +1022,8 @@ func contextImpl(buildOpts BuildOptions) (*internalContext, []Message) {
 
 	// Validate that the current working directory is an absolute path
 	absWorkingDir := buildOpts.AbsWorkingDir
-	realFS, err := fs.RealFS(fs.RealFSOptions{
+	inputFS := newInputFileSystem(buildOpts)
+	realFS, err := inputFS.create(fs.RealFSOptions{
 		AbsWorkingDir: absWorkingDir,
 
 		// This is a long-lived file system object so do not cache calls to
@@ -882,13 +1034,12 @@ func contextImpl(buildOpts BuildOptions) (*internalContext, []Message) {
 	if err != nil {
 		log := logger.NewStderrLog(logOptions)
 		log.AddError(nil, logger.Range{}, err.Error())
-		return nil, convertMessagesToPublic(logger.Error, log.Done())
+		return rebuildArgs{}, nil, convertMessagesToPublic(logger.Error, log.Done())
 	}
 
 	// Do not re-evaluate plugins when rebuilding. Also make sure the working
 	// directory doesn't change, since breaking that invariant would break the
 	// validation that we just did above.
-	caches := cache.MakeCacheSet()
 	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, logOptions.Overrides)
 	onEndCallbacks, onDisposeCallbacks, finalizeBuildOptions := loadPlugins(&buildOpts, realFS, log, caches)
 	options, entryPoints := validateBuildOptions(buildOpts, log, realFS)
@@ -912,7 +1063,7 @@ func contextImpl(buildOpts BuildOptions) (*internalContext, []Message) {
 			}
 			stderr.Done()
 		}
-		return nil, convertMessagesToPublic(logger.Error, msgs)
+		return rebuildArgs{}, nil, convertMessagesToPublic(logger.Error, msgs)
 	}
 
 	args := rebuildArgs{
@@ -925,14 +1076,12 @@ func contextImpl(buildOpts BuildOptions) (*internalContext, []Message) {
 		options:            options,
 		mangleCache:        buildOpts.MangleCache,
 		absWorkingDir:      absWorkingDir,
-		write:              buildOpts.Write,
+		inputFS:            inputFS,
+		outputWriter:       buildOpts.OutputWriter,
+		write:              buildOpts.Write || buildOpts.OutputWriter != nil,
 	}
 
-	return &internalContext{
-		args:          args,
-		realFS:        realFS,
-		absWorkingDir: absWorkingDir,
-	}, nil
+	return args, realFS, nil
 }
 
 type buildInProgress struct {
@@ -943,7 +1092,7 @@ type buildInProgress struct {
 
 type internalContext struct {
 	mutex         sync.Mutex
-	args          rebuildArgs
+	configs       []rebuildArgs
 	activeBuild   *buildInProgress
 	recentBuild   *BuildResult
 	realFS        fs.FS
@@ -954,8 +1103,9 @@ type internalContext struct {
 
 	// This saves just enough information to be able to compute a useful diff
 	// between two sets of output files. That way we don't need to hold both
-	// sets of output files in memory at once to compute a diff.
-	latestHashes map[string]string
+	// sets of output files in memory at once to compute a diff. There is one
+	// set of hashes for each configuration.
+	latestHashes []map[string]string
 }
 
 func (ctx *internalContext) rebuild() rebuildState {
@@ -978,18 +1128,20 @@ func (ctx *internalContext) rebuild() rebuildState {
 	build := &buildInProgress{}
 	build.waitGroup.Add(1)
 	ctx.activeBuild = build
-	args := ctx.args
+	configs := append([]rebuildArgs{}, ctx.configs...)
 	watcher := ctx.watcher
 	handler := ctx.handler
 	oldHashes := ctx.latestHashes
-	args.options.CancelFlag = &build.cancel
+	for i := range configs {
+		configs[i].options.CancelFlag = &build.cancel
+	}
 	ctx.mutex.Unlock()
 
 	// Do the build without holding the mutex
-	var newHashes map[string]string
-	build.state, newHashes = rebuildImpl(args, oldHashes)
+	var newHashes []map[string]string
+	build.state, newHashes = rebuildConfigs(configs, oldHashes)
 	if handler != nil {
-		handler.broadcastBuildResult(build.state.result, newHashes)
+		handler.broadcastBuildResult(build.state.result, mergeHashes(newHashes))
 	}
 	if watcher != nil {
 		watcher.setWatchData(build.state.watchData)
@@ -1065,18 +1217,20 @@ func (ctx *internalContext) Watch(options WatchOptions) error {
 		return errors.New("Watch mode has already been enabled")
 	}
 
-	logLevel := ctx.args.logOptions.LogLevel
+	logLevel := ctx.configs[0].logOptions.LogLevel
 	ctx.watcher = &watcher{
 		fs:        ctx.realFS,
 		shouldLog: logLevel == logger.LevelInfo || logLevel == logger.LevelDebug || logLevel == logger.LevelVerbose,
-		useColor:  ctx.args.logOptions.Color,
+		useColor:  ctx.configs[0].logOptions.Color,
 		rebuild: func() fs.WatchData {
 			return ctx.rebuild().watchData
 		},
 	}
 
 	// All subsequent builds will be watch mode builds
-	ctx.args.options.WatchMode = true
+	for i := range ctx.configs {
+		ctx.configs[i].options.WatchMode = true
+	}
 
 	// Start the file watcher goroutine
 	ctx.watcher.start()
@@ -1151,11 +1305,21 @@ func (ctx *internalContext) Dispose() {
 	}
 
 	// Run each "OnDispose" callback on its own goroutine
-	for _, fn := range ctx.args.onDisposeCallbacks {
-		go fn()
+	for _, args := range ctx.configs {
+		for _, fn := range args.onDisposeCallbacks {
+			go fn()
+		}
 	}
 }
 
+type multiContext struct {
+	*internalContext
+}
+
+func (ctx *multiContext) Rebuild() []BuildResult {
+	return ctx.rebuild().results
+}
+
 func prettyPrintByteCount(n int) string {
 	var size string
 	if n < 1024 {
@@ -1185,7 +1349,7 @@ func printSummary(color logger.UseColor, outputFiles []OutputFile, start time.Ti
 					path = file.Path
 				}
 				base := realFS.Base(path)
-				n := len(file.Contents)
+				n := file.size
 				table[i] = logger.SummaryTableEntry{
 					Dir:         path[:len(path)-len(base)],
 					Base:        base,
@@ -1193,6 +1357,10 @@ func printSummary(color logger.UseColor, outputFiles []OutputFile, start time.Ti
 					Bytes:       n,
 					IsSourceMap: strings.HasSuffix(base, ".map"),
 				}
+				if file.gzipBytes != 0 {
+					table[i].GzipSize = prettyPrintByteCount(file.gzipBytes)
+					table[i].BrotliSize = prettyPrintByteCount(file.brotliBytes)
+				}
 			}
 		}
 	}
@@ -1259,17 +1427,25 @@ func validateBuildOptions(
 		MangleQuoted:          buildOpts.MangleQuoted == MangleQuotedTrue,
 		DropLabels:            append([]string{}, buildOpts.DropLabels...),
 		DropDebugger:          (buildOpts.Drop & DropDebugger) != 0,
+		Integrity:             validateIntegrity(buildOpts.Integrity),
 		AllowOverwrite:        buildOpts.AllowOverwrite,
 		ASCIIOnly:             validateASCIIOnly(buildOpts.Charset),
 		IgnoreDCEAnnotations:  buildOpts.IgnoreAnnotations,
 		TreeShaking:           validateTreeShaking(buildOpts.TreeShaking, buildOpts.Bundle, buildOpts.Format),
 		GlobalName:            validateGlobalName(log, buildOpts.GlobalName),
 		CodeSplitting:         buildOpts.Splitting,
+		ModulePreload:         buildOpts.ModulePreload,
+		PreserveEvalOrder:     buildOpts.PreserveEvalOrder,
+		ManualChunks:          validateManualChunks(log, realFS, buildOpts.ManualChunks),
+		MinChunkSize:          buildOpts.MinChunkSize,
+		MaxChunks:             buildOpts.MaxChunks,
 		OutputFormat:          validateFormat(buildOpts.Format),
 		AbsOutputFile:         validatePath(log, realFS, buildOpts.Outfile, "outfile path"),
 		AbsOutputDir:          validatePath(log, realFS, buildOpts.Outdir, "outdir path"),
 		AbsOutputBase:         validatePath(log, realFS, buildOpts.Outbase, "outbase path"),
 		NeedsMetafile:         buildOpts.Metafile,
+		NeedsManifest:         buildOpts.Manifest,
+		CompressedSizes:       buildOpts.Metafile && buildOpts.CompressedSizes != CompressedSizesFalse,
 		EntryPathTemplate:     validatePathTemplate(buildOpts.EntryNames),
 		ChunkPathTemplate:     validatePathTemplate(buildOpts.ChunkNames),
 		AssetPathTemplate:     validatePathTemplate(buildOpts.AssetNames),
@@ -1284,6 +1460,7 @@ func validateBuildOptions(
 		TSConfigRaw:           buildOpts.TsconfigRaw,
 		MainFields:            buildOpts.MainFields,
 		PublicPath:            buildOpts.PublicPath,
+		WasmInline:            buildOpts.WasmInline,
 		KeepNames:             buildOpts.KeepNames,
 		InjectPaths:           append([]string{}, buildOpts.Inject...),
 		AbsNodePaths:          make([]string, len(buildOpts.NodePaths)),
@@ -1343,6 +1520,12 @@ func validateBuildOptions(
 		options.WriteToStdout = true
 
 		// Forbid certain features when writing to stdout
+		if buildOpts.OutputWriter != nil {
+			log.AddError(nil, logger.Range{}, "Cannot use an output writer without an output path")
+		}
+		if options.NeedsManifest {
+			log.AddError(nil, logger.Range{}, "Cannot generate a manifest without an output path")
+		}
 		if options.SourceMap != config.SourceMapNone && options.SourceMap != config.SourceMapInline {
 			log.AddError(nil, logger.Range{}, "Cannot use an external source map without an output path")
 		}
@@ -1397,9 +1580,38 @@ func validateBuildOptions(
 		options.Conditions = []string{"module"}
 	}
 
-	// Code splitting is experimental and currently only enabled for ES6 modules
-	if options.CodeSplitting && options.OutputFormat != config.FormatESModule {
-		log.AddError(nil, logger.Range{}, "Splitting currently only works with the \"esm\" format")
+	// Code splitting needs to know how chunks should reference each other
+	if options.CodeSplitting {
+		switch options.OutputFormat {
+		case config.FormatPreserve:
+			log.AddError(nil, logger.Range{}, "Splitting currently only works with the \"esm\", \"cjs\", and \"iife\" formats")
+		case config.FormatIIFE:
+			// Chunks are evaluated asynchronously by the chunk loader
+			if len(options.GlobalName) > 0 {
+				log.AddError(nil, logger.Range{}, "Cannot use \"globalName\" with code splitting")
+			}
+		}
+	}
+	if options.ModulePreload && !options.CodeSplitting {
+		log.AddError(nil, logger.Range{}, "Cannot use module preloading without code splitting")
+	} else if options.ModulePreload && options.OutputFormat != config.FormatESModule {
+		log.AddError(nil, logger.Range{}, "Module preloading currently only works with the \"esm\" format")
+	}
+	if options.PreserveEvalOrder && !options.CodeSplitting {
+		log.AddError(nil, logger.Range{}, "Cannot preserve evaluation order without code splitting")
+	}
+	if len(options.ManualChunks) > 0 && !options.CodeSplitting {
+		log.AddError(nil, logger.Range{}, "Cannot use manual chunks without code splitting")
+	}
+	if options.MinChunkSize < 0 {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid minimum chunk size: %d", options.MinChunkSize))
+	} else if options.MinChunkSize > 0 && !options.CodeSplitting {
+		log.AddError(nil, logger.Range{}, "Cannot use a minimum chunk size without code splitting")
+	}
+	if options.MaxChunks < 0 {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid maximum chunk count: %d", options.MaxChunks))
+	} else if options.MaxChunks > 0 && !options.CodeSplitting {
+		log.AddError(nil, logger.Range{}, "Cannot use a maximum chunk count without code splitting")
 	}
 
 	// Code splitting is experimental and currently only enabled for ES6 modules
@@ -1409,13 +1621,83 @@ func validateBuildOptions(
 
 	// If we aren't writing the output to the file system, then we can allow the
 	// output paths to be the same as the input paths. This helps when serving.
-	if !buildOpts.Write {
+	if !buildOpts.Write || buildOpts.OutputWriter != nil {
 		options.AllowOverwrite = true
 	}
 
 	return
 }
 
+// This determines where input files are read from. By default they come from
+// the real file system, but the API also lets the caller provide their own file
+// system and/or an overlay of in-memory files on top of it.
+type inputFileSystem struct {
+	backend fs.VirtualFileSystem
+	overlay map[string]string
+}
+
+func (inputFS inputFileSystem) create(options fs.RealFSOptions) (result fs.FS, err error) {
+	if inputFS.backend != nil {
+		result, err = fs.VirtualFS(fs.VirtualFSOptions{
+			Backend:       inputFS.backend,
+			AbsWorkingDir: options.AbsWorkingDir,
+			WantWatchData: options.WantWatchData,
+		})
+	} else {
+		result, err = fs.RealFS(options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fs.OverlayFS(result, inputFS.overlay), nil
+}
+
+// Custom file system backends are never shared because there's no way to tell
+// whether two of them will return the same results
+func (inputFS inputFileSystem) canShareWith(other inputFileSystem) bool {
+	if inputFS.backend != nil || other.backend != nil || len(inputFS.overlay) != len(other.overlay) {
+		return false
+	}
+	for k, v := range inputFS.overlay {
+		if otherV, ok := other.overlay[k]; !ok || v != otherV {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneOverlay(overlay map[string]string) map[string]string {
+	if overlay == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(overlay))
+	for k, v := range overlay {
+		clone[k] = v
+	}
+	return clone
+}
+
+type fileSystemAdapter struct {
+	fs FileSystem
+}
+
+func (adapter fileSystemAdapter) ReadDir(path string) ([]string, error) {
+	return adapter.fs.ReadDir(path)
+}
+
+func (adapter fileSystemAdapter) ReadFile(path string) ([]byte, error) {
+	return adapter.fs.ReadFile(path)
+}
+
+func (adapter fileSystemAdapter) Stat(path string) (fs.VirtualFileInfo, error) {
+	info, err := adapter.fs.Stat(path)
+	return fs.VirtualFileInfo{
+		ModTime: info.ModTime,
+		Size:    info.Size,
+		IsDir:   info.IsDir,
+	}, err
+}
+
 type onEndCallback struct {
 	pluginName string
 	fn         func(*BuildResult) (OnEndResult, error)
@@ -1431,6 +1713,8 @@ type rebuildArgs struct {
 	options            config.Options
 	mangleCache        map[string]interface{}
 	absWorkingDir      string
+	inputFS            inputFileSystem
+	outputWriter       OutputWriter
 	write              bool
 }
 
@@ -1438,9 +1722,90 @@ type rebuildState struct {
 	result    BuildResult
 	watchData fs.WatchData
 	options   config.Options
+
+	// When there are multiple configurations, "result" is the combination of
+	// all of them (for the dev server) and this holds each individual result
+	results []BuildResult
+}
+
+func rebuildConfigs(configs []rebuildArgs, oldHashes []map[string]string) (rebuildState, []map[string]string) {
+	// Configurations that read from the same file system share a single file
+	// system object. Directory entries are cached for the duration of a build,
+	// so this means each directory is only read once per rebuild.
+	realFSs := make([]fs.FS, len(configs))
+	for i, args := range configs {
+		for j, other := range configs[:i] {
+			if args.absWorkingDir == other.absWorkingDir && args.options.WatchMode == other.options.WatchMode &&
+				args.inputFS.canShareWith(other.inputFS) {
+				realFSs[i] = realFSs[j]
+				break
+			}
+		}
+		if realFSs[i] == nil {
+			realFS, err := args.inputFS.create(fs.RealFSOptions{
+				AbsWorkingDir: args.absWorkingDir,
+				WantWatchData: args.options.WatchMode,
+			})
+			if err != nil {
+				// This should already have been checked by the caller
+				panic(err.Error())
+			}
+			realFSs[i] = realFS
+		}
+	}
+
+	// Fast path for the common case of a single configuration
+	if len(configs) == 1 {
+		state, newHashes := rebuildImpl(configs[0], realFSs[0], oldHashes[0])
+		state.results = []BuildResult{state.result}
+		return state, []map[string]string{newHashes}
+	}
+
+	// Build all configurations in parallel
+	states := make([]rebuildState, len(configs))
+	newHashes := make([]map[string]string, len(configs))
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(len(configs))
+	for i := range configs {
+		go func(i int) {
+			states[i], newHashes[i] = rebuildImpl(configs[i], realFSs[i], oldHashes[i])
+			waitGroup.Done()
+		}(i)
+	}
+	waitGroup.Wait()
+
+	// Combine the results together
+	merged := rebuildState{
+		watchData: fs.WatchData{Paths: make(map[string]func() string)},
+		options:   states[0].options,
+		results:   make([]BuildResult, len(states)),
+	}
+	for i, state := range states {
+		merged.results[i] = state.result
+		merged.result.Errors = append(merged.result.Errors, state.result.Errors...)
+		merged.result.Warnings = append(merged.result.Warnings, state.result.Warnings...)
+		merged.result.OutputFiles = append(merged.result.OutputFiles, state.result.OutputFiles...)
+		for path, fn := range state.watchData.Paths {
+			merged.watchData.Paths[path] = fn
+		}
+	}
+	return merged, newHashes
 }
 
-func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, map[string]string) {
+func mergeHashes(allHashes []map[string]string) map[string]string {
+	if len(allHashes) == 1 {
+		return allHashes[0]
+	}
+	merged := make(map[string]string)
+	for _, hashes := range allHashes {
+		for absPath, hash := range hashes {
+			merged[absPath] = hash
+		}
+	}
+	return merged
+}
+
+func rebuildImpl(args rebuildArgs, realFS fs.FS, oldHashes map[string]string) (rebuildState, map[string]string) {
 	log := logger.NewStderrLog(args.logOptions)
 
 	// All validation warnings are repeated for every rebuild
@@ -1448,16 +1813,6 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 		log.AddMsg(msg)
 	}
 
-	// Convert and validate the buildOpts
-	realFS, err := fs.RealFS(fs.RealFSOptions{
-		AbsWorkingDir: args.absWorkingDir,
-		WantWatchData: args.options.WatchMode,
-	})
-	if err != nil {
-		// This should already have been checked by the caller
-		panic(err.Error())
-	}
-
 	var result BuildResult
 	var watchData fs.WatchData
 	var toWriteToStdout []byte
@@ -1467,8 +1822,17 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 		timer = &helpers.Timer{}
 	}
 
+	// Output files are sent to the output writer as soon as they are generated
+	// so that they don't all need to be kept in memory at the same time
+	options := args.options
+	var streamer *outputStreamer
+	if args.outputWriter != nil {
+		streamer = &outputStreamer{log: log, writer: args.outputWriter}
+		options.StreamOutputFile = streamer.writeFile
+	}
+
 	// Scan over the bundle
-	bundle := bundler.ScanBundle(config.BuildCall, log, realFS, args.caches, args.entryPoints, args.options, timer)
+	bundle := bundler.ScanBundle(config.BuildCall, log, realFS, args.caches, args.entryPoints, options, timer)
 	watchData = realFS.WatchData()
 
 	// The new build summary remains the same as the old one when there are
@@ -1498,14 +1862,18 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 				if args.options.WriteToStdout {
 					item.AbsPath = "<stdout>"
 				}
-				hasher := xxhash.New()
-				hasher.Write(item.Contents)
-				binary.LittleEndian.PutUint64(hashBytes[:], hasher.Sum64())
+				size, contentsHash := item.SizeAndHash()
+				binary.LittleEndian.PutUint64(hashBytes[:], contentsHash)
 				hash := base64.RawStdEncoding.EncodeToString(hashBytes[:])
 				result.OutputFiles[i] = OutputFile{
-					Path:     item.AbsPath,
-					Contents: item.Contents,
-					Hash:     hash,
+					Path:      item.AbsPath,
+					Contents:  item.Contents,
+					Hash:      hash,
+					Integrity: item.Integrity,
+
+					size:        size,
+					gzipBytes:   item.GzipBytes,
+					brotliBytes: item.BrotliBytes,
 				}
 				newHashes[item.AbsPath] = hash
 			}
@@ -1533,50 +1901,22 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 						}
 					}
 
-					// Process all file operations in parallel
-					waitGroup := sync.WaitGroup{}
-					waitGroup.Add(len(results) + len(toDelete))
-					for _, result := range results {
-						go func(result graph.OutputFile) {
-							defer waitGroup.Done()
-							fs.BeforeFileOpen()
-							defer fs.AfterFileClose()
-							if oldHash, ok := oldHashes[result.AbsPath]; ok && oldHash == newHashes[result.AbsPath] {
-								if contents, err := ioutil.ReadFile(result.AbsPath); err == nil && bytes.Equal(contents, result.Contents) {
-									// Skip writing out files that haven't changed since last time
-									return
-								}
-							}
-							if err := fs.MkdirAll(realFS, realFS.Dir(result.AbsPath), 0755); err != nil {
-								log.AddError(nil, logger.Range{}, fmt.Sprintf(
-									"Failed to create output directory: %s", err.Error()))
-							} else {
-								var mode os.FileMode = 0666
-								if result.IsExecutable {
-									mode = 0777
-								}
-								if err := ioutil.WriteFile(result.AbsPath, result.Contents, mode); err != nil {
-									log.AddError(nil, logger.Range{}, fmt.Sprintf(
-										"Failed to write to output file: %s", err.Error()))
-								}
-							}
-						}(result)
-					}
-					for _, absPath := range toDelete {
-						go func(absPath string) {
-							defer waitGroup.Done()
-							fs.BeforeFileOpen()
-							defer fs.AfterFileClose()
-							os.Remove(absPath)
-						}(absPath)
+					if streamer != nil {
+						streamer.finish(results, toDelete)
+					} else {
+						writeToFileSystem(log, realFS, results, toDelete, oldHashes, newHashes)
 					}
-					waitGroup.Wait()
 				}
 				timer.End("Write output files")
 			}
 		}
 	}
 
+	// Undo any output files that were already streamed out if the build failed
+	if streamer != nil && log.HasErrors() {
+		streamer.discard()
+	}
+
 	// Only return the mangle cache for a successful build
 	if log.HasErrors() {
 		result.MangleCache = nil
@@ -1653,6 +1993,129 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 	}, newHashes
 }
 
+func writeToFileSystem(
+	log logger.Log,
+	realFS fs.FS,
+	results []graph.OutputFile,
+	toDelete []string,
+	oldHashes map[string]string,
+	newHashes map[string]string,
+) {
+	// Process all file operations in parallel
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(len(results) + len(toDelete))
+	for _, result := range results {
+		go func(result graph.OutputFile) {
+			defer waitGroup.Done()
+			fs.BeforeFileOpen()
+			defer fs.AfterFileClose()
+			if oldHash, ok := oldHashes[result.AbsPath]; ok && oldHash == newHashes[result.AbsPath] {
+				if contents, err := ioutil.ReadFile(result.AbsPath); err == nil && bytes.Equal(contents, result.Contents) {
+					// Skip writing out files that haven't changed since last time
+					return
+				}
+			}
+			if err := fs.MkdirAll(realFS, realFS.Dir(result.AbsPath), 0755); err != nil {
+				log.AddError(nil, logger.Range{}, fmt.Sprintf(
+					"Failed to create output directory: %s", err.Error()))
+			} else {
+				var mode os.FileMode = 0666
+				if result.IsExecutable {
+					mode = 0777
+				}
+				if err := ioutil.WriteFile(result.AbsPath, result.Contents, mode); err != nil {
+					log.AddError(nil, logger.Range{}, fmt.Sprintf(
+						"Failed to write to output file: %s", err.Error()))
+				}
+			}
+		}(result)
+	}
+	for _, absPath := range toDelete {
+		go func(absPath string) {
+			defer waitGroup.Done()
+			fs.BeforeFileOpen()
+			defer fs.AfterFileClose()
+			os.Remove(absPath)
+		}(absPath)
+	}
+	waitGroup.Wait()
+}
+
+// This sends output files to an output writer. The linker passes most output
+// files to "writeFile" as soon as they are generated, possibly from several
+// goroutines at once. Any remaining output files (e.g. the manifest) are
+// written by "finish", which then commits the changes.
+type outputStreamer struct {
+	mutex    sync.Mutex
+	log      logger.Log
+	writer   OutputWriter
+	didWrite bool
+	failed   bool
+	isDone   bool
+}
+
+func (s *outputStreamer) writeFile(absPath string, contents []byte, isExecutable bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writeFileLocked(absPath, contents, isExecutable)
+}
+
+func (s *outputStreamer) writeFileLocked(absPath string, contents []byte, isExecutable bool) {
+	if s.failed {
+		return
+	}
+	var mode os.FileMode = 0666
+	if isExecutable {
+		mode = 0777
+	}
+	s.didWrite = true
+	if err := s.writer.WriteFile(absPath, contents, mode); err != nil {
+		s.log.AddError(nil, logger.Range{}, fmt.Sprintf(
+			"Failed to write to output file: %s", err.Error()))
+		s.failed = true
+	}
+}
+
+func (s *outputStreamer) finish(results []graph.OutputFile, toDelete []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, result := range results {
+		if !result.IsStreamed {
+			s.writeFileLocked(result.AbsPath, result.Contents, result.IsExecutable)
+		}
+	}
+	sort.Strings(toDelete) // Make the order deterministic
+	for _, absPath := range toDelete {
+		if s.failed {
+			break
+		}
+		if err := s.writer.RemoveFile(absPath); err != nil {
+			s.log.AddError(nil, logger.Range{}, fmt.Sprintf(
+				"Failed to remove output file: %s", err.Error()))
+			s.failed = true
+		}
+	}
+
+	// Either all changes are committed or none of them are
+	if s.failed {
+		return
+	}
+	s.isDone = true
+	if err := s.writer.Commit(); err != nil {
+		s.log.AddError(nil, logger.Range{}, fmt.Sprintf(
+			"Failed to commit output files: %s", err.Error()))
+	}
+}
+
+func (s *outputStreamer) discard() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.didWrite && !s.isDone {
+		s.isDone = true
+		s.writer.Discard()
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Transform API
 
@@ -1858,6 +2321,8 @@ func importKindToResolveKind(kind ast.ImportKind) ResolveKind {
 		return ResolveJSDynamicImport
 	case ast.ImportRequireResolve:
 		return ResolveJSRequireResolve
+	case ast.ImportWorker:
+		return ResolveJSWorker
 	case ast.ImportAt:
 		return ResolveCSSImportRule
 	case ast.ImportComposesFrom:
@@ -1881,6 +2346,8 @@ func resolveKindToImportKind(kind ResolveKind) ast.ImportKind {
 		return ast.ImportDynamic
 	case ResolveJSRequireResolve:
 		return ast.ImportRequireResolve
+	case ResolveJSWorker:
+		return ast.ImportWorker
 	case ResolveCSSImportRule:
 		return ast.ImportAt
 	case ResolveCSSComposesFrom:
@@ -2182,6 +2649,8 @@ type metafileEntry struct {
 	entryPoint string
 	entries    []metafileEntry
 	size       int
+	gzipSize   int
+	brotliSize int
 }
 
 // This type is just so we can use Go's native sort function
@@ -2260,12 +2729,19 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 
 							sort.Sort(children)
 
-							entries = append(entries, metafileEntry{
+							entry := metafileEntry{
 								name:       key,
 								size:       int(bytes.Value),
 								entries:    children,
 								entryPoint: entryPointPath,
-							})
+							}
+							if gzipBytes := getObjectPropertyNumber(output.ValueOrNil, "gzipBytes"); gzipBytes != nil {
+								entry.gzipSize = int(gzipBytes.Value)
+							}
+							if brotliBytes := getObjectPropertyNumber(output.ValueOrNil, "brotliBytes"); brotliBytes != nil {
+								entry.brotliSize = int(brotliBytes.Value)
+							}
+							entries = append(entries, entry)
 						}
 					}
 				}
@@ -2342,6 +2818,8 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 				first      string
 				second     string
 				third      string
+				gzip       string
+				brotli     string
 				firstLen   int
 				secondLen  int
 				thirdLen   int
@@ -2359,6 +2837,14 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 			for _, entry := range entries {
 				second := prettyPrintByteCount(entry.size)
 				third := "100.0%"
+				gzip := ""
+				brotli := ""
+
+				// Compressed sizes are only present if the metafile has them
+				if entry.gzipSize != 0 {
+					gzip = prettyPrintByteCount(entry.gzipSize)
+					brotli = prettyPrintByteCount(entry.brotliSize)
+				}
 
 				table = append(table, tableEntry{
 					first:      entry.name,
@@ -2367,6 +2853,8 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 					secondLen:  len(second),
 					third:      third,
 					thirdLen:   len(third),
+					gzip:       gzip,
+					brotli:     brotli,
 					isTopLevel: true,
 				})
 
@@ -2423,9 +2911,17 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 			maxFirstLen := 0
 			maxSecondLen := 0
 			maxThirdLen := 0
+			maxGzipLen := 0
+			maxBrotliLen := 0
 
 			// Calculate column widths
 			for _, entry := range table {
+				if maxGzipLen < len(entry.gzip) {
+					maxGzipLen = len(entry.gzip)
+				}
+				if maxBrotliLen < len(entry.brotli) {
+					maxBrotliLen = len(entry.brotli)
+				}
 				if maxFirstLen < entry.firstLen {
 					maxFirstLen = entry.firstLen
 				}
@@ -2467,7 +2963,20 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 					extraSpace = 1
 				}
 
-				sb.WriteString(fmt.Sprintf("%s  %s%s%s %s%s%s %s%s%s %s%s%s %s%s%s\n",
+				// Output files may also have compressed sizes
+				compressed := ""
+				if entry.gzip != "" {
+					compressed = fmt.Sprintf(" %s  gzip %s%s  brotli %s%s%s",
+						colors.Dim,
+						strings.Repeat(" ", maxGzipLen-len(entry.gzip)),
+						entry.gzip,
+						strings.Repeat(" ", maxBrotliLen-len(entry.brotli)),
+						entry.brotli,
+						colors.Reset,
+					)
+				}
+
+				sb.WriteString(fmt.Sprintf("%s  %s%s%s %s%s%s %s%s%s %s%s%s %s%s%s%s\n",
 					prefix,
 					color,
 					entry.first,
@@ -2484,6 +2993,7 @@ func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
 					color,
 					entry.third,
 					colors.Reset,
+					compressed,
 				))
 			}
 
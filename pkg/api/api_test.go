@@ -1,7 +1,10 @@
 package api_test
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/evanw/esbuild/internal/test"
 	"github.com/evanw/esbuild/pkg/api"
@@ -319,3 +322,296 @@ func TestFormatMessages(t *testing.T) {
 `,
 	)
 }
+
+type mapFileSystem map[string]string
+
+func (files mapFileSystem) ReadDir(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for path := range files {
+		if strings.HasPrefix(path, prefix) {
+			name := strings.SplitN(path[len(prefix):], "/", 2)[0]
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (files mapFileSystem) ReadFile(path string) ([]byte, error) {
+	if contents, ok := files[path]; ok {
+		return []byte(contents), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (files mapFileSystem) Stat(path string) (api.FileInfo, error) {
+	if contents, ok := files[path]; ok {
+		return api.FileInfo{Size: int64(len(contents))}, nil
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for file := range files {
+		if strings.HasPrefix(file, prefix) {
+			return api.FileInfo{IsDir: true}, nil
+		}
+	}
+	return api.FileInfo{}, os.ErrNotExist
+}
+
+func TestBuildFileSystem(t *testing.T) {
+	result := api.Build(api.BuildOptions{
+		EntryPoints:   []string{"src/entry.js"},
+		Bundle:        true,
+		Outdir:        "out",
+		AbsWorkingDir: "/project",
+		LogLevel:      api.LogLevelSilent,
+		FS: mapFileSystem{
+			"/project/src/entry.js":                  "import { foo } from 'pkg'; import { bar } from './bar'; console.log(foo, bar)",
+			"/project/src/bar.js":                    "export let bar = 'bar from disk'",
+			"/project/node_modules/pkg/package.json": `{ "main": "lib/index.js" }`,
+			"/project/node_modules/pkg/lib/index.js": "export let foo = 'foo'",
+		},
+		Overlay: map[string]string{
+			"/project/src/bar.js":     "export { bar } from './gen/bar'",
+			"/project/src/gen/bar.js": "export let bar = 'bar from overlay'",
+		},
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if len(result.OutputFiles) != 1 {
+		t.Fatalf("Expected one output file, got %d", len(result.OutputFiles))
+	}
+	test.AssertEqual(t, result.OutputFiles[0].Path, "/project/out/entry.js")
+	test.AssertEqualWithDiff(t, string(result.OutputFiles[0].Contents), `(() => {
+  // node_modules/pkg/lib/index.js
+  var foo = "foo";
+
+  // src/gen/bar.js
+  var bar = "bar from overlay";
+
+  // src/entry.js
+  console.log(foo, bar);
+})();
+`)
+}
+
+type mapOutputWriter struct {
+	committed map[string]string
+	pending   map[string]string
+	removed   []string
+	discards  int
+}
+
+func (w *mapOutputWriter) WriteFile(path string, contents []byte, mode os.FileMode) error {
+	w.pending[path] = string(contents)
+	return nil
+}
+
+func (w *mapOutputWriter) RemoveFile(path string) error {
+	w.removed = append(w.removed, path)
+	return nil
+}
+
+func (w *mapOutputWriter) Commit() error {
+	w.committed = w.pending
+	w.pending = make(map[string]string)
+	return nil
+}
+
+func (w *mapOutputWriter) Discard() {
+	w.discards++
+	w.pending = make(map[string]string)
+}
+
+func TestBuildOutputWriter(t *testing.T) {
+	files := mapFileSystem{
+		"/project/entry.js": "import('./lazy')",
+		"/project/lazy.js":  "console.log('lazy')",
+	}
+	writer := &mapOutputWriter{pending: make(map[string]string)}
+	ctx, ctxErr := api.Context(api.BuildOptions{
+		EntryPoints:   []string{"entry.js"},
+		Bundle:        true,
+		Splitting:     true,
+		Format:        api.FormatESModule,
+		Outdir:        "out",
+		ChunkNames:    "[name]",
+		AbsWorkingDir: "/project",
+		LogLevel:      api.LogLevelSilent,
+		FS:            files,
+		OutputWriter:  writer,
+	})
+	if ctxErr != nil {
+		t.Fatalf("Unexpected errors: %v", ctxErr.Errors)
+	}
+	defer ctx.Dispose()
+
+	result := ctx.Rebuild()
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	test.AssertEqual(t, len(writer.committed), 2)
+	test.AssertEqual(t, writer.committed["/project/out/lazy.js"], "// lazy.js\nconsole.log(\"lazy\");\n")
+
+	// The contents were streamed to the writer instead of being kept around
+	test.AssertEqual(t, len(result.OutputFiles), 2)
+	for _, file := range result.OutputFiles {
+		test.AssertEqual(t, file.Contents == nil, true)
+		test.AssertEqual(t, file.Hash != "", true)
+	}
+
+	// Removing the dynamic import should remove the chunk for it
+	files["/project/entry.js"] = "console.log('eager')"
+	result = ctx.Rebuild()
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	test.AssertEqual(t, len(writer.committed), 1)
+	test.AssertEqual(t, writer.committed["/project/out/entry.js"], "// entry.js\nconsole.log(\"eager\");\n")
+	test.AssertEqual(t, strings.Join(writer.removed, ","), "/project/out/lazy.js")
+}
+
+func TestBuildOutputWriterDiscard(t *testing.T) {
+	files := mapFileSystem{
+		"/project/a/entry.js": "console.log('a')",
+		"/project/b/entry.js": "console.log('b')",
+	}
+	writer := &mapOutputWriter{pending: make(map[string]string)}
+	result := api.Build(api.BuildOptions{
+		EntryPoints:   []string{"a/entry.js", "b/entry.js"},
+		Outdir:        "out",
+		EntryNames:    "[name]",
+		AbsWorkingDir: "/project",
+		LogLevel:      api.LogLevelSilent,
+		FS:            files,
+		OutputWriter:  writer,
+	})
+
+	// The output files were already streamed out when the collision between
+	// them was detected, so they must be discarded instead of committed
+	test.AssertEqual(t, len(result.Errors), 1)
+	test.AssertEqual(t, result.Errors[0].Text, "Two output files share the same path but have different contents: out/entry.js")
+	test.AssertEqual(t, writer.discards, 1)
+	test.AssertEqual(t, len(writer.pending), 0)
+	test.AssertEqual(t, len(writer.committed), 0)
+}
+
+func TestContextMany(t *testing.T) {
+	files := mapFileSystem{
+		"/project/entry.js": "import('./lazy')",
+		"/project/lazy.js":  "console.log('lazy')",
+	}
+	esmWriter := &mapOutputWriter{pending: make(map[string]string)}
+	cjsWriter := &mapOutputWriter{pending: make(map[string]string)}
+	ctx, ctxErr := api.ContextMany([]api.BuildOptions{
+		{
+			EntryPoints:   []string{"entry.js"},
+			Bundle:        true,
+			Splitting:     true,
+			Format:        api.FormatESModule,
+			Outdir:        "out/esm",
+			ChunkNames:    "[name]",
+			AbsWorkingDir: "/project",
+			LogLevel:      api.LogLevelSilent,
+			FS:            files,
+			OutputWriter:  esmWriter,
+		},
+		{
+			EntryPoints:   []string{"entry.js"},
+			Bundle:        true,
+			Format:        api.FormatCommonJS,
+			Outdir:        "out/cjs",
+			AbsWorkingDir: "/project",
+			LogLevel:      api.LogLevelSilent,
+			FS:            files,
+			OutputWriter:  cjsWriter,
+		},
+	})
+	if ctxErr != nil {
+		t.Fatalf("Unexpected errors: %v", ctxErr.Errors)
+	}
+	defer ctx.Dispose()
+
+	results := ctx.Rebuild()
+	test.AssertEqual(t, len(results), 2)
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			t.Fatalf("Unexpected errors: %v", result.Errors)
+		}
+	}
+	test.AssertEqual(t, len(results[0].OutputFiles), 2)
+	test.AssertEqual(t, len(results[1].OutputFiles), 1)
+	test.AssertEqual(t, results[0].OutputFiles[0].Path, "/project/out/esm/entry.js")
+	test.AssertEqual(t, results[1].OutputFiles[0].Path, "/project/out/cjs/entry.js")
+
+	// Stale output files should only be removed for the configuration that
+	// generated them, not for the other configurations
+	files["/project/entry.js"] = "console.log('eager')"
+	results = ctx.Rebuild()
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			t.Fatalf("Unexpected errors: %v", result.Errors)
+		}
+	}
+	test.AssertEqual(t, strings.Join(esmWriter.removed, ","), "/project/out/esm/lazy.js")
+	test.AssertEqual(t, len(cjsWriter.removed), 0)
+	test.AssertEqual(t, cjsWriter.committed["/project/out/cjs/entry.js"], "// entry.js\nconsole.log(\"eager\");\n")
+}
+
+// This file system reports a modification time so that esbuild's file system
+// cache is allowed to skip reading files that appear to be unchanged
+type timedFileSystem struct {
+	mapFileSystem
+}
+
+func (files timedFileSystem) Stat(path string) (api.FileInfo, error) {
+	info, err := files.mapFileSystem.Stat(path)
+	if err == nil && !info.IsDir {
+		info.ModTime = time.Unix(1, 0)
+	}
+	return info, err
+}
+
+func TestContextManySeparateFileSystems(t *testing.T) {
+	var allOptions []api.BuildOptions
+	for _, name := range []string{"one", "two"} {
+		allOptions = append(allOptions, api.BuildOptions{
+			EntryPoints:   []string{"entry.js"},
+			Bundle:        true,
+			AbsWorkingDir: "/project",
+			LogLevel:      api.LogLevelSilent,
+			FS:            timedFileSystem{mapFileSystem{"/project/entry.js": "console.log('" + name + "')"}},
+		})
+	}
+	ctx, ctxErr := api.ContextMany(allOptions)
+	if ctxErr != nil {
+		t.Fatalf("Unexpected errors: %v", ctxErr.Errors)
+	}
+	defer ctx.Dispose()
+
+	results := ctx.Rebuild()
+	test.AssertEqual(t, len(results), 2)
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			t.Fatalf("Unexpected errors: %v", result.Errors)
+		}
+	}
+	test.AssertEqual(t, string(results[0].OutputFiles[0].Contents), "(() => {\n  // entry.js\n  console.log(\"one\");\n})();\n")
+	test.AssertEqual(t, string(results[1].OutputFiles[0].Contents), "(() => {\n  // entry.js\n  console.log(\"two\");\n})();\n")
+}
+
+func TestContextManyErrors(t *testing.T) {
+	_, ctxErr := api.ContextMany([]api.BuildOptions{
+		{EntryPoints: []string{"entry.js"}, LogLevel: api.LogLevelSilent},
+		{EntryPoints: []string{"entry.js"}, Splitting: true, Format: api.FormatESModule, LogLevel: api.LogLevelSilent},
+	})
+	if ctxErr == nil {
+		t.Fatal("Expected an error")
+	}
+	test.AssertEqual(t, len(ctxErr.Errors), 1)
+	test.AssertEqual(t, ctxErr.Errors[0].Text, "Must use \"outdir\" when code splitting is enabled")
+}
@@ -132,8 +132,9 @@ func (h *apiHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		}
 
 		type fileToServe struct {
-			absPath  string
-			contents fs.OpenedFile
+			absPath   string
+			contents  fs.OpenedFile
+			integrity string
 		}
 
 		var kind fs.EntryKind
@@ -143,11 +144,14 @@ func (h *apiHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
 		// Check for a match with the results if we're within the output directory
 		if outdirQueryPath, ok := stripDirPrefix(queryPath, h.outdirPathPrefix, "/"); ok {
-			resultKind, inMemoryBytes, absPath, isImplicitIndexHTML := h.matchQueryPathToResult(outdirQueryPath, &result, dirEntries, fileEntries)
+			resultKind, outputFile, isImplicitIndexHTML := h.matchQueryPathToResult(outdirQueryPath, &result, dirEntries, fileEntries)
 			kind = resultKind
-			file = fileToServe{
-				absPath:  absPath,
-				contents: &fs.InMemoryOpenedFile{Contents: inMemoryBytes},
+			if outputFile != nil {
+				file = fileToServe{
+					absPath:   outputFile.Path,
+					contents:  &fs.InMemoryOpenedFile{Contents: outputFile.Contents},
+					integrity: outputFile.Integrity,
+				}
 			}
 			if isImplicitIndexHTML {
 				queryPath = path.Join(queryPath, "index.html")
@@ -304,6 +308,9 @@ func (h *apiHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 			if isRange {
 				res.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", begin, end-1, fileContentsLen))
 			}
+			if digest := integrityToReprDigest(file.integrity); digest != "" {
+				res.Header().Set("Repr-Digest", digest)
+			}
 			res.Header().Set("Content-Length", fmt.Sprintf("%d", len(fileBytes)))
 			go h.notifyRequest(time.Since(start), req, status)
 			res.WriteHeader(status)
@@ -551,7 +558,7 @@ func (h *apiHandler) matchQueryPathToResult(
 	result *BuildResult,
 	dirEntries map[string]bool,
 	fileEntries map[string]bool,
-) (fs.EntryKind, []byte, string, bool) {
+) (fs.EntryKind, *OutputFile, bool) {
 	queryIsDir := false
 	queryDir := queryPath
 	if queryDir != "" {
@@ -559,18 +566,19 @@ func (h *apiHandler) matchQueryPathToResult(
 	}
 
 	// Check the output files for a match
-	for _, file := range result.OutputFiles {
+	for i := range result.OutputFiles {
+		file := &result.OutputFiles[i]
 		if relPath, ok := h.fs.Rel(h.absOutputDir, file.Path); ok {
 			relPath = strings.ReplaceAll(relPath, "\\", "/")
 
 			// An exact match
 			if relPath == queryPath {
-				return fs.FileEntry, file.Contents, file.Path, false
+				return fs.FileEntry, file, false
 			}
 
 			// Serve an "index.html" file if present
 			if dir, base := path.Split(relPath); base == "index.html" && queryDir == dir {
-				return fs.FileEntry, file.Contents, file.Path, true
+				return fs.FileEntry, file, true
 			}
 
 			// A match inside this directory
@@ -588,10 +596,26 @@ func (h *apiHandler) matchQueryPathToResult(
 
 	// Treat this as a directory if it's non-empty
 	if queryIsDir {
-		return fs.DirEntry, nil, "", false
+		return fs.DirEntry, nil, false
 	}
 
-	return 0, nil, "", false
+	return 0, nil, false
+}
+
+// The integrity hashes of output files are exposed to HTTP clients using the
+// "Repr-Digest" header: https://www.rfc-editor.org/rfc/rfc9530. This header
+// describes the whole file even for range requests. Note that this header only
+// allows SHA-256 and SHA-512, so a SHA-384 hash is omitted here.
+func integrityToReprDigest(integrity string) string {
+	var digests []string
+	for _, value := range strings.Fields(integrity) {
+		if strings.HasPrefix(value, "sha256-") {
+			digests = append(digests, "sha-256=:"+value[len("sha256-"):]+":")
+		} else if strings.HasPrefix(value, "sha512-") {
+			digests = append(digests, "sha-512=:"+value[len("sha512-"):]+":")
+		}
+	}
+	return strings.Join(digests, ", ")
 }
 
 func respondWithDirList(queryPath string, dirEntries map[string]bool, fileEntries map[string]bool) []byte {
@@ -677,6 +701,22 @@ func prettyPrintPath(fs fs.FS, path string) string {
 	return path
 }
 
+func commonAncestorDir(fs fs.FS, a string, b string) string {
+	for {
+		if relPath, ok := fs.Rel(a, b); ok {
+			relPath = strings.ReplaceAll(relPath, "\\", "/") // Fix paths on Windows
+			if relPath != ".." && !strings.HasPrefix(relPath, "../") {
+				return a
+			}
+		}
+		parent := fs.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
+
 func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error) {
 	ctx.mutex.Lock()
 	defer ctx.mutex.Unlock()
@@ -714,31 +754,51 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 		}
 	}
 
-	// Stuff related to the output directory only matters if there are entry points
-	outdirPathPrefix := ""
-	if len(ctx.args.entryPoints) > 0 {
-		// Don't allow serving when builds are written to stdout
-		if ctx.args.options.WriteToStdout {
-			what := "entry points"
-			if len(ctx.args.entryPoints) == 1 {
-				what = "an entry point"
+	// Configurations with different output directories are served from the
+	// closest directory that contains all of them
+	absOutputDir := ctx.configs[0].options.AbsOutputDir
+	publicPath := ctx.configs[0].options.PublicPath
+	hasEntryPoints := false
+	for _, args := range ctx.configs {
+		if len(args.entryPoints) > 0 {
+			hasEntryPoints = true
+
+			// Don't allow serving when builds are written to stdout
+			if args.options.WriteToStdout {
+				what := "entry points"
+				if len(args.entryPoints) == 1 {
+					what = "an entry point"
+				}
+				return ServeResult{}, fmt.Errorf("Cannot serve %s without an output path", what)
 			}
-			return ServeResult{}, fmt.Errorf("Cannot serve %s without an output path", what)
+
+			// Output files sent to an output writer aren't kept in memory
+			if args.outputWriter != nil {
+				return ServeResult{}, errors.New("Cannot serve output files that are sent to an output writer")
+			}
+		}
+		absOutputDir = commonAncestorDir(ctx.realFS, absOutputDir, args.options.AbsOutputDir)
+		if args.options.PublicPath != publicPath {
+			publicPath = ""
 		}
+	}
 
+	// Stuff related to the output directory only matters if there are entry points
+	outdirPathPrefix := ""
+	if hasEntryPoints {
 		// Compute the output path prefix
-		if serveOptions.Servedir != "" && ctx.args.options.AbsOutputDir != "" {
+		if serveOptions.Servedir != "" && absOutputDir != "" {
 			// Make sure the output directory is contained in the "servedir" directory
-			relPath, ok := ctx.realFS.Rel(serveOptions.Servedir, ctx.args.options.AbsOutputDir)
+			relPath, ok := ctx.realFS.Rel(serveOptions.Servedir, absOutputDir)
 			if !ok {
 				return ServeResult{}, fmt.Errorf(
-					"Cannot compute relative path from %q to %q\n", serveOptions.Servedir, ctx.args.options.AbsOutputDir)
+					"Cannot compute relative path from %q to %q\n", serveOptions.Servedir, absOutputDir)
 			}
 			relPath = strings.ReplaceAll(relPath, "\\", "/") // Fix paths on Windows
 			if relPath == ".." || strings.HasPrefix(relPath, "../") {
 				return ServeResult{}, fmt.Errorf(
 					"Output directory %q must be contained in serve directory %q",
-					prettyPrintPath(ctx.realFS, ctx.args.options.AbsOutputDir),
+					prettyPrintPath(ctx.realFS, absOutputDir),
 					prettyPrintPath(ctx.realFS, serveOptions.Servedir),
 				)
 			}
@@ -805,8 +865,8 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 	handler := &apiHandler{
 		onRequest:        serveOptions.OnRequest,
 		outdirPathPrefix: outdirPathPrefix,
-		absOutputDir:     ctx.args.options.AbsOutputDir,
-		publicPath:       ctx.args.options.PublicPath,
+		absOutputDir:     absOutputDir,
+		publicPath:       publicPath,
 		servedir:         serveOptions.Servedir,
 		keyfileToLower:   strings.ToLower(serveOptions.Keyfile),
 		certfileToLower:  strings.ToLower(serveOptions.Certfile),
@@ -900,8 +960,8 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 	ctx.handler = handler
 
 	// Print the URL(s) that the server can be reached at
-	if ctx.args.logOptions.LogLevel <= logger.LevelInfo {
-		printURLs(result.Host, result.Port, isHTTPS, ctx.args.logOptions.Color)
+	if ctx.configs[0].logOptions.LogLevel <= logger.LevelInfo {
+		printURLs(result.Host, result.Port, isHTTPS, ctx.configs[0].logOptions.Color)
 	}
 
 	// Start the first build shortly after this function returns (but not
@@ -394,6 +394,13 @@ func parseOptionsImpl(
 		case strings.HasPrefix(arg, "--public-path=") && buildOpts != nil:
 			buildOpts.PublicPath = arg[len("--public-path="):]
 
+		case isBoolFlag(arg, "--wasm-inline") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.WasmInline = value
+			}
+
 		case strings.HasPrefix(arg, "--global-name="):
 			if buildOpts != nil {
 				buildOpts.GlobalName = arg[len("--global-name="):]
@@ -409,6 +416,54 @@ func parseOptionsImpl(
 			buildOpts.Metafile = true
 			extras.metafile = &value
 
+		case isBoolFlag(arg, "--module-preload") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.ModulePreload = value
+			}
+
+		case isBoolFlag(arg, "--preserve-eval-order") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.PreserveEvalOrder = value
+			}
+
+		case isBoolFlag(arg, "--compressed-sizes") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if value {
+				buildOpts.CompressedSizes = api.CompressedSizesTrue
+			} else {
+				buildOpts.CompressedSizes = api.CompressedSizesFalse
+			}
+
+		case isBoolFlag(arg, "--manifest") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.Manifest = value
+			}
+
+		case strings.HasPrefix(arg, "--integrity=") && buildOpts != nil:
+			buildOpts.Integrity = 0
+			for _, value := range splitWithEmptyCheck(arg[len("--integrity="):], ",") {
+				switch value {
+				case "sha256":
+					buildOpts.Integrity |= api.IntegritySHA256
+				case "sha384":
+					buildOpts.Integrity |= api.IntegritySHA384
+				case "sha512":
+					buildOpts.Integrity |= api.IntegritySHA512
+				default:
+					return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+						fmt.Sprintf("Invalid value %q in %q", value, arg),
+						"Valid values are \"sha256\", \"sha384\", or \"sha512\".",
+					)
+				}
+			}
+
 		case strings.HasPrefix(arg, "--outfile=") && buildOpts != nil:
 			buildOpts.Outfile = arg[len("--outfile="):]
 
@@ -647,6 +702,22 @@ func parseOptionsImpl(
 			}
 			buildOpts.Alias[value[:equals]] = value[equals+1:]
 
+		case strings.HasPrefix(arg, "--manual-chunk:") && buildOpts != nil:
+			value := arg[len("--manual-chunk:"):]
+			equals := strings.IndexByte(value, '=')
+			if equals == -1 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Missing \"=\" in %q", arg),
+					"You need to use \"=\" to specify both the chunk name and the modules to put in it. "+
+						"For example, \"--manual-chunk:vendor=react,react-dom\" puts \"react\" and \"react-dom\" into a chunk named \"vendor\".",
+				)
+			}
+			if buildOpts.ManualChunks == nil {
+				buildOpts.ManualChunks = make(map[string][]string)
+			}
+			name := value[:equals]
+			buildOpts.ManualChunks[name] = append(buildOpts.ManualChunks[name], splitWithEmptyCheck(value[equals+1:], ",")...)
+
 		case strings.HasPrefix(arg, "--jsx="):
 			value := arg[len("--jsx="):]
 			var mode api.JSX
@@ -754,6 +825,28 @@ func parseOptionsImpl(
 				transformOpts.LogLimit = limit
 			}
 
+		case strings.HasPrefix(arg, "--min-chunk-size=") && buildOpts != nil:
+			value := arg[len("--min-chunk-size="):]
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The minimum chunk size must be a non-negative integer.",
+				)
+			}
+			buildOpts.MinChunkSize = size
+
+		case strings.HasPrefix(arg, "--max-chunks=") && buildOpts != nil:
+			value := arg[len("--max-chunks="):]
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The maximum chunk count must be a non-negative integer.",
+				)
+			}
+			buildOpts.MaxChunks = count
+
 		case strings.HasPrefix(arg, "--line-limit="):
 			value := arg[len("--line-limit="):]
 			limit, err := strconv.Atoi(value)
@@ -851,6 +944,7 @@ func parseOptionsImpl(
 				"format":             true,
 				"global-name":        true,
 				"ignore-annotations": true,
+				"integrity":          true,
 				"jsx-factory":        true,
 				"jsx-fragment":       true,
 				"jsx-import-source":  true,